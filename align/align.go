@@ -20,6 +20,8 @@ type Alignment interface {
 	SeqBag
 	AddGaps(rate, lenprop float64)
 	Append(Alignment) error // Appends alignment sequences to this alignment
+	// Annotations attached to the alignment (Stockholm #=GF, #=GC, #=GS, #=GR lines)
+	Annotations() *Annotations
 	AvgAllelesPerSite() float64
 	BuildBootstrap() Alignment // Bootstrap alignment
 	CharStatsSite(site int) (map[rune]int, error)
@@ -63,6 +65,9 @@ type Alignment interface {
 	// It does not take into account 'N' and '-' as unique mutations
 	NumMutationsUniquePerSequence(profile *CountProfile) (numuniques []int, numnew []int, nummuts []int, err error)
 	Pssm(log bool, pseudocount float64, normalization int) (pssm map[rune][]float64, err error) // Normalization: PSSM_NORM_NONE, PSSM_NORM_UNIF, PSSM_NORM_DATA
+	// Same as Pssm, each sequence being counted with its weight
+	WeightedPssm(weights []float64, log bool, pseudocount float64, normalization int) (pssm map[rune][]float64, err error)
+	HenikoffWeights() []float64 // Position-based sequence weights (Henikoff & Henikoff 1994)
 	Rarefy(nb int, counts map[string]int) (Alignment, error)                                    // Take a new rarefied sample taking into accounts weights
 	RandSubAlign(length int) (Alignment, error)                                                 // Extract a random subalignment with given length from this alignment
 	Recombine(rate float64, lenprop float64)
@@ -73,18 +78,32 @@ type Alignment interface {
 	// Replaces match characters (.) by their corresponding characters on the first sequence
 	ReplaceMatchChars()
 	Sample(nb int) (Alignment, error) // generate a sub sample of the sequences
+	SetAnnotations(an *Annotations)   // Replaces the annotations attached to the alignment
+	Partitions() *PartitionSet        // Partitions attached to the alignment (nil if none, or if no longer consistent with its length)
+	SetPartitions(ps *PartitionSet)   // Replaces the partitions attached to the alignment
 	ShuffleSites(rate float64, roguerate float64, randroguefirst bool) []string
 	SimulateRogue(prop float64, proplen float64) ([]string, []string) // add "rogue" sequences
 	SiteConservation(position int) (int, error)                       // If the site is conserved:
+	Stats() AlignmentStats                                            // General characteristics of the alignment
 	Split(part *PartitionSet) ([]Alignment, error)                    //Splits the alignment given the paritions in argument
 	SubAlign(start, length int) (Alignment, error)                    // Extract a subalignment from this alignment
+	SubAlignFeature(name string, f *Feature) (Alignment, error)       // Extract the subalignment covered by a feature of the given sequence
+	SelectSites(sites []int) (Alignment, error)                       // Extract the subalignment made of the given sites, in the given order
+	// Characteristics of each sequence, compared to the reference sequence and to the count profile if not nil
+	SequenceStats(refSequence Sequence, countProfile *CountProfile) ([]SequenceStats, error)
+	// Variable sites compared to the given (aligned) reference sequence, see Variant
+	Variants(ref Sequence, gapsAsDeletions bool) (samples []string, variants []*Variant, err error)
 	Swap(rate float64)
 	TrimSequences(trimsize int, fromStart bool) error
+	// Runs the given validation checks (see ValidationChecks), and returns the issues found
+	Validate(checks []string, geneticcode int) ([]ValidationIssue, error)
 }
 
 type align struct {
 	seqbag
-	length int // Length of alignment
+	length      int           // Length of alignment
+	annotations *Annotations  // Per file/column/sequence/residue annotations
+	partitions  *PartitionSet // Partitions of the sites (ex: NEXUS SETS blocks), may be nil
 }
 
 // AlignChannel is used for iterating over alignments
@@ -108,8 +127,11 @@ func NewAlign(alphabet int) *align {
 			make(map[string]*seq),
 			make([]*seq, 0, 100),
 			false,
-			alphabet},
+			alphabet,
+			nil},
 		-1,
+		NewAnnotations(),
+		nil,
 	}
 }
 
@@ -128,8 +150,12 @@ func AlphabetFromString(alphabet string) int {
 
 // AddSequence Adds a sequence to this alignment
 func (a *align) AddSequence(name string, sequence string, comment string) error {
-	err := a.AddSequenceChar(name, []rune(sequence), comment)
-	return err
+	for i := 0; i < len(sequence); i++ {
+		if sequence[i] > unicode.MaxASCII {
+			return CheckSequenceChars([]rune(sequence))
+		}
+	}
+	return a.addSequenceBytes(name, []byte(sequence), comment)
 }
 
 // AddSequenceChar adds a sequence from its rune representation.
@@ -137,14 +163,24 @@ func (a *align) AddSequence(name string, sequence string, comment string) error
 // a sequence with the same name AND same sequence
 // already exists in the alignment
 func (a *align) AddSequenceChar(name string, sequence []rune, comment string) error {
+	if err := CheckSequenceChars(sequence); err != nil {
+		return err
+	}
+	return a.addSequenceBytes(name, runesToBytes(sequence), comment)
+}
+
+// addSequenceBytes adds the sequence, given as its internal byte representation,
+// and checks its length. The given slice is not copied.
+func (a *align) addSequenceBytes(name string, sequence []byte, comment string) error {
 	s, ok := a.seqmap[name]
 	idx := 0
 	tmpname := name
 
 	// If the sequence name already exists with the same sequence
 	// and ignoreidentical is true, then we ignore this sequence
-	if ok && a.ignoreidentical && s.SameSequence(sequence) {
+	if ok && a.ignoreidentical && bytes.Equal(s.SequenceBytes(), sequence) {
 		log.Print(fmt.Sprintf("Warning: sequence \"%s\" already exists in alignment with the same sequence, ignoring", name))
+		a.duplicates = append(a.duplicates, DuplicateName{name, ""})
 		return nil
 	}
 
@@ -161,12 +197,60 @@ func (a *align) AddSequenceChar(name string, sequence []rune, comment string) er
 		return errors.New("Sequence " + tmpname + " does not have same length as other sequences")
 	}
 	a.length = len(sequence)
-	seq := NewSequence(tmpname, sequence, comment)
+	if tmpname != name {
+		a.duplicates = append(a.duplicates, DuplicateName{name, tmpname})
+	}
+	seq := newSequenceBytes(tmpname, sequence, comment)
 	a.seqmap[tmpname] = seq
 	a.seqs = append(a.seqs, seq)
 	return nil
 }
 
+// addSequenceQual adds the sequence with its per-base qualities
+// (may be nil), see addSequenceBytes.
+func (a *align) addSequenceQual(name string, sequence, qualities []byte, comment string) error {
+	nb := len(a.seqs)
+	if err := a.addSequenceBytes(name, sequence, comment); err != nil {
+		return err
+	}
+	if len(a.seqs) > nb {
+		a.seqs[nb].qualities = qualities
+	}
+	return nil
+}
+
+// Annotations returns the annotations attached to the alignment.
+// It is never nil, but may be empty.
+func (a *align) Annotations() *Annotations {
+	return a.annotations
+}
+
+// SetAnnotations replaces the annotations attached to the alignment.
+// If an is nil, annotations are cleared.
+func (a *align) SetAnnotations(an *Annotations) {
+	if an == nil {
+		an = NewAnnotations()
+	}
+	a.annotations = an
+}
+
+// Partitions returns the partitions attached to the alignment, or nil
+// if there are none, or if their length is not the alignment length
+// anymore (operations that change the alignment length do not update
+// the partitions).
+func (a *align) Partitions() *PartitionSet {
+	if a.partitions == nil || a.partitions.AliLength() != a.Length() {
+		return nil
+	}
+	return a.partitions
+}
+
+// SetPartitions replaces the partitions attached to the alignment.
+// If ps is nil, partitions are cleared.
+func (a *align) SetPartitions(ps *PartitionSet) {
+	a.partitions = ps
+}
+
 // Clear removes all the sequences from the alignment
 func (a *align) Clear() {
 	a.seqbag.Clear()
@@ -215,16 +299,16 @@ func (a *align) ShuffleSites(rate float64, roguerate float64, randroguefirst boo
 			nbRogueSitesToShuffle, nbSitesToShuffle, a.Length()))
 	}
 
-	var temp rune
+	var temp byte
 	for i := 0; i < nbSitesToShuffle; i++ {
 		site := sitepermutation[i]
 		var n int = a.NbSequences()
 		for n > 1 {
 			r := rand.Intn(n)
 			n--
-			temp = a.seqs[n].sequence[site]
-			a.seqs[n].sequence[site] = a.seqs[r].sequence[site]
-			a.seqs[r].sequence[site] = temp
+			temp = a.seqs[n].bytes()[site]
+			a.seqs[n].bytes()[site] = a.seqs[r].bytes()[site]
+			a.seqs[r].bytes()[site] = temp
 		}
 	}
 	// We shuffle more sites for "rogue" taxa
@@ -234,7 +318,7 @@ func (a *align) ShuffleSites(rate float64, roguerate float64, randroguefirst boo
 			j := rand.Intn(r + 1)
 			seq1 := a.seqs[taxpermutation[r]]
 			seq2 := a.seqs[taxpermutation[j]]
-			seq1.sequence[site], seq2.sequence[site] = seq2.sequence[site], seq1.sequence[site]
+			seq1.bytes()[site], seq2.bytes()[site] = seq2.bytes()[site], seq1.bytes()[site]
 			rogues[r] = seq1.name
 		}
 	}
@@ -267,7 +351,7 @@ func (a *align) RemoveGapSites(cutoff float64, ends bool) (first, last int) {
 	for site := 0; site < a.Length(); site++ {
 		nbgaps = 0
 		for seq := 0; seq < a.NbSequences(); seq++ {
-			if a.seqs[seq].sequence[site] == GAP {
+			if a.seqs[seq].bytes()[site] == GAP {
 				nbgaps++
 			}
 		}
@@ -292,8 +376,12 @@ func (a *align) RemoveGapSites(cutoff float64, ends bool) (first, last int) {
 		if !ends || lastcontinuous == toremove[i] || toremove[i] <= firstcontinuous {
 			nbremoved++
 			for seq := 0; seq < a.NbSequences(); seq++ {
-				a.seqs[seq].sequence = append(a.seqs[seq].sequence[:toremove[i]], a.seqs[seq].sequence[toremove[i]+1:]...)
+				a.seqs[seq].sequence = append(a.seqs[seq].bytes()[:toremove[i]], a.seqs[seq].bytes()[toremove[i]+1:]...)
+				if q := a.seqs[seq].qualities; q != nil {
+					a.seqs[seq].qualities = append(q[:toremove[i]], q[toremove[i]+1:]...)
+				}
 			}
+			a.annotations.removeSite(toremove[i])
 		}
 	}
 	last = a.Length() - lastcontinuous
@@ -376,12 +464,12 @@ func (a *align) RemoveGapSeqs(cutoff float64) {
 	for _, seq := range oldseqs {
 		nbgaps = 0
 		for site := 0; site < length; site++ {
-			if seq.sequence[site] == GAP {
+			if seq.bytes()[site] == GAP {
 				nbgaps++
 			}
 		}
 		if !((cutoff > 0.0 && float64(nbgaps) >= cutoff*float64(length)) || (cutoff == 0 && nbgaps > 0)) {
-			a.AddSequenceChar(seq.name, seq.sequence, seq.comment)
+			a.addSequenceQual(seq.name, seq.bytes(), seq.qualities, seq.comment)
 		}
 	}
 }
@@ -394,7 +482,7 @@ func (a *align) RemoveGapSeqs(cutoff float64) {
 func (a *align) Swap(rate float64) {
 	var nb_to_shuffle, nb_sites int
 	var pos int
-	var tmpchar rune
+	var tmpchar byte
 	var seq1, seq2 *seq
 
 	if rate < 0 || rate > 1 {
@@ -411,9 +499,9 @@ func (a *align) Swap(rate float64) {
 		seq1 = a.seqs[permutation[i]]
 		seq2 = a.seqs[permutation[i+(int)(nb_to_shuffle/2)]]
 		for pos < nb_sites {
-			tmpchar = seq1.sequence[pos]
-			seq1.sequence[pos] = seq2.sequence[pos]
-			seq2.sequence[pos] = tmpchar
+			tmpchar = seq1.bytes()[pos]
+			seq1.bytes()[pos] = seq2.bytes()[pos]
+			seq2.bytes()[pos] = tmpchar
 			pos++
 		}
 	}
@@ -431,7 +519,7 @@ func (a *align) Replace(old, new string, regex bool) (err error) {
 		return
 	}
 	// Verify that sequences still have same length
-	a.IterateChar(func(name string, s []rune) bool {
+	a.IterateBytes(func(name string, s []byte) bool {
 		if len(s) != a.Length() {
 			err = fmt.Errorf("replace should not change the length of aligned sequences")
 			return true
@@ -452,8 +540,8 @@ func (a *align) ReplaceMatchChars() {
 	ref := a.seqs[0]
 	for seq := 1; seq < a.NbSequences(); seq++ {
 		for site := 0; site < a.Length(); site++ {
-			if ref.sequence[site] != POINT && a.seqs[seq].sequence[site] == POINT {
-				a.seqs[seq].sequence[site] = ref.sequence[site]
+			if ref.bytes()[site] != POINT && a.seqs[seq].bytes()[site] == POINT {
+				a.seqs[seq].bytes()[site] = ref.bytes()[site]
 			}
 		}
 	}
@@ -464,7 +552,7 @@ func (a *align) ReplaceMatchChars() {
 func (a *align) Translate(phase int, geneticcode int) (err error) {
 	err = a.seqbag.Translate(phase, geneticcode)
 	if len(a.seqs) > 0 {
-		a.length = len(a.seqs[0].sequence)
+		a.length = a.seqs[0].Length()
 	} else {
 		a.length = -1
 	}
@@ -497,7 +585,7 @@ func (a *align) Recombine(prop float64, lenprop float64) {
 		seq1 = a.seqs[permutation[i]]
 		seq2 = a.seqs[permutation[i+nb]]
 		for j := pos; j < pos+lentorecomb; j++ {
-			seq1.sequence[j] = seq2.sequence[j]
+			seq1.bytes()[j] = seq2.bytes()[j]
 		}
 	}
 }
@@ -522,7 +610,7 @@ func (a *align) AddGaps(lenprop float64, prop float64) {
 		permsites := rand.Perm(a.Length())
 		seq := a.seqs[permseqs[i]]
 		for j := 0; j < nbgaps; j++ {
-			seq.sequence[permsites[j]] = GAP
+			seq.bytes()[permsites[j]] = GAP
 		}
 	}
 }
@@ -538,7 +626,7 @@ func (a *align) Append(al Alignment) (err error) {
 
 func (a *align) append(al *align) (err error) {
 	for _, s := range al.seqs {
-		if err = a.AddSequenceChar(s.name, s.sequence, s.comment); err != nil {
+		if err = a.addSequenceQual(s.name, s.bytes(), s.qualities, s.comment); err != nil {
 			return
 		}
 	}
@@ -568,13 +656,13 @@ func (a *align) Mutate(rate float64) {
 			// We mutate only if rand is <= rate && character is not a gap
 			// or a special character.
 			// It takes a random nucleotide or amino acid uniformly
-			if r <= rate && seq.sequence[j] != GAP && seq.sequence[j] != POINT && seq.sequence[j] != OTHER {
+			if r <= rate && seq.bytes()[j] != GAP && seq.bytes()[j] != POINT && seq.bytes()[j] != OTHER {
 				if a.Alphabet() == AMINOACIDS {
 					newchar = rand.Intn(len(stdaminoacid))
-					seq.sequence[j] = stdaminoacid[newchar]
+					seq.bytes()[j] = byte(stdaminoacid[newchar])
 				} else {
 					newchar = rand.Intn(len(stdnucleotides))
-					seq.sequence[j] = stdnucleotides[newchar]
+					seq.bytes()[j] = byte(stdnucleotides[newchar])
 				}
 			}
 		}
@@ -614,7 +702,7 @@ func (a *align) SimulateRogue(prop float64, proplen float64) ([]string, []string
 		// we Shuffle some sequence sites
 		for i, _ := range sitesToShuffle {
 			j := rand.Intn(i + 1)
-			seq.sequence[sitesToShuffle[i]], seq.sequence[sitesToShuffle[j]] = seq.sequence[sitesToShuffle[j]], seq.sequence[sitesToShuffle[i]]
+			seq.bytes()[sitesToShuffle[i]], seq.bytes()[sitesToShuffle[j]] = seq.bytes()[sitesToShuffle[j]], seq.bytes()[sitesToShuffle[i]]
 		}
 	}
 	for nr := nb; nr < a.NbSequences(); nr++ {
@@ -636,11 +724,14 @@ func (a *align) TrimSequences(trimsize int, fromStart bool) error {
 	}
 	for _, seq := range a.seqs {
 		if fromStart {
-			seq.sequence = seq.sequence[trimsize:len(seq.sequence)]
+			seq.qualities = seq.subQualities(trimsize, seq.Length())
+			seq.sequence = seq.bytes()[trimsize:seq.Length()]
 		} else {
-			seq.sequence = seq.sequence[0 : len(seq.sequence)-trimsize]
+			seq.qualities = seq.subQualities(0, seq.Length()-trimsize)
+			seq.sequence = seq.bytes()[0 : seq.Length()-trimsize]
 		}
 	}
+	a.annotations.trim(trimsize, fromStart)
 	a.length = a.length - trimsize
 	return nil
 }
@@ -659,6 +750,7 @@ func (a *align) Sample(nb int) (al Alignment, err error) {
 	if ali, err = seqBagToAlignment(sampleSeqBag); err != nil {
 		return
 	}
+	ali.annotations = a.annotations.selectSequences(ali.seqmap)
 
 	al = ali
 
@@ -691,6 +783,7 @@ func (a *align) Rarefy(nb int, counts map[string]int) (al Alignment, err error)
 	if ali, err = seqBagToAlignment(rarefySeqBag); err != nil {
 		return
 	}
+	ali.annotations = a.annotations.selectSequences(ali.seqmap)
 
 	al = ali
 
@@ -702,21 +795,24 @@ func (a *align) Rarefy(nb int, counts map[string]int) (al Alignment, err error)
 // the index (in the original alignment) of all bootstrap sites.
 func (a *align) BuildBootstrap() (boot Alignment) {
 	n := a.Length()
-	boot = NewAlign(a.alphabet)
+	bootal := NewAlign(a.alphabet)
+	boot = bootal
 	indices := make([]int, n)
-	var buf []rune
+	var buf []byte
 
 	for i := 0; i < n; i++ {
 		indices[i] = rand.Intn(n)
 	}
 
 	for _, seq := range a.seqs {
-		buf = make([]rune, n)
+		buf = make([]byte, n)
+		sequence := seq.SequenceBytes()
 		for i, indice := range indices {
-			buf[i] = seq.sequence[indice]
+			buf[i] = sequence[indice]
 		}
-		boot.AddSequenceChar(seq.name, buf, seq.Comment())
+		bootal.addSequenceBytes(seq.name, buf, seq.Comment())
 	}
+	bootal.annotations = a.annotations.selectSites(indices)
 	return
 }
 
@@ -730,7 +826,7 @@ func (a *align) CharStatsSite(site int) (outmap map[rune]int, err error) {
 	} else {
 
 		for _, s := range a.seqs {
-			outmap[unicode.ToUpper(s.sequence[site])]++
+			outmap[unicode.ToUpper(s.CharAt(site))]++
 		}
 	}
 	return outmap, err
@@ -761,7 +857,7 @@ func (a *align) Mask(start, length int) (err error) {
 
 	for _, seq := range a.seqs {
 		for i := start; i < (start+length) && i < a.Length(); i++ {
-			seq.sequence[i] = rep
+			seq.bytes()[i] = byte(rep)
 		}
 	}
 	return
@@ -776,7 +872,7 @@ func (a *align) MaxCharStats(excludeGaps bool) (out []rune, occur []int) {
 		mapstats := make(map[rune]int)
 		max := 0
 		for _, seq := range a.seqs {
-			mapstats[unicode.ToUpper(seq.sequence[site])]++
+			mapstats[unicode.ToUpper(seq.CharAt(site))]++
 		}
 
 		out[site] = GAP
@@ -812,14 +908,17 @@ func RandomAlignment(alphabet, length, nbseq int) (al Alignment, err error) {
 }
 
 func (a *align) Clone() (c Alignment, err error) {
-	c = NewAlign(a.Alphabet())
-	c.IgnoreIdentical(a.ignoreidentical)
-	a.IterateAll(func(name string, sequence []rune, comment string) bool {
-		newseq := make([]rune, 0, len(sequence))
-		newseq = append(newseq, sequence...)
-		err = c.AddSequenceChar(name, newseq, comment)
-		return err != nil
-	})
+	clone := NewAlign(a.Alphabet())
+	clone.IgnoreIdentical(a.ignoreidentical)
+	for _, s := range a.seqs {
+		if err = clone.addSequenceQual(s.name, s.copyBytes(), s.subQualities(0, s.Length()), s.comment); err != nil {
+			return
+		}
+		clone.seqs[len(clone.seqs)-1].region = s.region.clone()
+	}
+	clone.SetAnnotations(a.annotations.Clone())
+	clone.SetPartitions(a.partitions)
+	c = clone
 	return
 }
 
@@ -830,7 +929,7 @@ func (a *align) AvgAllelesPerSite() float64 {
 		alleles := make(map[rune]bool)
 		onlygap := true
 		for seq := 0; seq < a.NbSequences(); seq++ {
-			s := a.seqs[seq].sequence[site]
+			s := a.seqs[seq].CharAt(site)
 			if s != GAP && s != POINT && s != OTHER {
 				alleles[s] = true
 				onlygap = false
@@ -856,7 +955,7 @@ func (a *align) Entropy(site int, removegaps bool) (float64, error) {
 	total := 0
 	entropy := 0.0
 	for seq := 0; seq < a.NbSequences(); seq++ {
-		s := a.seqs[seq].sequence[site]
+		s := a.seqs[seq].CharAt(site)
 		if s != OTHER && s != POINT && (!removegaps || s != GAP) {
 			nb, ok := occur[s]
 			if !ok {
@@ -894,12 +993,12 @@ func (a *align) Frameshifts(startingGapsAsIncomplete bool) (fs []struct{ Start,
 		started := false
 		for i := 0; i < a.Length(); i++ {
 			// Insertion in seq
-			if ref.sequence[i] == '-' {
+			if ref.bytes()[i] == '-' {
 				phase++
 				phase = (phase % 3)
 			}
 			// Deletion in seq
-			if seq.sequence[i] == '-' {
+			if seq.bytes()[i] == '-' {
 				phase--
 				if phase < 0 {
 					phase = 2
@@ -948,12 +1047,12 @@ func (a *align) Stops(startingGapsAsIncomplete bool, geneticcode int) (stops []i
 		pos := 0      // position on sequence (without -)
 		codonpos := 0 // nb nt in current codon
 		for i := 0; i < a.Length()-2; i++ {
-			if ref.sequence[i] == '-' {
+			if ref.bytes()[i] == '-' {
 				phase++
 				phase = (phase % 3)
 			}
 			// Deletion in seq
-			if seq.sequence[i] == '-' {
+			if seq.bytes()[i] == '-' {
 				phase--
 				if phase < 0 {
 					phase = 2
@@ -968,8 +1067,8 @@ func (a *align) Stops(startingGapsAsIncomplete bool, geneticcode int) (stops []i
 			}
 
 			// Deletion in seq
-			if seq.sequence[i] != '-' && (!startingGapsAsIncomplete || started) {
-				codon[codonpos] = seq.sequence[i]
+			if seq.bytes()[i] != '-' && (!startingGapsAsIncomplete || started) {
+				codon[codonpos] = seq.CharAt(i)
 				codonpos++
 				pos++
 			}
@@ -1003,12 +1102,36 @@ values may be normalized: normalization arg:
    PSSM_NORM_LOGO = 4 => Normalization like "Logo"
 */
 func (a *align) Pssm(log bool, pseudocount float64, normalization int) (pssm map[rune][]float64, err error) {
+	return a.WeightedPssm(nil, log, pseudocount, normalization)
+}
+
+// WeightedPssm computes a position-specific scoring matrix as Pssm,
+// each sequence being counted with its weight (weights are given in the
+// order of the sequences in the alignment). The number of sequences used
+// in the normalizations is then the sum of the weights.
+//
+// If weights is nil, all sequences have a weight of 1 (see Pssm).
+func (a *align) WeightedPssm(weights []float64, log bool, pseudocount float64, normalization int) (pssm map[rune][]float64, err error) {
 	// Number of occurences of each different aa/nt
 	pssm = make(map[rune][]float64)
 	var alphabet []rune
 	var normfactors map[rune]float64
 	/* Entropy at each position */
 	var entropy []float64
+	/* Number of sequences (sum of the weights) */
+	var nbseqs float64
+
+	if weights == nil {
+		nbseqs = float64(a.NbSequences())
+	} else {
+		if len(weights) != a.NbSequences() {
+			err = fmt.Errorf("Number of weights (%d) is different from the number of sequences (%d)", len(weights), a.NbSequences())
+			return
+		}
+		for _, w := range weights {
+			nbseqs += w
+		}
+	}
 	alphabet = a.AlphabetCharacters()
 	for _, c := range alphabet {
 		if _, ok := pssm[c]; !ok {
@@ -1025,15 +1148,15 @@ func (a *align) Pssm(log bool, pseudocount float64, normalization int) (pssm map
 		}
 	case PSSM_NORM_UNIF:
 		for _, c := range alphabet {
-			normfactors[c] = 1.0 / (float64(a.NbSequences()) + (float64(len(pssm)) * pseudocount)) / (1.0 / float64(len(alphabet)))
+			normfactors[c] = 1.0 / (nbseqs + (float64(len(pssm)) * pseudocount)) / (1.0 / float64(len(alphabet)))
 		}
 	case PSSM_NORM_FREQ:
 		for _, c := range alphabet {
-			normfactors[c] = 1.0 / (float64(a.NbSequences()) + (float64(len(pssm)) * pseudocount))
+			normfactors[c] = 1.0 / (nbseqs + (float64(len(pssm)) * pseudocount))
 		}
 	case PSSM_NORM_LOGO:
 		for _, c := range alphabet {
-			normfactors[c] = 1.0 / float64(a.NbSequences())
+			normfactors[c] = 1.0 / nbseqs
 		}
 	case PSSM_NORM_DATA:
 		stats := a.CharStats()
@@ -1048,7 +1171,7 @@ func (a *align) Pssm(log bool, pseudocount float64, normalization int) (pssm map
 		}
 		for _, c := range alphabet {
 			s, _ := stats[c]
-			normfactors[c] = 1.0 / (float64(a.NbSequences()) + (float64(len(pssm)) * pseudocount)) / (float64(s) / total)
+			normfactors[c] = 1.0 / (nbseqs + (float64(len(pssm)) * pseudocount)) / (float64(s) / total)
 		}
 	default:
 		err = errors.New("Unknown normalization option")
@@ -1058,11 +1181,14 @@ func (a *align) Pssm(log bool, pseudocount float64, normalization int) (pssm map
 	/* We count nt/aa occurences at each site */
 	for site := 0; site < a.Length(); site++ {
 		for seq := 0; seq < a.NbSequences(); seq++ {
-			s := a.seqs[seq].sequence[site]
-			s = unicode.ToUpper(s)
+			s := unicode.ToUpper(a.seqs[seq].CharAt(site))
 			if _, ok := normfactors[s]; ok {
 				if _, ok := pssm[s]; ok {
-					pssm[s][site] += 1.0
+					if weights == nil {
+						pssm[s][site] += 1.0
+					} else {
+						pssm[s][site] += weights[seq]
+					}
 				}
 			}
 		}
@@ -1083,7 +1209,8 @@ func (a *align) Pssm(log bool, pseudocount float64, normalization int) (pssm map
 	for k, v := range pssm {
 		for i, _ := range v {
 			v[i] = v[i] * normfactors[k]
-			if normalization == PSSM_NORM_LOGO {
+			// Absent characters do not contribute to the entropy (0*log(0) = 0)
+			if normalization == PSSM_NORM_LOGO && v[i] > 0 {
 				entropy[i] += -v[i] * math.Log(v[i]) / math.Log(2)
 			}
 		}
@@ -1110,6 +1237,43 @@ func (a *align) Pssm(log bool, pseudocount float64, normalization int) (pssm map
 	return
 }
 
+// HenikoffWeights computes position-based sequence weights
+// (Henikoff & Henikoff, 1994): at each site, each of the r different
+// characters gets a weight of 1/r, shared between the n sequences having it.
+// Sequences in groups of similar sequences thus get lower weights.
+//
+// Gaps are not taken into account. Weights are given in the order of the
+// sequences, and are normalized so that they sum to the number of sequences.
+func (a *align) HenikoffWeights() (weights []float64) {
+	var total float64
+	weights = make([]float64, a.NbSequences())
+	counts := make(map[rune]int)
+	for site := 0; site < a.Length(); site++ {
+		for k := range counts {
+			delete(counts, k)
+		}
+		for _, s := range a.seqs {
+			if c := unicode.ToUpper(s.CharAt(site)); c != GAP {
+				counts[c]++
+			}
+		}
+		for i, s := range a.seqs {
+			if c := unicode.ToUpper(s.CharAt(site)); c != GAP {
+				weights[i] += 1.0 / float64(len(counts)*counts[c])
+			}
+		}
+	}
+	for _, w := range weights {
+		total += w
+	}
+	if total > 0 {
+		for i := range weights {
+			weights[i] *= float64(len(weights)) / total
+		}
+	}
+	return
+}
+
 // Extract a subalignment from this alignment
 func (a *align) SubAlign(start, length int) (subalign Alignment, err error) {
 	if start < 0 || start > a.Length() {
@@ -1124,11 +1288,121 @@ func (a *align) SubAlign(start, length int) (subalign Alignment, err error) {
 		err = fmt.Errorf("Start+Length is outside the alignment")
 		return
 	}
-	subalign = NewAlign(a.alphabet)
+	sub := NewAlign(a.alphabet)
 	for i := 0; i < a.NbSequences(); i++ {
 		seq := a.seqs[i]
-		subalign.AddSequenceChar(seq.name, seq.SequenceChar()[start:start+length], seq.Comment())
+		subseq := make([]byte, length)
+		copy(subseq, seq.SequenceBytes()[start:start+length])
+		sub.addSequenceQual(seq.name, subseq, seq.subQualities(start, start+length), seq.Comment())
 	}
+	sub.annotations = a.annotations.subAnnotations(start, length)
+	subalign = sub
+	return
+}
+
+// SubAlignFeature extracts the sub-alignment corresponding to the location of
+// the given feature, whose coordinates are given on the sequence with the given name
+// (without gaps, see RefCoordinates).
+//
+// Intervals of the location are concatenated in the order of the location,
+// and intervals on the reverse strand are reverse complemented. If the feature
+// has a /codon_start qualifier, the output starts at its first complete codon.
+// Sequences are named after the feature: <sequence name>_<feature name>
+// (see Feature.Extract).
+func (a *align) SubAlignFeature(name string, f *Feature) (subalign Alignment, err error) {
+	var offset, alistart, alilen int
+	var sites []int
+	var complement []bool
+
+	if offset, err = f.CodonStart(); err != nil {
+		return
+	}
+	for k, inter := range f.Location {
+		start, end := inter.Start, inter.End
+		if k == 0 && inter.Complement {
+			end -= offset
+		} else if k == 0 {
+			start += offset
+		}
+		if end <= start {
+			continue
+		}
+		if alistart, alilen, err = a.RefCoordinates(name, start, end-start); err != nil {
+			return
+		}
+		for i := 0; i < alilen; i++ {
+			if inter.Complement {
+				sites = append(sites, alistart+alilen-1-i)
+			} else {
+				sites = append(sites, alistart+i)
+			}
+			complement = append(complement, inter.Complement)
+		}
+	}
+	if len(sites) == 0 {
+		err = fmt.Errorf("Feature %s is empty", f.Name())
+		return
+	}
+
+	sub := NewAlign(a.alphabet)
+	for _, seq := range a.seqs {
+		var subqual []byte
+		subseq := make([]byte, len(sites))
+		if seq.qualities != nil {
+			subqual = make([]byte, len(sites))
+		}
+		for i, site := range sites {
+			subseq[i] = byte(seq.CharAt(site))
+			if subqual != nil {
+				subqual[i] = seq.qualities[site]
+			}
+			if complement[i] && subseq[i] != GAP {
+				c, ok := complement_nuc_mapping[rune(subseq[i])]
+				if !ok {
+					err = fmt.Errorf("Character %c can not be complemented", subseq[i])
+					return
+				}
+				subseq[i] = byte(c)
+			}
+		}
+		if err = sub.addSequenceQual(fmt.Sprintf("%s_%s", seq.name, f.Name()), subseq, subqual, seq.comment); err != nil {
+			return
+		}
+	}
+	sub.annotations = a.annotations.selectSites(sites)
+	subalign = sub
+	return
+}
+
+// SelectSites extracts the sub-alignment made of the given sites
+// (0-based alignment positions), in the given order.
+func (a *align) SelectSites(sites []int) (subalign Alignment, err error) {
+	for _, site := range sites {
+		if site < 0 || site >= a.Length() {
+			err = fmt.Errorf("Site %d is outside the alignment", site)
+			return
+		}
+	}
+
+	sub := NewAlign(a.alphabet)
+	for _, seq := range a.seqs {
+		var subqual []byte
+		subseq := make([]byte, len(sites))
+		if seq.qualities != nil {
+			subqual = make([]byte, len(sites))
+		}
+		for i, site := range sites {
+			subseq[i] = byte(seq.CharAt(site))
+			if subqual != nil {
+				subqual[i] = seq.qualities[site]
+			}
+		}
+		if err = sub.addSequenceQual(seq.name, subseq, subqual, seq.comment); err != nil {
+			return
+		}
+	}
+	sub.annotations = a.annotations.selectSites(sites)
+	subalign = sub
 	return
 }
 
@@ -1145,8 +1419,9 @@ func (a *align) RandSubAlign(length int) (Alignment, error) {
 	start := rand.Intn(a.Length() - length + 1)
 	for i := 0; i < a.NbSequences(); i++ {
 		seq := a.seqs[i]
-		subalign.AddSequenceChar(seq.name, seq.SequenceChar()[start:start+length], seq.Comment())
+		subalign.AddSequenceChar(seq.name, seq.copyChars()[start:start+length], seq.Comment())
 	}
+	subalign.annotations = a.annotations.subAnnotations(start, length)
 	return subalign, nil
 }
 
@@ -1161,9 +1436,9 @@ func (a *align) Compress() (weights []int) {
 	npat := 0
 	// We add new patterns if not already insterted in the radix tree
 	for site := 0; site < a.Length(); site++ {
-		pattern := make([]rune, a.NbSequences())
+		pattern := make([]byte, a.NbSequences())
 		for seq := 0; seq < a.NbSequences(); seq++ {
-			pattern[seq] = a.seqs[seq].sequence[site]
+			pattern[seq] = a.seqs[seq].bytes()[site]
 		}
 		patstring := string(pattern)
 		if count, ok = r.Get(patstring); !ok {
@@ -1179,15 +1454,16 @@ func (a *align) Compress() (weights []int) {
 	npat = 0
 	r.Walk(func(pattern string, count interface{}) bool {
 		weights[npat] = count.(*struct{ count int }).count
-		for seq, c := range pattern {
-			a.seqs[seq].sequence[npat] = c
+		for seq := 0; seq < len(pattern); seq++ {
+			a.seqs[seq].bytes()[npat] = pattern[seq]
 		}
 		npat++
 		return false
 	})
 	// We remove what remains of the sequences after al patterns
 	for seq := 0; seq < a.NbSequences(); seq++ {
-		a.seqs[seq].sequence = a.seqs[seq].sequence[:npat]
+		a.seqs[seq].sequence = a.seqs[seq].bytes()[:npat]
+		a.seqs[seq].qualities = nil
 	}
 	a.length = npat
 	return
@@ -1209,7 +1485,7 @@ func (a *align) Concat(c Alignment) (err error) {
 		if !ok {
 			// This sequence is present in a but not in c
 			// So we append full gap sequence to a
-			err = a.appendToSequence(name, []rune(strings.Repeat(string(GAP), c.Length())))
+			err = a.appendToSequence(name, []byte(strings.Repeat(string(GAP), c.Length())))
 		}
 		return err != nil
 	})
@@ -1224,7 +1500,7 @@ func (a *align) Concat(c Alignment) (err error) {
 			err = a.AddSequence(name, strings.Repeat(string(GAP), a.Length()), comment)
 		}
 		// Then we append the c sequence to a
-		err = a.appendToSequence(name, sequence)
+		err = a.appendToSequence(name, runesToBytes(sequence))
 		return err != nil
 	})
 	if err != nil {
@@ -1232,7 +1508,7 @@ func (a *align) Concat(c Alignment) (err error) {
 	}
 
 	leng := -1
-	a.IterateChar(func(name string, sequence []rune) bool {
+	a.IterateBytes(func(name string, sequence []byte) bool {
 		if leng == -1 {
 			leng = len(sequence)
 		} else {
@@ -1344,8 +1620,8 @@ func (a *align) NbVariableSites() int {
 		charmap := make(map[rune]bool)
 		variable := false
 		for _, seq := range a.seqs {
-			if seq.sequence[site] != GAP && seq.sequence[site] != POINT && seq.sequence[site] != OTHER {
-				charmap[seq.sequence[site]] = true
+			if c := seq.CharAt(site); c != GAP && c != POINT && c != OTHER {
+				charmap[c] = true
 			}
 			if len(charmap) > 1 {
 				variable = true
@@ -1387,7 +1663,7 @@ func (a *align) NumGapsUniquePerSequence(countProfile *CountProfile) (numuniques
 		nbGapsColumn = 0
 
 		for j, s := range a.seqs {
-			r := s.sequence[i]
+			r := s.CharAt(i)
 			if r == GAP {
 				nbGapsColumn++
 				uniqueIndex = j
@@ -1447,7 +1723,7 @@ func (a *align) NumMutationsUniquePerSequence(countProfile *CountProfile) (numun
 		indices := make([]int, 130)
 
 		for j, s := range a.seqs {
-			r := s.sequence[i]
+			r := s.CharAt(i)
 			occurences[int(r)]++
 			indices[int(r)] = j
 			if countProfile != nil && r != all && r != GAP {
@@ -1555,27 +1831,27 @@ func (a *align) SiteConservation(position int) (conservation int, err error) {
 	tmpweakgroups := make([]int, len(weakGroups))
 	same := true
 	prevchar := ';'
-	a.IterateChar(func(name string, sequence []rune) bool {
+	a.IterateBytes(func(name string, sequence []byte) bool {
 		if a.Alphabet() == AMINOACIDS {
 			for i, g := range strongGroups {
 				for _, aa := range g {
-					if aa == unicode.ToUpper(sequence[position]) {
+					if aa == unicode.ToUpper(rune(sequence[position])) {
 						tmpstronggroups[i]++
 					}
 				}
 			}
 			for i, g := range weakGroups {
 				for _, aa := range g {
-					if aa == unicode.ToUpper(sequence[position]) {
+					if aa == unicode.ToUpper(rune(sequence[position])) {
 						tmpweakgroups[i]++
 					}
 				}
 			}
 		}
-		if (prevchar != ';' && sequence[position] != prevchar) || sequence[position] == GAP {
+		if (prevchar != ';' && rune(sequence[position]) != prevchar) || sequence[position] == GAP {
 			same = false
 		}
-		prevchar = sequence[position]
+		prevchar = rune(sequence[position])
 		return false
 	})
 
@@ -1619,14 +1895,16 @@ func (a *align) Split(part *PartitionSet) (als []Alignment, err error) {
 		alsimpl[pi] = NewAlign(a.Alphabet())
 		als[pi] = alsimpl[pi]
 		firstpos := true
+		positions := make([]int, 0)
 		for pos := 0; pos < part.AliLength(); pos++ {
 			if part.Partition(pos) == pi {
+				positions = append(positions, pos)
 				for si := 0; si < a.NbSequences(); si++ {
 					seq := a.seqs[si]
 					if firstpos {
 						alsimpl[pi].AddSequenceChar(seq.Name(), []rune{seq.CharAt(pos)}, seq.Comment())
 					} else {
-						alsimpl[pi].seqs[si].sequence = append(alsimpl[pi].seqs[si].sequence, seq.sequence[pos])
+						alsimpl[pi].seqs[si].sequence = append(alsimpl[pi].seqs[si].bytes(), byte(seq.CharAt(pos)))
 					}
 				}
 				if firstpos {
@@ -1637,6 +1915,7 @@ func (a *align) Split(part *PartitionSet) (als []Alignment, err error) {
 				firstpos = false
 			}
 		}
+		alsimpl[pi].annotations = a.annotations.selectSites(positions)
 	}
 	return
 }
@@ -1646,7 +1925,7 @@ func seqBagToAlignment(sb *seqbag) (al *align, err error) {
 
 	// We just check that sequence lengths are all equal
 	al.length = -1
-	sb.IterateChar(func(name string, s []rune) bool {
+	sb.IterateBytes(func(name string, s []byte) bool {
 		l := len(s)
 		if al.length != -1 && al.length != l {
 			err = fmt.Errorf("Sequence %s does not have same length as other sequences", name)
@@ -1661,3 +1940,55 @@ func seqBagToAlignment(sb *seqbag) (al *align, err error) {
 
 	return
 }
+
+// Rename renames sequences of the alignment based on the map in argument
+// (see seqbag.Rename). Sequence annotations are renamed accordingly.
+func (a *align) Rename(namemap map[string]string) {
+	a.seqbag.Rename(namemap)
+	a.annotations.renameSequences(namemap)
+}
+
+// RenameRegexp renames sequences of the alignment based on the given regex
+// and replace strings (see seqbag.RenameRegexp). Sequence annotations are
+// renamed accordingly.
+func (a *align) RenameRegexp(regex, replace string, namemap map[string]string) (err error) {
+	if namemap == nil {
+		namemap = make(map[string]string)
+	}
+	if err = a.seqbag.RenameRegexp(regex, replace, namemap); err != nil {
+		return
+	}
+	a.annotations.renameSequences(namemap)
+	return
+}
+
+// CleanNames cleans sequence names of the alignment (see seqbag.CleanNames).
+// Sequence annotations are renamed accordingly.
+func (a *align) CleanNames(namemap map[string]string) {
+	if namemap == nil {
+		namemap = make(map[string]string)
+	}
+	a.seqbag.CleanNames(namemap)
+	a.annotations.renameSequences(namemap)
+}
+
+// TrimNames shortens sequence names of the alignment (see seqbag.TrimNames).
+// Sequence annotations are renamed accordingly.
+func (a *align) TrimNames(namemap map[string]string, size int) (err error) {
+	if err = a.seqbag.TrimNames(namemap, size); err != nil {
+		return
+	}
+	a.annotations.renameSequences(namemap)
+	return
+}
+
+// TrimNamesAuto renames sequences of the alignment with automatic
+// short identifiers (see seqbag.TrimNamesAuto).
+// Sequence annotations are renamed accordingly.
+func (a *align) TrimNamesAuto(namemap map[string]string, curid *int) (err error) {
+	if err = a.seqbag.TrimNamesAuto(namemap, curid); err != nil {
+		return
+	}
+	a.annotations.renameSequences(namemap)
+	return
+}
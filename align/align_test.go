@@ -342,6 +342,52 @@ func TestRemoveOneGapSequence2(t *testing.T) {
 	}
 }
 
+// Rune accessors return the sequences themselves,
+// until they are modified by another method
+func TestGetSequenceCharWriteThrough(t *testing.T) {
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("s1", "ACGT", "")
+	a.AddSequence("s2", "ACGA", "")
+
+	seq0, _ := a.GetSequenceCharById(0)
+	seq0[0] = GAP
+	if s, _ := a.GetSequenceById(0); s != "-CGT" {
+		t.Errorf("Modifying the result of GetSequenceCharById should modify the alignment: %s", s)
+	}
+
+	a.IterateChar(func(name string, sequence []rune) bool {
+		sequence[1] = GAP
+		return false
+	})
+	if string(seq0) != "--GT" {
+		t.Errorf("Modifications done in IterateChar should be visible in the held sequence: %s", string(seq0))
+	}
+	if s, _ := a.GetSequenceById(1); s != "A-GA" {
+		t.Errorf("Modifications done in IterateChar should be copied back: %s", s)
+	}
+
+	a.Pack()
+	seq1, _ := a.GetSequenceChar("s2")
+	seq1[3] = 'T'
+	if s, _ := a.GetSequenceById(1); s != "A-GT" {
+		t.Errorf("Modifying the result of GetSequenceChar should modify a packed alignment: %s", s)
+	}
+}
+
+func TestNewSequenceNonASCII(t *testing.T) {
+	s := NewSequence("s1", []rune("ACéGT"), "")
+	if s.Sequence() != "AC*GT" {
+		t.Errorf("Non ASCII characters should be replaced by %c: %s", OTHER, s.Sequence())
+	}
+	if CheckSequenceChars([]rune("ACéGT")) == nil {
+		t.Errorf("CheckSequenceChars should detect non ASCII characters")
+	}
+	a := NewAlign(NUCLEOTIDS)
+	if err := a.AddSequence("s1", "ACéGT", ""); err == nil {
+		t.Errorf("AddSequence should refuse non ASCII characters")
+	}
+}
+
 func TestRemoveAllGapSequences(t *testing.T) {
 	a, err := RandomAlignment(AMINOACIDS, 300, 300)
 	if err != nil {
@@ -1885,3 +1931,405 @@ func Test_align_NumMutationsComparedToReferenceSequence(t *testing.T) {
 		}
 	}
 }
+
+// Alignment used by the benchmarks: 200 sequences of 10,000 nucleotides
+func benchAlignment(b *testing.B) Alignment {
+	a, err := RandomAlignment(NUCLEOTIDS, 10000, 200)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return a
+}
+
+func BenchmarkCompress(b *testing.B) {
+	a := benchAlignment(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		c, _ := a.Clone()
+		b.StartTimer()
+		c.Compress()
+	}
+}
+
+func BenchmarkCharStatsSite(b *testing.B) {
+	a := benchAlignment(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for site := 0; site < a.Length(); site++ {
+			a.CharStatsSite(site)
+		}
+	}
+}
+
+func BenchmarkBuildBootstrap(b *testing.B) {
+	a := benchAlignment(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a.BuildBootstrap()
+	}
+}
+
+func BenchmarkPack(b *testing.B) {
+	a := benchAlignment(b)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		c, _ := a.Clone()
+		b.StartTimer()
+		c.Pack()
+	}
+}
+
+func TestSubAlignFeature(t *testing.T) {
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("ref", "AT-GAAACCCTAAGG", "")
+	a.AddSequence("s2", "ATTGAAACCCTGAGG", "")
+
+	// CDS on the reverse strand: complement(join(1..3,6..8)), with codon_start=2
+	f := NewFeature("CDS", Location{{Start: 5, End: 8, Complement: true}, {Start: 0, End: 3, Complement: true}})
+	f.AddQualifier("codon_start", "2")
+
+	sub, err := a.SubAlignFeature("ref", f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{"ref_CDS": "GTC-AT", "s2_CDS": "GTCAAT"}
+	for name, seq := range exp {
+		if s, ok := sub.GetSequence(name); !ok || s != seq {
+			t.Errorf("Sequence %s is not as expected: %s vs. %s", name, s, seq)
+		}
+	}
+
+	if _, err = a.SubAlignFeature("none", f); err == nil {
+		t.Errorf("There should be an error: reference sequence does not exist")
+	}
+}
+
+func TestVariants(t *testing.T) {
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("ref", "AC-GTNAC", "")
+	a.AddSequence("s1", "ACTGTAAC", "")
+	a.AddSequence("s2", "GCAG-CRC", "")
+	a.AddSequence("s3", "aC-GAGCC", "")
+
+	ref, _ := a.SequenceByName("ref")
+	samples, variants, err := a.Variants(ref, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(samples) != 3 || samples[0] != "s1" || samples[2] != "s3" {
+		t.Errorf("Samples are not as expected: %v", samples)
+	}
+	// Site 2 is not reported (gap in ref), site 5 neither (N in ref),
+	// characters are compared case insensitively, and ambiguities are missing
+	exp := []Variant{
+		{Site: 0, RefPos: 0, Ref: "A", Alts: []string{"G"}, Genotypes: []int{0, 1, 0}, Sites: []int{0}},
+		{Site: 4, RefPos: 3, Ref: "T", Alts: []string{"A"}, Genotypes: []int{0, -1, 1}, Sites: []int{4}},
+		{Site: 6, RefPos: 5, Ref: "A", Alts: []string{"C"}, Genotypes: []int{0, -1, 1}, Sites: []int{6}},
+	}
+	if len(variants) != len(exp) {
+		t.Fatalf("There should be %d variants (%d)", len(exp), len(variants))
+	}
+	for i, v := range variants {
+		if fmt.Sprint(*v) != fmt.Sprint(exp[i]) {
+			t.Errorf("Variant %d is not as expected: %v vs. %v", i, *v, exp[i])
+		}
+	}
+
+	// With deletions: anchored on the previous reference base
+	if _, variants, err = a.Variants(ref, true); err != nil {
+		t.Fatal(err)
+	}
+	if v := variants[1]; v.Site != 3 || v.RefPos != 2 || v.Ref != "GT" || fmt.Sprint(v.Alts) != "[G GA]" || fmt.Sprint(v.Genotypes) != "[0 1 2]" || fmt.Sprint(v.Sites) != "[4]" {
+		t.Errorf("Variant with deletion is not as expected: %v", *v)
+	}
+
+	// Deletions at the start of the reference (anchored on the next base)
+	// and overlapping deletions are merged, and common suffixes removed:
+	// both deletions of the CC homopolymer give the same allele
+	d := NewAlign(NUCLEOTIDS)
+	d.AddSequence("ref", "ACGTACCG", "")
+	d.AddSequence("s1", "--GTACCG", "")
+	d.AddSequence("s2", "A--TACCG", "")
+	d.AddSequence("s3", "ACG-AC-G", "")
+	d.AddSequence("s4", "ACGTA-CG", "")
+	ref, _ = d.SequenceByName("ref")
+	if _, variants, err = d.Variants(ref, true); err != nil {
+		t.Fatal(err)
+	}
+	exp = []Variant{
+		{Site: 0, RefPos: 0, Ref: "ACGT", Alts: []string{"GT", "AT", "ACG"}, Genotypes: []int{1, 2, 3, 0}, Sites: []int{0, 1, 2, 3}},
+		{Site: 4, RefPos: 4, Ref: "AC", Alts: []string{"A"}, Genotypes: []int{0, 0, 1, 1}, Sites: []int{5, 6}},
+	}
+	if len(variants) != len(exp) {
+		t.Fatalf("There should be %d variants (%d)", len(exp), len(variants))
+	}
+	for i, v := range variants {
+		if fmt.Sprint(*v) != fmt.Sprint(exp[i]) {
+			t.Errorf("Deletion variant %d is not as expected: %v vs. %v", i, *v, exp[i])
+		}
+	}
+
+	snps, err := a.SelectSites([]int{0, 4, 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := snps.GetSequence("s2"); s != "G-R" {
+		t.Errorf("SNP alignment is not as expected: %s", s)
+	}
+	if _, err = a.SelectSites([]int{8}); err == nil {
+		t.Errorf("There should be an error: site outside the alignment")
+	}
+}
+
+func TestStats(t *testing.T) {
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("s1", "AC-GT", "")
+	a.AddSequence("s2", "ACTGT", "")
+	a.AddSequence("s3", "GC-GA", "")
+
+	stats := a.Stats()
+	if stats.Length != 5 || stats.NbSequences != 3 || stats.VariableSites != 2 || stats.Alphabet != "nucleotide" {
+		t.Errorf("Wrong alignment stats: %v", stats)
+	}
+	if stats.AvgAlleles != a.AvgAllelesPerSite() {
+		t.Errorf("Wrong average number of alleles: have %f, want %f", stats.AvgAlleles, a.AvgAllelesPerSite())
+	}
+	expchars := []CharCount{{"-", 2, 2.0 / 15}, {"A", 3, 3.0 / 15}, {"C", 3, 3.0 / 15}, {"G", 4, 4.0 / 15}, {"T", 3, 3.0 / 15}}
+	if !reflect.DeepEqual(expchars, stats.Characters) {
+		t.Errorf("Wrong character counts: have %v, want %v", stats.Characters, expchars)
+	}
+}
+
+func TestSequenceStats(t *testing.T) {
+	var stats []SequenceStats
+	var err error
+
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("s1", "AC-GT", "")
+	a.AddSequence("s2", "ACTGT", "")
+	a.AddSequence("s3", "GC-GA", "")
+
+	if stats, err = a.SequenceStats(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats) != 3 {
+		t.Fatalf("Wrong number of sequence stats: %d", len(stats))
+	}
+	if s := stats[0]; s.Name != "s1" || s.Gaps != 1 || s.GapsOpenning != 1 || s.GapsUnique != 0 || s.Length != 4 || s.CharCounts["A"] != 1 {
+		t.Errorf("Wrong stats for s1: %v", s)
+	}
+	if s := stats[2]; s.MutUnique != 2 || s.CharCounts["G"] != 2 || s.MutRef != nil || s.GapsNew != nil {
+		t.Errorf("Wrong stats for s3: %v", s)
+	}
+
+	ref := NewSequence("ref", []rune("ACTGT"), "")
+	if stats, err = a.SequenceStats(ref, NewCountProfileFromAlignment(a)); err != nil {
+		t.Fatal(err)
+	}
+	for i, exp := range []int{0, 0, 2} {
+		if stats[i].MutRef == nil || *stats[i].MutRef != exp {
+			t.Errorf("Wrong number of mutations compared to the reference for %s: want %d", stats[i].Name, exp)
+		}
+	}
+	if stats[0].GapsNew == nil || stats[0].MutBoth == nil {
+		t.Errorf("Profile stats should be defined when a profile is given")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var issues []ValidationIssue
+	var err error
+
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("sequence_001", "ACGTACGT", "")
+	a.AddSequence("sequence_002", "ACGTAC-T", "")
+	a.AddSequence("s(1)", "--------", "")
+	a.AddSequence("s-1-", "NNNN-NNN", "")
+	a.AddSequence("s3", "ACGTAC*T", "")
+	a.AddSequence("s3", "ACGTACGA", "")
+
+	if issues, err = a.Validate(DefaultValidationChecks, GENETIC_CODE_STANDARD); err != nil {
+		t.Fatal(err)
+	}
+	exp := []ValidationIssue{
+		{CHECK_DUPLICATE_NAMES, SEVERITY_ERROR, "s3_0001", -1, "Sequence name s3 is duplicated (renamed s3_0001)"},
+		{CHECK_PHYLIP_NAMES, SEVERITY_WARNING, "sequence_002", -1, "Sequence names sequence_001 and sequence_002 are both sequence_0 after truncation to 10 characters"},
+		{CHECK_CLEAN_NAMES, SEVERITY_ERROR, "s-1-", -1, "Sequence names s(1) and s-1- are both s-1- after name cleaning"},
+		{CHECK_ALPHABET, SEVERITY_ERROR, "s3", 6, "Characters outside the nucleotide alphabet: *"},
+		{CHECK_ALL_GAPS, SEVERITY_ERROR, "s(1)", -1, "Sequence is only made of gaps"},
+		{CHECK_ALL_N, SEVERITY_WARNING, "s-1-", -1, "Sequence is only made of N"},
+	}
+	if !reflect.DeepEqual(exp, issues) {
+		t.Errorf("Wrong validation issues:\nhave %v\nwant %v", issues, exp)
+	}
+
+	// Legitimate names ending with 4 digits, and ignored identical sequences
+	a = NewAlign(NUCLEOTIDS)
+	a.IgnoreIdentical(true)
+	a.AddSequence("s1", "ACGT", "")
+	a.AddSequence("s1_0001", "ACGA", "")
+	a.AddSequence("s2", "ACGT", "")
+	a.AddSequence("s2", "ACGT", "")
+	if issues, err = a.Validate([]string{CHECK_DUPLICATE_NAMES}, GENETIC_CODE_STANDARD); err != nil {
+		t.Fatal(err)
+	}
+	exp = []ValidationIssue{
+		{CHECK_DUPLICATE_NAMES, SEVERITY_WARNING, "s2", -1, "Sequence name s2 is duplicated (identical sequence ignored)"},
+	}
+	if !reflect.DeepEqual(exp, issues) {
+		t.Errorf("Wrong duplicate issues:\nhave %v\nwant %v", issues, exp)
+	}
+
+	// Terminal stop codons are not reported, the first sequence is checked
+	a = NewAlign(NUCLEOTIDS)
+	a.AddSequence("ref", "ATGTAACCCGGGTAA", "")
+	a.AddSequence("s1", "ATGAAATAGGGGTAA", "")
+	a.AddSequence("s2", "ATGAAACCC---TGA", "")
+	a.AddSequence("s3", "ATGAAACCCGGGTAG", "")
+	if issues, err = a.Validate([]string{CHECK_STOPS}, GENETIC_CODE_STANDARD); err != nil {
+		t.Fatal(err)
+	}
+	exp = []ValidationIssue{
+		{CHECK_STOPS, SEVERITY_ERROR, "ref", -1, "Stop codon in frame at position 6 of the sequence (without gaps)"},
+		{CHECK_STOPS, SEVERITY_ERROR, "s1", -1, "Stop codon in frame at position 9 of the sequence (without gaps)"},
+	}
+	if !reflect.DeepEqual(exp, issues) {
+		t.Errorf("Wrong stop issues:\nhave %v\nwant %v", issues, exp)
+	}
+
+	// Unknown alphabet and stray amino acid in nucleotides:
+	// checked against the closest alphabet
+	for _, c := range []struct {
+		seq      string
+		alphabet int
+		pos      int
+		char     string
+	}{
+		{"ACGT1CGT", UNKNOWN, 4, "1"},
+		{"ACGTECGT", AMINOACIDS, 4, "E"},
+	} {
+		a = NewAlign(UNKNOWN)
+		a.AddSequence("a", c.seq, "")
+		a.AddSequence("b", "ACGTACGT", "")
+		a.AutoAlphabet()
+		if a.Alphabet() != c.alphabet {
+			t.Errorf("Wrong detected alphabet for %s: %s", c.seq, a.AlphabetStr())
+		}
+		if issues, err = a.Validate([]string{CHECK_ALPHABET}, GENETIC_CODE_STANDARD); err != nil {
+			t.Fatal(err)
+		}
+		exp = []ValidationIssue{
+			{CHECK_ALPHABET, SEVERITY_ERROR, "a", c.pos, "Characters outside the nucleotide alphabet: " + c.char},
+		}
+		if !reflect.DeepEqual(exp, issues) {
+			t.Errorf("Wrong alphabet issues:\nhave %v\nwant %v", issues, exp)
+		}
+	}
+
+	// Proteins are checked as proteins
+	a = NewAlign(UNKNOWN)
+	a.AddSequence("a", "MKLPQEIR1", "")
+	a.AddSequence("b", "MKLPQEIRW", "")
+	a.AutoAlphabet()
+	if issues, err = a.Validate([]string{CHECK_ALPHABET}, GENETIC_CODE_STANDARD); err != nil {
+		t.Fatal(err)
+	}
+	exp = []ValidationIssue{
+		{CHECK_ALPHABET, SEVERITY_ERROR, "a", 8, "Characters outside the protein alphabet: 1"},
+	}
+	if !reflect.DeepEqual(exp, issues) {
+		t.Errorf("Wrong protein alphabet issues:\nhave %v\nwant %v", issues, exp)
+	}
+
+	if _, err = a.Validate([]string{"unknown"}, GENETIC_CODE_STANDARD); err == nil {
+		t.Errorf("Unknown check should return an error")
+	}
+}
+
+func TestWeightedPssm(t *testing.T) {
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("s1", "AC", "")
+	a.AddSequence("s2", "AC", "")
+	a.AddSequence("s3", "AG", "")
+	a.AddSequence("s4", "A-", "")
+
+	weights := a.HenikoffWeights()
+	// Site 1: all A, 1/4 each. Site 2: C 1/2 shared by 2 sequences, G 1/2
+	exp := []float64{4 * 0.5 / 2, 4 * 0.5 / 2, 4 * 0.75 / 2, 4 * 0.25 / 2}
+	if !reflect.DeepEqual(exp, weights) {
+		t.Errorf("Wrong Henikoff weights: have %v, want %v", weights, exp)
+	}
+
+	pssm, err := a.WeightedPssm(weights, false, 0, PSSM_NORM_NONE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pssm['A'][0] != 4 || pssm['C'][1] != 2 || pssm['G'][1] != 1.5 {
+		t.Errorf("Wrong weighted counts: A:%v C:%v G:%v", pssm['A'], pssm['C'], pssm['G'])
+	}
+
+	// Logo without pseudo counts: 2 bits at the conserved site
+	if pssm, err = a.Pssm(false, 0, PSSM_NORM_LOGO); err != nil {
+		t.Fatal(err)
+	}
+	if pssm['A'][0] != 2 || pssm['C'][0] != 0 || math.IsNaN(pssm['C'][1]) {
+		t.Errorf("Wrong logo values: A:%v C:%v", pssm['A'], pssm['C'])
+	}
+
+	if _, err = a.WeightedPssm([]float64{1}, false, 0, PSSM_NORM_NONE); err == nil {
+		t.Errorf("Wrong number of weights should return an error")
+	}
+}
+
+func TestSampleAnnotations(t *testing.T) {
+	a := NewAlign(NUCLEOTIDS)
+	a.AddSequence("s1", "ACGT", "")
+	a.AddSequence("s2", "ACGA", "")
+	a.AddSequence("s3", "ACGC", "")
+	an := a.Annotations()
+	an.AddFileAnnotation("ID", "test")
+	an.AddColumnAnnotation("SS_cons", []rune("<..>"))
+	an.AddSequenceAnnotation("s1", "AC", "P00001")
+	an.AddSequenceAnnotation("s2", "AC", "P00002")
+	an.AddResidueAnnotation("s2", "SS", []rune("<..>"))
+
+	rarefied, err := a.Rarefy(1, map[string]int{"s1": 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampled, err := a.Sample(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, al := range []Alignment{rarefied, sampled} {
+		nbseq, nbres, nbfile, nbcol := 0, 0, 0, 0
+		al.Annotations().IterateSequences(func(n, feature, text string) bool {
+			if _, ok := al.SequenceByName(n); !ok {
+				t.Errorf("Annotation of sequence %s should have been removed", n)
+			}
+			nbseq++
+			return false
+		})
+		al.Annotations().IterateResidues(func(n, feature string, annot []rune) bool {
+			if _, ok := al.SequenceByName(n); !ok {
+				t.Errorf("Residue annotation of sequence %s should have been removed", n)
+			}
+			nbres++
+			return false
+		})
+		al.Annotations().IterateFile(func(feature, text string) bool { nbfile++; return false })
+		al.Annotations().IterateColumns(func(feature string, annot []rune) bool { nbcol++; return false })
+		if nbfile != 1 || nbcol != 1 || nbseq+nbres > 2 {
+			t.Errorf("Wrong number of annotations: %d file, %d column, %d sequence, %d residue", nbfile, nbcol, nbseq, nbres)
+		}
+	}
+	if rarefied.NbSequences() != 1 || rarefied.Annotations().sequences[0].name != "s1" || len(rarefied.Annotations().residues) != 0 {
+		t.Errorf("Rarefied alignment should only keep annotations of s1")
+	}
+}
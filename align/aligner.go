@@ -13,11 +13,15 @@ type PairwiseAligner interface {
 	SetGapOpenScore(open float64)
 	SetGapExtendScore(extend float64)
 	SetScore(match, mismatch float64)
-	MaxScore() float64 // Maximum score of the alignment
-	NbMatches() int    // Number of matches
-	NbMisMatches() int // Number of mismatches
-	NbGaps() int       // Nuber of gaps
-	Length() int       // Length of the alignment
+	SetLinearSpace(linear bool)
+	SetBand(width int)
+	MaxScore() float64        // Maximum score of the alignment
+	NbMatches() int           // Number of matches
+	NbMisMatches() int        // Number of mismatches
+	NbGaps() int              // Nuber of gaps
+	Length() int              // Length of the alignment
+	PercentIdentity() float64 // Percentage of identical residues
+	Cigar() (string, int)     // CIGAR string of the second sequence against the first one, and its 0-based start
 	Alignment() (Alignment, error)
 	AlignmentStr() string
 }
@@ -28,16 +32,29 @@ const (
 	ALIGN_DIAG
 	ALIGN_STOP
 
-	ALIGN_ALGO_SW = iota
+	ALIGN_ALGO_SW = iota // Local alignment (Smith & Waterman)
 	ALIGN_ALGO_ATG
+	ALIGN_ALGO_NW         // Global alignment (Needleman & Wunsch), with affine gaps
+	ALIGN_ALGO_SEMIGLOBAL // Global alignment without scoring gaps at the ends of the sequences
 )
 
+// Above this number of cells, dynamic programming matrices are not
+// stored, and alignments are computed in linear memory
+const pwMaxMatrixCells = 10000000
+
 type pwaligner struct {
 	algo   int
 	matrix [][]float64 // dynamix programming matrix
 	trace  [][]int     // trace matrix
 	maxa   []float64   // keep track of best gap opened
 
+	// Gotoh matrices, for global and semi-global alignments:
+	// match/mismatch, gap in seq1, and gap in seq2
+	mmat, gap1mat, gap2mat [][]float64
+
+	linear bool // Linear memory alignment
+	band   int  // Width of the band around the diagonals (0: no band)
+
 	maxscore         float64 // Maximum score of the matrix
 	nbmatches        int     // number of matches
 	nbmismatches     int     // number of mismatches
@@ -116,6 +133,20 @@ func (a *pwaligner) SetGapExtendScore(gap float64) {
 	a.gapextend = gap
 }
 
+// Computes the alignment in linear memory (Myers & Miller
+// algorithm), instead of storing the whole matrices.
+// This is done automatically for long sequences.
+func (a *pwaligner) SetLinearSpace(linear bool) {
+	a.linear = linear
+}
+
+// Restricts global and semi-global alignments to the diagonals
+// at most width away from the diagonals of the first and of the
+// last cells of the matrix. 0 means no band.
+func (a *pwaligner) SetBand(width int) {
+	a.band = width
+}
+
 // Sets manually match and mismatch scores
 // Substitution matrix is not used any more
 func (a *pwaligner) SetScore(match, mismatch float64) {
@@ -153,6 +184,8 @@ func (a *pwaligner) fillMatrix() (err error) {
 		a.seq1.Reverse()
 		a.seq2.Reverse()
 		err = a.fillMatrix_SW()
+	case ALIGN_ALGO_NW, ALIGN_ALGO_SEMIGLOBAL:
+		err = a.fillMatrix_NW()
 	default:
 		err = a.fillMatrix_SW()
 	}
@@ -249,12 +282,12 @@ func (a *pwaligner) fillMatrix_SW() (err error) {
 
 	// Each line
 	for i := 1; i < l1; i++ {
-		c1 = a.seq1.SequenceChar()[i]
+		c1 = a.seq1.CharAt(i)
 		// Temp value for max left gap extensions of this line
 		bx := a.matrix[i][0] + a.gapopen + a.gapextend
 		// Each column
 		for j := 1; j < l2; j++ {
-			c2 = a.seq2.SequenceChar()[j]
+			c2 = a.seq2.CharAt(j)
 			match = a.matchScore(c1, c2, indexseq1[i], indexseq2[j])
 
 			// diag score
@@ -354,6 +387,8 @@ func (a *pwaligner) backTrack() {
 		Reverse(a.seq2ali)
 		a.start1, a.end1 = a.seq1.Length()-a.end1-1, a.seq1.Length()-a.start1-1
 		a.start2, a.end2 = a.seq2.Length()-a.end2-1, a.seq2.Length()-a.start2-1
+	case ALIGN_ALGO_NW, ALIGN_ALGO_SEMIGLOBAL:
+		a.backTrack_NW()
 	default:
 		a.backTrack_SW()
 	}
@@ -461,10 +496,19 @@ func (a *pwaligner) Seq2Ali() []rune {
 
 func (a *pwaligner) Alignment() (align Alignment, err error) {
 
-	if err = a.fillMatrix(); err != nil {
+	switch {
+	case a.band > 0:
+		err = a.alignBanded()
+	case a.linear || a.seq1.Length()*a.seq2.Length() > pwMaxMatrixCells:
+		err = a.alignLinear()
+	default:
+		if err = a.fillMatrix(); err == nil {
+			a.backTrack()
+		}
+	}
+	if err != nil {
 		return
 	}
-	a.backTrack()
 	align = NewAlign(UNKNOWN)
 	align.AddSequenceChar(a.seq1.Name(), a.seq1ali, "")
 	align.AddSequenceChar(a.seq2.Name(), a.seq2ali, "")
@@ -479,7 +523,7 @@ func (a *pwaligner) seqToindices(s Sequence) (indices []int, err error) {
 	var ok bool
 
 	indices = make([]int, s.Length())
-	for i = 0; i < len(s.SequenceChar()); i++ {
+	for i = 0; i < s.Length(); i++ {
 		indices[i], ok = a.chartopos[unicode.ToUpper(s.CharAt(i))]
 		if !ok {
 			err = fmt.Errorf("Character not part of alphabet : %c", s.CharAt(i))
@@ -0,0 +1,203 @@
+package align
+
+import (
+	"errors"
+	"math"
+)
+
+// Banded global and semi-global alignments: the Gotoh matrices (see
+// fillMatrix_NW) are only computed for the cells (i, j) such that
+// lo <= j-i <= hi, i.e. at most a.band diagonals away from the diagonals
+// of the first and of the last cells of the matrix. Only two rows of
+// scores are kept, and the previous state of each state of each cell is
+// stored in one byte (bits 0-1: match, bits 2-3: gap in seq2, bits 4-5:
+// gap in seq1), so that the memory is proportional to
+// l1*(band*2+|l2-l1|).
+func (a *pwaligner) alignBanded() (err error) {
+	var indexseq1, indexseq2 []int
+
+	if a.algo != ALIGN_ALGO_NW && a.algo != ALIGN_ALGO_SEMIGLOBAL {
+		return errors.New("Banded alignment is only available for global and semi-global alignments")
+	}
+	if indexseq1, err = a.seqToindices(a.seq1); err != nil {
+		return
+	}
+	if indexseq2, err = a.seqToindices(a.seq2); err != nil {
+		return
+	}
+
+	l1 := a.seq1.Length()
+	l2 := a.seq2.Length()
+	lo, hi := -a.band, a.band
+	if l2 > l1 {
+		hi += l2 - l1
+	} else {
+		lo += l2 - l1
+	}
+	width := hi - lo + 1
+	// Index of cell (i, j) in the band of row i
+	k := func(i, j int) int { return j - i - lo }
+
+	inf := math.Inf(-1)
+	newRow := func() []float64 {
+		r := make([]float64, width+1)
+		for d := range r {
+			r[d] = inf
+		}
+		return r
+	}
+	m, gap2, gap1 := newRow(), newRow(), newRow()
+	pm, pgap2, pgap1 := newRow(), newRow(), newRow()
+	trace := make([]byte, (l1+1)*width)
+
+	// Ends of the alignment on the last column and on the last row
+	lastcol, lastcoli, lastcolstate := inf, 0, nwStateMatch
+	var lastrow []float64
+	var lastrowstate []int
+
+	for i := 0; i <= l1; i++ {
+		m, pm = pm, m
+		gap2, pgap2 = pgap2, gap2
+		gap1, pgap1 = pgap1, gap1
+		jmin, jmax := i+lo, i+hi
+		if jmin < 0 {
+			jmin = 0
+		}
+		if jmax > l2 {
+			jmax = l2
+		}
+		for d := range m {
+			m[d], gap2[d], gap1[d] = inf, inf, inf
+		}
+		for j := jmin; j <= jmax; j++ {
+			d := k(i, j)
+			switch {
+			case i == 0 && j == 0:
+				m[d] = .0
+			case j == 0:
+				gap2[d] = a.endGapScore(i)
+				trace[i*width+d] = nwStateGap2 << 2
+			case i == 0:
+				gap1[d] = a.endGapScore(j)
+				trace[i*width+d] = nwStateGap1 << 4
+			default:
+				// Match: diagonal, same index in the previous row
+				s := a.matchScore(a.seq1.CharAt(i-1), a.seq2.CharAt(j-1), indexseq1[i-1], indexseq2[j-1])
+				state := argmax3(pm[d], pgap2[d], pgap1[d])
+				m[d] = max3(pm[d], pgap2[d], pgap1[d]) + s
+				tr := byte(state)
+				// Gap in seq2: cell (i-1, j), next index in the previous row
+				gap2[d], state = bandedGap(pm[d+1]+a.gapopen, pgap1[d+1]+a.gapopen, pgap2[d+1]+a.gapextend, nwStateGap1, nwStateGap2)
+				tr |= byte(state) << 2
+				// Gap in seq1: cell (i, j-1), previous index in the row
+				if d > 0 {
+					gap1[d], state = bandedGap(m[d-1]+a.gapopen, gap2[d-1]+a.gapopen, gap1[d-1]+a.gapextend, nwStateGap2, nwStateGap1)
+					tr |= byte(state) << 4
+				}
+				trace[i*width+d] = tr
+			}
+		}
+		if jmax == l2 {
+			d := k(i, l2)
+			if s := max3(m[d], gap2[d], gap1[d]); s > lastcol {
+				lastcol, lastcoli, lastcolstate = s, i, argmax3(m[d], gap2[d], gap1[d])
+			}
+		}
+		if i == l1 {
+			lastrow = make([]float64, l2+1)
+			lastrowstate = make([]int, l2+1)
+			for j := range lastrow {
+				lastrow[j] = inf
+				if j >= jmin && j <= jmax {
+					d := k(i, j)
+					lastrow[j] = max3(m[d], gap2[d], gap1[d])
+					lastrowstate[j] = argmax3(m[d], gap2[d], gap1[d])
+				}
+			}
+		}
+	}
+
+	// End of the alignment (see fillMatrix_NW)
+	a.maxi, a.maxj = l1, l2
+	a.maxscore = lastrow[l2]
+	state := lastrowstate[l2]
+	if a.algo == ALIGN_ALGO_SEMIGLOBAL {
+		if lastcol > a.maxscore {
+			a.maxscore, a.maxi, state = lastcol, lastcoli, lastcolstate
+		}
+		for j := 0; j <= l2; j++ {
+			if lastrow[j] > a.maxscore {
+				a.maxscore, a.maxi, a.maxj, state = lastrow[j], l1, j, lastrowstate[j]
+			}
+		}
+	}
+
+	a.backTrackBanded(trace, width, lo, state)
+	return
+}
+
+// bandedGap returns the best score of a gap state, from the scores of
+// opening it after a match or after a gap in the other sequence, or of
+// extending it, with the corresponding previous state. Ties are resolved
+// as in backTrack_NW.
+func bandedGap(open, openother, extend float64, other, self int) (float64, int) {
+	if open >= openother && open >= extend {
+		return open, nwStateMatch
+	}
+	if openother >= extend {
+		return openother, other
+	}
+	return extend, self
+}
+
+// backTrackBanded builds the alignment from the band trace, starting from
+// the end of the alignment (a.maxi, a.maxj), in the given state.
+func (a *pwaligner) backTrackBanded(trace []byte, width, lo, state int) {
+	l1 := a.seq1.Length()
+	l2 := a.seq2.Length()
+	seq1 := make([]rune, 0, l1+l2)
+	seq2 := make([]rune, 0, l1+l2)
+
+	// Unscored end gaps (semi-global mode)
+	for i := l1; i > a.maxi; i-- {
+		seq1 = append(seq1, a.seq1.CharAt(i-1))
+		seq2 = append(seq2, GAP)
+	}
+	for j := l2; j > a.maxj; j-- {
+		seq1 = append(seq1, GAP)
+		seq2 = append(seq2, a.seq2.CharAt(j-1))
+	}
+
+	i, j := a.maxi, a.maxj
+	for i > 0 || j > 0 {
+		// First row and first column: only gaps
+		if j == 0 {
+			state = nwStateGap2
+		} else if i == 0 {
+			state = nwStateGap1
+		}
+		tr := trace[i*width+j-i-lo]
+		switch state {
+		case nwStateMatch:
+			seq1 = append(seq1, a.seq1.CharAt(i-1))
+			seq2 = append(seq2, a.seq2.CharAt(j-1))
+			i--
+			j--
+			state = int(tr & 3)
+		case nwStateGap2:
+			seq1 = append(seq1, a.seq1.CharAt(i-1))
+			seq2 = append(seq2, GAP)
+			i--
+			state = int(tr >> 2 & 3)
+		case nwStateGap1:
+			seq1 = append(seq1, GAP)
+			seq2 = append(seq2, a.seq2.CharAt(j-1))
+			j--
+			state = int(tr >> 4 & 3)
+		}
+	}
+
+	Reverse(seq1)
+	Reverse(seq2)
+	a.setAlignment(seq1, seq2)
+}
@@ -0,0 +1,472 @@
+package align
+
+import (
+	"math"
+)
+
+// Linear memory alignment (Myers & Miller 1988, after Hirschberg 1975),
+// for sequences whose dynamic programming matrices would not fit in
+// memory. Global alignments are computed by divide and conquer: the
+// middle row of the matrix is computed forward and backward in linear
+// memory, the optimal path crossing this row is located, and both halves
+// are aligned recursively.
+//
+// Local and semi-global alignments are first delimited by two linear
+// memory passes: a forward pass gives the end of the best alignment and
+// its score, and a backward pass anchored on this end gives its start.
+// The region between the start and the end is then aligned globally.
+//
+// ATG alignments are delimited the other way around: a backward local
+// pass gives the start of the best alignment beginning with the first
+// residue of seq1, and its score, and a forward pass anchored on this
+// start gives its end, at the end of seq1 or of seq2 (see backTrack).
+type linearAligner struct {
+	a                *pwaligner
+	seq1, seq2       []rune
+	index1, index2   []int // Positions in the substitution matrix
+	g, h             float64
+	cc, dd, rr, ss   []float64
+	seq1ali, seq2ali []rune
+	pos1, pos2       int // Next residues to add to the alignment
+}
+
+func (a *pwaligner) alignLinear() (err error) {
+	var l *linearAligner
+	var score float64
+	var s1, s2, e1, e2 int
+
+	if l, err = newLinearAligner(a); err != nil {
+		return
+	}
+	l1, l2 := len(l.seq1), len(l.seq2)
+
+	switch a.algo {
+	case ALIGN_ALGO_NW:
+		s1, s2, e1, e2 = 0, 0, l1, l2
+	case ALIGN_ALGO_ATG:
+		score, s2 = l.atgStart()
+		e1, e2 = l.atgEnd(s2)
+	default:
+		local := a.algo != ALIGN_ALGO_SEMIGLOBAL
+		score, e1, e2 = l.alignmentEnd(local)
+		s1, s2 = l.alignmentStart(e1, e2, local)
+	}
+
+	// Unscored start gaps (semi-global mode)
+	if a.algo == ALIGN_ALGO_SEMIGLOBAL {
+		l.del(s1)
+		l.ins(s2)
+	} else {
+		l.pos1, l.pos2 = s1, s2
+	}
+
+	cost := l.diff(s1, e1-s1, s2, e2-s2, l.g, l.g)
+	if a.algo == ALIGN_ALGO_NW {
+		score = -cost
+	}
+
+	// Unscored end gaps (semi-global mode)
+	if a.algo == ALIGN_ALGO_SEMIGLOBAL {
+		l.ins(l2 - e2)
+		l.del(l1 - e1)
+	}
+
+	a.setAlignment(l.seq1ali, l.seq2ali)
+	a.maxscore = score
+	a.maxi, a.maxj = e1, e2
+	if a.algo != ALIGN_ALGO_NW && a.algo != ALIGN_ALGO_SEMIGLOBAL {
+		// Local alignments do not contain the whole sequences
+		a.start1 += s1
+		a.end1 += s1
+		a.start2 += s2
+		a.end2 += s2
+	}
+	return
+}
+
+func newLinearAligner(a *pwaligner) (l *linearAligner, err error) {
+	l = &linearAligner{
+		a:    a,
+		seq1: []rune(a.seq1.Sequence()),
+		seq2: []rune(a.seq2.Sequence()),
+		// A gap of length k has a cost of g + k*h
+		g: a.gapextend - a.gapopen,
+		h: -a.gapextend,
+	}
+	if l.index1, err = a.seqToindices(a.seq1); err != nil {
+		return
+	}
+	if l.index2, err = a.seqToindices(a.seq2); err != nil {
+		return
+	}
+	n := len(l.seq2) + 1
+	l.cc = make([]float64, n)
+	l.dd = make([]float64, n)
+	l.rr = make([]float64, n)
+	l.ss = make([]float64, n)
+	l.seq1ali = make([]rune, 0, len(l.seq1)+len(l.seq2))
+	l.seq2ali = make([]rune, 0, len(l.seq1)+len(l.seq2))
+	return
+}
+
+// score of aligning residue i of seq1 with residue j of seq2
+func (l *linearAligner) score(i, j int) float64 {
+	return l.a.matchScore(l.seq1[i], l.seq2[j], l.index1[i], l.index2[j])
+}
+
+func (l *linearAligner) gap(length int) float64 {
+	if length <= 0 {
+		return .0
+	}
+	return l.g + float64(length)*l.h
+}
+
+// del adds n residues of seq1 aligned with gaps
+func (l *linearAligner) del(n int) {
+	for k := 0; k < n; k++ {
+		l.seq1ali = append(l.seq1ali, l.seq1[l.pos1])
+		l.seq2ali = append(l.seq2ali, GAP)
+		l.pos1++
+	}
+}
+
+// ins adds n residues of seq2 aligned with gaps
+func (l *linearAligner) ins(n int) {
+	for k := 0; k < n; k++ {
+		l.seq1ali = append(l.seq1ali, GAP)
+		l.seq2ali = append(l.seq2ali, l.seq2[l.pos2])
+		l.pos2++
+	}
+}
+
+// rep adds one residue of seq1 aligned with one residue of seq2
+func (l *linearAligner) rep() {
+	l.seq1ali = append(l.seq1ali, l.seq1[l.pos1])
+	l.seq2ali = append(l.seq2ali, l.seq2[l.pos2])
+	l.pos1++
+	l.pos2++
+}
+
+// diff aligns globally seq1[i0:i0+m] and seq2[j0:j0+n], and returns the
+// cost of the alignment. tb (resp. te) is the cost of opening a gap in
+// seq2 at the start (resp. at the end) of the region: 0 if the gap
+// continues a gap of the surrounding alignment, g otherwise.
+func (l *linearAligner) diff(i0, m, j0, n int, tb, te float64) float64 {
+	if n <= 0 {
+		l.del(m)
+		return l.gap(m)
+	}
+	if m <= 1 {
+		if m <= 0 {
+			l.ins(n)
+			return l.gap(n)
+		}
+		// Only one residue in seq1: either deleted, or aligned
+		// with one residue of seq2
+		midc := minf(tb, te) + l.h + l.gap(n)
+		midj := 0
+		for j := 1; j <= n; j++ {
+			if c := l.gap(j-1) - l.score(i0, j0+j-1) + l.gap(n-j); c < midc {
+				midc, midj = c, j
+			}
+		}
+		if midj == 0 {
+			if tb <= te {
+				l.del(1)
+				l.ins(n)
+			} else {
+				l.ins(n)
+				l.del(1)
+			}
+		} else {
+			l.ins(midj - 1)
+			l.rep()
+			l.ins(n - midj)
+		}
+		return midc
+	}
+
+	midi := m / 2
+	cc, dd, rr, ss := l.cc, l.dd, l.rr, l.ss
+
+	// Forward pass: costs from (i0, j0) to each cell of the middle row.
+	// cc: best cost, dd: best cost ending with a gap in seq2
+	cc[0] = .0
+	t := l.g
+	for j := 1; j <= n; j++ {
+		t += l.h
+		cc[j] = t
+		dd[j] = t + l.g
+	}
+	t = tb
+	for i := 1; i <= midi; i++ {
+		s := cc[0]
+		t += l.h
+		c := t
+		cc[0] = c
+		e := t + l.g
+		for j := 1; j <= n; j++ {
+			e = minf(e, c+l.g) + l.h
+			d := minf(dd[j], cc[j]+l.g) + l.h
+			c = minf(minf(d, e), s-l.score(i0+i-1, j0+j-1))
+			s = cc[j]
+			cc[j] = c
+			dd[j] = d
+		}
+	}
+	dd[0] = cc[0]
+
+	// Backward pass: costs from each cell of the middle row to (i0+m, j0+n).
+	// rr: best cost, ss: best cost starting with a gap in seq2
+	rr[n] = .0
+	t = l.g
+	for j := n - 1; j >= 0; j-- {
+		t += l.h
+		rr[j] = t
+		ss[j] = t + l.g
+	}
+	t = te
+	for i := m - 1; i >= midi; i-- {
+		s := rr[n]
+		t += l.h
+		c := t
+		rr[n] = c
+		e := t + l.g
+		for j := n - 1; j >= 0; j-- {
+			e = minf(e, c+l.g) + l.h
+			d := minf(ss[j], rr[j]+l.g) + l.h
+			c = minf(minf(d, e), s-l.score(i0+i, j0+j))
+			s = rr[j]
+			rr[j] = c
+			ss[j] = d
+		}
+	}
+	ss[n] = rr[n]
+
+	// Where the optimal path crosses the middle row: either through a
+	// cell (type 1), or with a gap in seq2 spanning the middle row (type 2)
+	midc := cc[0] + rr[0]
+	midj := 0
+	spanning := false
+	for j := 0; j <= n; j++ {
+		if c := cc[j] + rr[j]; c < midc || c == midc && cc[j] != dd[j] && rr[j] == ss[j] {
+			midc, midj = c, j
+		}
+	}
+	for j := n; j >= 0; j-- {
+		if c := dd[j] + ss[j] - l.g; c < midc {
+			midc, midj, spanning = c, j, true
+		}
+	}
+
+	if !spanning {
+		l.diff(i0, midi, j0, midj, tb, l.g)
+		l.diff(i0+midi, m-midi, j0+midj, n-midj, l.g, te)
+	} else {
+		l.diff(i0, midi-1, j0, midj, tb, .0)
+		l.del(2)
+		l.diff(i0+midi+1, m-midi-1, j0+midj, n-midj, .0, te)
+	}
+	return midc
+}
+
+// alignmentEnd computes the score of the best local (or semi-global)
+// alignment in linear memory, and returns it with the end of the
+// alignment: seq1[:e1] and seq2[:e2].
+func (l *linearAligner) alignmentEnd(local bool) (best float64, e1, e2 int) {
+	l1, l2 := len(l.seq1), len(l.seq2)
+	inf := math.Inf(-1)
+	gapopen, gapextend := l.a.gapopen, l.a.gapextend
+
+	// hh: best scores of the previous/current row,
+	// ee: best scores ending with a gap in seq2
+	hh := make([]float64, l2+1)
+	ee := make([]float64, l2+1)
+	for j := range ee {
+		ee[j] = inf
+	}
+
+	// Semi-global: last column, then last row (see fillMatrix_NW)
+	lastcol, lastcoli := .0, 0
+	if local {
+		best = .0
+	}
+	for i := 1; i <= l1; i++ {
+		diag := hh[0]
+		f := inf
+		for j := 1; j <= l2; j++ {
+			ee[j] = maxf(ee[j]+gapextend, hh[j]+gapopen)
+			f = maxf(f+gapextend, hh[j-1]+gapopen)
+			h := maxf(maxf(diag+l.score(i-1, j-1), ee[j]), f)
+			if local && h < .0 {
+				h = .0
+			}
+			diag = hh[j]
+			hh[j] = h
+			if local && h > best {
+				best, e1, e2 = h, i, j
+			}
+		}
+		if !local && hh[l2] > lastcol {
+			lastcol, lastcoli = hh[l2], i
+		}
+	}
+
+	if !local {
+		best, e1, e2 = hh[l2], l1, l2
+		if lastcol > best {
+			best, e1 = lastcol, lastcoli
+		}
+		for j := 0; j <= l2; j++ {
+			if hh[j] > best {
+				best, e1, e2 = hh[j], l1, j
+			}
+		}
+	}
+	return
+}
+
+// alignmentStart returns the start (s1, s2) of the best local (or
+// semi-global) alignment ending at (e1, e2): seq1[:e1] and seq2[:e2] are
+// aligned backward, from their ends, in linear memory. The start is
+// the best scoring cell (local), or the best scoring cell of the first
+// row or of the first column (semi-global).
+func (l *linearAligner) alignmentStart(e1, e2 int, local bool) (s1, s2 int) {
+	inf := math.Inf(-1)
+	gapopen, gapextend := l.a.gapopen, l.a.gapextend
+	best := inf
+	s1, s2 = e1, e2
+
+	hh := make([]float64, e2+1)
+	ee := make([]float64, e2+1)
+	ee[0] = inf
+	for j := 1; j <= e2; j++ {
+		hh[j] = gapopen + float64(j-1)*gapextend
+		ee[j] = inf
+	}
+	// Semi-global: cells of the first column and of the first row
+	border := func(i int) {
+		for j := 0; j <= e2; j++ {
+			if (i == e1 || j == e2) && hh[j] > best {
+				best, s1, s2 = hh[j], e1-i, e2-j
+			}
+		}
+	}
+	if !local {
+		border(0)
+	}
+
+	for i := 1; i <= e1; i++ {
+		diag := hh[0]
+		hh[0] = gapopen + float64(i-1)*gapextend
+		f := inf
+		for j := 1; j <= e2; j++ {
+			ee[j] = maxf(ee[j]+gapextend, hh[j]+gapopen)
+			f = maxf(f+gapextend, hh[j-1]+gapopen)
+			h := maxf(maxf(diag+l.score(e1-i, e2-j), ee[j]), f)
+			diag = hh[j]
+			hh[j] = h
+			if local && h > best {
+				best, s1, s2 = h, e1-i, e2-j
+			}
+		}
+		if !local {
+			border(i)
+		}
+	}
+	return
+}
+
+// atgStart computes the score of the best local alignment starting with
+// the first residue of seq1 in linear memory, and returns it with the
+// start of the alignment in seq2. As for the full matrix (see fillMatrix),
+// sequences are aligned backward, and the best cell of the last row is
+// kept.
+func (l *linearAligner) atgStart() (best float64, s2 int) {
+	l1, l2 := len(l.seq1), len(l.seq2)
+	inf := math.Inf(-1)
+	gapopen, gapextend := l.a.gapopen, l.a.gapextend
+
+	hh := make([]float64, l2+1)
+	ee := make([]float64, l2+1)
+	for j := range ee {
+		ee[j] = inf
+	}
+	for i := 1; i <= l1; i++ {
+		diag := hh[0]
+		f := inf
+		for j := 1; j <= l2; j++ {
+			ee[j] = maxf(ee[j]+gapextend, hh[j]+gapopen)
+			f = maxf(f+gapextend, hh[j-1]+gapopen)
+			h := maxf(maxf(diag+l.score(l1-i, l2-j), ee[j]), f)
+			if h < .0 {
+				h = .0
+			}
+			diag = hh[j]
+			hh[j] = h
+			if i == l1 && h > best {
+				best, s2 = h, l2-j
+			}
+		}
+	}
+	return
+}
+
+// atgEnd returns the end (e1, e2) of the ATG alignment starting at
+// (0, s2): the alignment goes on until the end of seq1 or of seq2, and
+// its best end is found on the last row or on the last column of the
+// matrix of seq1 and seq2[s2:], aligned forward in linear memory.
+func (l *linearAligner) atgEnd(s2 int) (e1, e2 int) {
+	l1, n := len(l.seq1), len(l.seq2)-s2
+	inf := math.Inf(-1)
+	gapopen, gapextend := l.a.gapopen, l.a.gapextend
+
+	hh := make([]float64, n+1)
+	ee := make([]float64, n+1)
+	ee[0] = inf
+	for j := 1; j <= n; j++ {
+		hh[j] = gapopen + float64(j-1)*gapextend
+		ee[j] = inf
+	}
+	lastcol, lastcoli := hh[n], 0
+	for i := 1; i <= l1; i++ {
+		diag := hh[0]
+		hh[0] = gapopen + float64(i-1)*gapextend
+		f := inf
+		for j := 1; j <= n; j++ {
+			ee[j] = maxf(ee[j]+gapextend, hh[j]+gapopen)
+			f = maxf(f+gapextend, hh[j-1]+gapopen)
+			h := maxf(maxf(diag+l.score(i-1, s2+j-1), ee[j]), f)
+			diag = hh[j]
+			hh[j] = h
+		}
+		if hh[n] > lastcol {
+			lastcol, lastcoli = hh[n], i
+		}
+	}
+
+	best := lastcol
+	e1, e2 = lastcoli, s2+n
+	for j := 0; j <= n; j++ {
+		if hh[j] > best {
+			best, e1, e2 = hh[j], l1, s2+j
+		}
+	}
+	return
+}
+
+// minf and maxf are faster than math.Min and math.Max,
+// scores being neither NaN nor signed zeros
+func minf(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,314 @@
+package align
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Gotoh states: last column of the alignment is a match/mismatch,
+// a gap in the second sequence, or a gap in the first sequence
+const (
+	nwStateMatch = iota
+	nwStateGap2
+	nwStateGap1
+)
+
+// Global (Needleman & Wunsch) and semi-global alignments, with affine gap
+// scores (Gotoh 1982): a gap of length n has a score of
+// gapopen + (n-1)*gapextend. In semi-global mode, gaps at the start and at
+// the end of the sequences are not scored.
+//
+// mmat[i][j], gap2mat[i][j] and gap1mat[i][j] are the best scores of the
+// alignments of seq1[:i] and seq2[:j] ending respectively with a
+// match/mismatch, a gap in seq2 and a gap in seq1.
+func (a *pwaligner) fillMatrix_NW() (err error) {
+	var indexseq1, indexseq2 []int
+
+	if indexseq1, err = a.seqToindices(a.seq1); err != nil {
+		return
+	}
+	if indexseq2, err = a.seqToindices(a.seq2); err != nil {
+		return
+	}
+
+	l1 := a.seq1.Length()
+	l2 := a.seq2.Length()
+	a.mmat = newScoreMatrix(l1+1, l2+1)
+	a.gap1mat = newScoreMatrix(l1+1, l2+1)
+	a.gap2mat = newScoreMatrix(l1+1, l2+1)
+
+	inf := math.Inf(-1)
+	a.mmat[0][0] = .0
+	a.gap1mat[0][0] = inf
+	a.gap2mat[0][0] = inf
+	for i := 1; i <= l1; i++ {
+		a.mmat[i][0] = inf
+		a.gap1mat[i][0] = inf
+		a.gap2mat[i][0] = a.endGapScore(i)
+	}
+	for j := 1; j <= l2; j++ {
+		a.mmat[0][j] = inf
+		a.gap1mat[0][j] = a.endGapScore(j)
+		a.gap2mat[0][j] = inf
+	}
+
+	for i := 1; i <= l1; i++ {
+		c1 := a.seq1.CharAt(i - 1)
+		for j := 1; j <= l2; j++ {
+			c2 := a.seq2.CharAt(j - 1)
+			a.mmat[i][j] = max3(a.mmat[i-1][j-1], a.gap2mat[i-1][j-1], a.gap1mat[i-1][j-1]) +
+				a.matchScore(c1, c2, indexseq1[i-1], indexseq2[j-1])
+			a.gap2mat[i][j] = max3(a.mmat[i-1][j]+a.gapopen, a.gap2mat[i-1][j]+a.gapextend, a.gap1mat[i-1][j]+a.gapopen)
+			a.gap1mat[i][j] = max3(a.mmat[i][j-1]+a.gapopen, a.gap1mat[i][j-1]+a.gapextend, a.gap2mat[i][j-1]+a.gapopen)
+		}
+	}
+
+	// End of the alignment: last cell in global mode, best cell
+	// of the last row or of the last column in semi-global mode
+	a.maxi, a.maxj = l1, l2
+	a.maxscore = a.cellScore(l1, l2)
+	if a.algo == ALIGN_ALGO_SEMIGLOBAL {
+		for i := 0; i <= l1; i++ {
+			if s := a.cellScore(i, l2); s > a.maxscore {
+				a.maxscore, a.maxi, a.maxj = s, i, l2
+			}
+		}
+		for j := 0; j <= l2; j++ {
+			if s := a.cellScore(l1, j); s > a.maxscore {
+				a.maxscore, a.maxi, a.maxj = s, l1, j
+			}
+		}
+	}
+	return
+}
+
+// backTrack_NW builds the alignment from the Gotoh matrices, starting
+// from the end of the alignment (a.maxi, a.maxj). In semi-global mode,
+// the remaining ends of the sequences are aligned with gaps.
+func (a *pwaligner) backTrack_NW() {
+	l1 := a.seq1.Length()
+	l2 := a.seq2.Length()
+	seq1 := make([]rune, 0, l1+l2)
+	seq2 := make([]rune, 0, l1+l2)
+
+	// Unscored end gaps (semi-global mode)
+	for i := l1; i > a.maxi; i-- {
+		seq1 = append(seq1, a.seq1.CharAt(i-1))
+		seq2 = append(seq2, GAP)
+	}
+	for j := l2; j > a.maxj; j-- {
+		seq1 = append(seq1, GAP)
+		seq2 = append(seq2, a.seq2.CharAt(j-1))
+	}
+
+	i, j := a.maxi, a.maxj
+	state := argmax3(a.mmat[i][j], a.gap2mat[i][j], a.gap1mat[i][j])
+	for i > 0 || j > 0 {
+		// First row and first column: only gaps
+		if j == 0 {
+			state = nwStateGap2
+		} else if i == 0 {
+			state = nwStateGap1
+		}
+		switch state {
+		case nwStateMatch:
+			seq1 = append(seq1, a.seq1.CharAt(i-1))
+			seq2 = append(seq2, a.seq2.CharAt(j-1))
+			i--
+			j--
+			state = argmax3(a.mmat[i][j], a.gap2mat[i][j], a.gap1mat[i][j])
+		case nwStateGap2:
+			seq1 = append(seq1, a.seq1.CharAt(i-1))
+			seq2 = append(seq2, GAP)
+			score := a.gap2mat[i][j]
+			i--
+			if score == a.mmat[i][j]+a.gapopen {
+				state = nwStateMatch
+			} else if score == a.gap1mat[i][j]+a.gapopen {
+				state = nwStateGap1
+			}
+		case nwStateGap1:
+			seq1 = append(seq1, GAP)
+			seq2 = append(seq2, a.seq2.CharAt(j-1))
+			score := a.gap1mat[i][j]
+			j--
+			if score == a.mmat[i][j]+a.gapopen {
+				state = nwStateMatch
+			} else if score == a.gap2mat[i][j]+a.gapopen {
+				state = nwStateGap2
+			}
+		}
+	}
+
+	Reverse(seq1)
+	Reverse(seq2)
+	a.setAlignment(seq1, seq2)
+}
+
+// setAlignment sets the aligned sequences of a global or semi-global
+// alignment, and computes the alignment statistics. Starts and ends are
+// the first and last residues of each sequence aligned with a residue of
+// the other sequence.
+func (a *pwaligner) setAlignment(seq1, seq2 []rune) {
+	var pos1, pos2 int
+
+	a.seq1ali = seq1
+	a.seq2ali = seq2
+	a.alistr = make([]rune, len(seq1))
+	a.length = len(seq1)
+	a.nbmatches, a.nbmismatches, a.nbgaps = 0, 0, 0
+	a.start1, a.start2, a.end1, a.end2 = 0, 0, -1, -1
+	first := true
+	for k := range seq1 {
+		switch {
+		case seq1[k] == GAP:
+			a.alistr[k] = ' '
+			a.nbgaps++
+			pos2++
+		case seq2[k] == GAP:
+			a.alistr[k] = ' '
+			a.nbgaps++
+			pos1++
+		default:
+			if seq1[k] == seq2[k] {
+				a.alistr[k] = '|'
+				a.nbmatches++
+			} else {
+				a.alistr[k] = '.'
+				a.nbmismatches++
+			}
+			if first {
+				a.start1, a.start2 = pos1, pos2
+				first = false
+			}
+			a.end1, a.end2 = pos1, pos2
+			pos1++
+			pos2++
+		}
+	}
+}
+
+// endGapScore returns the score of a gap of the given length at the
+// start of a sequence: 0 in semi-global mode
+func (a *pwaligner) endGapScore(length int) float64 {
+	if a.algo == ALIGN_ALGO_SEMIGLOBAL {
+		return .0
+	}
+	return a.gapopen + float64(length-1)*a.gapextend
+}
+
+func (a *pwaligner) cellScore(i, j int) float64 {
+	return max3(a.mmat[i][j], a.gap2mat[i][j], a.gap1mat[i][j])
+}
+
+// Cigar returns the CIGAR string of the alignment, the first sequence
+// being the reference and the second sequence the query: M for aligned
+// residues, I for gaps in the reference, D for gaps in the query, and S
+// for query residues before the first or after the last aligned residues
+// (soft clipping). Reference residues outside the aligned region are not
+// described: pos is the 0-based position on the reference of the first
+// aligned residue.
+func (a *pwaligner) Cigar() (cigar string, pos int) {
+	var sb strings.Builder
+	var op rune
+	var n int
+
+	write := func(newop rune, count int) {
+		if newop != op && n > 0 {
+			fmt.Fprintf(&sb, "%d%c", n, op)
+			n = 0
+		}
+		op = newop
+		n += count
+	}
+
+	// Local alignments do not contain the whole sequences
+	offset1, offset2 := 0, 0
+	if a.algo != ALIGN_ALGO_NW && a.algo != ALIGN_ALGO_SEMIGLOBAL {
+		offset1, offset2 = a.start1, a.start2
+	}
+	pos = offset1
+	nbquery := 0
+
+	write('S', offset2)
+	first, last := a.alignedColumns()
+	for k := range a.seq1ali {
+		if a.seq2ali[k] != GAP {
+			nbquery++
+		} else if k < first {
+			pos++
+		}
+		switch {
+		case a.seq2ali[k] == GAP:
+			if k > first && k < last {
+				write('D', 1)
+			}
+		case k < first || k > last:
+			write('S', 1)
+		case a.seq1ali[k] == GAP:
+			write('I', 1)
+		default:
+			write('M', 1)
+		}
+	}
+	write('S', a.seq2.Length()-offset2-nbquery)
+	if n > 0 {
+		fmt.Fprintf(&sb, "%d%c", n, op)
+	}
+	return sb.String(), pos
+}
+
+// PercentIdentity returns the percentage of identical residues over the
+// alignment length. In semi-global mode, unscored end gaps are not taken
+// into account.
+func (a *pwaligner) PercentIdentity() float64 {
+	length := a.length
+	if a.algo == ALIGN_ALGO_SEMIGLOBAL {
+		first, last := a.alignedColumns()
+		length = last - first + 1
+	}
+	if length <= 0 {
+		return .0
+	}
+	return 100.0 * float64(a.nbmatches) / float64(length)
+}
+
+// alignedColumns returns the indices of the first and last columns of the
+// alignment having residues in both sequences (-1, -2 if none)
+func (a *pwaligner) alignedColumns() (first, last int) {
+	first, last = -1, -2
+	for k := range a.seq1ali {
+		if a.seq1ali[k] != GAP && a.seq2ali[k] != GAP {
+			if first < 0 {
+				first = k
+			}
+			last = k
+		}
+	}
+	return
+}
+
+func newScoreMatrix(l1, l2 int) (m [][]float64) {
+	m = make([][]float64, l1)
+	for i := range m {
+		m[i] = make([]float64, l2)
+	}
+	return
+}
+
+func max3(a, b, c float64) float64 {
+	return math.Max(a, math.Max(b, c))
+}
+
+// argmax3 returns the Gotoh state having the maximum score
+// (nwStateMatch if equal)
+func argmax3(m, gap2, gap1 float64) int {
+	if m >= gap2 && m >= gap1 {
+		return nwStateMatch
+	}
+	if gap2 >= gap1 {
+		return nwStateGap2
+	}
+	return nwStateGap1
+}
@@ -0,0 +1,201 @@
+package align
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPwAlignerGlobal(t *testing.T) {
+	s1 := NewSequence("s1", []rune("ACGTACGTACGT"), "")
+	s2 := NewSequence("s2", []rune("ACGTAGTACCT"), "")
+
+	a := NewPwAligner(s1, s2, ALIGN_ALGO_NW)
+	a.SetGapOpenScore(-3)
+	a.SetGapExtendScore(-1)
+	a.SetScore(2, -1)
+	if _, err := a.Alignment(); err != nil {
+		t.Fatal(err)
+	}
+	if string(a.Seq1Ali()) != "ACGTACGTACGT" || string(a.Seq2Ali()) != "ACGTA-GTACCT" {
+		t.Errorf("Wrong global alignment:\n%s", a.AlignmentStr())
+	}
+	// 10 matches, 1 mismatch, 1 gap
+	if a.MaxScore() != 16 || a.NbMatches() != 10 || a.NbMisMatches() != 1 || a.NbGaps() != 1 || a.Length() != 12 {
+		t.Errorf("Wrong global alignment statistics: score %f, %d matches, %d mismatches, %d gaps, length %d",
+			a.MaxScore(), a.NbMatches(), a.NbMisMatches(), a.NbGaps(), a.Length())
+	}
+	if cigar, pos := a.Cigar(); cigar != "5M1D6M" || pos != 0 {
+		t.Errorf("Wrong CIGAR: %s %d", cigar, pos)
+	}
+	if math.Abs(a.PercentIdentity()-100.0*10/12) > 1e-9 {
+		t.Errorf("Wrong percent identity: %f", a.PercentIdentity())
+	}
+
+	// Affine gaps: one gap of length 3 rather than 3 gaps
+	s1 = NewSequence("s1", []rune("AAACCCGGGTTT"), "")
+	s2 = NewSequence("s2", []rune("AAACGGGTTT"), "")
+	a = NewPwAligner(s1, s2, ALIGN_ALGO_NW)
+	a.SetGapOpenScore(-5)
+	a.SetGapExtendScore(-1)
+	a.SetScore(1, -1)
+	if _, err := a.Alignment(); err != nil {
+		t.Fatal(err)
+	}
+	if a.MaxScore() != 4 || a.NbGaps() != 2 {
+		t.Errorf("Wrong affine gap alignment (score %f):\n%s", a.MaxScore(), a.AlignmentStr())
+	}
+	if cigar, _ := a.Cigar(); cigar != "3M2D7M" {
+		t.Errorf("Wrong CIGAR: %s", cigar)
+	}
+}
+
+func TestPwAlignerSemiGlobal(t *testing.T) {
+	// s2 overlaps the end of s1
+	s1 := NewSequence("s1", []rune("TTTTTACGTACGT"), "")
+	s2 := NewSequence("s2", []rune("ACGTACGTGGGG"), "")
+
+	for _, test := range []struct {
+		algo         int
+		seq1, seq2   string
+		score        float64
+		cigar        string
+		pos          int
+		identity     float64
+		start1, end1 int
+	}{
+		{ALIGN_ALGO_SEMIGLOBAL, "TTTTTACGTACGT----", "-----ACGTACGTGGGG", 8, "8M4S", 5, 100, 5, 12},
+		{ALIGN_ALGO_NW, "TTTTTACGTACGT----", "-----ACGTACGTGGGG", -7, "8M4S", 5, 100.0 * 8 / 17, 5, 12},
+	} {
+		a := NewPwAligner(s1, s2, test.algo)
+		a.SetGapOpenScore(-4)
+		a.SetGapExtendScore(-1)
+		a.SetScore(1, -2)
+		if _, err := a.Alignment(); err != nil {
+			t.Fatal(err)
+		}
+		if string(a.Seq1Ali()) != test.seq1 || string(a.Seq2Ali()) != test.seq2 {
+			t.Errorf("Wrong alignment (algo %d):\n%s", test.algo, a.AlignmentStr())
+		}
+		if a.MaxScore() != test.score {
+			t.Errorf("Wrong score (algo %d): %f, expected %f", test.algo, a.MaxScore(), test.score)
+		}
+		if cigar, pos := a.Cigar(); cigar != test.cigar || pos != test.pos {
+			t.Errorf("Wrong CIGAR (algo %d): %s %d", test.algo, cigar, pos)
+		}
+		if math.Abs(a.PercentIdentity()-test.identity) > 1e-9 {
+			t.Errorf("Wrong percent identity (algo %d): %f", test.algo, a.PercentIdentity())
+		}
+		if start1, _ := a.AlignStarts(); start1 != test.start1 {
+			t.Errorf("Wrong start (algo %d): %d", test.algo, start1)
+		}
+		if end1, _ := a.AlignEnds(); end1 != test.end1 {
+			t.Errorf("Wrong end (algo %d): %d", test.algo, end1)
+		}
+	}
+}
+
+func TestPwAlignerLocalCigar(t *testing.T) {
+	s1 := NewSequence("s1", []rune("GGGGGACGTACGTACGGGGG"), "")
+	s2 := NewSequence("s2", []rune("TTACGTACGTACTT"), "")
+
+	a := NewPwAligner(s1, s2, ALIGN_ALGO_SW)
+	a.SetScore(1, -2)
+	if _, err := a.Alignment(); err != nil {
+		t.Fatal(err)
+	}
+	if cigar, pos := a.Cigar(); cigar != "2S10M2S" || pos != 5 {
+		t.Errorf("Wrong local CIGAR: %s %d\n%s", cigar, pos, a.AlignmentStr())
+	}
+}
+
+func TestPwAlignerLinear(t *testing.T) {
+	for _, test := range []struct {
+		algo       int
+		seq1, seq2 string
+	}{
+		{ALIGN_ALGO_NW, "ACGTACGTACGT", "ACGTAGTACCT"},
+		{ALIGN_ALGO_NW, "AAAACCCCGGGGTTTT", "AAAAGGGGTTTT"},
+		{ALIGN_ALGO_NW, "TTTTTACGTACGT", "ACGTACGTGGGG"},
+		{ALIGN_ALGO_SEMIGLOBAL, "TTTTTACGTACGT", "ACGTACGTGGGG"},
+		{ALIGN_ALGO_SEMIGLOBAL, "ACGTTGCAAACCTAGGTTACAGGT", "GCAAACGCTAGGTT"},
+		{ALIGN_ALGO_SW, "GGGGGACGTACGTACGGGGG", "TTACGTACGTACTT"},
+		{ALIGN_ALGO_SW, "CCCCCCGATTACAGATTACACCCCCC", "TTTTGATTACATTACATTTT"},
+		{ALIGN_ALGO_ATG, "ATGACGTACGTA", "CCCCATGACGTACGTACCCC"},
+		{ALIGN_ALGO_ATG, "ATGACGTACGTACCCCGGGG", "TTATGACGTTACGTACC"},
+		{ALIGN_ALGO_ATG, "ATGAAACCCGGGTTT", "GGATGAAACCGGGTTTAAACCC"},
+		{ALIGN_ALGO_ATG, "ATGCCCAAATTTGGG", "CCCATGCCCATTTGGG"},
+	} {
+		full := NewPwAligner(NewSequence("s1", []rune(test.seq1), ""), NewSequence("s2", []rune(test.seq2), ""), test.algo)
+		linear := NewPwAligner(NewSequence("s1", []rune(test.seq1), ""), NewSequence("s2", []rune(test.seq2), ""), test.algo)
+		linear.SetLinearSpace(true)
+		for _, a := range []*pwaligner{full, linear} {
+			a.SetGapOpenScore(-4)
+			a.SetGapExtendScore(-1)
+			a.SetScore(1, -2)
+			if _, err := a.Alignment(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if linear.MaxScore() != full.MaxScore() || linear.AlignmentStr() != full.AlignmentStr() {
+			t.Errorf("Linear alignment (algo %d, score %f) differs from full alignment (score %f):\n%s\n%s",
+				test.algo, linear.MaxScore(), full.MaxScore(), linear.AlignmentStr(), full.AlignmentStr())
+		}
+		lcigar, lpos := linear.Cigar()
+		fcigar, fpos := full.Cigar()
+		if lcigar != fcigar || lpos != fpos {
+			t.Errorf("Linear CIGAR (algo %d) differs from full CIGAR: %s %d vs. %s %d", test.algo, lcigar, lpos, fcigar, fpos)
+		}
+		lstart1, lstart2 := linear.AlignStarts()
+		fstart1, fstart2 := full.AlignStarts()
+		lend1, lend2 := linear.AlignEnds()
+		fend1, fend2 := full.AlignEnds()
+		if lstart1 != fstart1 || lstart2 != fstart2 || lend1 != fend1 || lend2 != fend2 {
+			t.Errorf("Linear bounds (algo %d) differ from full bounds: %d-%d,%d-%d vs. %d-%d,%d-%d", test.algo,
+				lstart1, lend1, lstart2, lend2, fstart1, fend1, fstart2, fend2)
+		}
+	}
+}
+
+func TestPwAlignerBanded(t *testing.T) {
+	s1 := NewSequence("s1", []rune("ACGTTGCAAACCTAGGTTACAGGTACCA"), "")
+	s2 := NewSequence("s2", []rune("ACGTTGCAAACGCTAGGTTAGGTACCA"), "")
+
+	for _, algo := range []int{ALIGN_ALGO_NW, ALIGN_ALGO_SEMIGLOBAL} {
+		full := NewPwAligner(s1, s2, algo)
+		if _, err := full.Alignment(); err != nil {
+			t.Fatal(err)
+		}
+		for _, band := range []int{2, 5, 100} {
+			a := NewPwAligner(s1, s2, algo)
+			a.SetBand(band)
+			if _, err := a.Alignment(); err != nil {
+				t.Fatal(err)
+			}
+			if a.MaxScore() != full.MaxScore() || a.AlignmentStr() != full.AlignmentStr() {
+				t.Errorf("Banded alignment (algo %d, band %d, score %f) differs from full alignment (score %f):\n%s\n%s",
+					algo, band, a.MaxScore(), full.MaxScore(), a.AlignmentStr(), full.AlignmentStr())
+			}
+		}
+	}
+
+	// Too narrow band: the score can only be lower
+	s2 = NewSequence("s2", []rune("GCAAACCTAGGTTACAGGTACCAACGTT"), "")
+	full := NewPwAligner(s1, s2, ALIGN_ALGO_NW)
+	banded := NewPwAligner(s1, s2, ALIGN_ALGO_NW)
+	banded.SetBand(1)
+	for _, a := range []*pwaligner{full, banded} {
+		if _, err := a.Alignment(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if banded.MaxScore() >= full.MaxScore() {
+		t.Errorf("Narrow band score should be lower than full score: %f vs. %f", banded.MaxScore(), full.MaxScore())
+	}
+
+	// Banded local alignment is not available
+	a := NewPwAligner(s1, s2, ALIGN_ALGO_SW)
+	a.SetBand(10)
+	if _, err := a.Alignment(); err == nil {
+		t.Errorf("Banded local alignment should fail")
+	}
+}
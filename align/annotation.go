@@ -0,0 +1,303 @@
+package align
+
+import "fmt"
+
+// Annotations stores markup lines attached to an alignment, such as the ones
+// found in Stockholm files:
+//   - #=GF <feature> <text>           : free text about the whole alignment
+//   - #=GC <feature> <per-column>     : one character per alignment column (SS_cons, RF, etc.)
+//   - #=GS <seqname> <feature> <text> : free text about a given sequence
+//   - #=GR <seqname> <feature> <per-column> : one character per residue of a given sequence
+//
+// Per-column and per-residue annotations follow the alignment when its
+// columns are extracted or removed (SubAlign, RemoveGapSites, etc.).
+// Per-sequence annotations are indexed by sequence name.
+type Annotations struct {
+	file      []annotation
+	columns   []columnAnnotation
+	sequences []seqAnnotation
+	residues  []residueAnnotation
+}
+
+type annotation struct {
+	feature string
+	text    string
+}
+
+type columnAnnotation struct {
+	feature string
+	annot   []rune
+}
+
+type seqAnnotation struct {
+	name    string
+	feature string
+	text    string
+}
+
+type residueAnnotation struct {
+	name    string
+	feature string
+	annot   []rune
+}
+
+// NewAnnotations initializes an empty set of annotations
+func NewAnnotations() *Annotations {
+	return &Annotations{
+		file:      make([]annotation, 0),
+		columns:   make([]columnAnnotation, 0),
+		sequences: make([]seqAnnotation, 0),
+		residues:  make([]residueAnnotation, 0),
+	}
+}
+
+// Empty returns true if there are no annotations at all
+func (an *Annotations) Empty() bool {
+	return len(an.file) == 0 && len(an.columns) == 0 && len(an.sequences) == 0 && len(an.residues) == 0
+}
+
+// AddFileAnnotation adds a #=GF like annotation.
+// Several annotations may have the same feature name (ex: several CC lines)
+func (an *Annotations) AddFileAnnotation(feature, text string) {
+	an.file = append(an.file, annotation{feature, text})
+}
+
+// AddColumnAnnotation adds a #=GC like annotation.
+// If the feature already exists, then the given annotation is appended
+// to the existing one (useful for multi-block files).
+func (an *Annotations) AddColumnAnnotation(feature string, annot []rune) {
+	for i, c := range an.columns {
+		if c.feature == feature {
+			an.columns[i].annot = append(an.columns[i].annot, annot...)
+			return
+		}
+	}
+	an.columns = append(an.columns, columnAnnotation{feature, append([]rune(nil), annot...)})
+}
+
+// AddSequenceAnnotation adds a #=GS like annotation.
+// Several annotations may have the same feature name for the same sequence
+func (an *Annotations) AddSequenceAnnotation(name, feature, text string) {
+	an.sequences = append(an.sequences, seqAnnotation{name, feature, text})
+}
+
+// AddResidueAnnotation adds a #=GR like annotation.
+// If the feature already exists for the given sequence, then the given annotation is appended
+// to the existing one (useful for multi-block files).
+func (an *Annotations) AddResidueAnnotation(name, feature string, annot []rune) {
+	for i, r := range an.residues {
+		if r.name == name && r.feature == feature {
+			an.residues[i].annot = append(an.residues[i].annot, annot...)
+			return
+		}
+	}
+	an.residues = append(an.residues, residueAnnotation{name, feature, append([]rune(nil), annot...)})
+}
+
+// ColumnAnnotation returns the per-column annotation of the given feature
+// and true if it exists, nil and false otherwise
+func (an *Annotations) ColumnAnnotation(feature string) ([]rune, bool) {
+	for _, c := range an.columns {
+		if c.feature == feature {
+			return c.annot, true
+		}
+	}
+	return nil, false
+}
+
+// IterateFile iterates over all #=GF like annotations, in insertion order.
+// If the given function returns true, the iteration stops.
+func (an *Annotations) IterateFile(it func(feature, text string) bool) {
+	for _, a := range an.file {
+		if it(a.feature, a.text) {
+			return
+		}
+	}
+}
+
+// IterateColumns iterates over all #=GC like annotations, in insertion order.
+// If the given function returns true, the iteration stops.
+func (an *Annotations) IterateColumns(it func(feature string, annot []rune) bool) {
+	for _, c := range an.columns {
+		if it(c.feature, c.annot) {
+			return
+		}
+	}
+}
+
+// IterateSequences iterates over all #=GS like annotations, in insertion order.
+// If the given function returns true, the iteration stops.
+func (an *Annotations) IterateSequences(it func(name, feature, text string) bool) {
+	for _, s := range an.sequences {
+		if it(s.name, s.feature, s.text) {
+			return
+		}
+	}
+}
+
+// IterateResidues iterates over all #=GR like annotations, in insertion order.
+// If the given function returns true, the iteration stops.
+func (an *Annotations) IterateResidues(it func(name, feature string, annot []rune) bool) {
+	for _, r := range an.residues {
+		if it(r.name, r.feature, r.annot) {
+			return
+		}
+	}
+}
+
+// Clone returns a deep copy of the annotations
+func (an *Annotations) Clone() *Annotations {
+	c := NewAnnotations()
+	c.file = append(c.file, an.file...)
+	c.sequences = append(c.sequences, an.sequences...)
+	for _, col := range an.columns {
+		c.columns = append(c.columns, columnAnnotation{col.feature, append([]rune(nil), col.annot...)})
+	}
+	for _, r := range an.residues {
+		c.residues = append(c.residues, residueAnnotation{r.name, r.feature, append([]rune(nil), r.annot...)})
+	}
+	return c
+}
+
+// selectSequences returns a copy of the annotations, with only the
+// per-sequence and per-residue annotations of the given sequences
+// (used for sample, rarefy, etc.).
+func (an *Annotations) selectSequences(names map[string]*seq) *Annotations {
+	c := NewAnnotations()
+	c.file = append(c.file, an.file...)
+	for _, s := range an.sequences {
+		if _, ok := names[s.name]; ok {
+			c.sequences = append(c.sequences, s)
+		}
+	}
+	for _, col := range an.columns {
+		c.columns = append(c.columns, columnAnnotation{col.feature, append([]rune(nil), col.annot...)})
+	}
+	for _, r := range an.residues {
+		if _, ok := names[r.name]; ok {
+			c.residues = append(c.residues, residueAnnotation{r.name, r.feature, append([]rune(nil), r.annot...)})
+		}
+	}
+	return c
+}
+
+// subAnnotations returns a copy of the annotations restricted to
+// the columns [start,start+length[.
+func (an *Annotations) subAnnotations(start, length int) *Annotations {
+	c := NewAnnotations()
+	c.file = append(c.file, an.file...)
+	c.sequences = append(c.sequences, an.sequences...)
+	for _, col := range an.columns {
+		if start+length <= len(col.annot) {
+			c.columns = append(c.columns, columnAnnotation{col.feature, append([]rune(nil), col.annot[start:start+length]...)})
+		}
+	}
+	for _, r := range an.residues {
+		if start+length <= len(r.annot) {
+			c.residues = append(c.residues, residueAnnotation{r.name, r.feature, append([]rune(nil), r.annot[start:start+length]...)})
+		}
+	}
+	return c
+}
+
+// selectSites returns a copy of the annotations with only the given
+// columns, in the given order (used for bootstrap, split, etc.).
+func (an *Annotations) selectSites(sites []int) *Annotations {
+	c := NewAnnotations()
+	c.file = append(c.file, an.file...)
+	c.sequences = append(c.sequences, an.sequences...)
+	maxsite := -1
+	for _, s := range sites {
+		if s > maxsite {
+			maxsite = s
+		}
+	}
+	for _, col := range an.columns {
+		if maxsite < len(col.annot) {
+			c.columns = append(c.columns, columnAnnotation{col.feature, selectRunes(col.annot, sites)})
+		}
+	}
+	for _, r := range an.residues {
+		if maxsite < len(r.annot) {
+			c.residues = append(c.residues, residueAnnotation{r.name, r.feature, selectRunes(r.annot, sites)})
+		}
+	}
+	return c
+}
+
+// removeSite removes the given column from all per-column
+// and per-residue annotations
+func (an *Annotations) removeSite(site int) {
+	for i, col := range an.columns {
+		if site < len(col.annot) {
+			an.columns[i].annot = append(col.annot[:site], col.annot[site+1:]...)
+		}
+	}
+	for i, r := range an.residues {
+		if site < len(r.annot) {
+			an.residues[i].annot = append(r.annot[:site], r.annot[site+1:]...)
+		}
+	}
+}
+
+// trim removes trimsize columns from the start (fromStart) or from the end
+// of all per-column and per-residue annotations
+func (an *Annotations) trim(trimsize int, fromStart bool) {
+	for i, col := range an.columns {
+		an.columns[i].annot = trimRunes(col.annot, trimsize, fromStart)
+	}
+	for i, r := range an.residues {
+		an.residues[i].annot = trimRunes(r.annot, trimsize, fromStart)
+	}
+}
+
+// CheckLength returns an error if a per-column or a per-residue annotation
+// does not have the given length
+func (an *Annotations) CheckLength(length int) (err error) {
+	for _, col := range an.columns {
+		if len(col.annot) != length {
+			err = fmt.Errorf("Column annotation %s does not have the same length as the alignment (%d vs. %d)", col.feature, len(col.annot), length)
+			return
+		}
+	}
+	for _, r := range an.residues {
+		if len(r.annot) != length {
+			err = fmt.Errorf("Residue annotation %s of sequence %s does not have the same length as the alignment (%d vs. %d)", r.feature, r.name, len(r.annot), length)
+			return
+		}
+	}
+	return
+}
+
+// renameSequences renames the sequences in all per-sequence
+// and per-residue annotations, using the given map oldname=>newname
+func (an *Annotations) renameSequences(namemap map[string]string) {
+	for i, s := range an.sequences {
+		if newname, ok := namemap[s.name]; ok {
+			an.sequences[i].name = newname
+		}
+	}
+	for i, r := range an.residues {
+		if newname, ok := namemap[r.name]; ok {
+			an.residues[i].name = newname
+		}
+	}
+}
+
+func selectRunes(annot []rune, sites []int) (out []rune) {
+	out = make([]rune, len(sites))
+	for i, s := range sites {
+		out[i] = annot[s]
+	}
+	return
+}
+
+func trimRunes(annot []rune, trimsize int, fromStart bool) []rune {
+	if trimsize > len(annot) {
+		return annot[:0]
+	}
+	if fromStart {
+		return annot[trimsize:]
+	}
+	return annot[:len(annot)-trimsize]
+}
@@ -25,10 +25,17 @@ const (
 	PSSM_NORM_UNIF = 3 // Normalization by uniform frequency
 	PSSM_NORM_LOGO = 4 // Normalization like LOGO : v(site)=freq*(log2(alphabet)-H(site)-pseudocount
 
-	FORMAT_FASTA   = 0
-	FORMAT_PHYLIP  = 1
-	FORMAT_NEXUS   = 2
-	FORMAT_CLUSTAL = 3
+	FORMAT_FASTA     = 0
+	FORMAT_PHYLIP    = 1
+	FORMAT_NEXUS     = 2
+	FORMAT_CLUSTAL   = 3
+	FORMAT_STOCKHOLM = 4
+	FORMAT_GENBANK   = 5
+	FORMAT_EMBL      = 6
+	FORMAT_FASTQ     = 7
+	FORMAT_A2M       = 8
+	FORMAT_A3M       = 9
+	FORMAT_MAF       = 10
 
 	POSITION_IDENTICAL      = 0 // All characters in a position are the same
 	POSITION_CONSERVED      = 1 // Same strong group
@@ -270,11 +277,12 @@ var iupacCodeByte = [][]uint8{
 // This matrix was created by Todd Lowe   12/10/92
 //
 // Uses ambiguous nucleotide codes, probabilities rounded to
-//  nearest integer
+//
+//	nearest integer
 //
 // Lowest score = -4, Highest score = 5
 //
-//A   T   G   C   S   W   R   Y   K   M   B   V   H   D   N   U
+// A   T   G   C   S   W   R   Y   K   M   B   V   H   D   N   U
 var dnafull_subst_matrix = [][]float64{
 	[]float64{5, -4, -4, -4, -4, 1, 1, -4, -4, 1, -4, -1, -1, -1, -2, -4},
 	[]float64{-4, 5, -4, -4, -4, 1, -4, 1, 1, -4, -1, -4, -1, -1, -2, 5},
@@ -398,7 +406,8 @@ func Nt2Index(nt rune) (idx int, err error) {
 	return
 }
 
-/*PossibleNtIUPAC returns the possible meaning of the given iupac nucleotide
+/*
+PossibleNtIUPAC returns the possible meaning of the given iupac nucleotide
 Ex: NT_B : {NT_C, NT_G, NT_T}
 */
 func PossibleNtIUPAC(nt uint8) (idx []uint8, err error) {
@@ -422,6 +431,34 @@ func Nt2IndexIUPAC(nt rune) (idx uint8, err error) {
 	return
 }
 
+// IUPAC nucleotide corresponding to each int code (see Nt2IndexIUPAC)
+var intToIupac = []rune{'-', 'A', 'C', 'M', 'G', 'R', 'S', 'V', 'T', 'W', 'Y', 'H', 'K', 'D', 'B', 'N'}
+
+/*
+Nts2IUPAC returns the IUPAC nucleotide corresponding to the given
+set of nucleotides, that may themselves be IUPAC nucleotides.
+Ex: {'A','G'}: 'R', {'R','C'}: 'V'
+*/
+func Nts2IUPAC(nts []rune) (nt rune, err error) {
+	var code, idx uint8
+	for _, n := range nts {
+		if idx, err = Nt2IndexIUPAC(n); err != nil {
+			return
+		}
+		if idx == NT_OTHER {
+			err = fmt.Errorf("%c is not a nucleotide", n)
+			return
+		}
+		code |= idx
+	}
+	if code == NT_OTHER {
+		err = fmt.Errorf("Empty set of nucleotides")
+		return
+	}
+	nt = intToIupac[code]
+	return
+}
+
 /*
 Returns the index of each nts
 0=A
@@ -0,0 +1,250 @@
+package align
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Feature is an annotated region of a sequence, as described in the
+// feature tables of GenBank and EMBL files (gene, CDS, mat_peptide, etc.).
+type Feature struct {
+	Type       string      // Feature key: gene, CDS, mat_peptide, etc.
+	Location   Location    // Location of the feature on the sequence
+	Qualifiers []Qualifier // Qualifiers (/gene="S", etc.), in the input order
+}
+
+// Qualifier is a key/value pair describing a feature
+// (ex: /gene="S" gives Key="gene" and Value="S")
+type Qualifier struct {
+	Key   string
+	Value string
+}
+
+// Interval is a contiguous part of a feature location.
+// Coordinates are 0-based, Start is inclusive and End is exclusive.
+type Interval struct {
+	Start        int
+	End          int
+	Complement   bool // Interval is on the reverse strand
+	PartialStart bool // Feature extends before Start ('<' in the location)
+	PartialEnd   bool // Feature extends after End ('>' in the location)
+}
+
+// Location of a feature: the list of its intervals, in the order
+// in which they are joined (i.e. in the order of the transcript).
+// For example, complement(join(1..10,21..30)) is [21..30(-), 1..10(-)].
+type Location []Interval
+
+// NewFeature creates a new feature without qualifiers
+func NewFeature(ftype string, location Location) *Feature {
+	return &Feature{
+		Type:       ftype,
+		Location:   location,
+		Qualifiers: make([]Qualifier, 0),
+	}
+}
+
+// AddQualifier adds a qualifier to the feature
+func (f *Feature) AddQualifier(key, value string) {
+	f.Qualifiers = append(f.Qualifiers, Qualifier{Key: key, Value: value})
+}
+
+// Qualifier returns the value of the first qualifier having the given key
+func (f *Feature) Qualifier(key string) (value string, ok bool) {
+	for _, q := range f.Qualifiers {
+		if q.Key == key {
+			return q.Value, true
+		}
+	}
+	return "", false
+}
+
+// Qualifiers used to name features, in order of preference
+var featureNameQualifiers = []string{"gene", "locus_tag", "protein_id", "product"}
+
+// Name returns the name of the feature: the value of its first gene,
+// locus_tag, protein_id or product qualifier. If none of them is
+// defined, returns the feature type.
+func (f *Feature) Name() string {
+	for _, k := range featureNameQualifiers {
+		if v, ok := f.Qualifier(k); ok {
+			return v
+		}
+	}
+	return f.Type
+}
+
+// HasName returns true if the gene, locus_tag, protein_id or product
+// qualifier of the feature is equal to the given name
+func (f *Feature) HasName(name string) bool {
+	for _, q := range f.Qualifiers {
+		for _, k := range featureNameQualifiers {
+			if q.Key == k && q.Value == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CodonStart returns the offset (0, 1 or 2) of the first complete codon
+// of the feature, given by its /codon_start qualifier (1, 2 or 3).
+// Returns 0 if the qualifier is not defined.
+func (f *Feature) CodonStart() (offset int, err error) {
+	var v string
+	var ok bool
+	if v, ok = f.Qualifier("codon_start"); !ok {
+		return 0, nil
+	}
+	if offset, err = strconv.Atoi(v); err != nil || offset < 1 || offset > 3 {
+		return 0, fmt.Errorf("Feature %s: wrong codon_start: %s", f.Name(), v)
+	}
+	return offset - 1, nil
+}
+
+// GeneticCode returns the genetic code given by the /transl_table qualifier
+// of the feature: GENETIC_CODE_STANDARD (1, or no qualifier),
+// GENETIC_CODE_VETEBRATE_MITO (2) or GENETIC_CODE_INVETEBRATE_MITO (5).
+// Returns an error for other tables.
+func (f *Feature) GeneticCode() (code int, err error) {
+	v, ok := f.Qualifier("transl_table")
+	if !ok {
+		return GENETIC_CODE_STANDARD, nil
+	}
+	switch v {
+	case "1":
+		code = GENETIC_CODE_STANDARD
+	case "2":
+		code = GENETIC_CODE_VETEBRATE_MITO
+	case "5":
+		code = GENETIC_CODE_INVETEBRATE_MITO
+	default:
+		err = fmt.Errorf("Feature %s: transl_table %s is not supported", f.Name(), v)
+	}
+	return
+}
+
+// Extract returns the subsequence of s corresponding to the feature location.
+// Intervals on the reverse strand are reverse complemented.
+// Output sequence is named <sequence name>_<feature name>.
+func (f *Feature) Extract(s Sequence) (ex Sequence, err error) {
+	var buf bytes.Buffer
+	seq := s.SequenceBytes()
+
+	if len(f.Location) == 0 {
+		return nil, fmt.Errorf("Feature %s does not have any location", f.Name())
+	}
+	for _, i := range f.Location {
+		if i.Start < 0 || i.End > len(seq) || i.Start > i.End {
+			return nil, fmt.Errorf("Feature %s: location %s is outside sequence %s", f.Name(), f.Location.String(), s.Name())
+		}
+		if !i.Complement {
+			buf.Write(seq[i.Start:i.End])
+			continue
+		}
+		part := make([]byte, i.End-i.Start)
+		copy(part, seq[i.Start:i.End])
+		rc := newSequenceBytes("", part, "")
+		rc.Reverse()
+		if err = rc.Complement(); err != nil {
+			return nil, fmt.Errorf("Feature %s: %v", f.Name(), err)
+		}
+		buf.Write(rc.bytes())
+	}
+	ex = newSequenceBytes(fmt.Sprintf("%s_%s", s.Name(), f.Name()), buf.Bytes(), s.Comment())
+	return
+}
+
+// ExtractCDS extracts the feature from s (see Extract), starting at
+// its first complete codon (see CodonStart), so that the output is in frame.
+func (f *Feature) ExtractCDS(s Sequence) (cds Sequence, err error) {
+	var offset int
+	if offset, err = f.CodonStart(); err != nil {
+		return
+	}
+	if cds, err = f.Extract(s); err != nil {
+		return
+	}
+	if offset > 0 {
+		b := cds.SequenceBytes()
+		if offset > len(b) {
+			offset = len(b)
+		}
+		cds = newSequenceBytes(cds.Name(), b[offset:], cds.Comment())
+	}
+	if product, ok := f.Qualifier("product"); ok {
+		cds = newSequenceBytes(cds.Name(), cds.SequenceBytes(), product)
+	}
+	return
+}
+
+// Start returns the lowest (0-based) position covered by the location
+func (l Location) Start() int {
+	start := -1
+	for _, i := range l {
+		if start == -1 || i.Start < start {
+			start = i.Start
+		}
+	}
+	return start
+}
+
+// End returns the highest (0-based, exclusive) position covered by the location
+func (l Location) End() int {
+	end := -1
+	for _, i := range l {
+		if i.End > end {
+			end = i.End
+		}
+	}
+	return end
+}
+
+// Length returns the total length of the intervals of the location
+func (l Location) Length() (length int) {
+	for _, i := range l {
+		length += i.End - i.Start
+	}
+	return
+}
+
+// Complement returns true if all the intervals are on the reverse strand
+func (l Location) Complement() bool {
+	for _, i := range l {
+		if !i.Complement {
+			return false
+		}
+	}
+	return len(l) > 0
+}
+
+// String returns the location in the GenBank notation (1-based coordinates).
+// Reverse strand intervals are written as complement(start..end)
+func (l Location) String() string {
+	parts := make([]string, len(l))
+	for k, i := range l {
+		var s string
+		start, end := strconv.Itoa(i.Start+1), strconv.Itoa(i.End)
+		if i.PartialStart {
+			start = "<" + start
+		}
+		if i.PartialEnd {
+			end = ">" + end
+		}
+		if i.End-i.Start == 1 && !i.PartialStart && !i.PartialEnd {
+			s = end
+		} else {
+			s = start + ".." + end
+		}
+		if i.Complement {
+			s = "complement(" + s + ")"
+		}
+		parts[k] = s
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "join(" + strings.Join(parts, ",") + ")"
+}
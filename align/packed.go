@@ -0,0 +1,191 @@
+package align
+
+import (
+	"fmt"
+)
+
+const (
+	PACKED_2BITS = 2 // Pure A/C/G/T sequences: 4 nucleotides per byte
+	PACKED_4BITS = 4 // IUPAC nucleotides and gaps: 2 nucleotides per byte
+)
+
+// 4 bits codes are the IUPAC bitwise codes (NT_A, NT_R, etc.), NT_OTHER being
+// used for gaps
+var packed4BitsChars = [16]byte{
+	NT_OTHER: GAP,
+	NT_A:     'A', NT_C: 'C', NT_G: 'G', NT_T: 'T',
+	NT_R: 'R', NT_Y: 'Y', NT_S: 'S', NT_W: 'W', NT_K: 'K', NT_M: 'M',
+	NT_B: 'B', NT_D: 'D', NT_H: 'H', NT_V: 'V', NT_N: 'N',
+}
+
+var packed2BitsChars = [4]byte{'A', 'C', 'G', 'T'}
+
+// PackedSequence is a read-only nucleotide sequence stored with
+// 2 bits (A, C, G, T only) or 4 bits (IUPAC codes and gaps) per character.
+//
+// Packing is lossless: sequences containing other characters, including
+// lower case nucleotides, can not be packed.
+type PackedSequence struct {
+	name    string
+	comment string
+	bits    int
+	length  int
+	data    []byte
+}
+
+// Pack packs the given nucleotide sequence, using 2 bits per character if it
+// only contains A, C, G and T, or 4 bits per character if it only contains
+// upper case IUPAC nucleotide codes and gaps.
+//
+// Returns an error if the sequence contains any other character.
+func Pack(s Sequence) (p *PackedSequence, err error) {
+	seq := s.SequenceBytes()
+
+	bits := PACKED_2BITS
+	codes := &packed2BitsCodes
+	for _, c := range seq {
+		if packed2BitsCodes[c] < 0 {
+			bits = PACKED_4BITS
+			codes = &packed4BitsCodes
+			break
+		}
+	}
+
+	p = &PackedSequence{
+		name:    s.Name(),
+		comment: s.Comment(),
+		bits:    bits,
+		length:  len(seq),
+		data:    make([]byte, (len(seq)*bits+7)/8),
+	}
+
+	perbyte := 8 / bits
+	for i, c := range seq {
+		code := codes[c]
+		if code < 0 {
+			return nil, fmt.Errorf("Sequence %s can not be packed: character '%c' is not an upper case IUPAC nucleotide or a gap", s.Name(), c)
+		}
+		p.data[i/perbyte] |= byte(code) << uint((i%perbyte)*bits)
+	}
+	return
+}
+
+// Codes of the characters, -1 if the character can not be packed
+var packed2BitsCodes, packed4BitsCodes [256]int8
+
+func init() {
+	for i := range packed2BitsCodes {
+		packed2BitsCodes[i] = -1
+		packed4BitsCodes[i] = -1
+	}
+	for i, c := range packed2BitsChars {
+		packed2BitsCodes[c] = int8(i)
+	}
+	for i, c := range packed4BitsChars {
+		packed4BitsCodes[c] = int8(i)
+	}
+}
+
+// Name returns the name of the sequence
+func (p *PackedSequence) Name() string {
+	return p.name
+}
+
+// Comment returns the comment of the sequence
+func (p *PackedSequence) Comment() string {
+	return p.comment
+}
+
+// Bits returns the number of bits used per character: PACKED_2BITS or PACKED_4BITS
+func (p *PackedSequence) Bits() int {
+	return p.bits
+}
+
+// Length returns the number of characters of the sequence
+func (p *PackedSequence) Length() int {
+	return p.length
+}
+
+// Size returns the number of bytes used to store the packed characters
+func (p *PackedSequence) Size() int {
+	return len(p.data)
+}
+
+// At returns the character at the given position.
+// Panics if the position is outside the sequence.
+func (p *PackedSequence) At(i int) rune {
+	if i < 0 || i >= p.length {
+		panic(fmt.Sprintf("Position %d is outside the packed sequence (length %d)", i, p.length))
+	}
+	return rune(p.at(i))
+}
+
+func (p *PackedSequence) at(i int) byte {
+	perbyte := 8 / p.bits
+	code := (p.data[i/perbyte] >> uint((i%perbyte)*p.bits)) & (1<<uint(p.bits) - 1)
+	if p.bits == PACKED_2BITS {
+		return packed2BitsChars[code]
+	}
+	return packed4BitsChars[code]
+}
+
+// Unpack returns the unpacked Sequence
+func (p *PackedSequence) Unpack() Sequence {
+	return newSequenceBytes(p.name, p.unpackBytes(), p.comment)
+}
+
+func (p *PackedSequence) unpackBytes() []byte {
+	seq := make([]byte, p.length)
+	for i := range seq {
+		seq[i] = p.at(i)
+	}
+	return seq
+}
+
+// Pack stores the sequences of the seqbag packed on 2 or 4 bits
+// per character (see Pack), and returns the number of packed sequences.
+// Sequences that can not be packed, or that have qualities, are kept
+// as they are.
+//
+// Packed sequences are unpacked as soon as they are modified or accessed
+// as a whole, except by read-only accessors (Sequence, SequenceBytes,
+// CharAt, Length, Iterate, IterateBytes, GetSequence, GetSequenceById,
+// GetSequenceBytesById and writers), which decode them without unpacking
+// them. Packing is thus interesting to load, write or compute distances on
+// large nucleotide alignments.
+func (sb *seqbag) Pack() (nb int) {
+	for _, s := range sb.seqs {
+		if s.packed != nil {
+			nb++
+			continue
+		}
+		if s.qualities != nil {
+			continue
+		}
+		if p, err := Pack(s); err == nil {
+			p.name, p.comment = "", ""
+			s.packed = p
+			s.sequence = nil
+			s.runes = nil
+			nb++
+		}
+	}
+	return
+}
+
+// Unpack stores all the sequences of the seqbag on 1 byte per character
+func (sb *seqbag) Unpack() {
+	for _, s := range sb.seqs {
+		s.bytes()
+	}
+}
+
+// NbPacked returns the number of packed sequences of the seqbag (see Pack)
+func (sb *seqbag) NbPacked() (nb int) {
+	for _, s := range sb.seqs {
+		if s.packed != nil {
+			nb++
+		}
+	}
+	return
+}
@@ -0,0 +1,116 @@
+package align
+
+import (
+	"testing"
+)
+
+func TestPack(t *testing.T) {
+	tests := []struct {
+		name     string
+		sequence string
+		wantBits int
+		wantSize int
+		wantErr  bool
+	}{
+		{name: "2bits", sequence: "ACGTACGTA", wantBits: PACKED_2BITS, wantSize: 3, wantErr: false},
+		{name: "4bits", sequence: "ACGT-RYSWKMBDHVN", wantBits: PACKED_4BITS, wantSize: 8, wantErr: false},
+		{name: "4bits odd", sequence: "ACGTN", wantBits: PACKED_4BITS, wantSize: 3, wantErr: false},
+		{name: "empty", sequence: "", wantBits: PACKED_2BITS, wantSize: 0, wantErr: false},
+		{name: "lower case", sequence: "ACGTa", wantErr: true},
+		{name: "amino acids", sequence: "ACGTEFL", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSequence("s1", []rune(tt.sequence), "comment")
+			p, err := Pack(s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Pack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if p.Bits() != tt.wantBits {
+				t.Errorf("Bits() = %d, want %d", p.Bits(), tt.wantBits)
+			}
+			if p.Size() != tt.wantSize {
+				t.Errorf("Size() = %d, want %d", p.Size(), tt.wantSize)
+			}
+			if p.Length() != len(tt.sequence) {
+				t.Errorf("Length() = %d, want %d", p.Length(), len(tt.sequence))
+			}
+			for i, c := range tt.sequence {
+				if p.At(i) != c {
+					t.Errorf("At(%d) = %c, want %c", i, p.At(i), c)
+				}
+			}
+			u := p.Unpack()
+			if u.Sequence() != tt.sequence || u.Name() != "s1" || u.Comment() != "comment" {
+				t.Errorf("Unpack() = %s %s %s, want s1 %s comment", u.Name(), u.Sequence(), u.Comment(), tt.sequence)
+			}
+		})
+	}
+}
+
+func TestSeqBagPack(t *testing.T) {
+	sb := NewSeqBag(NUCLEOTIDS)
+	sb.AddSequence("s1", "ACGTACGT", "")
+	sb.AddSequence("s2", "ACGTNNNN--", "")
+	sb.AddSequence("s3", "ACGT*", "")
+	if nb := sb.Pack(); nb != 2 {
+		t.Fatalf("There should be 2 packed sequences (%d)", nb)
+	}
+	if sb.seqs[0].packed.Bits() != PACKED_2BITS || sb.seqs[1].packed.Bits() != PACKED_4BITS || sb.seqs[2].packed != nil {
+		t.Errorf("Sequences are not packed as expected")
+	}
+
+	// Read-only accessors do not unpack sequences
+	if s, _ := sb.GetSequenceById(1); s != "ACGTNNNN--" {
+		t.Errorf("Wrong packed sequence: %s", s)
+	}
+	if s, _ := sb.GetSequenceBytesById(0); string(s) != "ACGTACGT" {
+		t.Errorf("Wrong packed sequence: %s", string(s))
+	}
+	if s, _ := sb.Sequence(1); s.Length() != 10 || s.CharAt(9) != GAP || s.NumGaps() != 2 {
+		t.Errorf("Wrong packed sequence length or characters")
+	}
+	if nb := sb.NbPacked(); nb != 2 {
+		t.Errorf("Read-only accessors should not unpack sequences (%d packed sequences)", nb)
+	}
+
+	// Modifications unpack the sequence
+	sb.SetSequenceChar(0, 1, 'T')
+	if s, _ := sb.GetSequenceById(0); s != "ATGTACGT" || sb.NbPacked() != 1 {
+		t.Errorf("Wrong modified sequence: %s (%d packed sequences)", s, sb.NbPacked())
+	}
+	sb.Unpack()
+	if sb.NbPacked() != 0 {
+		t.Errorf("All sequences should be unpacked")
+	}
+}
+
+func TestAlignPack(t *testing.T) {
+	a, err := RandomAlignment(NUCLEOTIDS, 100, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, _ := a.Clone()
+	if nb := c.Pack(); nb != 10 {
+		t.Fatalf("There should be 10 packed sequences (%d)", nb)
+	}
+	if !c.Identical(a) {
+		t.Errorf("Packed alignment should be identical to the original alignment")
+	}
+	if c.NbVariableSites() != a.NbVariableSites() || c.NbPacked() != 10 {
+		t.Errorf("Wrong number of variable sites of the packed alignment")
+	}
+	sub, _ := c.SubAlign(10, 20)
+	asub, _ := a.SubAlign(10, 20)
+	if !sub.Identical(asub) {
+		t.Errorf("Wrong sub alignment of the packed alignment")
+	}
+	c.Compress()
+	a.Compress()
+	if !c.Identical(a) || c.NbPacked() != 0 {
+		t.Errorf("Compressed packed alignment should be unpacked and identical to the original alignment")
+	}
+}
@@ -165,3 +165,80 @@ func (ps *PartitionSet) ModeleName(code int) string {
 func (ps *PartitionSet) AliLength() int {
 	return ps.length
 }
+
+// Sites returns the sites (0-based) of the partition associated to the given index,
+// in increasing order
+func (ps *PartitionSet) Sites(code int) (sites []int) {
+	sites = make([]int, 0)
+	for i, p := range ps.partitions {
+		if p == code {
+			sites = append(sites, i)
+		}
+	}
+	return
+}
+
+// Concat returns a new PartitionSet corresponding to the concatenation of
+// the alignment of ps and of the alignment of other: partitions of other
+// are shifted by the length of ps. Partitions having the same name in both
+// sets are merged (with the model of ps).
+func (ps *PartitionSet) Concat(other *PartitionSet) (concat *PartitionSet) {
+	concat = NewPartitionSet(ps.length + other.length)
+	concat.names = append(concat.names, ps.names...)
+	concat.models = append(concat.models, ps.models...)
+	copy(concat.partitions, ps.partitions)
+	for i, p := range other.partitions {
+		if p < 0 {
+			continue
+		}
+		code := -1
+		for j, n := range concat.names {
+			if n == other.names[p] {
+				code = j
+			}
+		}
+		if code == -1 {
+			concat.names = append(concat.names, other.names[p])
+			concat.models = append(concat.models, other.models[p])
+			code = len(concat.names) - 1
+		}
+		concat.partitions[ps.length+i] = code
+	}
+	return
+}
+
+// PartitionRange is a range of sites of a partition: from Start to End
+// (0-based, included), every Step sites
+type PartitionRange struct {
+	Start int
+	End   int
+	Step  int
+}
+
+// Ranges returns the sites of the partition associated to the given index,
+// as ranges of sites separated by a constant step, in increasing order.
+// Consecutive sites are grouped first: sites 1,2,3,5,7,9 give [1-3/1, 5-9/2].
+func (ps *PartitionSet) Ranges(code int) (ranges []PartitionRange) {
+	sites := ps.Sites(code)
+	ranges = make([]PartitionRange, 0)
+	for i := 0; i < len(sites); {
+		j := i + 1
+		step := 1
+		if j < len(sites) {
+			step = sites[j] - sites[i]
+			for j < len(sites) && sites[j]-sites[j-1] == step {
+				j++
+			}
+		}
+		if step > 1 && j-i == 2 {
+			// Two sites only: we do not group them
+			j = i + 1
+		}
+		if j-i == 1 {
+			step = 1
+		}
+		ranges = append(ranges, PartitionRange{sites[i], sites[j-1], step})
+		i = j
+	}
+	return
+}
@@ -20,13 +20,13 @@ func NewCountProfileFromAlignment(al Alignment) (p *CountProfile) {
 	p.header = make([]rune, 0, 100)
 	p.counts = make([][]int, 0, 100)
 
-	al.IterateChar(func(name string, seq []rune) bool {
+	al.IterateBytes(func(name string, seq []byte) bool {
 		for i, r := range seq {
 			idx := p.names[int(r)]
 			if idx < 0 {
 				idx = len(p.header)
 				p.names[int(r)] = idx
-				p.header = append(p.header, r)
+				p.header = append(p.header, rune(r))
 				p.counts = append(p.counts, make([]int, al.Length()))
 			}
 			p.counts[idx][i]++
@@ -0,0 +1,167 @@
+package align
+
+import (
+	"fmt"
+)
+
+// Per-base qualities are stored as Phred scores (not ASCII encoded),
+// one per character of the sequence. Sequences read from formats without
+// qualities (everything but FASTQ) do not have qualities (nil).
+//
+// Operations that move or remove characters (subseq, trim, unalign, etc.)
+// move or remove the corresponding qualities. Operations for which it does
+// not make sense (compress, concat, replace, etc.) drop the qualities.
+
+// Qualities returns the per-base Phred qualities of the sequence,
+// or nil if the sequence does not have qualities.
+func (s *seq) Qualities() []byte {
+	return s.qualities
+}
+
+// SetQualities sets the per-base Phred qualities of the sequence.
+// The number of qualities must be the same as the sequence length.
+// If q is nil, qualities are removed.
+func (s *seq) SetQualities(q []byte) error {
+	if q != nil && len(q) != s.Length() {
+		return fmt.Errorf("Sequence %s: number of qualities (%d) is different from sequence length (%d)", s.name, len(q), s.Length())
+	}
+	s.qualities = q
+	return nil
+}
+
+// MeanQuality returns the mean Phred quality of the non gap characters of the sequence.
+// Returns an error if the sequence does not have qualities.
+func (s *seq) MeanQuality() (mean float64, err error) {
+	var nb int
+	if s.qualities == nil {
+		return 0, fmt.Errorf("Sequence %s does not have qualities", s.name)
+	}
+	for i, q := range s.qualities {
+		if s.bytes()[i] != GAP {
+			mean += float64(q)
+			nb++
+		}
+	}
+	if nb > 0 {
+		mean /= float64(nb)
+	}
+	return
+}
+
+// MaskQuality replaces the characters whose Phred quality is < minq with
+// the given character. Gaps are not replaced.
+// Returns the number of replaced characters, or an error if the sequence
+// does not have qualities.
+func (s *seq) MaskQuality(minq int, char uint8) (nb int, err error) {
+	if s.qualities == nil {
+		return 0, fmt.Errorf("Sequence %s does not have qualities", s.name)
+	}
+	for i, q := range s.qualities {
+		if int(q) < minq && s.bytes()[i] != GAP && s.bytes()[i] != char {
+			s.bytes()[i] = char
+			nb++
+		}
+	}
+	return
+}
+
+// TrimQuality removes the characters at both ends of the sequence
+// as long as their Phred quality is < minq.
+// Returns the number of characters removed at the start and at the end,
+// or an error if the sequence does not have qualities.
+func (s *seq) TrimQuality(minq int) (start, end int, err error) {
+	if s.qualities == nil {
+		return 0, 0, fmt.Errorf("Sequence %s does not have qualities", s.name)
+	}
+	l := len(s.qualities)
+	for start < l && int(s.qualities[start]) < minq {
+		start++
+	}
+	for end < l-start && int(s.qualities[l-1-end]) < minq {
+		end++
+	}
+	s.sequence = s.bytes()[start : l-end]
+	s.qualities = s.qualities[start : l-end]
+	return
+}
+
+// subQualities returns a copy of the qualities from start (inclusive) to
+// end (exclusive), or nil if there are no qualities
+func (s *seq) subQualities(start, end int) []byte {
+	if s.qualities == nil {
+		return nil
+	}
+	q := make([]byte, end-start)
+	copy(q, s.qualities[start:end])
+	return q
+}
+
+// HasQualities returns true if all the sequences have qualities
+// (and there is at least one sequence)
+func (sb *seqbag) HasQualities() bool {
+	for _, s := range sb.seqs {
+		if s.qualities == nil {
+			return false
+		}
+	}
+	return len(sb.seqs) > 0
+}
+
+// MaskQuality replaces the characters whose Phred quality is < minq with
+// N (nucleotides) or X (amino acids). Gaps are not replaced.
+//
+// Returns the number of replaced characters, or an error if a sequence
+// does not have qualities.
+func (sb *seqbag) MaskQuality(minq int) (nb int, err error) {
+	var nbseq int
+	char := uint8(ALL_NUCLE)
+	if sb.Alphabet() == AMINOACIDS {
+		char = uint8(ALL_AMINO)
+	}
+	for _, s := range sb.seqs {
+		if nbseq, err = s.MaskQuality(minq, char); err != nil {
+			return
+		}
+		nb += nbseq
+	}
+	return
+}
+
+// TrimQuality removes the low quality ends (Phred quality < minq) of all
+// the sequences (see Sequence.TrimQuality). Sequences may then have
+// different lengths.
+//
+// Returns an error if a sequence does not have qualities.
+func (sb *seqbag) TrimQuality(minq int) (err error) {
+	for _, s := range sb.seqs {
+		if _, _, err = s.TrimQuality(minq); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// RemoveLowQualitySeqs removes the sequences whose mean Phred quality is < minmean.
+//
+// Returns the names of the removed sequences, or an error if a sequence
+// does not have qualities (in that case, no sequence is removed).
+func (sb *seqbag) RemoveLowQualitySeqs(minmean float64) (removed []string, err error) {
+	means := make([]float64, len(sb.seqs))
+	for i, s := range sb.seqs {
+		if means[i], err = s.MeanQuality(); err != nil {
+			return nil, err
+		}
+	}
+	keep := make([]*seq, 0, len(sb.seqs))
+	removed = make([]string, 0)
+	for i, s := range sb.seqs {
+		if means[i] < minmean {
+			removed = append(removed, s.name)
+			delete(sb.seqmap, s.name)
+		} else {
+			keep = append(keep, s)
+		}
+	}
+	sb.seqs = keep
+	return
+}
@@ -0,0 +1,123 @@
+package align
+
+import (
+	"testing"
+)
+
+func qualitySeqBag(t *testing.T) *seqbag {
+	sb := NewSeqBag(NUCLEOTIDS)
+	sb.AddSequence("s1", "ACGTACGTAC", "")
+	sb.AddSequence("s2", "ACG-ACGTAA", "")
+	s1, _ := sb.Sequence(0)
+	s2, _ := sb.Sequence(1)
+	if err := s1.SetQualities([]byte{10, 40, 2, 40, 40, 40, 40, 40, 0, 0}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.SetQualities([]byte{30, 40, 40, 0, 40, 40, 40, 40, 40, 20}); err != nil {
+		t.Fatal(err)
+	}
+	return sb
+}
+
+func TestSetQualities(t *testing.T) {
+	s := NewSequence("s1", []rune("ACGT"), "")
+	if err := s.SetQualities([]byte{1, 2, 3}); err == nil {
+		t.Errorf("There should be an error: wrong number of qualities")
+	}
+	if _, err := s.MeanQuality(); err == nil {
+		t.Errorf("There should be an error: sequence does not have qualities")
+	}
+}
+
+func TestMaskQuality(t *testing.T) {
+	sb := qualitySeqBag(t)
+	nb, err := sb.MaskQuality(20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nb != 4 {
+		t.Errorf("Number of masked bases should be 4 (%d)", nb)
+	}
+	exp := []string{"NCNTACGTNN", "ACG-ACGTAA"}
+	for i, e := range exp {
+		if s, _ := sb.GetSequenceById(i); s != e {
+			t.Errorf("Masked sequence %d is not as expected: %s vs. %s", i, s, e)
+		}
+	}
+}
+
+func TestTrimQuality(t *testing.T) {
+	sb := qualitySeqBag(t)
+	if err := sb.TrimQuality(20); err != nil {
+		t.Fatal(err)
+	}
+	exp := []string{"CGTACGT", "ACG-ACGTAA"}
+	for i, e := range exp {
+		s, _ := sb.Sequence(i)
+		if s.Sequence() != e {
+			t.Errorf("Trimmed sequence %d is not as expected: %s vs. %s", i, s.Sequence(), e)
+		}
+		if len(s.Qualities()) != len(e) {
+			t.Errorf("Qualities of sequence %d are not trimmed", i)
+		}
+	}
+
+	s := NewSequence("s1", []rune("ACGT"), "")
+	s.SetQualities([]byte{1, 2, 3, 4})
+	if start, end, _ := s.TrimQuality(20); start != 4 || end != 0 || s.Length() != 0 {
+		t.Errorf("Whole sequence should be trimmed (%d, %d, %d)", start, end, s.Length())
+	}
+}
+
+func TestRemoveLowQualitySeqs(t *testing.T) {
+	sb := qualitySeqBag(t)
+	removed, err := sb.RemoveLowQualitySeqs(30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0] != "s1" || sb.NbSequences() != 1 {
+		t.Errorf("Sequence s1 should be removed: %v", removed)
+	}
+	if _, ok := sb.GetSequence("s1"); ok {
+		t.Errorf("Sequence s1 should be removed from the map")
+	}
+
+	sb.AddSequence("s3", "ACGT", "")
+	if _, err = sb.RemoveLowQualitySeqs(30); err == nil {
+		t.Errorf("There should be an error: s3 does not have qualities")
+	}
+	if sb.NbSequences() != 2 {
+		t.Errorf("No sequence should be removed on error")
+	}
+}
+
+func TestQualitiesSubAlign(t *testing.T) {
+	sb := qualitySeqBag(t)
+	a := NewAlign(NUCLEOTIDS)
+	for _, s := range sb.seqs {
+		a.addSequenceQual(s.name, s.sequence, s.qualities, s.comment)
+	}
+
+	sub, err := a.SubAlign(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, _ := sub.Sequence(1)
+	if string(s.Qualities()) != string([]byte{40, 0, 40}) {
+		t.Errorf("Qualities of sub alignment are not as expected: %v", s.Qualities())
+	}
+
+	if err = a.TrimSequences(2, true); err != nil {
+		t.Fatal(err)
+	}
+	s, _ = a.Sequence(0)
+	if string(s.Qualities()) != string([]byte{2, 40, 40, 40, 40, 40, 0, 0}) {
+		t.Errorf("Qualities of trimmed alignment are not as expected: %v", s.Qualities())
+	}
+
+	unal := a.Unalign()
+	s, _ = unal.Sequence(1)
+	if s.Sequence() != "GACGTAA" || string(s.Qualities()) != string([]byte{40, 40, 40, 40, 40, 40, 20}) {
+		t.Errorf("Qualities of unaligned sequence are not as expected: %s %v", s.Sequence(), s.Qualities())
+	}
+}
@@ -0,0 +1,80 @@
+package align
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Region gives the location of an aligned sequence on its source sequence
+// (chromosome, scaffold, etc.), as given by the "s" lines of MAF files.
+//
+// As in MAF files, Start is 0-based, and is relative to the given strand:
+// if Strand is '-', then Start is counted from the end of the source sequence.
+//
+// Regions are not updated by operations that change the sequences
+// (subseq, trim, etc.).
+type Region struct {
+	Source     string // Name of the source sequence, ex: hg38.chr1
+	Start      int    // Start of the aligned region on the given strand (0-based)
+	Size       int    // Size of the aligned region (number of non gap characters)
+	Strand     byte   // '+' or '-'
+	SourceSize int    // Size of the whole source sequence
+}
+
+// NewRegion returns a new Region. Returns an error if the
+// coordinates are not consistent or if strand is not '+' or '-'.
+func NewRegion(source string, start, size int, strand byte, sourcesize int) (r *Region, err error) {
+	if strand != '+' && strand != '-' {
+		return nil, fmt.Errorf("Region %s: wrong strand '%c'", source, strand)
+	}
+	if start < 0 || size < 0 || start+size > sourcesize {
+		return nil, fmt.Errorf("Region %s: wrong coordinates (start=%d, size=%d, source size=%d)", source, start, size, sourcesize)
+	}
+	return &Region{source, start, size, strand, sourcesize}, nil
+}
+
+// Species returns the species (or assembly) part of the source name,
+// i.e. everything before the first '.' (ex: hg38 for hg38.chr1).
+func (r *Region) Species() string {
+	if idx := strings.Index(r.Source, "."); idx >= 0 {
+		return r.Source[:idx]
+	}
+	return r.Source
+}
+
+// ForwardStart returns the 0-based start of the region on the '+' strand
+func (r *Region) ForwardStart() int {
+	if r.Strand == '-' {
+		return r.SourceSize - r.Start - r.Size
+	}
+	return r.Start
+}
+
+// ReverseStrand returns the same region, expressed on the other strand
+func (r *Region) ReverseStrand() *Region {
+	strand := byte('-')
+	if r.Strand == '-' {
+		strand = '+'
+	}
+	return &Region{r.Source, r.SourceSize - r.Start - r.Size, r.Size, strand, r.SourceSize}
+}
+
+// clone returns a copy of the region (nil if r is nil)
+func (r *Region) clone() *Region {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	return &c
+}
+
+// Region returns the location of the sequence on its source
+// sequence, or nil if not known (only MAF inputs have regions)
+func (s *seq) Region() *Region {
+	return s.region
+}
+
+// SetRegion sets the location of the sequence on its source sequence
+func (s *seq) SetRegion(r *Region) {
+	s.region = r
+}
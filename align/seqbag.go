@@ -27,18 +27,22 @@ type SeqBag interface {
 	AutoAlphabet()                  // detects and sets alphabet automatically for all the sequences
 	CharStats() map[rune]int64
 	UniqueCharacters() []rune
+	CDS(name string) (SeqBag, error)                // Extracts annotated CDS (all of them if name is empty)
 	CharStatsSeq(idx int) (map[rune]int, error)     // Computes frequency of characters for the given sequence
 	CleanNames(namemap map[string]string)           // Clean sequence names (newick special char)
 	Clear()                                         // Removes all sequences
 	CloneSeqBag() (seqs SeqBag, err error)          // Clones the seqqbag
 	Deduplicate() (identical [][]string, err error) // Remove duplicate sequences
+	DuplicateNames() []DuplicateName                // Sequences whose name already existed when they were added
 	FilterLength(minlength, maxlength int) error    // Remove sequences whose length is <minlength or >maxlength
 	GetSequence(name string) (string, bool)         // Get a sequence by names
 	GetSequenceById(ith int) (string, bool)
-	GetSequenceChar(name string) ([]rune, bool)
-	GetSequenceCharById(ith int) ([]rune, bool)
+	GetSequenceChar(name string) ([]rune, bool)  // The sequence itself: modifying it modifies the seqbag (see Sequence.SequenceChar)
+	GetSequenceCharById(ith int) ([]rune, bool)  // The sequence itself: modifying it modifies the seqbag (see Sequence.SequenceChar)
+	GetSequenceBytesById(ith int) ([]byte, bool) // Internal representation of the sequence: must not be modified
 	GetSequenceNameById(ith int) (string, bool)
 	GetSequenceByName(name string) (Sequence, bool)
+	HasQualities() bool // True if all sequences have per-base qualities (FASTQ)
 	SetSequenceChar(ithAlign, ithSite int, char rune) error
 	IgnoreIdentical(bool)                // if true, then it won't add the sequence if a sequence with the same name AND same sequence exists
 	SampleSeqBag(nb int) (SeqBag, error) // generate a sub sample of the sequences
@@ -46,14 +50,20 @@ type SeqBag interface {
 	SequenceByName(name string) (Sequence, bool)
 	Identical(SeqBag) bool
 	Iterate(it func(name string, sequence string) bool)
-	IterateChar(it func(name string, sequence []rune) bool)
-	IterateAll(it func(name string, sequence []rune, comment string) bool)
+	IterateChar(it func(name string, sequence []rune) bool)                // Modifications are copied back into the seqbag after each call
+	IterateAll(it func(name string, sequence []rune, comment string) bool) // Modifications are copied back into the seqbag after each call
+	IterateBytes(it func(name string, sequence []byte) bool)               // Iterates over internal representation of the sequences: must not be modified
 	Sequences() []Sequence
 	SequencesChan() chan Sequence
 	LongestORF(reverse bool) (orf Sequence, err error)
-	MaxNameLength() int // maximum sequence name length
+	MaskQuality(minq int) (nb int, err error) // Replaces characters having quality < minq with N (or X)
+	MaxNameLength() int                       // maximum sequence name length
 	NbSequences() int
-	RarefySeqBag(nb int, counts map[string]int) (SeqBag, error) // Take a new rarefied sample taking into accounts weights
+	Pack() int                                                          // Stores nucleotide sequences on 2 or 4 bits per character
+	Unpack()                                                            // Stores all sequences on 1 byte per character
+	NbPacked() int                                                      // Number of packed sequences
+	RarefySeqBag(nb int, counts map[string]int) (SeqBag, error)         // Take a new rarefied sample taking into accounts weights
+	RemoveLowQualitySeqs(minmean float64) (removed []string, err error) // Removes sequences whose mean quality is < minmean
 	Rename(namemap map[string]string)
 	RenameRegexp(regex, replace string, namemap map[string]string) error
 	Replace(old, new string, regex bool) error        // Replaces old string with new string in sequences of the alignment
@@ -62,7 +72,8 @@ type SeqBag interface {
 	Translate(phase int, geneticcode int) (err error) // Translates nt sequence in aa
 	TrimNames(namemap map[string]string, size int) error
 	TrimNamesAuto(namemap map[string]string, curid *int) error
-	Sort() // Sorts the sequences by name
+	TrimQuality(minq int) error // Removes low quality (< minq) ends of sequences
+	Sort()                      // Sorts the sequences by name
 	Unalign() SeqBag
 }
 
@@ -71,6 +82,15 @@ type seqbag struct {
 	seqs            []*seq          // Set of sequences (to preserve order)
 	ignoreidentical bool            // if true, then it won't add the sequence if a sequence with the same name AND same sequence exists
 	alphabet        int             // AMINOACIDS , NUCLEOTIDS or UNKOWN
+	duplicates      []DuplicateName // Sequences renamed or ignored when added, because their name already existed
+}
+
+// DuplicateName is a sequence whose name already existed when it was
+// added to the seqbag: it was renamed NewName, or it was ignored (NewName
+// is empty) because the existing sequence was identical (see IgnoreIdentical)
+type DuplicateName struct {
+	Name    string
+	NewName string
 }
 
 func NewSeqBag(alphabet int) *seqbag {
@@ -85,6 +105,7 @@ func NewSeqBag(alphabet int) *seqbag {
 		make([]*seq, 0, 100),
 		false,
 		alphabet,
+		nil,
 	}
 }
 
@@ -112,28 +133,42 @@ func (sb *seqbag) sampleSeqBag(nb int) (*seqbag, error) {
 	permutation := rand.Perm(sb.NbSequences())
 	for i := 0; i < nb; i++ {
 		seq := sb.seqs[permutation[i]]
-		sample.AddSequenceChar(seq.name, seq.SequenceChar(), seq.Comment())
+		sample.AddSequenceChar(seq.name, seq.copyChars(), seq.Comment())
 	}
 	return sample, nil
 }
 
 // Adds a sequence to this alignment
 func (sb *seqbag) AddSequence(name string, sequence string, comment string) error {
-	err := sb.AddSequenceChar(name, []rune(sequence), comment)
-	return err
+	for i := 0; i < len(sequence); i++ {
+		if sequence[i] > unicode.MaxASCII {
+			return CheckSequenceChars([]rune(sequence))
+		}
+	}
+	return sb.addSequenceBytes(name, []byte(sequence), comment)
 }
 
 // If sb.ignoreidentical is true, then it won't add the sequence if a sequence with the same name AND same sequence
 // already exists in the alignment
 func (sb *seqbag) AddSequenceChar(name string, sequence []rune, comment string) error {
+	if err := CheckSequenceChars(sequence); err != nil {
+		return err
+	}
+	return sb.addSequenceBytes(name, runesToBytes(sequence), comment)
+}
+
+// addSequenceBytes adds the sequence, given as its internal byte representation.
+// The given slice is not copied.
+func (sb *seqbag) addSequenceBytes(name string, sequence []byte, comment string) error {
 	s, ok := sb.seqmap[name]
 	idx := 0
 	tmpname := name
 
 	// If the sequence name already exists with the same sequence
 	// and ignoreidentical is true, then we ignore this sequence
-	if ok && sb.ignoreidentical && s.SameSequence(sequence) {
+	if ok && sb.ignoreidentical && bytes.Equal(s.SequenceBytes(), sequence) {
 		log.Print(fmt.Sprintf("Warning: sequence \"%s\" already exists in alignment with the same sequence, ignoring", name))
+		sb.duplicates = append(sb.duplicates, DuplicateName{name, ""})
 		return nil
 	}
 	// Other possibility: we rename the sequence
@@ -144,12 +179,28 @@ func (sb *seqbag) AddSequenceChar(name string, sequence []rune, comment string)
 		tmpname = fmt.Sprintf("%s_%04d", name, idx)
 		_, ok = sb.seqmap[tmpname]
 	}
-	seq := NewSequence(tmpname, sequence, comment)
+	if tmpname != name {
+		sb.duplicates = append(sb.duplicates, DuplicateName{name, tmpname})
+	}
+	seq := newSequenceBytes(tmpname, sequence, comment)
 	sb.seqmap[tmpname] = seq
 	sb.seqs = append(sb.seqs, seq)
 	return nil
 }
 
+// addSequenceQual adds the sequence with its per-base qualities
+// (may be nil), see addSequenceBytes.
+func (sb *seqbag) addSequenceQual(name string, sequence, qualities []byte, comment string) error {
+	nb := len(sb.seqs)
+	if err := sb.addSequenceBytes(name, sequence, comment); err != nil {
+		return err
+	}
+	if len(sb.seqs) > nb {
+		sb.seqs[nb].qualities = qualities
+	}
+	return nil
+}
+
 // Append a string to all sequence names of the alignment
 // If right is true, then append it to the right of each names,
 // otherwise, appends it to the left
@@ -209,38 +260,51 @@ func (sb *seqbag) AlphabetCharToIndex(c rune) int {
 	}
 }
 
+var cleanNameFirstLast = regexp.MustCompile("(^[\\s\\t]+|[\\s\\t]+$)")
+var cleanNameInside = regexp.MustCompile("[\\|\\s\\t,\\[\\]\\(\\),;\\.:]+")
+
 // Removes spaces and tabs at beginning and end of sequence names
 // and replaces newick special characters \s\t()[];,.: by "-"
 func (sb *seqbag) CleanNames(namemap map[string]string) {
-	firstlast := regexp.MustCompile("(^[\\s\\t]+|[\\s\\t]+$)")
-	inside := regexp.MustCompile("[\\|\\s\\t,\\[\\]\\(\\),;\\.:]+")
-
 	for _, seq := range sb.seqs {
 		old := seq.name
-		seq.name = firstlast.ReplaceAllString(seq.name, "")
-		seq.name = inside.ReplaceAllString(seq.name, "-")
+		seq.name = CleanName(seq.name)
 		if namemap != nil {
 			namemap[old] = seq.name
 		}
 	}
 }
 
+// CleanName removes spaces and tabs at beginning and end of the given name
+// and replaces newick special characters \s\t()[];,.: by "-"
+func CleanName(name string) string {
+	name = cleanNameFirstLast.ReplaceAllString(name, "")
+	return cleanNameInside.ReplaceAllString(name, "-")
+}
+
 // Removes all the sequences from the seqbag
 func (sb *seqbag) Clear() {
 	sb.seqmap = make(map[string]*seq)
 	sb.seqs = make([]*seq, 0, 100)
+	sb.duplicates = nil
+}
+
+// DuplicateNames returns the sequences whose name already existed
+// when they were added, in the order they were added
+func (sb *seqbag) DuplicateNames() []DuplicateName {
+	return sb.duplicates
 }
 
 func (sb *seqbag) CloneSeqBag() (SeqBag, error) {
 	c := NewSeqBag(sb.Alphabet())
 	c.IgnoreIdentical(sb.ignoreidentical)
 	var err error
-	sb.IterateAll(func(name string, sequence []rune, comment string) bool {
-		newseq := make([]rune, 0, len(sequence))
-		newseq = append(newseq, sequence...)
-		err = c.AddSequenceChar(name, newseq, comment)
-		return err != nil
-	})
+	for _, s := range sb.seqs {
+		if err = c.addSequenceQual(s.name, s.copyBytes(), s.subQualities(0, s.Length()), s.comment); err != nil {
+			break
+		}
+		c.seqs[len(c.seqs)-1].region = s.region.clone()
+	}
 	return c, err
 }
 
@@ -261,7 +325,7 @@ func (sb *seqbag) Deduplicate() (identical [][]string, err error) {
 	// of a given sequence in the "identical" slice of slice
 	seqs := make(map[string]int)
 	for _, seq := range oldseqs {
-		s := string(seq.sequence)
+		s := seq.Sequence()
 		// If the group does not exist
 		if i, ok := seqs[s]; !ok {
 			if err = sb.AddSequence(seq.name, s, seq.comment); err != nil {
@@ -287,7 +351,7 @@ func (sb *seqbag) FilterLength(minlength, maxlength int) (err error) {
 	sb.Clear()
 	for _, seq := range oldseqs {
 		if (minlength >= 0 && seq.Length() >= minlength) || (maxlength > 0 && seq.Length() <= maxlength) {
-			if err = sb.AddSequenceChar(seq.name, seq.sequence, seq.comment); err != nil {
+			if err = sb.addSequenceQual(seq.name, seq.bytes(), seq.qualities, seq.comment); err != nil {
 				return
 			}
 		}
@@ -332,6 +396,17 @@ func (sb *seqbag) GetSequenceCharById(ith int) ([]rune, bool) {
 	return nil, false
 }
 
+// If ith >=0 && i < nbSequences() return the internal representation
+// of the sequence (not a copy, it must not be modified, or a decoded
+// copy if the sequence is packed), true
+// Otherwise, return nil, false
+func (sb *seqbag) GetSequenceBytesById(ith int) ([]byte, bool) {
+	if ith >= 0 && ith < sb.NbSequences() {
+		return sb.seqs[ith].SequenceBytes(), true
+	}
+	return nil, false
+}
+
 // If sequence exists in alignment, return sequence, true
 // Otherwise, return nil,false
 func (sb *seqbag) GetSequenceChar(name string) ([]rune, bool) {
@@ -384,7 +459,11 @@ func (sb *seqbag) SetSequenceChar(ithAlign, ithSite int, char rune) error {
 		return errors.New("Site index is outside sequence length")
 	}
 
-	sb.seqs[ithAlign].sequence[ithSite] = char
+	if char > unicode.MaxASCII {
+		return fmt.Errorf("Non ASCII character '%c' can not be set", char)
+	}
+
+	sb.seqs[ithAlign].bytes()[ithSite] = byte(char)
 	return nil
 }
 
@@ -405,7 +484,7 @@ func (sb *seqbag) Identical(comp SeqBag) bool {
 		if !ok {
 			return false
 		}
-		if string(seq.sequence) != seq2 {
+		if seq.Sequence() != seq2 {
 			return false
 		}
 	}
@@ -416,25 +495,48 @@ func (sb *seqbag) Identical(comp SeqBag) bool {
 func (sb *seqbag) Iterate(it func(name string, sequence string) bool) {
 	var stop bool = false
 	for _, seq := range sb.seqs {
-		if stop = it(seq.name, string(seq.sequence)); stop {
+		if stop = it(seq.name, seq.Sequence()); stop {
 			return
 		}
 	}
 }
 
+// IterateChar iterates over the sequences, given as runes.
+// Sequences are stored as bytes, so each sequence is converted
+// to runes before the call, and modifications of the characters
+// are copied back after the call.
 func (sb *seqbag) IterateChar(it func(name string, sequence []rune) bool) {
 	var stop bool = false
 	for _, seq := range sb.seqs {
-		if stop = it(seq.name, seq.sequence); stop {
+		runes := seq.copyChars()
+		stop = it(seq.name, runes)
+		seq.copyCharsBack(runes)
+		if stop {
 			return
 		}
 	}
 }
 
+// IterateAll iterates over the sequences, given as runes, with their comments.
+// Same as IterateChar, modifications of the characters are copied back after the call.
 func (sb *seqbag) IterateAll(it func(name string, sequence []rune, comment string) bool) {
 	var stop bool = false
 	for _, seq := range sb.seqs {
-		if stop = it(seq.name, seq.sequence, seq.comment); stop {
+		runes := seq.copyChars()
+		stop = it(seq.name, runes, seq.comment)
+		seq.copyCharsBack(runes)
+		if stop {
+			return
+		}
+	}
+}
+
+// IterateBytes iterates over the sequences, given as their internal
+// byte representation, without any copy. Sequences must not be modified.
+func (sb *seqbag) IterateBytes(it func(name string, sequence []byte) bool) {
+	var stop bool = false
+	for _, seq := range sb.seqs {
+		if stop = it(seq.name, seq.SequenceBytes()); stop {
 			return
 		}
 	}
@@ -460,12 +562,13 @@ func (sb *seqbag) SequencesChan() (seqs chan Sequence) {
 }
 
 /* It appends the given sequence to the sequence having given name */
-func (sb *seqbag) appendToSequence(name string, sequence []rune) error {
+func (sb *seqbag) appendToSequence(name string, sequence []byte) error {
 	seq, ok := sb.seqmap[name]
 	if !ok {
 		return fmt.Errorf("Sequence with name %s does not exist in alignment", name)
 	}
-	seq.sequence = append(seq.sequence, sequence...)
+	seq.sequence = append(seq.bytes(), sequence...)
+	seq.qualities = nil
 	return nil
 }
 
@@ -473,9 +576,9 @@ func (sb *seqbag) AutoAlphabet() {
 	isaa := true
 	isnt := true
 
-	sb.IterateChar(func(name string, seq []rune) bool {
-		for _, nt := range seq {
-			nt = unicode.ToUpper(nt)
+	sb.IterateBytes(func(name string, seq []byte) bool {
+		for _, b := range seq {
+			nt := unicode.ToUpper(rune(b))
 			couldbent := false
 			couldbeaa := false
 			switch nt {
@@ -508,8 +611,8 @@ func (sb *seqbag) CharStats() (chars map[rune]int64) {
 	present := make([]int, 130)
 
 	for _, seq := range sb.seqs {
-		for _, r := range seq.sequence {
-			present[unicode.ToUpper(r)]++
+		for _, r := range seq.SequenceBytes() {
+			present[unicode.ToUpper(rune(r))]++
 		}
 	}
 
@@ -528,8 +631,8 @@ func (sb *seqbag) UniqueCharacters() (chars []rune) {
 	present := make([]bool, 130)
 
 	for _, seq := range sb.seqs {
-		for _, r := range seq.sequence {
-			present[unicode.ToUpper(r)] = true
+		for _, r := range seq.SequenceBytes() {
+			present[unicode.ToUpper(rune(r))] = true
 		}
 	}
 
@@ -739,13 +842,15 @@ func (sb *seqbag) Replace(old, new string, regex bool) (err error) {
 			return err
 		}
 		for seq := 0; seq < sb.NbSequences(); seq++ {
-			newseq := []rune(r.ReplaceAllString(string(sb.seqs[seq].sequence), new))
-			sb.seqs[seq].sequence = newseq
+			newseq := []rune(r.ReplaceAllString(string(sb.seqs[seq].bytes()), new))
+			sb.seqs[seq].sequence = runesToBytes(newseq)
+			sb.seqs[seq].qualities = nil
 		}
 	} else {
 		for seq := 0; seq < sb.NbSequences(); seq++ {
-			newseq := strings.Replace(string(sb.seqs[seq].sequence), old, new, -1)
-			sb.seqs[seq].sequence = []rune(newseq)
+			newseq := strings.Replace(string(sb.seqs[seq].bytes()), old, new, -1)
+			sb.seqs[seq].sequence = runesToBytes([]rune(newseq))
+			sb.seqs[seq].qualities = nil
 		}
 	}
 	return nil
@@ -829,6 +934,39 @@ func (sb *seqbag) Translate(phase int, geneticcode int) (err error) {
 	return
 }
 
+// CDS extracts the CDS features annotated on the sequences (GenBank or EMBL
+// inputs), starting at their first complete codon (see Feature.ExtractCDS).
+//
+// If name is not empty, only the CDS having this name (gene, locus_tag,
+// protein_id or product) are extracted.
+// Output sequences are named <sequence name>_<CDS name>.
+//
+// Returns an error if no CDS is found.
+func (sb *seqbag) CDS(name string) (cds SeqBag, err error) {
+	var ex Sequence
+	cds = NewSeqBag(NUCLEOTIDS)
+	for _, s := range sb.seqs {
+		for _, f := range s.features {
+			if f.Type != "CDS" || (name != "" && !f.HasName(name)) {
+				continue
+			}
+			if ex, err = f.ExtractCDS(s); err != nil {
+				return nil, err
+			}
+			if err = cds.AddSequenceChar(ex.Name(), ex.SequenceChar(), ex.Comment()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if cds.NbSequences() == 0 {
+		if name != "" {
+			return nil, fmt.Errorf("No CDS named %s in the sequences", name)
+		}
+		return nil, fmt.Errorf("No CDS annotated in the sequences")
+	}
+	return
+}
+
 // Translate sequences in 3 phases (or 6 phases if reverse strand is true)
 // And return the longest orf found
 func (sb *seqbag) LongestORF(reverse bool) (orf Sequence, err error) {
@@ -954,13 +1092,27 @@ func (sb *seqbag) TrimNamesAuto(namemap map[string]string, curid *int) (err erro
 	return
 }
 
-func (sb *seqbag) Unalign() (unal SeqBag) {
-	unal = NewSeqBag(sb.Alphabet())
+func (sb *seqbag) Unalign() SeqBag {
+	unal := NewSeqBag(sb.Alphabet())
 
 	for _, seq := range sb.seqs {
-		unal.AddSequence(seq.name, strings.Replace(string(seq.sequence), "-", "", -1), seq.comment)
+		var qual []byte
+		newseq := make([]byte, 0, seq.Length())
+		if seq.qualities != nil {
+			qual = make([]byte, 0, len(seq.qualities))
+		}
+		for i, c := range seq.SequenceBytes() {
+			if c == GAP {
+				continue
+			}
+			newseq = append(newseq, c)
+			if qual != nil {
+				qual = append(qual, seq.qualities[i])
+			}
+		}
+		unal.addSequenceQual(seq.name, newseq, qual, seq.comment)
 	}
-	return
+	return unal
 }
 
 func (sb *seqbag) String() string {
@@ -968,7 +1120,7 @@ func (sb *seqbag) String() string {
 	buffer.WriteString("\n")
 	for _, seq := range sb.seqs {
 		buffer.WriteString(seq.name + ":")
-		buffer.WriteString(string(seq.sequence))
+		buffer.WriteString(seq.Sequence())
 		buffer.WriteRune('\n')
 	}
 	return buffer.String()
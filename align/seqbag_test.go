@@ -20,8 +20,8 @@ func Test_seqbag_UniqueCharacters(t *testing.T) {
 		{name: "t1",
 			fields: fields{seqmap: nil,
 				seqs: []*seq{
-					&seq{sequence: []rune("ACGTACGTACGT")},
-					&seq{sequence: []rune("ACGTAC*TACGT")}}},
+					&seq{sequence: []byte("ACGTACGTACGT")},
+					&seq{sequence: []byte("ACGTAC*TACGT")}}},
 			wantChars: []rune("*ACGT")},
 	}
 	for _, tt := range tests {
@@ -13,7 +13,12 @@ import (
 
 type Sequence interface {
 	Sequence() string
+	// Returns the sequence as runes: modifying the returned slice modifies the
+	// sequence, until the sequence is modified by another method
 	SequenceChar() []rune
+	// Returns the sequence as stored internally (1 byte per character, no copy,
+	// or a decoded copy if the sequence is packed). The returned slice must not be modified
+	SequenceBytes() []byte
 	SameSequence([]rune) bool
 	CharAt(int) rune
 	Name() string
@@ -35,27 +40,175 @@ type Sequence interface {
 	/// sequence (ref sequence can have a '-' or a 'N')
 	NumMutationsComparedToReferenceSequence(alphabet int, seq Sequence) (nummutations int, err error)
 
+	Features() []*Feature  // Features annotated on the sequence (GenBank/EMBL)
+	AddFeature(f *Feature) // Adds a feature to the sequence
+
+	Qualities() []byte                                // Per-base Phred qualities (FASTQ), nil if none
+	SetQualities(q []byte) error                      // Sets the per-base Phred qualities (nil: removes them)
+	MeanQuality() (float64, error)                    // Mean Phred quality of the non gap characters
+	MaskQuality(minq int, char uint8) (int, error)    // Replaces characters having quality < minq with char
+	TrimQuality(minq int) (start, end int, err error) // Removes low quality (< minq) ends
+
+	Region() *Region     // Location on the source sequence (MAF), nil if unknown
+	SetRegion(r *Region) // Sets the location on the source sequence
 	Clone() Sequence
 }
 
+// SequenceChannel is used to stream sequences one by one
+// (see fasta.Parser.ParseStream). Err must be checked once
+// Schan is closed.
+type SequenceChannel struct {
+	Schan chan Sequence
+	Err   error
+}
+
+// Sequences are stored as bytes (1 byte per character), which is sufficient
+// for nucleotide and amino acid alphabets, and 4 times smaller than runes.
+// Nucleotide sequences may also be packed on 2 or 4 bits per character
+// (see SeqBag.Pack): sequence is then nil, and is unpacked by bytes() as
+// soon as it is accessed, except by read-only accessors (Sequence,
+// SequenceBytes, CharAt, Length), which decode it without unpacking it.
+// Once returned by SequenceChar, the sequence is stored as runes, so that
+// modifications of the returned slice modify the sequence, until it is
+// modified by another method, which stores it back as bytes.
 type seq struct {
-	name     string // Name of the sequence
-	sequence []rune // Sequence of nucleotides/aa
-	comment  string // Comment if any
+	name      string          // Name of the sequence
+	sequence  []byte          // Sequence of nucleotides/aa, nil if packed or runes
+	packed    *PackedSequence // Packed sequence if any
+	runes     []rune          // Sequence as runes if returned by SequenceChar
+	comment   string          // Comment if any
+	features  []*Feature      // Annotated features if any (gene, CDS, etc.)
+	qualities []byte          // Per-base Phred qualities if any (FASTQ)
+	region    *Region         // Location on the source sequence if any (MAF)
 }
 
+// NewSequence creates a new sequence from its rune representation.
+// Sequences are stored on 1 byte per character: non ASCII characters
+// are silently replaced by OTHER ('*'). Use CheckSequenceChars before
+// to detect them (as AddSequence and AddSequenceChar do).
 func NewSequence(name string, sequence []rune, comment string) *seq {
+	return newSequenceBytes(name, runesToBytes(sequence), comment)
+}
+
+// newSequenceBytes creates a new sequence from its byte representation,
+// the given slice is not copied
+func newSequenceBytes(name string, sequence []byte, comment string) *seq {
 	return &seq{
-		name,
-		sequence,
-		comment,
+		name:     name,
+		sequence: sequence,
+		comment:  comment,
+	}
+}
+
+// CheckSequenceChars returns an error if the given sequence contains
+// characters that can not be stored as bytes (non ASCII characters)
+func CheckSequenceChars(sequence []rune) error {
+	for i, r := range sequence {
+		if r > unicode.MaxASCII {
+			return fmt.Errorf("Non ASCII character '%c' at position %d in sequence", r, i)
+		}
+	}
+	return nil
+}
+
+func runesToBytes(sequence []rune) (b []byte) {
+	b = make([]byte, len(sequence))
+	copyRunesToBytes(b, sequence)
+	return
+}
+
+// copyRunesToBytes copies the runes into the given byte slice,
+// which must be at least as long as the rune slice
+func copyRunesToBytes(b []byte, sequence []rune) {
+	for i, r := range sequence {
+		if r > unicode.MaxASCII {
+			r = OTHER
+		}
+		b[i] = byte(r)
+	}
+}
+
+func bytesToRunes(sequence []byte) (r []rune) {
+	r = make([]rune, len(sequence))
+	for i, b := range sequence {
+		r[i] = rune(b)
+	}
+	return
+}
+
+// bytes returns the internal representation of the sequence,
+// unpacking it first if it is packed or stored as runes
+func (s *seq) bytes() []byte {
+	if s.packed != nil {
+		s.sequence = s.packed.unpackBytes()
+		s.packed = nil
+	}
+	if s.runes != nil {
+		s.sequence = runesToBytes(s.runes)
+		s.runes = nil
+	}
+	return s.sequence
+}
+
+// copyBytes returns a copy of the internal representation of the
+// sequence (unpacked if the sequence is packed)
+func (s *seq) copyBytes() []byte {
+	if s.packed != nil {
+		return s.packed.unpackBytes()
+	}
+	if s.runes != nil {
+		return runesToBytes(s.runes)
 	}
+	c := make([]byte, len(s.sequence))
+	copy(c, s.sequence)
+	return c
 }
 
 func (s *seq) Sequence() string {
-	return string(s.sequence)
+	return string(s.SequenceBytes())
 }
+
+// SequenceChar returns the sequence as runes. The returned slice is the
+// sequence itself: modifying it modifies the sequence, until the sequence
+// is modified by another method.
 func (s *seq) SequenceChar() []rune {
+	if s.runes == nil {
+		s.runes = bytesToRunes(s.SequenceBytes())
+		s.sequence = nil
+		s.packed = nil
+	}
+	return s.runes
+}
+
+// copyChars returns a copy of the sequence as runes, without
+// changing its internal representation
+func (s *seq) copyChars() []rune {
+	if s.runes != nil {
+		return append([]rune(nil), s.runes...)
+	}
+	return bytesToRunes(s.SequenceBytes())
+}
+
+// copyCharsBack copies the given runes (as long as the sequence)
+// into the sequence, keeping its rune representation if any
+func (s *seq) copyCharsBack(runes []rune) {
+	if s.runes != nil {
+		copy(s.runes, runes)
+		return
+	}
+	copyRunesToBytes(s.bytes(), runes)
+}
+
+// SequenceBytes returns the internal representation of the sequence,
+// which must not be modified. If the sequence is packed or stored as
+// runes, returns a decoded copy, the representation being unchanged.
+func (s *seq) SequenceBytes() []byte {
+	if s.packed != nil {
+		return s.packed.unpackBytes()
+	}
+	if s.runes != nil {
+		return runesToBytes(s.runes)
+	}
 	return s.sequence
 }
 
@@ -65,11 +218,11 @@ Returns true iif :
 - slices have same rune at each position
 */
 func (s *seq) SameSequence(runeseq []rune) bool {
-	if len(s.sequence) != len(runeseq) {
+	if s.Length() != len(runeseq) {
 		return false
 	}
-	for i, v := range s.sequence {
-		if runeseq[i] != v {
+	for i, v := range s.SequenceBytes() {
+		if runeseq[i] != rune(v) {
 			return false
 		}
 	}
@@ -77,7 +230,13 @@ func (s *seq) SameSequence(runeseq []rune) bool {
 }
 
 func (s *seq) CharAt(i int) rune {
-	return s.sequence[i]
+	if s.packed != nil {
+		return s.packed.At(i)
+	}
+	if s.runes != nil {
+		return s.runes[i]
+	}
+	return rune(s.sequence[i])
 }
 
 func (s *seq) Name() string {
@@ -93,6 +252,12 @@ func (s *seq) Comment() string {
 }
 
 func (s *seq) Length() int {
+	if s.packed != nil {
+		return s.packed.length
+	}
+	if s.runes != nil {
+		return len(s.runes)
+	}
 	return len(s.sequence)
 }
 
@@ -107,7 +272,7 @@ func (s *seq) LongestORF() (start, end int) {
 	//re.Longest()
 	idx := re.FindAllStringIndex(
 		strings.Replace(
-			strings.ToUpper(string(s.sequence)),
+			strings.ToUpper(s.Sequence()),
 			"U", "T", -1),
 		-1)
 	if idx != nil {
@@ -145,7 +310,12 @@ func Reverse(seq []rune) {
 
 // Reverse sequence order
 func (s *seq) Reverse() {
-	Reverse(s.sequence)
+	for i, j := 0, s.Length()-1; i < j; i, j = i+1, j-1 {
+		s.bytes()[i], s.bytes()[j] = s.bytes()[j], s.bytes()[i]
+	}
+	for i, j := 0, len(s.qualities)-1; i < j; i, j = i+1, j-1 {
+		s.qualities[i], s.qualities[j] = s.qualities[j], s.qualities[i]
+	}
 }
 
 // Complement sequence
@@ -168,15 +338,22 @@ func (s *seq) Complement() error {
 	if a != NUCLEOTIDS && a != BOTH {
 		return fmt.Errorf("Wrong alphabet for Complementing sequence")
 	}
-	return Complement(s.sequence)
+	for i, n := range s.bytes() {
+		c, ok := complement_nuc_mapping[rune(n)]
+		if !ok {
+			return fmt.Errorf("Character %c can not be complemented", n)
+		}
+		s.bytes()[i] = byte(c)
+	}
+	return nil
 }
 
 func (s *seq) DetectAlphabet() int {
 	isaa := true
 	isnt := true
 
-	for _, nt := range s.sequence {
-		nt = unicode.ToUpper(nt)
+	for _, b := range s.SequenceBytes() {
+		nt := unicode.ToUpper(rune(b))
 		couldbent := false
 		couldbeaa := false
 		switch nt {
@@ -203,10 +380,10 @@ func (s *seq) DetectAlphabet() int {
 	}
 }
 
-//NumGaps returns the number of Gaps on the given sequence
+// NumGaps returns the number of Gaps on the given sequence
 func (s *seq) NumGaps() (numgaps int) {
 	numgaps = 0
-	for _, c := range s.sequence {
+	for _, c := range s.SequenceBytes() {
 		if c == GAP {
 			numgaps++
 		}
@@ -214,11 +391,11 @@ func (s *seq) NumGaps() (numgaps int) {
 	return
 }
 
-//NumGapsOpenning returns the number of Gaps on the given sequence
+// NumGapsOpenning returns the number of Gaps on the given sequence
 func (s *seq) NumGapsOpenning() (numgaps int) {
 	numgaps = 0
-	var prevChar rune = '>'
-	for _, c := range s.sequence {
+	var prevChar byte = '>'
+	for _, c := range s.SequenceBytes() {
 		if c == GAP && prevChar != GAP {
 			numgaps++
 		}
@@ -232,7 +409,7 @@ func (s *seq) NumGapsOpenning() (numgaps int) {
 // Ex: -----A-AAA--AA = 5
 func (s *seq) NumGapsFromStart() (numgaps int) {
 	numgaps = 0
-	for _, c := range s.sequence {
+	for _, c := range s.SequenceBytes() {
 		if c != GAP {
 			return
 		}
@@ -246,9 +423,8 @@ func (s *seq) NumGapsFromStart() (numgaps int) {
 // Ex: // -----A-AAA--AA---- = 4
 func (s *seq) NumGapsFromEnd() (numgaps int) {
 	numgaps = 0
-	for i := range s.sequence {
-		c := s.sequence[len(s.sequence)-i-1]
-		if c != GAP {
+	for i := s.Length() - 1; i >= 0; i-- {
+		if s.CharAt(i) != GAP {
 			return
 		}
 		numgaps++
@@ -275,7 +451,7 @@ func (s *seq) NumMutationsComparedToReferenceSequence(alphabet int, refseq Seque
 	if alphabet == NUCLEOTIDS {
 		refseqCode = make([]uint8, s.Length())
 		for i := 0; i < s.Length(); i++ {
-			if refseqCode[i], err = Nt2IndexIUPAC(refseq.SequenceChar()[i]); err != nil {
+			if refseqCode[i], err = Nt2IndexIUPAC(refseq.CharAt(i)); err != nil {
 				return
 			}
 		}
@@ -287,16 +463,16 @@ func (s *seq) NumMutationsComparedToReferenceSequence(alphabet int, refseq Seque
 	for i := 0; i < s.Length(); i++ {
 		eq := true
 		if alphabet == NUCLEOTIDS {
-			if nt, err = Nt2IndexIUPAC(s.sequence[i]); err != nil {
+			if nt, err = Nt2IndexIUPAC(s.CharAt(i)); err != nil {
 				return
 			}
 			if eq, err = EqualOrCompatible(nt, refseqCode[i]); err != nil {
 				return
 			}
 		} else {
-			eq = (s.sequence[i] == refseq.SequenceChar()[i])
+			eq = (s.CharAt(i) == refseq.CharAt(i))
 		}
-		if s.SequenceChar()[i] != GAP && s.SequenceChar()[i] != all && !eq {
+		if s.CharAt(i) != GAP && s.CharAt(i) != all && !eq {
 			nummutations++
 		}
 	}
@@ -319,7 +495,7 @@ func (s *seq) Translate(phase int, geneticcode int) (tr Sequence, err error) {
 		return
 	}
 
-	tr = NewSequence(s.name, []rune(buffer.String()), s.comment)
+	tr = newSequenceBytes(s.name, []byte(buffer.String()), s.comment)
 	return
 }
 
@@ -330,16 +506,16 @@ func bufferTranslate(s *seq, phase int, code map[string]rune, buffer *bytes.Buff
 		return
 	}
 
-	if len(s.sequence) < 3+phase {
+	if s.Length() < 3+phase {
 		err = fmt.Errorf("Cannot translate a sequence with length < 3+phase (%s)", s.name)
 		return
 	}
-	for i := phase; i < len(s.sequence)-2; i += 3 {
+	for i := phase; i < s.Length()-2; i += 3 {
 		var aa rune = ' '
 		var aatmp rune = ' '
 		var found bool = false
 		// We handle possible IUPAC characters
-		codons := GenAllPossibleCodons(s.sequence[i], s.sequence[i+1], s.sequence[i+2])
+		codons := GenAllPossibleCodons(s.CharAt(i), s.CharAt(i+1), s.CharAt(i+2))
 		if len(codons) == 0 {
 			aa = 'X'
 		}
@@ -364,9 +540,24 @@ func bufferTranslate(s *seq, phase int, code map[string]rune, buffer *bytes.Buff
 }
 
 func (s *seq) Clone() Sequence {
-	seq2 := make([]rune, len(s.sequence))
-	copy(seq2, s.sequence)
-	return NewSequence(s.name, seq2, s.comment)
+	c := newSequenceBytes(s.name, s.copyBytes(), s.comment)
+	if s.features != nil {
+		c.features = append([]*Feature(nil), s.features...)
+	}
+	c.qualities = s.subQualities(0, s.Length())
+	c.region = s.region.clone()
+	return c
+}
+
+// Features returns the features annotated on the sequence
+// (only GenBank and EMBL inputs have features)
+func (s *seq) Features() []*Feature {
+	return s.features
+}
+
+// AddFeature adds a feature to the sequence
+func (s *seq) AddFeature(f *Feature) {
+	s.features = append(s.features, f)
 }
 
 // GenAllPossibleCodons generates all possible codons given the 3 nucleotides in arguments
@@ -464,12 +655,13 @@ func EqualOrCompatible(nt1, nt2 uint8) (ok bool, err error) {
 //
 // - if the two nucleotides are identical : returns 0.0
 // - if the two nucleotides are different:
-//      1) If none are ambigous: returns 1.0
-//      2) Otherwise, returns 1-Card(I)/Card(U), I being the
-//         intersection of the sets of possible
-//         nucleotides of nt1 and nt2, and U being
-//         the union of the sets of possible nucleotides
-//         of nt1 and nt2.
+//  1. If none are ambigous: returns 1.0
+//  2. Otherwise, returns 1-Card(I)/Card(U), I being the
+//     intersection of the sets of possible
+//     nucleotides of nt1 and nt2, and U being
+//     the union of the sets of possible nucleotides
+//     of nt1 and nt2.
+//
 // For example, if we want to compare Y and S :
 // Y = {C | T} and S = {G | C}. Card(I)=1, Card(U)=3, so diff=2/3
 //
@@ -52,15 +52,15 @@ func Test_seq_Translate(t *testing.T) {
 		wantTr  Sequence
 		wantErr bool
 	}{
-		{name: "Seq1", fields: fields{name: "seq1", sequence: []rune{'G', 'A', 'Y', 'A', 'A', 'R', 'U', 'A', 'Y', 'C', 'A', 'Y', 'R', 'A', 'Y', 'U', 'A', 'G'}}, args: args{phase: 0, geneticcode: 0}, wantTr: &seq{name: "seq1", sequence: []rune{'D', 'K', 'Y', 'H', 'X', '*'}}, wantErr: false},
-		{name: "Seq2", fields: fields{name: "seq1", sequence: []rune{'G', 'A', 'Y', 'A', 'A', 'R', 'U', 'A', 'Y', 'C', 'A', 'Y', 'A', 'A', 'Y', 'U', 'A', 'G'}}, args: args{phase: 0, geneticcode: 0}, wantTr: &seq{name: "seq1", sequence: []rune{'D', 'K', 'Y', 'H', 'N', '*'}}, wantErr: false},
-		{name: "Seq3", fields: fields{name: "seq1", sequence: []rune{'-', 'A', 'Y', 'A', 'A', 'R', 'U', 'A', 'Y', 'C', 'A', 'Y', 'A', 'A', 'Y', 'U', 'A', 'G'}}, args: args{phase: 0, geneticcode: 0}, wantTr: &seq{name: "seq1", sequence: []rune{'X', 'K', 'Y', 'H', 'N', '*'}}, wantErr: false},
+		{name: "Seq1", fields: fields{name: "seq1", sequence: []rune{'G', 'A', 'Y', 'A', 'A', 'R', 'U', 'A', 'Y', 'C', 'A', 'Y', 'R', 'A', 'Y', 'U', 'A', 'G'}}, args: args{phase: 0, geneticcode: 0}, wantTr: &seq{name: "seq1", sequence: []byte{'D', 'K', 'Y', 'H', 'X', '*'}}, wantErr: false},
+		{name: "Seq2", fields: fields{name: "seq1", sequence: []rune{'G', 'A', 'Y', 'A', 'A', 'R', 'U', 'A', 'Y', 'C', 'A', 'Y', 'A', 'A', 'Y', 'U', 'A', 'G'}}, args: args{phase: 0, geneticcode: 0}, wantTr: &seq{name: "seq1", sequence: []byte{'D', 'K', 'Y', 'H', 'N', '*'}}, wantErr: false},
+		{name: "Seq3", fields: fields{name: "seq1", sequence: []rune{'-', 'A', 'Y', 'A', 'A', 'R', 'U', 'A', 'Y', 'C', 'A', 'Y', 'A', 'A', 'Y', 'U', 'A', 'G'}}, args: args{phase: 0, geneticcode: 0}, wantTr: &seq{name: "seq1", sequence: []byte{'X', 'K', 'Y', 'H', 'N', '*'}}, wantErr: false},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &seq{
 				name:     tt.fields.name,
-				sequence: tt.fields.sequence,
+				sequence: runesToBytes(tt.fields.sequence),
 				comment:  tt.fields.comment,
 			}
 			gotTr, err := s.Translate(tt.args.phase, tt.args.geneticcode)
@@ -94,7 +94,7 @@ func Test_seq_NumGapsFromEnd(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &seq{
 				name:     tt.fields.name,
-				sequence: tt.fields.sequence,
+				sequence: runesToBytes(tt.fields.sequence),
 				comment:  tt.fields.comment,
 			}
 			if gotNumgaps := s.NumGapsFromEnd(); gotNumgaps != tt.wantNumgaps {
@@ -123,7 +123,7 @@ func Test_seq_NumGapsFromStart(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &seq{
 				name:     tt.fields.name,
-				sequence: tt.fields.sequence,
+				sequence: runesToBytes(tt.fields.sequence),
 				comment:  tt.fields.comment,
 			}
 			if gotNumgaps := s.NumGapsFromStart(); gotNumgaps != tt.wantNumgaps {
@@ -152,7 +152,7 @@ func Test_seq_NumGaps(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &seq{
 				name:     tt.fields.name,
-				sequence: tt.fields.sequence,
+				sequence: runesToBytes(tt.fields.sequence),
 				comment:  tt.fields.comment,
 			}
 			if gotNumgaps := s.NumGaps(); gotNumgaps != tt.wantNumgaps {
@@ -269,7 +269,7 @@ func Test_seq_NumGapsOpenning(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := &seq{
 				name:     tt.fields.name,
-				sequence: tt.fields.sequence,
+				sequence: runesToBytes(tt.fields.sequence),
 				comment:  tt.fields.comment,
 			}
 			if gotNumgaps := s.NumGapsOpenning(); gotNumgaps != tt.wantNumgaps {
@@ -278,3 +278,29 @@ func Test_seq_NumGapsOpenning(t *testing.T) {
 		})
 	}
 }
+
+func TestRegion(t *testing.T) {
+	if _, err := NewRegion("hg38.chr1", 95, 10, '+', 100); err == nil {
+		t.Errorf("There should be an error: region goes beyond the end of the source")
+	}
+	if _, err := NewRegion("hg38.chr1", 0, 10, '.', 100); err == nil {
+		t.Errorf("There should be an error: wrong strand")
+	}
+	r, err := NewRegion("hg38.chr1", 20, 10, '-', 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Species() != "hg38" || r.ForwardStart() != 70 {
+		t.Errorf("Region species or forward start is not as expected: %s, %d", r.Species(), r.ForwardStart())
+	}
+	if rev := r.ReverseStrand(); rev.Strand != '+' || rev.Start != 70 || rev.Size != 10 {
+		t.Errorf("Reverse region is not as expected: %v", rev)
+	}
+
+	s := NewSequence("s1", []rune("ACGT-ACGTAC"), "")
+	s.SetRegion(r)
+	c := s.Clone()
+	if c.Region() == nil || *c.Region() != *r || c.Region() == r {
+		t.Errorf("Cloned sequence should have a copy of the region: %v", c.Region())
+	}
+}
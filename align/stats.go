@@ -0,0 +1,126 @@
+package align
+
+import (
+	"sort"
+)
+
+// AlignmentStats are the general characteristics of an alignment,
+// as printed by goalign stats
+type AlignmentStats struct {
+	Length        int         // Length of the alignment
+	NbSequences   int         // Number of sequences
+	AvgAlleles    float64     // Average number of alleles per site
+	VariableSites int         // Number of variable sites (gaps and other characters excluded)
+	Alphabet      string      // nucleotide, protein or unknown
+	Characters    []CharCount // Character counts, in alphabetical order
+}
+
+// CharCount is the number of occurences of a character in an alignment
+type CharCount struct {
+	Char  string
+	Count int64
+	Freq  float64 // Count / total number of characters
+}
+
+// SequenceStats are the characteristics of a sequence of an alignment,
+// as printed by goalign stats --per-sequences.
+//
+// Fields comparing the sequence to a count profile or to a reference
+// sequence are nil if no profile or no reference was given.
+type SequenceStats struct {
+	Name         string
+	Gaps         int            // Number of gaps
+	GapsStart    int            // Number of consecutive gaps at the beginning
+	GapsEnd      int            // Number of consecutive gaps at the end
+	GapsUnique   int            // Number of gaps unique to the sequence
+	GapsNew      *int           // Number of gaps not found in the profile
+	GapsBoth     *int           // Number of unique gaps not found in the profile
+	GapsOpenning int            // Number of gap openings
+	MutUnique    int            // Number of characters unique to the sequence
+	MutNew       *int           // Number of characters not found in the profile
+	MutBoth      *int           // Number of unique characters not found in the profile
+	MutRef       *int           // Number of mutations compared to the reference sequence
+	Length       int            // Length of the sequence without gaps
+	CharCounts   map[string]int // Number of occurences of each character of the alignment
+}
+
+// Stats returns the general characteristics of the alignment
+func (a *align) Stats() (stats AlignmentStats) {
+	var total int64
+
+	stats = AlignmentStats{
+		Length:        a.Length(),
+		NbSequences:   a.NbSequences(),
+		AvgAlleles:    a.AvgAllelesPerSite(),
+		VariableSites: a.NbVariableSites(),
+		Alphabet:      a.AlphabetStr(),
+		Characters:    make([]CharCount, 0),
+	}
+
+	charmap := a.CharStats()
+	for c, nb := range charmap {
+		stats.Characters = append(stats.Characters, CharCount{Char: string(c), Count: nb})
+		total += nb
+	}
+	sort.Slice(stats.Characters, func(i, j int) bool { return stats.Characters[i].Char < stats.Characters[j].Char })
+	for i := range stats.Characters {
+		stats.Characters[i].Freq = float64(stats.Characters[i].Count) / float64(total)
+	}
+	return
+}
+
+// SequenceStats returns the characteristics of each sequence of the alignment,
+// in the order of the alignment.
+//
+// If countProfile is not nil, gaps and characters unique to each sequence are
+// also compared to the profile (see NumGapsUniquePerSequence). If refSequence
+// is not nil, sequences are compared to it (see NumMutationsComparedToReferenceSequence).
+func (a *align) SequenceStats(refSequence Sequence, countProfile *CountProfile) (stats []SequenceStats, err error) {
+	var numgapsuniques, numnewgaps, numgapsboth []int
+	var nummutuniques, numnewmuts, nummutsboth []int
+	var sequencemap map[rune]int
+
+	if numgapsuniques, numnewgaps, numgapsboth, err = a.NumGapsUniquePerSequence(countProfile); err != nil {
+		return
+	}
+	if nummutuniques, numnewmuts, nummutsboth, err = a.NumMutationsUniquePerSequence(countProfile); err != nil {
+		return
+	}
+
+	uniquechars := a.UniqueCharacters()
+	stats = make([]SequenceStats, a.NbSequences())
+	for i, s := range a.seqs {
+		if sequencemap, err = a.CharStatsSeq(i); err != nil {
+			return
+		}
+		gaps := s.NumGaps()
+		stats[i] = SequenceStats{
+			Name:         s.Name(),
+			Gaps:         gaps,
+			GapsStart:    s.NumGapsFromStart(),
+			GapsEnd:      s.NumGapsFromEnd(),
+			GapsUnique:   numgapsuniques[i],
+			GapsOpenning: s.NumGapsOpenning(),
+			MutUnique:    nummutuniques[i],
+			Length:       s.Length() - gaps,
+			CharCounts:   make(map[string]int),
+		}
+		if countProfile != nil {
+			stats[i].GapsNew = &numnewgaps[i]
+			stats[i].GapsBoth = &numgapsboth[i]
+			stats[i].MutNew = &numnewmuts[i]
+			stats[i].MutBoth = &nummutsboth[i]
+		}
+		if refSequence != nil {
+			var nummutations int
+			if nummutations, err = s.NumMutationsComparedToReferenceSequence(a.Alphabet(), refSequence); err != nil {
+				return
+			}
+			stats[i].MutRef = &nummutations
+		}
+		for _, c := range uniquechars {
+			stats[i].CharCounts[string(c)] = sequencemap[c]
+		}
+	}
+	return
+}
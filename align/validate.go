@@ -0,0 +1,292 @@
+package align
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Validation checks, see Alignment.Validate
+const (
+	CHECK_DUPLICATE_NAMES = "duplicate-names" // Names found several times in the input
+	CHECK_PHYLIP_NAMES    = "phylip-names"    // Names colliding after truncation to 10 characters (strict phylip)
+	CHECK_CLEAN_NAMES     = "clean-names"     // Names colliding after CleanName
+	CHECK_ALPHABET        = "alphabet"        // Characters outside the alphabet of the alignment
+	CHECK_ALL_GAPS        = "all-gaps"        // Sequences made only of gaps
+	CHECK_ALL_N           = "all-n"           // Sequences made only of N (or X for proteins) and gaps
+	CHECK_STOPS           = "stops"           // Stop codons in frame (coding alignments)
+	CHECK_FRAMESHIFTS     = "frameshifts"     // Frameshifts (coding alignments)
+)
+
+// Severities of validation issues
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+// ValidationChecks is the list of all available validation checks
+var ValidationChecks = []string{
+	CHECK_DUPLICATE_NAMES, CHECK_PHYLIP_NAMES, CHECK_CLEAN_NAMES, CHECK_ALPHABET,
+	CHECK_ALL_GAPS, CHECK_ALL_N, CHECK_STOPS, CHECK_FRAMESHIFTS,
+}
+
+// DefaultValidationChecks is the list of validation checks that do not
+// assume that the alignment is a coding alignment
+var DefaultValidationChecks = []string{
+	CHECK_DUPLICATE_NAMES, CHECK_PHYLIP_NAMES, CHECK_CLEAN_NAMES, CHECK_ALPHABET,
+	CHECK_ALL_GAPS, CHECK_ALL_N,
+}
+
+// Characters accepted by the alphabet check, in addition to GAP and '?'
+const (
+	validNucleotides = "ACGTURYSWKMBDHVN"
+	validAminoAcids  = "ARNDCQEGHILKMFPSTWYVBZJXUO"
+)
+
+// ValidationIssue is a problem found by Alignment.Validate
+type ValidationIssue struct {
+	Check    string `json:"check"`
+	Severity string `json:"severity"`           // SEVERITY_ERROR or SEVERITY_WARNING
+	Sequence string `json:"sequence,omitempty"` // Concerned sequence, if any
+	Position int    `json:"position"`           // 0-based position in the alignment, -1 if not relevant
+	Message  string `json:"message"`
+}
+
+// Validate runs the given checks (see ValidationChecks) on the alignment,
+// and returns the issues that are found, in the order of the checks.
+//
+// The duplicate-names check reports the sequences that were renamed (or
+// ignored, see IgnoreIdentical) when they were added to the alignment,
+// because their name already existed (see DuplicateNames).
+//
+// Stops and frameshifts are searched only if the alignment is a nucleotide
+// alignment. Each sequence (without gaps) is translated from its first
+// nucleotide, and its last codon is not reported if it is a stop codon.
+// Frameshifts are searched taking the first sequence as the reference
+// (see Frameshifts).
+func (a *align) Validate(checks []string, geneticcode int) (issues []ValidationIssue, err error) {
+	issues = make([]ValidationIssue, 0)
+	for _, check := range checks {
+		switch check {
+		case CHECK_DUPLICATE_NAMES:
+			issues = append(issues, a.checkDuplicateNames()...)
+		case CHECK_PHYLIP_NAMES:
+			if a.MaxNameLength() <= 10 {
+				break
+			}
+			issues = append(issues, a.checkNameCollisions(check, SEVERITY_WARNING, "truncation to 10 characters", func(name string) string {
+				if len(name) > 10 {
+					name = name[:10]
+				}
+				return strings.Replace(name, " ", "", -1)
+			})...)
+		case CHECK_CLEAN_NAMES:
+			issues = append(issues, a.checkNameCollisions(check, SEVERITY_ERROR, "name cleaning", CleanName)...)
+		case CHECK_ALPHABET:
+			issues = append(issues, a.checkAlphabet()...)
+		case CHECK_ALL_GAPS:
+			issues = append(issues, a.checkOnly(check, SEVERITY_ERROR, "gaps", GAP)...)
+		case CHECK_ALL_N:
+			if a.Alphabet() == AMINOACIDS {
+				issues = append(issues, a.checkOnly(check, SEVERITY_WARNING, "X", ALL_AMINO)...)
+			} else {
+				issues = append(issues, a.checkOnly(check, SEVERITY_WARNING, "N", ALL_NUCLE)...)
+			}
+		case CHECK_STOPS:
+			var stops []int
+			if a.Alphabet() != NUCLEOTIDS || a.NbSequences() == 0 {
+				break
+			}
+			if stops, err = a.internalStops(geneticcode); err != nil {
+				return
+			}
+			for i, s := range stops {
+				if s >= 0 {
+					issues = append(issues, ValidationIssue{check, SEVERITY_ERROR, a.seqs[i].name, -1,
+						fmt.Sprintf("Stop codon in frame at position %d of the sequence (without gaps)", s)})
+				}
+			}
+		case CHECK_FRAMESHIFTS:
+			if a.Alphabet() != NUCLEOTIDS || a.NbSequences() == 0 {
+				break
+			}
+			for i, fs := range a.Frameshifts(false) {
+				if i > 0 && fs.End > fs.Start {
+					issues = append(issues, ValidationIssue{check, SEVERITY_WARNING, a.seqs[i].name, -1,
+						fmt.Sprintf("Frameshift between positions %d and %d of the sequence (without gaps)", fs.Start, fs.End)})
+				}
+			}
+		default:
+			err = fmt.Errorf("Unknown validation check: %s", check)
+			return
+		}
+	}
+	return
+}
+
+func (a *align) checkDuplicateNames() (issues []ValidationIssue) {
+	for _, d := range a.DuplicateNames() {
+		if d.NewName == "" {
+			issues = append(issues, ValidationIssue{CHECK_DUPLICATE_NAMES, SEVERITY_WARNING, d.Name, -1,
+				fmt.Sprintf("Sequence name %s is duplicated (identical sequence ignored)", d.Name)})
+		} else {
+			issues = append(issues, ValidationIssue{CHECK_DUPLICATE_NAMES, SEVERITY_ERROR, d.NewName, -1,
+				fmt.Sprintf("Sequence name %s is duplicated (renamed %s)", d.Name, d.NewName)})
+		}
+	}
+	return
+}
+
+// internalStops returns, for each sequence, the position (without gaps)
+// following its first stop codon, or -1 if it has none. The sequence is
+// translated from its first nucleotide, and a stop codon at its
+// last complete codon is not taken into account.
+func (a *align) internalStops(geneticcode int) (stops []int, err error) {
+	var code map[string]rune
+
+	if code, err = geneticCode(geneticcode); err != nil {
+		return
+	}
+
+	stops = make([]int, a.NbSequences())
+	codon := make([]byte, 3)
+	for i, s := range a.seqs {
+		stops[i] = -1
+		length := s.Length() - s.NumGaps()
+		pos := 0
+		for _, c := range s.SequenceBytes() {
+			if c == GAP {
+				continue
+			}
+			codon[pos%3] = c
+			pos++
+			// Last complete codon of the sequence: not an internal stop
+			if pos+3 > length {
+				break
+			}
+			if pos%3 == 0 {
+				codonstr := strings.Replace(strings.ToUpper(string(codon)), "U", "T", -1)
+				if aa, found := code[codonstr]; found && aa == '*' {
+					stops[i] = pos
+					break
+				}
+			}
+		}
+	}
+	return
+}
+
+// checkNameCollisions reports sequences whose name, transformed by
+// the given function, is the same as the name of a previous sequence
+func (a *align) checkNameCollisions(check, severity, transformation string, transform func(string) string) (issues []ValidationIssue) {
+	first := make(map[string]string)
+	for _, s := range a.seqs {
+		newname := transform(s.name)
+		if prev, ok := first[newname]; ok {
+			issues = append(issues, ValidationIssue{check, severity, s.name, -1,
+				fmt.Sprintf("Sequence names %s and %s are both %s after %s", prev, s.name, newname, transformation)})
+		} else {
+			first[newname] = s.name
+		}
+	}
+	return
+}
+
+// checkAlphabet reports, for each sequence, the first position of a
+// character outside the closest alphabet of the alignment (see closestAlphabet)
+func (a *align) checkAlphabet() (issues []ValidationIssue) {
+	var valid, alphabet string
+	var invalid []rune
+
+	if a.closestAlphabet() == NUCLEOTIDS {
+		valid, alphabet = validNucleotides, "nucleotide"
+	} else {
+		valid, alphabet = validAminoAcids, "protein"
+	}
+	valid += string([]rune{GAP, '?'})
+
+	for _, c := range a.UniqueCharacters() {
+		if !strings.ContainsRune(valid, c) {
+			invalid = append(invalid, c)
+		}
+	}
+	if len(invalid) == 0 {
+		return
+	}
+
+	for _, s := range a.seqs {
+		found := make(map[rune]bool)
+		first := -1
+		for i, c := range s.SequenceBytes() {
+			if strings.ContainsRune(valid, unicode.ToUpper(rune(c))) {
+				continue
+			}
+			if first < 0 {
+				first = i
+			}
+			found[unicode.ToUpper(rune(c))] = true
+		}
+		if first >= 0 {
+			chars := make([]string, 0, len(found))
+			for c := range found {
+				chars = append(chars, string(c))
+			}
+			sort.Strings(chars)
+			issues = append(issues, ValidationIssue{CHECK_ALPHABET, SEVERITY_ERROR, s.name, first,
+				fmt.Sprintf("Characters outside the %s alphabet: %s", alphabet, strings.Join(chars, ","))})
+		}
+	}
+	return
+}
+
+// closestAlphabet returns the alphabet against which characters are
+// checked. Nucleotide alignments are checked as nucleotides. Otherwise,
+// the alphabet may have been detected as protein or unknown because of a
+// few stray characters: the alignment is then considered as nucleotides
+// if at least 75% of its characters (gaps and '?' excluded) are A, C, G,
+// T, U or N, and as amino acids otherwise.
+func (a *align) closestAlphabet() int {
+	if a.Alphabet() == NUCLEOTIDS {
+		return NUCLEOTIDS
+	}
+	nt, total := 0, 0
+	for _, s := range a.seqs {
+		for _, c := range s.SequenceBytes() {
+			switch unicode.ToUpper(rune(c)) {
+			case GAP, '?':
+			case 'A', 'C', 'G', 'T', 'U', 'N':
+				nt++
+				total++
+			default:
+				total++
+			}
+		}
+	}
+	if nt*4 >= total*3 {
+		return NUCLEOTIDS
+	}
+	return AMINOACIDS
+}
+
+// checkOnly reports sequences made only of the given character
+// (and of gaps, if the character is not GAP)
+func (a *align) checkOnly(check, severity, what string, char rune) (issues []ValidationIssue) {
+	for _, s := range a.seqs {
+		only := true
+		nb := 0
+		for _, c := range s.SequenceBytes() {
+			if unicode.ToUpper(rune(c)) == char {
+				nb++
+			} else if rune(c) != GAP {
+				only = false
+				break
+			}
+		}
+		if only && nb > 0 {
+			issues = append(issues, ValidationIssue{check, severity, s.name, -1,
+				fmt.Sprintf("Sequence is only made of %s", what)})
+		}
+	}
+	return
+}
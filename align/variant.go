@@ -0,0 +1,250 @@
+package align
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+)
+
+// Variant is a variant compared to a reference sequence, as written in
+// VCF files: a single variable site, or deleted reference bases with
+// their anchor base (see Variants).
+type Variant struct {
+	Site      int      // Position on the alignment of the first reference base of the variant (0-based)
+	RefPos    int      // Position on the ungapped reference sequence of the first reference base (0-based)
+	Ref       string   // Reference allele
+	Alts      []string // Alternative alleles, in order of first appearance
+	Genotypes []int    // Haploid genotype of each sample: 0=Ref, i=Alts[i-1], -1=missing
+	Sites     []int    // Variable sites of the variant on the alignment (0-based)
+}
+
+// Variants returns the variants of the alignment compared to the given
+// reference sequence, which must be aligned with the alignment (same
+// length). Only nucleotide alignments are supported.
+//
+// samples are the names of the genotyped sequences: all the sequences of the
+// alignment, except the reference itself if it belongs to the alignment.
+//
+// Positions are given in ungapped reference coordinates (see
+// RefCoordinates), and:
+//   - Sites where the reference has a gap are not reported (insertions)
+//   - Sites where the reference is not A, C, G or T are not reported,
+//     except inside deletions
+//   - A, C, G and T characters (case insensitive) are genotyped as is
+//   - Other characters (N and IUPAC ambiguities) are genotyped as missing
+//   - Gaps are genotyped as missing if gapsAsDeletions is false.
+//     Otherwise, gaps are deletions, written in normalized form: the
+//     reference bases before the deletion (anchor base) and of the
+//     deletion are the reference allele, and the remaining bases are
+//     the alternative allele (e.g. REF=ACG, ALT=A). The anchor base is
+//     the reference base after the deletion if the deletion starts the
+//     reference sequence. Overlapping deletions of several samples are
+//     merged into the same variant, and the suffix common to all the
+//     alleles is removed.
+//
+// A variant is reported if at least one sample has an alternative allele.
+func (a *align) Variants(ref Sequence, gapsAsDeletions bool) (samples []string, variants []*Variant, err error) {
+	var seqs []*seq
+	var blocks [][2]int
+
+	if a.Alphabet() != NUCLEOTIDS {
+		err = fmt.Errorf("Variants can only be computed on nucleotide alignments")
+		return
+	}
+	if ref.Length() != a.Length() {
+		err = fmt.Errorf("Reference sequence length (%d) is different from the alignment length (%d)", ref.Length(), a.Length())
+		return
+	}
+
+	samples = make([]string, 0, a.NbSequences())
+	seqs = make([]*seq, 0, a.NbSequences())
+	for _, s := range a.seqs {
+		if Sequence(s) == ref {
+			continue
+		}
+		samples = append(samples, s.name)
+		seqs = append(seqs, s)
+	}
+
+	// Alignment sites of the reference bases
+	refseq := ref.SequenceBytes()
+	sites := make([]int, 0, len(refseq))
+	for site, c := range refseq {
+		if c != GAP {
+			sites = append(sites, site)
+		}
+	}
+
+	if gapsAsDeletions {
+		blocks = deletionBlocks(seqs, sites)
+	}
+
+	variants = make([]*Variant, 0)
+	for refpos := 0; refpos < len(sites); refpos++ {
+		if len(blocks) > 0 && blocks[0][0] == refpos {
+			if v := newDeletionVariant(refseq, seqs, sites, blocks[0][0], blocks[0][1]); len(v.Alts) > 0 {
+				variants = append(variants, v)
+			}
+			refpos = blocks[0][1] - 1
+			blocks = blocks[1:]
+			continue
+		}
+		site := sites[refpos]
+		r := variantAllele(refseq[site])
+		if r == 0 {
+			continue
+		}
+		v := &Variant{Site: site, RefPos: refpos, Ref: string(r), Alts: make([]string, 0), Genotypes: make([]int, len(seqs)), Sites: []int{site}}
+		for i, s := range seqs {
+			switch allele := variantAllele(byte(s.CharAt(site))); allele {
+			case 0:
+				v.Genotypes[i] = -1
+			case r:
+				v.Genotypes[i] = 0
+			default:
+				v.Genotypes[i] = v.addAlt(string(allele))
+			}
+		}
+		if len(v.Alts) > 0 {
+			variants = append(variants, v)
+		}
+	}
+	return
+}
+
+// deletionBlocks returns the ranges [start,end[ of reference bases
+// (ungapped coordinates) of the deletion variants: each run of gaps of a
+// sequence on reference bases, with its anchor base, overlapping runs
+// being merged.
+func deletionBlocks(seqs []*seq, sites []int) (blocks [][2]int) {
+	var start, end int
+
+	spans := make([][2]int, 0)
+	for _, s := range seqs {
+		for p := 0; p < len(sites); p++ {
+			if s.CharAt(sites[p]) != GAP {
+				continue
+			}
+			for start = p; p < len(sites) && s.CharAt(sites[p]) == GAP; p++ {
+			}
+			if start > 0 {
+				spans = append(spans, [2]int{start - 1, p})
+			} else if p < len(sites) {
+				spans = append(spans, [2]int{start, p + 1})
+			} else {
+				spans = append(spans, [2]int{start, p})
+			}
+		}
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i][0] < spans[j][0] })
+
+	blocks = make([][2]int, 0, len(spans))
+	for i, sp := range spans {
+		if i > 0 && sp[0] < end {
+			if sp[1] > end {
+				end = sp[1]
+				blocks[len(blocks)-1][1] = end
+			}
+			continue
+		}
+		end = sp[1]
+		blocks = append(blocks, sp)
+	}
+	return
+}
+
+// newDeletionVariant returns the variant of the reference bases
+// [start,end[ (see deletionBlocks). The allele of a sample is made of its
+// nucleotides on these bases (without gaps), and is missing if one of
+// them is not A, C, G or T, or if all of them are deleted.
+func newDeletionVariant(refseq []byte, seqs []*seq, sites []int, start, end int) (v *Variant) {
+	var allele []byte
+	var missing bool
+
+	ref := make([]byte, 0, end-start)
+	for p := start; p < end; p++ {
+		ref = append(ref, byte(unicode.ToUpper(rune(refseq[sites[p]]))))
+	}
+	alleles := make([][]byte, len(seqs))
+	for i, s := range seqs {
+		allele, missing = make([]byte, 0, end-start), false
+		for p := start; p < end && !missing; p++ {
+			c := byte(s.CharAt(sites[p]))
+			if c == GAP {
+				continue
+			}
+			if c = variantAllele(c); c == 0 {
+				missing = true
+			}
+			allele = append(allele, c)
+		}
+		if !missing && len(allele) > 0 {
+			alleles[i] = allele
+		}
+	}
+	ref, alleles = trimAlleles(ref, alleles)
+
+	v = &Variant{Site: sites[start], RefPos: start, Ref: string(ref), Alts: make([]string, 0), Genotypes: make([]int, len(seqs)), Sites: make([]int, 0)}
+	for i, allele := range alleles {
+		switch {
+		case allele == nil:
+			v.Genotypes[i] = -1
+		case string(allele) == v.Ref:
+			v.Genotypes[i] = 0
+		default:
+			v.Genotypes[i] = v.addAlt(string(allele))
+		}
+	}
+	for p := start; p < end; p++ {
+		r := unicode.ToUpper(rune(refseq[sites[p]]))
+		for _, s := range seqs {
+			if c := s.CharAt(sites[p]); c == GAP || (variantAllele(byte(c)) != 0 && unicode.ToUpper(c) != r) {
+				v.Sites = append(v.Sites, sites[p])
+				break
+			}
+		}
+	}
+	return
+}
+
+// trimAlleles removes the suffix common to the reference allele and to
+// all the non missing alleles, keeping at least one base in each allele
+func trimAlleles(ref []byte, alleles [][]byte) ([]byte, [][]byte) {
+	for len(ref) > 1 {
+		last := ref[len(ref)-1]
+		for _, a := range alleles {
+			if a != nil && (len(a) < 2 || a[len(a)-1] != last) {
+				return ref, alleles
+			}
+		}
+		ref = ref[:len(ref)-1]
+		for i, a := range alleles {
+			if a != nil {
+				alleles[i] = a[:len(a)-1]
+			}
+		}
+	}
+	return ref, alleles
+}
+
+// addAlt returns the genotype of the given alternative allele,
+// and adds it to the alternative alleles if not already present
+func (v *Variant) addAlt(allele string) int {
+	for i, a := range v.Alts {
+		if a == allele {
+			return i + 1
+		}
+	}
+	v.Alts = append(v.Alts, allele)
+	return len(v.Alts)
+}
+
+// variantAllele returns the upper case nucleotide if c is A, C, G or T,
+// 0 otherwise (missing)
+func variantAllele(c byte) byte {
+	switch u := byte(unicode.ToUpper(rune(c))); u {
+	case 'A', 'C', 'G', 'T':
+		return u
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+// a2mCmd : to reformat in A2M format
+var a2mCmd = &cobra.Command{
+	Use:   "a2m",
+	Short: "Reformats an input alignment into A2M format",
+	Long: `Reformats an alignment into A2M format (HMMER, SAM). 
+It may take a Phylip, Fasta, Nexus, Clustal, Stockholm, A2M or A3M input alignment.
+
+If the input alignment contains several alignments, will take all of them.
+
+Columns having a fraction of gaps > --a3m-max-gaps (default 0.5) are insert 
+columns: their characters are written in lower case, and their gaps as '.'. 
+Other columns are match columns, written in upper case (gaps as '-').
+
+Example of usage:
+
+goalign reformat a2m -i align.phylip -p
+goalign reformat a2m -i align.fasta --a3m-max-gaps 0.2
+goalign reformat a2m -i align.a3m --a3m
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var f outputFile
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		if f, err = openWriteFile(reformatOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, reformatOutput)
+
+		for al := range aligns.Achan {
+			if reformatCleanNames {
+				al.CleanNames(nil)
+			}
+			writeAlignA2M(al, f)
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+		}
+		return
+	},
+}
+
+func init() {
+	reformatCmd.AddCommand(a2mCmd)
+}
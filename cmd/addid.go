@@ -1,10 +1,9 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +20,7 @@ var addidCmd = &cobra.Command{
 The string may be added to the left or to the right of each sequence name.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 
 		if f, err = openWriteFile(addIdOutput); err != nil {
 			io.LogError(err)
@@ -30,14 +29,18 @@ The string may be added to the left or to the right of each sequence name.
 		defer closeWriteFile(f, addIdOutput)
 
 		if unaligned {
-			var seqs align.SeqBag
-
-			if seqs, err = readsequences(infile); err != nil {
+			// Sequences are renamed one by one, without loading the whole file
+			if err = processSequencesStream(infile, f, func(s align.Sequence, w *fasta.StreamWriter) error {
+				if addIdRight {
+					s.SetName(s.Name() + addIdName)
+				} else {
+					s.SetName(addIdName + s.Name())
+				}
+				return w.Write(s)
+			}); err != nil {
 				io.LogError(err)
 				return
 			}
-			seqs.AppendSeqIdentifier(addIdName, addIdRight)
-			writeSequences(seqs, f)
 		} else {
 
 			var aligns *align.AlignChannel
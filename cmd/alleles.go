@@ -5,6 +5,7 @@ import (
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -12,26 +13,50 @@ import (
 var allelesCmd = &cobra.Command{
 	Use:   "alleles",
 	Short: "Prints the average number of alleles per sites of the alignment",
-	Long:  `Prints the average number of alleles per sites of the alignment.`,
+	Long: `Prints the average number of alleles per sites of the alignment.
+
+If --format is given (tsv, csv or json), values are printed as a table 
+(tab separated, comma separated, or as a json array of objects) with 
+one record per input alignment, having the fields alignment (index 
+of the alignment in the input file) and avgalleles.
+`,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
+		var structured bool
 
+		if structured, err = statsStructured(); err != nil {
+			io.LogError(err)
+			return
+		}
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
 			return
 		}
+		t := table.NewTable("alignment", "avgalleles")
 		for al := range aligns.Achan {
-			fmt.Println(al.AvgAllelesPerSite())
+			if !structured {
+				fmt.Println(al.AvgAllelesPerSite())
+			} else if err = t.AddRow(len(t.Rows), al.AvgAllelesPerSite()); err != nil {
+				io.LogError(err)
+				return
+			}
 		}
 
 		if aligns.Err != nil {
 			err = aligns.Err
 			io.LogError(err)
+			return
+		}
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
+			}
 		}
 		return
 	},
 }
 
 func init() {
+	addStatsFormatFlag(allelesCmd)
 	statsCmd.AddCommand(allelesCmd)
 }
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -34,7 +32,7 @@ goalign append -i align.fasta others*.fasta
 		var refAligns *align.AlignChannel = nil
 		var refAlign align.Alignment = nil
 
-		var f *os.File
+		var f outputFile
 
 		if infile != "none" {
 			if refAligns, err = readalign(infile); err != nil {
@@ -42,6 +42,13 @@ The input may be a Phylip or Fasta file.
 - It is possible to give a initial seed (--seed). In this case several runs of 
   the tool will give the exact same results.
 
+- With --partition (RAxML-like partition file, or NEXUS file with SETS,
+  ASSUMPTIONS or MRBAYES blocks), sites are resampled within each partition,
+  and the partitions of the bootstrap alignments are written in
+  --out-partition. If --partition is not given and the input NEXUS alignment
+  defines partitions, they are used. Partitions are also written in the
+  bootstrap alignments if the output format is NEXUS.
+
 Example of usage:
 
 goalign build seqboot -i align.phylip -p -n 500 -o boot --tar-gz
@@ -79,8 +86,9 @@ goalign build seqboot -i align.phylip -p -n 500 -o boot_
 		}
 
 		// If a partition file is given, then we parse it
+		// otherwise, we take the partitions of the input alignment, if any
 		if bootstrappartitionstr != "none" {
-			if inputpartition, err = parsePartition(bootstrappartitionstr, al.Length()); err != nil {
+			if inputpartition, err = parsePartition(bootstrappartitionstr, al); err != nil {
 				io.LogError(err)
 				return
 			}
@@ -88,12 +96,28 @@ goalign build seqboot -i align.phylip -p -n 500 -o boot_
 				io.LogError(err)
 				return
 			}
+		} else {
+			inputpartition = al.Partitions()
+		}
+
+		if inputpartition != nil {
 			if aligns, err = al.Split(inputpartition); err != nil {
 				io.LogError(err)
 				return
 			}
+			// We initialize an outputpartition
+			// Which will have all the sites of each
+			// partition grouped together.
 			outputpartition = align.NewPartitionSet(al.Length())
-			//fmt.Println(bootstrappartition.String())
+			var start, end int = 0, 0
+			for i, a := range aligns {
+				start = end
+				end = start + a.Length()
+				outputpartition.AddRange(
+					inputpartition.PartitionName(i),
+					inputpartition.ModeleName(i),
+					start, end-1, 1)
+			}
 		} else {
 			aligns = []align.Alignment{al}
 		}
@@ -141,6 +165,8 @@ goalign build seqboot -i align.phylip -p -n 500 -o boot_
 			if bootstrapOrder {
 				boot.ShuffleSequences()
 			}
+			// Partitions are written in NEXUS outputs
+			boot.SetPartitions(outputpartition)
 
 			bootstring = writeAlignString(boot)
 
@@ -158,19 +184,7 @@ goalign build seqboot -i align.phylip -p -n 500 -o boot_
 			}
 		}
 
-		var start, end int = 0, 0
-		if outputpartition != nil {
-			for i, a := range aligns {
-				start = end
-				end = start + a.Length()
-				// We initialize an outputpartition
-				// Which will have all the sites of each
-				// partition grouped together.
-				outputpartition.AddRange(
-					inputpartition.PartitionName(i),
-					inputpartition.ModeleName(i),
-					start, end-1, 1)
-			}
+		if outputpartition != nil && (bootstrappartitionstr != "none" || bootstrapoutputpartitionstr != "") {
 			if bootstrapoutputpartitionstr == "" {
 				bootstrapoutputpartitionstr = bootstrappartitionstr + "_boot"
 			}
@@ -241,7 +255,7 @@ func init() {
 	seqbootCmd.PersistentFlags().BoolVar(&bootstraptar, "tar", false, "Will create a single tar file with all bootstrap alignments (one thread for tar, but not a bottleneck)")
 	seqbootCmd.PersistentFlags().BoolVar(&bootstrapgz, "gz", false, "Will gzip output file(s). Maybe slow if combined with --tar (only one thread working for tar/gz)")
 	seqbootCmd.PersistentFlags().IntVarP(&bootstrapNb, "nboot", "n", 1, "Number of bootstrap replicates to build")
-	seqbootCmd.PersistentFlags().StringVar(&bootstrappartitionstr, "partition", "none", "File containing definition of the partitions")
+	seqbootCmd.PersistentFlags().StringVar(&bootstrappartitionstr, "partition", "none", "File containing definition of the partitions (RAxML-like or NEXUS)")
 	seqbootCmd.PersistentFlags().StringVar(&bootstrapoutputpartitionstr, "out-partition", "", "File containing output partitions (default: same name as input partition with _boot suffix)")
 	seqbootCmd.PersistentFlags().StringVarP(&bootstrapoutprefix, "out-prefix", "o", "none", "Prefix of output bootstrap files")
 }
@@ -7,7 +7,7 @@ import (
 // buildCmd represents the build command
 var buildCmd = &cobra.Command{
 	Use:   "build",
-	Short: "Command to build bootstrap replicates",
+	Short: "Command to build bootstrap replicates, or alignments from VCF files",
 	Long: `This command builds bootstrap replicates from an input alignment (fasta or phylip):
 
 1. goalign build seqboot : Builds bootstrap alignments from an input alignment (nt or aa). Sequence order may be shuffled with option -S. Output alignments may be written in compressed files (--gz) and/or added in a tar archive (--tar).
@@ -18,6 +18,7 @@ var buildCmd = &cobra.Command{
     - f81  : Felsenstein 81
     - f84  : Felsenstein 84
     - tn93 : Tamura and Nei 1993
+3. goalign build vcf: Builds an alignment from a reference sequence and VCF files, applying the SNPs (and optionally the indels) of each sample to the reference sequence, and masking regions given in a BED file.
 `,
 }
 
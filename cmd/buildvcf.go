@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	goio "io"
+	"path/filepath"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/bed"
+	"github.com/evolbioinfo/goalign/io/utils"
+	"github.com/evolbioinfo/goalign/io/vcf"
+	"github.com/spf13/cobra"
+)
+
+var buildvcfout string
+var buildvcffiles []string
+var buildvcfmask string
+var buildvcfindels bool
+var buildvcfcontig string
+
+// buildvcfCmd represents the build vcf command
+var buildvcfCmd = &cobra.Command{
+	Use:   "vcf",
+	Short: "Builds an alignment from a reference sequence and VCF files",
+	Long: `Builds an alignment from a reference sequence and VCF files.
+
+The reference sequence is given with -i. If the input file contains several
+sequences (ex: chromosomes), the one to use is given with --contig (default:
+the first one). Only the VCF records whose CHROM is the first word of the
+reference sequence name, and whose FILTER is PASS or '.', are taken into account.
+
+VCF files (--vcf, several files may be given) may contain one or several
+samples. If a VCF file does not contain any sample (sites only), it is
+considered as a single sample, named after the file (without extension),
+carrying the first alternative allele of each record.
+
+For each sample, the genotypes are applied to the reference sequence:
+- SNPs (and MNPs) are applied;
+- Indels are applied only if --indels is given. In that case, deletions are
+  written as gaps, and insertions are added after the reference allele, all
+  the other sequences being padded with gaps;
+- Symbolic (<...>) and '*' alleles are ignored;
+- Missing genotypes are written as N;
+- Heterozygous genotypes are written with the IUPAC code of their alleles
+  (or N for heterozygous indels);
+- Records overlapping a record already applied to the sample are ignored.
+
+Positions given in the BED file --mask (ex: low coverage regions) are then
+masked with N in all samples.
+
+The output alignment contains the reference sequence first, followed
+by the samples.
+
+Example of usage:
+
+goalign build vcf -i ref.fa --vcf s1.vcf,s2.vcf --indels --mask lowcov.bed -o align.fa
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var sb align.SeqBag
+		var ref align.Sequence
+		var ok bool
+		var vcfs []*vcf.VCF
+		var v *vcf.VCF
+		var mask []*bed.Interval
+		var al align.Alignment
+		var f outputFile
+
+		if len(buildvcffiles) == 0 {
+			err = errors.New("--vcf must be specified")
+			io.LogError(err)
+			return
+		}
+
+		if sb, err = readsequences(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		if buildvcfcontig != "" {
+			for i := 0; i < sb.NbSequences() && ref == nil; i++ {
+				s, _ := sb.Sequence(i)
+				if fields := strings.Fields(s.Name()); len(fields) > 0 && fields[0] == buildvcfcontig {
+					ref = s
+				}
+			}
+			if ref == nil {
+				err = fmt.Errorf("Contig %s is not in the reference file", buildvcfcontig)
+				io.LogError(err)
+				return
+			}
+		} else if ref, ok = sb.Sequence(0); !ok {
+			err = fmt.Errorf("The reference file does not contain any sequence")
+			io.LogError(err)
+			return
+		}
+
+		vcfs = make([]*vcf.VCF, 0, len(buildvcffiles))
+		for _, file := range buildvcffiles {
+			if v, err = parseVCF(file); err != nil {
+				io.LogError(err)
+				return
+			}
+			base := filepath.Base(file)
+			v.SetSample(strings.TrimSuffix(base, filepath.Ext(base)))
+			vcfs = append(vcfs, v)
+		}
+
+		if buildvcfmask != "none" {
+			if mask, err = parseBed(buildvcfmask); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+
+		if al, err = vcf.Consensus(ref, vcfs, mask, buildvcfindels); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if f, err = openWriteFile(buildvcfout); err != nil {
+			io.LogError(err)
+			return
+		}
+		writeAlign(al, f)
+		closeWriteFile(f, buildvcfout)
+		return
+	},
+}
+
+func parseVCF(file string) (v *vcf.VCF, err error) {
+	var f goio.Closer
+	var r *bufio.Reader
+
+	if f, r, err = utils.GetReader(file); err != nil {
+		return
+	}
+	defer f.Close()
+	return vcf.NewParser(r).Parse()
+}
+
+func parseBed(file string) (intervals []*bed.Interval, err error) {
+	var f goio.Closer
+	var r *bufio.Reader
+
+	if f, r, err = utils.GetReader(file); err != nil {
+		return
+	}
+	defer f.Close()
+	return bed.NewParser(r).Parse()
+}
+
+func init() {
+	buildCmd.AddCommand(buildvcfCmd)
+	buildvcfCmd.PersistentFlags().StringVarP(&buildvcfout, "output", "o", "stdout", "Alignment output file")
+	buildvcfCmd.PersistentFlags().StringSliceVar(&buildvcffiles, "vcf", nil, "VCF file(s) (comma separated, or flag given several times)")
+	buildvcfCmd.PersistentFlags().StringVar(&buildvcfmask, "mask", "none", "BED file of the regions to mask with N in all samples (ex: low coverage regions)")
+	buildvcfCmd.PersistentFlags().BoolVar(&buildvcfindels, "indels", false, "Applies indels (otherwise, only SNPs are applied)")
+	buildvcfCmd.PersistentFlags().StringVar(&buildvcfcontig, "contig", "", "Name of the reference sequence to use, if the input file contains several sequences (default: the first one)")
+}
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -28,7 +27,7 @@ If cutoff is <0 or >1, it will be considered as 0, which means that every sequen
 will be removed.`,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
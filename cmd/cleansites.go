@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -31,7 +30,7 @@ will be removed.`,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var nbstart, nbend int
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -23,7 +21,7 @@ goalign reformat clustal -i align.fasta
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -3,7 +3,6 @@ package cmd
 import (
 	"bufio"
 	goio "io"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -33,7 +32,7 @@ the protein alignment length * 3, returns an error.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 		var ntseqsf *bufio.Reader
 		var toclose goio.Closer
 		var ntseqs align.SeqBag
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -39,7 +38,7 @@ and weight file:
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f, wf *os.File
+		var f, wf outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -82,7 +81,7 @@ func init() {
 	RootCmd.AddCommand(compressCmd)
 }
 
-func writeWeights(weights []int, f *os.File) {
+func writeWeights(weights []int, f outputFile) {
 	for _, w := range weights {
 		fmt.Fprintf(f, "%d\n", w)
 	}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"math"
-	"os"
 
 	"github.com/spf13/cobra"
 
@@ -60,7 +59,7 @@ if -a is given: display only the average distance
 
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 		var model dna.DistModel
 		var aligns *align.AlignChannel
 		var protmodel int
@@ -162,7 +161,7 @@ func init() {
 	computedistCmd.PersistentFlags().Float64Var(&computedistAlpha, "alpha", 0.0, "Gamma alpha parameter, if not given : no gamma")
 }
 
-func writeDistMatrix(al align.Alignment, matrix [][]float64, f *os.File) (err error) {
+func writeDistMatrix(al align.Alignment, matrix [][]float64, f outputFile) (err error) {
 	f.WriteString(fmt.Sprintf("%d\n", len(matrix)))
 	for i := 0; i < len(matrix); i++ {
 		if name, ok := al.GetSequenceNameById(i); ok {
@@ -178,7 +177,7 @@ func writeDistMatrix(al align.Alignment, matrix [][]float64, f *os.File) (err er
 	return
 }
 
-func writeDistAverage(al align.Alignment, matrix [][]float64, f *os.File) {
+func writeDistAverage(al align.Alignment, matrix [][]float64, f outputFile) {
 	sum := 0.0
 	total := 0
 	nan := 0
@@ -6,6 +6,7 @@ import (
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -37,11 +38,22 @@ the computation does not take into account the following characters:
 
 If a site is made fully of '-' (if --remove-gaps is given) or '*', then its entropy will be "NaN",
 and it will not be taken into account in the average.
+
+If --format is given (tsv, csv or json), entropies are printed as a table 
+(tab separated, comma separated, or as a json array of objects), having 
+the fields alignment and avgentropy (--average), or alignment, site and entropy.
+NaN entropies are written as null in json.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var e float64
+		var structured bool
+		var t *table.Table
 
+		if structured, err = statsStructured(); err != nil {
+			io.LogError(err)
+			return
+		}
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
 			return
@@ -49,8 +61,13 @@ and it will not be taken into account in the average.
 
 		nb := 0
 		if entropyAverage {
-			fmt.Println("Alignment\tAvgEntropy")
+			t = table.NewTable("alignment", "avgentropy")
 		} else {
+			t = table.NewTable("alignment", "site", "entropy")
+		}
+		if !structured && entropyAverage {
+			fmt.Println("Alignment\tAvgEntropy")
+		} else if !structured {
 			fmt.Println("Alignment\tSite\tEntropy")
 		}
 		for align := range aligns.Achan {
@@ -66,12 +83,22 @@ and it will not be taken into account in the average.
 							avg += e
 							total++
 						}
+					} else if structured {
+						if err = t.AddRow(nb, i, e); err != nil {
+							io.LogError(err)
+							return
+						}
 					} else {
 						fmt.Println(fmt.Sprintf("%d\t%d\t%.3f", nb, i, e))
 					}
 				}
 			}
-			if entropyAverage {
+			if entropyAverage && structured {
+				if err = t.AddRow(nb, avg/float64(total)); err != nil {
+					io.LogError(err)
+					return
+				}
+			} else if entropyAverage {
 				fmt.Println(fmt.Sprintf("%d\t%.3f", nb, avg/float64(total)))
 			}
 			nb++
@@ -80,6 +107,12 @@ and it will not be taken into account in the average.
 		if aligns.Err != nil {
 			err = aligns.Err
 			io.LogError(err)
+			return
+		}
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
+			}
 		}
 		return
 	},
@@ -89,4 +122,5 @@ func init() {
 	computeCmd.AddCommand(entropyCmd)
 	entropyCmd.PersistentFlags().BoolVarP(&entropyAverage, "average", "a", false, "Compute only the average entropy of input alignment")
 	entropyCmd.PersistentFlags().BoolVarP(&entropyRemoveGaps, "remove-gaps", "g", false, "If true, then do not take into account gaps in the computation")
+	addStatsFormatFlag(entropyCmd)
 }
@@ -1,7 +1,9 @@
 package cmd
 
 import (
-	"os"
+	"fmt"
+	"path/filepath"
+	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -9,6 +11,7 @@ import (
 )
 
 var concatout string
+var concatoutpartition string
 
 // concatCmd represents the concat command
 var concatCmd = &cobra.Command{
@@ -28,12 +31,25 @@ It is possible to give only otherfiles, without -i, by giving -i none
    goalign concat -i none align*.fasta
 or goalign concat -i none -p align*.phy
 
+If input alignments define partitions (ex: NEXUS SETS blocks), the partitions
+of the concatenated alignment are computed: partitions having the same name are
+merged, and each input alignment without partitions is a partition named after
+its file (without extension, with a _<n> suffix for the nth alignment of the file
+if n>1). They are written in the output alignment if it is in NEXUS format.
+Partitions of the concatenated alignment can also be written in a RAxML-like
+partition file with --out-partition (even if input alignments do not define
+partitions).
+
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var alchan *align.AlignChannel
+		// Partitions of the concatenated alignment
+		var partition *align.PartitionSet
+		var haspartitions bool
 		var align align.Alignment = nil
-		var f *os.File
+		var f outputFile
+		var nbaligns int
 
 		if infile != "none" {
 			if aligns, err = readalign(infile); err != nil {
@@ -41,13 +57,9 @@ or goalign concat -i none -p align*.phy
 				return
 			}
 			for al := range aligns.Achan {
-				if align == nil {
-					align = al
-				} else {
-					if err = align.Concat(al); err != nil {
-						io.LogError(err)
-						return
-					}
+				if align, err = concatAlign(align, al, infile, &nbaligns, &partition, &haspartitions); err != nil {
+					io.LogError(err)
+					return
 				}
 			}
 			if aligns.Err != nil {
@@ -61,14 +73,11 @@ or goalign concat -i none -p align*.phy
 				io.LogError(err)
 				return
 			}
+			nbaligns = 0
 			for al := range alchan.Achan {
-				if align == nil {
-					align = al
-				} else {
-					if err = align.Concat(al); err != nil {
-						io.LogError(err)
-						return
-					}
+				if align, err = concatAlign(align, al, otherfile, &nbaligns, &partition, &haspartitions); err != nil {
+					io.LogError(err)
+					return
 				}
 			}
 			if alchan.Err != nil {
@@ -78,6 +87,10 @@ or goalign concat -i none -p align*.phy
 			}
 		}
 
+		if haspartitions {
+			align.SetPartitions(partition)
+		}
+
 		if f, err = openWriteFile(concatout); err != nil {
 			io.LogError(err)
 			return
@@ -85,6 +98,13 @@ or goalign concat -i none -p align*.phy
 		writeAlign(align, f)
 		closeWriteFile(f, concatout)
 
+		if concatoutpartition != "none" && partition != nil {
+			if err = writenewfile(concatoutpartition, false, partition.String()); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+
 		return
 	},
 }
@@ -92,4 +112,31 @@ or goalign concat -i none -p align*.phy
 func init() {
 	RootCmd.AddCommand(concatCmd)
 	concatCmd.PersistentFlags().StringVarP(&concatout, "output", "o", "stdout", "Alignment output file")
+	concatCmd.PersistentFlags().StringVar(&concatoutpartition, "out-partition", "none", "Output file of the partitions of the concatenated alignment")
+}
+
+// concatAlign concatenates al (the nbalign th alignment of the given file)
+// to concat, and updates the partitions of the concatenated alignment
+// (haspartitions is set to true if al defines partitions).
+func concatAlign(concat, al align.Alignment, file string, nbaligns *int, partition **align.PartitionSet, haspartitions *bool) (align.Alignment, error) {
+	*nbaligns++
+	ps := al.Partitions()
+	if ps != nil {
+		*haspartitions = true
+	} else {
+		base := filepath.Base(file)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		if *nbaligns > 1 {
+			name = fmt.Sprintf("%s_%d", name, *nbaligns)
+		}
+		ps = align.NewPartitionSet(al.Length())
+		ps.AddRange(name, defaultModel(al), 0, al.Length()-1, 1)
+	}
+
+	if concat == nil {
+		*partition = ps
+		return al, nil
+	}
+	*partition = (*partition).Concat(ps)
+	return concat, concat.Concat(al)
 }
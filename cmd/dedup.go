@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -43,7 +41,7 @@ This means that seq1 is identical to seq2 and seq3 is identical to seq4.
 
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f, l *os.File
+		var f, l outputFile
 		var id [][]string
 
 		if f, err = openWriteFile(dedupOutput); err != nil {
@@ -106,7 +104,7 @@ func init() {
 	dedupCmd.PersistentFlags().StringVarP(&dedupLogOutput, "log", "l", "none", "Deduplicated output log file")
 }
 
-func writeIdentical(id [][]string, logfile *os.File) {
+func writeIdentical(id [][]string, logfile outputFile) {
 	for _, s := range id {
 		for i, name := range s {
 			if i > 0 {
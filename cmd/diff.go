@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"sort"
 	"strings"
 
@@ -37,7 +36,7 @@ If option --reverse is given, then replaces . with the characters on the first s
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 
 		if f, err = openWriteFile(diffOutput); err != nil {
 			io.LogError(err)
@@ -72,7 +71,7 @@ If option --reverse is given, then replaces . with the characters on the first s
 	},
 }
 
-func writeDiffCounts(al align.Alignment, alldiffs []string, diffs []map[string]int, f *os.File) {
+func writeDiffCounts(al align.Alignment, alldiffs []string, diffs []map[string]int, f outputFile) {
 	sort.Strings(alldiffs)
 	for _, d := range alldiffs {
 		if !(strings.Contains(d, "-") && diffNoGaps) {
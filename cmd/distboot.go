@@ -3,7 +3,6 @@ package cmd
 import (
 	"fmt"
 	"github.com/spf13/cobra"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/distance/dna"
@@ -57,7 +56,7 @@ goalign build distboot -m k2p -i align.fa -o mats.txt
 		var protmodelI int
 		var d *mat.Dense
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 		var weights []float64
 
 		if aligns, err = readalign(infile); err != nil {
@@ -134,7 +133,7 @@ func init() {
 	distbootCmd.PersistentFlags().Float64Var(&distbootAlpha, "alpha", 0.0, "Gamma alpha parameter, if not given : no gamma")
 }
 
-func writeDistBootMatrix(matrix [][]float64, a align.Alignment, f *os.File) {
+func writeDistBootMatrix(matrix [][]float64, a align.Alignment, f outputFile) {
 	f.WriteString(fmt.Sprintf("%d\n", len(matrix)))
 	for i := 0; i < len(matrix); i++ {
 		name, ok := a.GetSequenceNameById(i)
@@ -150,7 +149,7 @@ func writeDistBootMatrix(matrix [][]float64, a align.Alignment, f *os.File) {
 	}
 }
 
-func writeDenseDistBootMatrix(matrix *mat.Dense, a align.Alignment, f *os.File) {
+func writeDenseDistBootMatrix(matrix *mat.Dense, a align.Alignment, f outputFile) {
 	r, c := matrix.Dims()
 	f.WriteString(fmt.Sprintf("%d\n", c))
 	for i := 0; i < r; i++ {
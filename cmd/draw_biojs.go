@@ -3,7 +3,6 @@ package cmd
 import (
 	"bufio"
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/evolbioinfo/goalign/align"
@@ -23,7 +22,7 @@ See http://msa.biojs.net/ for more informations
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var l draw.AlignLayout
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
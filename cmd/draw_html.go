@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/draw"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+// drawHtmlCmd represents the draw html command
+var drawHtmlCmd = &cobra.Command{
+	Use:   "html",
+	Short: "Draw alignments in a self-contained html file",
+	Long: `Draw alignments in a self-contained html file
+
+Contrary to goalign draw biojs, the javascript and css needed to display the 
+alignment are embedded in the html file, which can therefore be viewed without 
+any network access.
+
+The viewer displays:
+- Position rulers;
+- Sequences, coloured with a nucleotide or an amino acid colour scheme 
+  (chosen according to the alphabet of the alignment, and modifiable);
+- The majority consensus and the conservation of each site (as in clustal 
+  format: * identical, : conserved, . semi-conserved);
+- A search field to filter sequences by name.
+
+If the input file contains several alignments, they are written in 
+<output>_<index>.<extension> files (except the first one).
+
+Example:
+goalign draw html -i align.fa -o align.html
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var f outputFile
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		nalign := 0
+		for al := range aligns.Achan {
+			fname := drawOutput
+			// Add an index to file output name
+			// if there are several alignments to draw
+			if nalign > 0 {
+				ext := filepath.Ext(fname)
+				fname = fmt.Sprintf("%s_%d%s", fname[0:len(fname)-len(ext)], nalign, ext)
+			}
+			if f, err = openWriteFile(fname); err != nil {
+				io.LogError(err)
+				return
+			}
+			w := bufio.NewWriter(f)
+			if err = draw.NewHTMLLayout(w).DrawAlign(al); err != nil {
+				io.LogError(err)
+				return
+			}
+			w.Flush()
+			closeWriteFile(f, fname)
+			nalign++
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+		}
+		return
+	},
+}
+
+func init() {
+	drawCmd.AddCommand(drawHtmlCmd)
+}
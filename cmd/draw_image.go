@@ -0,0 +1,151 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"path/filepath"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/draw"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+var drawImageScheme string
+var drawImageStart int
+var drawImageLength int
+var drawImageRefSeq string
+var drawImageNoRuler bool
+var drawImageTracks []string
+
+const drawImageLong = `
+
+Draws the window of the alignment given by --start (0-based) and --length 
+(default: until the end of the alignment), with:
+- Sequence names on the left;
+- Residues, coloured with the scheme given by --scheme:
+  - auto (default): nucleotide for nucleotide alignments, clustalx otherwise;
+  - nucleotide: A, C, G, T/U;
+  - clustalx: amino acids coloured by type, as in ClustalX;
+  - zappo: amino acids coloured by physico-chemical properties;
+  - taylor: one colour per amino acid;
+  - none;
+- Position ticks and numbers (1-based) above the alignment, unless --no-ruler
+  is given;
+- The tracks given by --tracks (comma separated, or flag given several times), 
+  stacked below the sequences in the given order:
+  - consensus: majority consensus;
+  - gaps: fraction of gaps of each site;
+  - entropy: entropy of each site (gaps excluded), relative to its maximum 
+    value (log of the alphabet size).
+
+If --ref-seq is given, residues identical to the residues of this sequence 
+are drawn as '.' without colour, highlighting differences from the reference 
+(as goalign diff does with the first sequence).
+
+Images larger than 50,000,000 pixels (e.g. whole genome alignments) are
+refused: use --start and --length to draw a window of the alignment.
+
+If the input file contains several alignments, they are written in 
+<output>_<index>.<extension> files (except the first one).
+`
+
+// drawSvgCmd represents the draw svg command
+var drawSvgCmd = &cobra.Command{
+	Use:   "svg",
+	Short: "Draw alignments in svg",
+	Long: `Draw alignments in svg` + drawImageLong + `
+Example:
+goalign draw svg -i align.fa --start 100 --length 60 --tracks consensus,entropy -o align.svg
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return drawImage(func(w *bufio.Writer) draw.ImageLayout { return draw.NewSVGLayout(w) })
+	},
+}
+
+// drawPngCmd represents the draw png command
+var drawPngCmd = &cobra.Command{
+	Use:   "png",
+	Short: "Draw alignments in png",
+	Long: `Draw alignments in png` + drawImageLong + `
+Example:
+goalign draw png -i align.fa --start 100 --length 60 --tracks consensus,gaps -o align.png
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		return drawImage(func(w *bufio.Writer) draw.ImageLayout { return draw.NewPNGLayout(w) })
+	},
+}
+
+// drawImage draws all the input alignments with the layout
+// returned by newlayout, configured with the draw svg/png options
+func drawImage(newlayout func(w *bufio.Writer) draw.ImageLayout) (err error) {
+	var aligns *align.AlignChannel
+	var f outputFile
+	var scheme int
+	var tracks []int
+
+	if scheme, err = draw.SchemeFromString(drawImageScheme); err != nil {
+		io.LogError(err)
+		return
+	}
+	for _, t := range drawImageTracks {
+		var track int
+		if track, err = draw.TrackFromString(t); err != nil {
+			io.LogError(err)
+			return
+		}
+		tracks = append(tracks, track)
+	}
+
+	if aligns, err = readalign(infile); err != nil {
+		io.LogError(err)
+		return
+	}
+
+	nalign := 0
+	for al := range aligns.Achan {
+		fname := drawOutput
+		// Add an index to file output name
+		// if there are several alignments to draw
+		if nalign > 0 {
+			ext := filepath.Ext(fname)
+			fname = fmt.Sprintf("%s_%d%s", fname[0:len(fname)-len(ext)], nalign, ext)
+		}
+		if f, err = openWriteFile(fname); err != nil {
+			io.LogError(err)
+			return
+		}
+		w := bufio.NewWriter(f)
+		l := newlayout(w)
+		l.SetScheme(scheme)
+		l.SetWindow(drawImageStart, drawImageLength)
+		l.SetReference(drawImageRefSeq)
+		l.SetRuler(!drawImageNoRuler)
+		l.SetTracks(tracks...)
+		if err = l.DrawAlign(al); err != nil {
+			io.LogError(err)
+			return
+		}
+		w.Flush()
+		closeWriteFile(f, fname)
+		nalign++
+	}
+
+	if aligns.Err != nil {
+		err = aligns.Err
+		io.LogError(err)
+	}
+	return
+}
+
+func init() {
+	for _, c := range []*cobra.Command{drawSvgCmd, drawPngCmd} {
+		c.PersistentFlags().StringVar(&drawImageScheme, "scheme", "auto", "Colour scheme: auto, nucleotide, clustalx, zappo, taylor or none")
+		c.PersistentFlags().IntVar(&drawImageStart, "start", 0, "Start of the window to draw (0-based)")
+		c.PersistentFlags().IntVar(&drawImageLength, "length", -1, "Length of the window to draw (-1: until the end of the alignment)")
+		c.PersistentFlags().StringVar(&drawImageRefSeq, "ref-seq", "", "Highlights differences from this sequence (identical residues drawn as '.')")
+		c.PersistentFlags().BoolVar(&drawImageNoRuler, "no-ruler", false, "Do not draw position ticks and numbers")
+		c.PersistentFlags().StringSliceVar(&drawImageTracks, "tracks", nil, "Tracks drawn below the sequences: consensus, gaps, entropy (comma separated, or flag given several times)")
+		drawCmd.AddCommand(c)
+	}
+}
@@ -0,0 +1,201 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	goio "io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/draw"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/utils"
+	"github.com/spf13/cobra"
+)
+
+var drawLogoScheme string
+var drawLogoStart int
+var drawLogoLength int
+var drawLogoRefSeq string
+var drawLogoWeights string
+var drawLogoHenikoff bool
+var drawLogoNoErrorBars bool
+
+// drawLogoCmd represents the draw logo command
+var drawLogoCmd = &cobra.Command{
+	Use:   "logo",
+	Short: "Draw the sequence logo of alignments in svg",
+	Long: `Draw the sequence logo of alignments in svg
+
+The height of each stack of letters is the information content of the site 
+(log2 of the alphabet size minus its entropy, in bits), and letters are 
+stacked by increasing frequency (see goalign compute pssm -n 4). Gaps are not 
+drawn, but reduce the height of the stacks.
+
+Error bars drawn around the top of each stack represent the small sample 
+correction of the information content: (s-1)/(2*ln(2)*n), s being the size 
+of the alphabet, and n the number of sequences without gap at the site. They 
+are not drawn with --no-error-bars.
+
+Letters are coloured with the scheme given by --scheme:
+- auto (default): WebLogo colours for nucleotides (A, C, G, T/U) or amino 
+  acids (by chemical properties);
+- nucleotide, clustalx, zappo, taylor: see goalign draw svg;
+- none: all letters in black.
+
+The logo may be restricted to the window given by --start (0-based) and 
+--length (default: until the end of the alignment). If --ref-seq is given, 
+--start and --length are given on this sequence (without gaps, as in goalign 
+subseq), and positions are labelled with coordinates on this sequence.
+
+Sequences may be weighted:
+- With weights given in a tab separated file (--weights), one line per 
+  sequence: seqname\tweight (all the sequences must be given);
+- With position-based weights (--henikoff, Henikoff & Henikoff 1994), 
+  giving lower weights to groups of similar sequences.
+
+If the input file contains several alignments, they are written in 
+<output>_<index>.<extension> files (except the first one).
+
+Example:
+goalign draw logo -i align.fa --start 10 --length 20 -o logo.svg
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var f outputFile
+		var scheme int
+		var weightmap map[string]float64
+
+		if scheme, err = draw.SchemeFromString(drawLogoScheme); err != nil {
+			io.LogError(err)
+			return
+		}
+		if drawLogoWeights != "none" && drawLogoHenikoff {
+			err = errors.New("--weights and --henikoff can not be given together")
+			io.LogError(err)
+			return
+		}
+		if drawLogoWeights != "none" {
+			if weightmap, err = parseWeightFile(drawLogoWeights); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		nalign := 0
+		for al := range aligns.Achan {
+			var weights []float64
+			start, length := drawLogoStart, drawLogoLength
+
+			if drawLogoRefSeq != "" {
+				if length < 0 {
+					if ref, ok := al.GetSequenceByName(drawLogoRefSeq); ok {
+						length = len(strings.Replace(ref.Sequence(), string(align.GAP), "", -1)) - start
+					}
+				}
+				if start, length, err = al.RefCoordinates(drawLogoRefSeq, start, length); err != nil {
+					io.LogError(err)
+					return
+				}
+			}
+			if drawLogoHenikoff {
+				weights = al.HenikoffWeights()
+			} else if weightmap != nil {
+				weights = make([]float64, 0, al.NbSequences())
+				al.Iterate(func(name string, sequence string) bool {
+					w, ok := weightmap[name]
+					if !ok {
+						err = fmt.Errorf("Sequence %s is not in the weight file", name)
+						return true
+					}
+					weights = append(weights, w)
+					return false
+				})
+				if err != nil {
+					io.LogError(err)
+					return
+				}
+			}
+
+			fname := drawOutput
+			// Add an index to file output name
+			// if there are several alignments to draw
+			if nalign > 0 {
+				ext := filepath.Ext(fname)
+				fname = fmt.Sprintf("%s_%d%s", fname[0:len(fname)-len(ext)], nalign, ext)
+			}
+			if f, err = openWriteFile(fname); err != nil {
+				io.LogError(err)
+				return
+			}
+			w := bufio.NewWriter(f)
+			l := draw.NewLogoLayout(w)
+			l.SetScheme(scheme)
+			l.SetWindow(start, length)
+			l.SetReference(drawLogoRefSeq)
+			l.SetWeights(weights)
+			l.SetErrorBars(!drawLogoNoErrorBars)
+			if err = l.DrawAlign(al); err != nil {
+				io.LogError(err)
+				return
+			}
+			w.Flush()
+			closeWriteFile(f, fname)
+			nalign++
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+		}
+		return
+	},
+}
+
+// parseWeightFile parses a tab separated file of sequence weights:
+// one line per sequence, seqname\tweight
+func parseWeightFile(file string) (weights map[string]float64, err error) {
+	var f goio.Closer
+	var r *bufio.Reader
+	var w float64
+
+	weights = make(map[string]float64)
+
+	if f, r, err = utils.GetReader(file); err != nil {
+		return
+	}
+	defer f.Close()
+	l, e := utils.Readln(r)
+	for e == nil {
+		cols := strings.Split(l, "\t")
+		if len(cols) != 2 {
+			err = errors.New("Bad format from weights: Wrong number of columns")
+			return
+		}
+		if w, err = strconv.ParseFloat(cols[1], 64); err != nil {
+			return
+		}
+		weights[cols[0]] = w
+		l, e = utils.Readln(r)
+	}
+	return
+}
+
+func init() {
+	drawLogoCmd.PersistentFlags().StringVar(&drawLogoScheme, "scheme", "auto", "Colour scheme: auto, nucleotide, clustalx, zappo, taylor or none")
+	drawLogoCmd.PersistentFlags().IntVar(&drawLogoStart, "start", 0, "Start of the window to draw (0-based, on the reference sequence if --ref-seq is given)")
+	drawLogoCmd.PersistentFlags().IntVar(&drawLogoLength, "length", -1, "Length of the window to draw (-1: until the end)")
+	drawLogoCmd.PersistentFlags().StringVar(&drawLogoRefSeq, "ref-seq", "", "Start and length are given on this sequence, and positions are labelled with its coordinates")
+	drawLogoCmd.PersistentFlags().StringVar(&drawLogoWeights, "weights", "none", "Sequence weight file (tab separated), one line per sequence: seqname\\tweight")
+	drawLogoCmd.PersistentFlags().BoolVar(&drawLogoHenikoff, "henikoff", false, "Weights sequences with position-based weights (Henikoff & Henikoff 1994)")
+	drawLogoCmd.PersistentFlags().BoolVar(&drawLogoNoErrorBars, "no-error-bars", false, "Do not draw small sample error bars")
+	drawCmd.AddCommand(drawLogoCmd)
+}
@@ -0,0 +1,168 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"os"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/draw"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+var drawTermScheme string
+var drawTermWrap int
+var drawTermRefSeq string
+var drawTermDiffOnly bool
+var drawTermNoColor bool
+var drawTermForceColor bool
+var drawTermInteractive bool
+
+// drawTermCmd represents the draw term command
+var drawTermCmd = &cobra.Command{
+	Use:   "term",
+	Short: "Print alignments in the terminal with ANSI colours",
+	Long: `Print alignments in the terminal with ANSI colours
+
+Prints the alignment as blocks of --wrap sites (-1: fitted to the width of
+the terminal, 0: no wrapping), each block having:
+- A position ruler (1-based);
+- One line per sequence, residues being coloured with the scheme given by
+  --scheme (see goalign draw svg), using the 256 colours ANSI escape
+  sequences;
+- The majority consensus;
+- The conservation of each site (as in clustal format: * identical,
+  : conserved, . semi-conserved).
+
+If --ref-seq is given, residues identical to the residues of this sequence
+are printed as '.'. With --diff-only, only the sites where at least one
+sequence differs from the reference are printed, their positions being
+written vertically above the blocks.
+
+Colours are disabled with --no-color, and if the output is not a terminal,
+unless --force-color is given. To scroll through a coloured output with
+less, use --force-color and less -R.
+
+With --interactive, the alignment is displayed in a pager, the output being
+the terminal (-o stdout). Commands are:
+- arrows or h/j/k/l: scroll one site/sequence;
+- H/L: scroll one screen left/right, J/K: one screen down/up;
+- g/G: go to the start/end of the alignment;
+- :<position> then enter: go to the given site (1-based);
+- /<text> then enter: go to the next sequence whose name contains text;
+- q: quit (and display the next alignment, if any).
+
+Commands are read from the terminal, even if the alignment is read from
+stdin. If the terminal can not be put in non canonical mode (e.g. on
+Windows), commands must be followed by enter.
+
+Example:
+goalign draw term -i align.fa --ref-seq seq1 --diff-only
+goalign draw term -i align.fa --interactive
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var f outputFile
+		var scheme int
+		var tty *os.File
+
+		if scheme, err = draw.SchemeFromString(drawTermScheme); err != nil {
+			io.LogError(err)
+			return
+		}
+		if drawTermDiffOnly && drawTermRefSeq == "" {
+			err = errors.New("--diff-only needs a reference sequence (--ref-seq)")
+			io.LogError(err)
+			return
+		}
+		if drawTermInteractive && drawOutput != "stdout" && drawOutput != "-" {
+			err = errors.New("--interactive output must be the terminal (-o stdout)")
+			io.LogError(err)
+			return
+		}
+
+		rows, cols := termSize()
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if drawTermInteractive {
+			if tty, err = os.Open("/dev/tty"); err != nil {
+				io.LogError(err)
+				return
+			}
+			defer tty.Close()
+			restore := termRawMode(tty)
+			defer restore()
+		}
+
+		if f, err = openWriteFile(drawOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, drawOutput)
+		w := bufio.NewWriter(f)
+		colors := !drawTermNoColor && (drawTermForceColor || outputIsTerminal(f))
+
+		nalign := 0
+		for al := range aligns.Achan {
+			wrap := drawTermWrap
+			if wrap < 0 {
+				// Names (at least "Conservation") are followed by 2 spaces
+				namewidth := len("Conservation")
+				if al.MaxNameLength() > namewidth {
+					namewidth = al.MaxNameLength()
+				}
+				if wrap = cols - namewidth - 2; wrap < 10 {
+					wrap = 10
+				}
+			}
+			l := draw.NewTermLayout(w)
+			l.SetScheme(scheme)
+			l.SetWrap(wrap)
+			l.SetReference(drawTermRefSeq)
+			l.SetDiffOnly(drawTermDiffOnly)
+			l.SetColors(colors)
+			if drawTermInteractive {
+				err = l.Page(al, tty, cols, rows)
+			} else {
+				if nalign > 0 {
+					w.WriteString("\n")
+				}
+				err = l.DrawAlign(al)
+			}
+			if err != nil {
+				io.LogError(err)
+				return
+			}
+			nalign++
+		}
+		w.Flush()
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+		}
+		return
+	},
+}
+
+// outputIsTerminal returns true if the given output file is a terminal
+func outputIsTerminal(f outputFile) bool {
+	of, ok := f.(*os.File)
+	return ok && isTerminal(of.Fd())
+}
+
+func init() {
+	drawTermCmd.PersistentFlags().StringVar(&drawTermScheme, "scheme", "auto", "Colour scheme: auto, nucleotide, clustalx, zappo, taylor or none")
+	drawTermCmd.PersistentFlags().IntVar(&drawTermWrap, "wrap", -1, "Number of sites per block (-1: fitted to the terminal width, 0: no wrapping)")
+	drawTermCmd.PersistentFlags().StringVar(&drawTermRefSeq, "ref-seq", "", "Residues identical to this sequence are printed as '.'")
+	drawTermCmd.PersistentFlags().BoolVar(&drawTermDiffOnly, "diff-only", false, "Prints only the sites where a sequence differs from the reference (--ref-seq)")
+	drawTermCmd.PersistentFlags().BoolVar(&drawTermNoColor, "no-color", false, "Do not print ANSI colours")
+	drawTermCmd.PersistentFlags().BoolVar(&drawTermForceColor, "force-color", false, "Prints ANSI colours even if the output is not a terminal")
+	drawTermCmd.PersistentFlags().BoolVarP(&drawTermInteractive, "interactive", "I", false, "Displays alignments in an interactive pager")
+	drawCmd.AddCommand(drawTermCmd)
+}
@@ -0,0 +1,9 @@
+package cmd
+
+import "syscall"
+
+// ioctl requests reading and setting the terminal state
+const (
+	ioctlGetTermios = syscall.TIOCGETA
+	ioctlSetTermios = syscall.TIOCSETA
+)
@@ -0,0 +1,24 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package cmd
+
+import "os"
+
+// isTerminal returns true if the given file descriptor is a terminal:
+// terminals are not detected on this system
+func isTerminal(fd uintptr) bool {
+	return false
+}
+
+// termSize returns the default size of the terminal
+// (24 rows and 80 columns) on this system
+func termSize() (rows, cols int) {
+	return 24, 80
+}
+
+// termRawMode does nothing on this system: commands
+// of the interactive pager must be followed by enter
+func termRawMode(tty *os.File) (restore func()) {
+	return func() {}
+}
@@ -0,0 +1,65 @@
+//go:build linux || darwin
+// +build linux darwin
+
+package cmd
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// winsize is the size of the terminal, given by the TIOCGWINSZ ioctl
+type winsize struct {
+	rows, cols, xpixel, ypixel uint16
+}
+
+func ioctl(fd, req uintptr, arg unsafe.Pointer) error {
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, req, uintptr(arg)); errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// isTerminal returns true if the given file descriptor is a terminal
+func isTerminal(fd uintptr) bool {
+	var state syscall.Termios
+	return ioctl(fd, ioctlGetTermios, unsafe.Pointer(&state)) == nil
+}
+
+// termSize returns the size of the terminal,
+// or 24 rows and 80 columns if it can not be determined
+func termSize() (rows, cols int) {
+	var ws winsize
+
+	rows, cols = 24, 80
+	tty, err := os.Open("/dev/tty")
+	if err != nil {
+		return
+	}
+	defer tty.Close()
+	if ioctl(tty.Fd(), syscall.TIOCGWINSZ, unsafe.Pointer(&ws)) == nil && ws.rows > 0 && ws.cols > 0 {
+		rows, cols = int(ws.rows), int(ws.cols)
+	}
+	return
+}
+
+// termRawMode puts the terminal in non canonical mode without
+// echo, and returns the function restoring its previous state.
+// Does nothing if the state of the terminal can not be read.
+func termRawMode(tty *os.File) (restore func()) {
+	var state syscall.Termios
+
+	fd := tty.Fd()
+	if ioctl(fd, ioctlGetTermios, unsafe.Pointer(&state)) != nil {
+		return func() {}
+	}
+	raw := state
+	raw.Lflag &^= syscall.ICANON | syscall.ECHO
+	raw.Cc[syscall.VMIN] = 1
+	raw.Cc[syscall.VTIME] = 0
+	if ioctl(fd, ioctlSetTermios, unsafe.Pointer(&raw)) != nil {
+		return func() {}
+	}
+	return func() { ioctl(fd, ioctlSetTermios, unsafe.Pointer(&state)) }
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -25,7 +23,7 @@ goalign reformat fasta -i align.fasta
 
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 
 		if f, err = openWriteFile(reformatOutput); err != nil {
 			io.LogError(err)
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fastq"
+	"github.com/spf13/cobra"
+)
+
+// fastqCmd : to reformat in fastq format
+var fastqCmd = &cobra.Command{
+	Use:   "fastq",
+	Short: "Reformats an input alignment into FASTQ",
+	Long: `Reformats an alignment into FASTQ.
+It may take a FASTQ, Phylip, Fasta, Nexus, Clustal or Stockholm input alignment.
+
+If the input alignment contains several alignments, will take the first one only.
+
+If the input sequences come from a FASTQ file, their qualities are written as well
+(Phred+33). Otherwise, all qualities are set to 40 ('I').
+
+With --unaligned, sequences may have different lengths.
+
+Example of usage:
+
+goalign reformat fastq -i reads.fq --unaligned
+goalign reformat fastq -i align.fasta
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var f outputFile
+		var seqs align.SeqBag
+
+		if f, err = openWriteFile(reformatOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, reformatOutput)
+
+		if unaligned {
+			if seqs, err = readsequences(infile); err != nil {
+				io.LogError(err)
+				return
+			}
+		} else {
+			var aligns *align.AlignChannel
+
+			if aligns, err = readalign(infile); err != nil {
+				io.LogError(err)
+				return
+			}
+			a, _ := <-aligns.Achan
+			if aligns.Err != nil {
+				err = aligns.Err
+				io.LogError(err)
+				return
+			}
+			seqs = a
+		}
+		if reformatCleanNames {
+			seqs.CleanNames(nil)
+		}
+		f.WriteString(fastq.WriteAlignment(seqs))
+		return
+	},
+}
+
+func init() {
+	reformatCmd.AddCommand(fastqCmd)
+}
@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
+	"github.com/spf13/cobra"
+)
+
+// indexCmd represents the index command
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Index a fasta file",
+	Long: `Index a fasta file
+
+It builds a samtools compatible index of the input fasta file
+(given with -i, must be a local file), and writes it next to the
+input file, with the .fai extension:
+
+name<TAB>length<TAB>offset<TAB>bases per line<TAB>bytes per line
+
+As with samtools faidx, sequence names are the first word of the headers,
+all lines of a sequence, except the last one, must have the same length,
+and duplicate sequences are ignored.
+
+If the input file is compressed with bgzip, the positions of its compressed
+blocks are also written in a .gzi file. Files compressed with plain gzip
+(or other compression formats) can not be indexed.
+
+When an index is present next to the input fasta file, and is more recent than
+it, goalign subset and goalign subseq (without --ref-seq nor --cds) use it to
+read only the required sequences, or parts of sequences.
+
+For example:
+goalign index -i al.fa
+goalign subset -i al.fa s1 s2
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var in, fai, gzi *os.File
+		var records []*fasta.IndexRecord
+		var blocks []fasta.BgzfBlock
+
+		if infile == "stdin" || infile == "-" {
+			err = fmt.Errorf("goalign index requires a local input file (-i)")
+			io.LogError(err)
+			return
+		}
+		if in, err = os.Open(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer in.Close()
+
+		if records, blocks, err = fasta.BuildIndex(in); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if fai, err = os.Create(infile + ".fai"); err != nil {
+			io.LogError(err)
+			return
+		}
+		if err = fasta.WriteIndex(fai, records); err != nil {
+			fai.Close()
+			io.LogError(err)
+			return
+		}
+		if err = fai.Close(); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if blocks != nil {
+			if gzi, err = os.Create(infile + ".gzi"); err != nil {
+				io.LogError(err)
+				return
+			}
+			if err = fasta.WriteGzi(gzi, blocks); err != nil {
+				gzi.Close()
+				io.LogError(err)
+				return
+			}
+			if err = gzi.Close(); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+		return
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(indexCmd)
+}
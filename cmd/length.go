@@ -5,6 +5,7 @@ import (
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -26,20 +27,44 @@ Example of usages:
 goalign stats length -i align.phylip -p
 goalign stats length -i align.fasta
 
+If --format is given (tsv, csv or json), lengths are printed as a table 
+(tab separated, comma separated, or as a json array of objects) with 
+one record per input alignment, having the fields alignment (index 
+of the alignment in the input file) and length, or one record per sequence
+with --unaligned, having the fields sequence and length.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var structured bool
+		var t *table.Table
+
+		if structured, err = statsStructured(); err != nil {
+			io.LogError(err)
+			return
+		}
 		if unaligned {
-			var seqs align.SeqBag
+			var seqs *align.SequenceChannel
 
-			if seqs, err = readsequences(infile); err != nil {
+			// Sequences are read one by one, without loading the whole file
+			if seqs, err = readsequencesstream(infile); err != nil {
+				io.LogError(err)
+				return
+			}
+			defer drainSequences(seqs)
+
+			t = table.NewTable("sequence", "length")
+			for s := range seqs.Schan {
+				if !structured {
+					fmt.Println(s.Name(), "\t", s.Length())
+				} else if err = t.AddRow(s.Name(), s.Length()); err != nil {
+					io.LogError(err)
+					return
+				}
+			}
+			if seqs.Err != nil {
+				err = seqs.Err
 				io.LogError(err)
 				return
 			}
-
-			seqs.IterateChar(func(name string, sequence []rune) bool {
-				fmt.Println(name, "\t", len(sequence))
-				return false
-			})
 		} else {
 			var aligns *align.AlignChannel
 
@@ -48,13 +73,25 @@ goalign stats length -i align.fasta
 				return
 			}
 
+			t = table.NewTable("alignment", "length")
 			for al := range aligns.Achan {
-				fmt.Println(al.Length())
+				if !structured {
+					fmt.Println(al.Length())
+				} else if err = t.AddRow(len(t.Rows), al.Length()); err != nil {
+					io.LogError(err)
+					return
+				}
 			}
 
 			if aligns.Err != nil {
 				err = aligns.Err
 				io.LogError(err)
+				return
+			}
+		}
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
 			}
 		}
 		return
@@ -63,5 +100,6 @@ goalign stats length -i align.fasta
 
 func init() {
 	lengthCmd.PersistentFlags().BoolVar(&unaligned, "unaligned", false, "Considers sequences as unaligned and format fasta (phylip, nexus,... options are ignored)")
+	addStatsFormatFlag(lengthCmd)
 	statsCmd.AddCommand(lengthCmd)
 }
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/maf"
+	"github.com/spf13/cobra"
+)
+
+// mafCmd : to reformat in MAF format
+var mafCmd = &cobra.Command{
+	Use:   "maf",
+	Short: "Reformats an input alignment into MAF format",
+	Long: `Reformats an alignment into MAF format (UCSC Multiple Alignment Format). 
+It may take a Phylip, Fasta, Nexus, Clustal, Stockholm or MAF input alignment.
+
+If the input alignment contains several alignments (ex: MAF blocks), each of 
+them is written as a MAF alignment block.
+
+Sequences coming from a MAF input are written with their source coordinates. 
+Other sequences are considered as whole source sequences on the '+' strand.
+
+Example of usage:
+
+goalign reformat maf -i align.phylip -p
+goalign reformat maf -i blocks.maf --maf
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var f outputFile
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		if f, err = openWriteFile(reformatOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, reformatOutput)
+
+		f.WriteString(maf.MAF_HEADER + "\n\n")
+		for al := range aligns.Achan {
+			if reformatCleanNames {
+				al.CleanNames(nil)
+			}
+			f.WriteString(maf.WriteBlock(al))
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+		}
+		return
+	},
+}
+
+func init() {
+	reformatCmd.AddCommand(mafCmd)
+}
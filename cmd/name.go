@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -33,7 +31,7 @@ Id -a is given, then names are generated with the pattern "S000<i>".
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -83,7 +81,7 @@ Id -a is given, then names are generated with the pattern "S000<i>".
 }
 
 func writeNameMap(namemap map[string]string, outfile string) (err error) {
-	var f *os.File
+	var f outputFile
 
 	if f, err = openWriteFile(outfile); err != nil {
 		return
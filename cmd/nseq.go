@@ -5,6 +5,7 @@ import (
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -21,9 +22,21 @@ Example of usages:
 
 goalign stats nseq -i align.phylip -p
 goalign stats nseq -i align.fasta
+
+If --format is given (tsv, csv or json), numbers are printed as a table 
+(tab separated, comma separated, or as a json array of objects) with 
+one record per input alignment, having the fields alignment (index 
+of the alignment in the input file) and nseqs, or one record with 
+the field nseqs with --unaligned.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var structured bool
+		var t *table.Table
 
+		if structured, err = statsStructured(); err != nil {
+			io.LogError(err)
+			return
+		}
 		if unaligned {
 			var seqs align.SeqBag
 
@@ -31,7 +44,13 @@ goalign stats nseq -i align.fasta
 				io.LogError(err)
 				return
 			}
-			fmt.Println(seqs.NbSequences())
+			t = table.NewTable("nseqs")
+			if !structured {
+				fmt.Println(seqs.NbSequences())
+			} else if err = t.AddRow(seqs.NbSequences()); err != nil {
+				io.LogError(err)
+				return
+			}
 		} else {
 			var aligns *align.AlignChannel
 
@@ -40,13 +59,25 @@ goalign stats nseq -i align.fasta
 				return
 			}
 
+			t = table.NewTable("alignment", "nseqs")
 			for al := range aligns.Achan {
-				fmt.Println(al.NbSequences())
+				if !structured {
+					fmt.Println(al.NbSequences())
+				} else if err = t.AddRow(len(t.Rows), al.NbSequences()); err != nil {
+					io.LogError(err)
+					return
+				}
 			}
 
 			if aligns.Err != nil {
 				err = aligns.Err
 				io.LogError(err)
+				return
+			}
+		}
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
 			}
 		}
 		return
@@ -55,5 +86,6 @@ goalign stats nseq -i align.fasta
 
 func init() {
 	nseqCmd.PersistentFlags().BoolVar(&unaligned, "unaligned", false, "Considers sequences as unaligned and format fasta (phylip, nexus,... options are ignored)")
+	addStatsFormatFlag(nseqCmd)
 	statsCmd.AddCommand(nseqCmd)
 }
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -10,6 +8,7 @@ import (
 
 var orfOutput string
 var orfreverse bool
+var orfcds string
 
 // translateCmd represents the addid command
 var orfCmd = &cobra.Command{
@@ -20,10 +19,16 @@ var orfCmd = &cobra.Command{
 If input sequences are not nucleotidic, then returns an error.
 If input sequences are aligned (contain '-'), then they are unaligned first.
 
+If input sequences are in GenBank or EMBL format and have annotated CDS, 
+then the longest orf is not searched: annotated CDS are extracted instead 
+(joined and reverse complemented if needed, starting at their codon_start).
+--cds allows to extract only the CDS having the given name (gene, locus_tag, 
+protein_id or product qualifier).
+
 Output is in fasta format.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 		var reforf align.SeqBag
 		var inseqs align.SeqBag
 		var orf align.Sequence
@@ -39,6 +44,14 @@ Output is in fasta format.
 			return
 		}
 
+		if reforf, err = annotatedCDS(inseqs, orfcds); err != nil {
+			io.LogError(err)
+			return
+		} else if reforf != nil {
+			writeSequences(reforf, f)
+			return
+		}
+
 		inseqs = inseqs.Unalign()
 
 		if orf, err = inseqs.LongestORF(orfreverse); err != nil {
@@ -58,4 +71,31 @@ func init() {
 	RootCmd.AddCommand(orfCmd)
 	orfCmd.PersistentFlags().StringVarP(&orfOutput, "output", "o", "stdout", "ORF Output Fasta File")
 	orfCmd.PersistentFlags().BoolVar(&orfreverse, "reverse", false, "Search for the longest ORF ALSO in the reverse strand")
+	orfCmd.PersistentFlags().StringVar(&orfcds, "cds", "all", "Name of the annotated CDS to extract, if input sequences have annotated CDS (all: all the CDS)")
+}
+
+// annotatedCDS returns the CDS annotated in the given sequences (from GenBank or
+// EMBL files), having the given name ("all": all the CDS).
+// Returns nil if the sequences do not have any annotated CDS.
+func annotatedCDS(sb align.SeqBag, name string) (cds align.SeqBag, err error) {
+	if !hasCDS(sb) {
+		return nil, nil
+	}
+	if name == "all" {
+		name = ""
+	}
+	return sb.CDS(name)
+}
+
+// hasCDS returns true if any sequence of sb has an annotated CDS
+func hasCDS(sb align.SeqBag) bool {
+	for i := 0; i < sb.NbSequences(); i++ {
+		s, _ := sb.Sequence(i)
+		for _, f := range s.Features() {
+			if f.Type == "CDS" {
+				return true
+			}
+		}
+	}
+	return false
 }
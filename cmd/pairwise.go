@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
+	"github.com/evolbioinfo/goalign/version"
+	"github.com/spf13/cobra"
+)
+
+var pairwiseOutput string
+var pairwiseMode string
+var pairwiseFormat string
+var pairwiseRefSeq string
+var pairwiseGapOpen, pairwiseGapExtend float64
+var pairwiseMatch, pairwiseMismatch float64
+var pairwiseLinearSpace bool
+var pairwiseBand int
+
+// pairwiseCmd represents the pairwise command
+var pairwiseCmd = &cobra.Command{
+	Use:   "pairwise",
+	Short: "Aligns sequences against a reference sequence",
+	Long: `Aligns sequences against a reference sequence.
+
+Each input sequence is aligned against the reference sequence (the first
+sequence of the input file, or the sequence given by --ref-seq), using the
+algorithm given by --mode:
+- global (default): Needleman & Wunsch, whole sequences are aligned;
+- local: Smith & Waterman (as goalign sw), the best scoring local alignment;
+- glocal: semi-global, whole sequences are aligned, but gaps at the start
+  and at the end of the sequences are not scored (e.g. overlapping
+  sequences, or a short sequence inside a longer one).
+
+Gap scores are affine: a gap of length n has a score of
+gap-open + (n-1)*gap-extend (they should be negative).
+If neither --match nor --mismatch are specified, then match and mismatch scores
+are taken from blosum62 or dnafull substitution matrices, depending on the
+input sequences alphabets.
+
+Long sequences (e.g. genomes) may be aligned with:
+- --linear-space: alignments are computed in linear memory (Myers & Miller),
+  instead of storing whole dynamic programming matrices. This is done
+  automatically if the product of the sequence lengths exceeds 10,000,000;
+- --band <w>: global and glocal alignments are restricted to the diagonals
+  at most w away from the diagonals of both ends of the sequences (the time
+  and memory are proportional to the length of the reference times
+  2*w+length difference). Alignments may not be optimal if they need
+  longer indels. Not available in local mode.
+
+Output format is given by --format:
+- align (default): the pairwise alignments, in the format given by the
+  formatting options (-p, -x, etc.);
+- sam: SAM records of the sequences against the reference (header included):
+  query residues outside the aligned region are soft clipped, and records
+  have the tags AS:i (score, rounded), NM:i (number of differences) and
+  ZI:f (percent identity);
+- tsv, csv or json: one record per sequence with the fields reference,
+  query, mode, score, length, matches, mismatches, gaps, identity
+  (percentage of identical residues over the alignment length, end gaps
+  excluded in glocal mode), ref_start, ref_end, query_start, query_end
+  (0-based positions of the first and last aligned residues), and cigar.
+
+Example:
+goalign pairwise -i seqs.fa --mode glocal --format sam -o out.sam
+goalign pairwise -i genomes.fa --band 500 --format tsv
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var seqs align.SeqBag
+		var ref align.Sequence
+		var f outputFile
+		var algo, format int
+		var ok bool
+
+		switch pairwiseMode {
+		case "global":
+			algo = align.ALIGN_ALGO_NW
+		case "local":
+			algo = align.ALIGN_ALGO_SW
+		case "glocal":
+			algo = align.ALIGN_ALGO_SEMIGLOBAL
+		default:
+			err = fmt.Errorf("Unknown alignment mode : %s", pairwiseMode)
+			io.LogError(err)
+			return
+		}
+
+		structured := pairwiseFormat != "align" && pairwiseFormat != "sam"
+		if structured {
+			if format, err = table.FormatFromString(pairwiseFormat); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+
+		if pairwiseBand < 0 {
+			err = fmt.Errorf("Band width must be positive : %d", pairwiseBand)
+			io.LogError(err)
+			return
+		}
+		if pairwiseBand > 0 && algo == align.ALIGN_ALGO_SW {
+			err = fmt.Errorf("--band is not available in local mode")
+			io.LogError(err)
+			return
+		}
+
+		if seqs, err = readsequences(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if seqs.NbSequences() < 2 {
+			err = fmt.Errorf("The input file must contain at least 2 sequences")
+			io.LogError(err)
+			return
+		}
+		ref, _ = seqs.Sequence(0)
+		if pairwiseRefSeq != "" {
+			if ref, ok = seqs.GetSequenceByName(pairwiseRefSeq); !ok {
+				err = fmt.Errorf("Reference sequence %s does not exist in the input sequences", pairwiseRefSeq)
+				io.LogError(err)
+				return
+			}
+		}
+
+		if f, err = openWriteFile(pairwiseOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, pairwiseOutput)
+
+		t := table.NewTable("reference", "query", "mode", "score", "length", "matches", "mismatches", "gaps", "identity",
+			"ref_start", "ref_end", "query_start", "query_end", "cigar")
+		if pairwiseFormat == "sam" {
+			fmt.Fprintf(f, "@HD\tVN:1.6\tSO:unsorted\n")
+			fmt.Fprintf(f, "@SQ\tSN:%s\tLN:%d\n", samName(ref.Name()), ref.Length())
+			fmt.Fprintf(f, "@PG\tID:goalign\tPN:goalign\tVN:%s\n", version.Version)
+		}
+
+		for i := 0; i < seqs.NbSequences(); i++ {
+			var al align.Alignment
+			seq, _ := seqs.Sequence(i)
+			if seq.Name() == ref.Name() {
+				continue
+			}
+
+			aligner := align.NewPwAligner(ref, seq, algo)
+			aligner.SetGapOpenScore(pairwiseGapOpen)
+			aligner.SetGapExtendScore(pairwiseGapExtend)
+			aligner.SetLinearSpace(pairwiseLinearSpace)
+			aligner.SetBand(pairwiseBand)
+			if cmd.Flags().Changed("mismatch") || cmd.Flags().Changed("match") {
+				aligner.SetScore(pairwiseMatch, pairwiseMismatch)
+			}
+			if al, err = aligner.Alignment(); err != nil {
+				io.LogError(err)
+				return
+			}
+
+			switch {
+			case pairwiseFormat == "align":
+				writeAlign(al, f)
+			case pairwiseFormat == "sam":
+				f.WriteString(samRecord(aligner, ref, seq))
+			default:
+				cigar, _ := aligner.Cigar()
+				start1, start2 := aligner.AlignStarts()
+				end1, end2 := aligner.AlignEnds()
+				if err = t.AddRow(ref.Name(), seq.Name(), pairwiseMode, aligner.MaxScore(), aligner.Length(),
+					aligner.NbMatches(), aligner.NbMisMatches(), aligner.NbGaps(), aligner.PercentIdentity(),
+					start1, end1, start2, end2, cigar); err != nil {
+					io.LogError(err)
+					return
+				}
+			}
+		}
+
+		if structured {
+			if err = t.Write(f, format); err != nil {
+				io.LogError(err)
+			}
+		}
+		return
+	},
+}
+
+// samRecord returns the SAM record of the alignment of
+// the query sequence against the reference sequence
+func samRecord(aligner align.PairwiseAligner, ref, query align.Sequence) string {
+	cigar, pos := aligner.Cigar()
+	seq1, seq2 := aligner.Seq1Ali(), aligner.Seq2Ali()
+
+	// Number of differences: mismatches and gaps between
+	// the first and the last aligned residues
+	first, last, nm := -1, -1, 0
+	for k := range seq1 {
+		if seq1[k] != align.GAP && seq2[k] != align.GAP {
+			if first < 0 {
+				first = k
+			}
+			last = k
+		}
+	}
+	for k := first; first >= 0 && k <= last; k++ {
+		if seq1[k] != seq2[k] {
+			nm++
+		}
+	}
+
+	if first < 0 {
+		// Unmapped
+		return fmt.Sprintf("%s\t4\t*\t0\t0\t*\t*\t0\t0\t%s\t*\n", samName(query.Name()), query.Sequence())
+	}
+	return fmt.Sprintf("%s\t0\t%s\t%d\t255\t%s\t*\t0\t0\t%s\t*\tAS:i:%d\tNM:i:%d\tZI:f:%.2f\n",
+		samName(query.Name()), samName(ref.Name()), pos+1, cigar, query.Sequence(),
+		int(math.Round(aligner.MaxScore())), nm, aligner.PercentIdentity())
+}
+
+// samName returns the name of the sequence in SAM records: the
+// first word of its fasta header (SAM names can not contain spaces)
+func samName(name string) string {
+	if fields := strings.Fields(name); len(fields) > 0 {
+		return fields[0]
+	}
+	return "*"
+}
+
+func init() {
+	RootCmd.AddCommand(pairwiseCmd)
+	pairwiseCmd.PersistentFlags().StringVarP(&pairwiseOutput, "output", "o", "stdout", "Output file")
+	pairwiseCmd.PersistentFlags().StringVar(&pairwiseMode, "mode", "global", "Alignment mode: global, local or glocal (semi-global)")
+	pairwiseCmd.PersistentFlags().StringVar(&pairwiseFormat, "format", "align", "Output format: align, sam, tsv, csv or json")
+	pairwiseCmd.PersistentFlags().StringVar(&pairwiseRefSeq, "ref-seq", "", "Reference sequence (default: first sequence)")
+	pairwiseCmd.PersistentFlags().Float64Var(&pairwiseGapOpen, "gap-open", -10.0, "Score for opening a gap")
+	pairwiseCmd.PersistentFlags().Float64Var(&pairwiseGapExtend, "gap-extend", -0.5, "Score for extending a gap")
+	pairwiseCmd.PersistentFlags().Float64Var(&pairwiseMatch, "match", 1.0, "Score for a match (if omitted, then take substitution matrix)")
+	pairwiseCmd.PersistentFlags().Float64Var(&pairwiseMismatch, "mismatch", -1.0, "Score for a mismatch (if omitted, then take substitution matrix)")
+	pairwiseCmd.PersistentFlags().BoolVar(&pairwiseLinearSpace, "linear-space", false, "Computes the alignments in linear memory")
+	pairwiseCmd.PersistentFlags().IntVar(&pairwiseBand, "band", 0, "Width of the band around the diagonals, for global and glocal modes (0: no band)")
+}
@@ -0,0 +1,20 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// partitionCmd represents the partition command
+var partitionCmd = &cobra.Command{
+	Use:   "partition",
+	Short: "Command to manipulate partition files",
+	Long: `Command to manipulate partition files.
+
+1. goalign partition convert: Converts a partition file between RAxML/RAxML-NG,
+   IQ-TREE NEXUS and PartitionFinder formats.
+`,
+}
+
+func init() {
+	RootCmd.AddCommand(partitionCmd)
+}
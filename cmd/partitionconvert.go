@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/partition"
+	"github.com/spf13/cobra"
+)
+
+var partconvertin string
+var partconvertout string
+var partconvertinformat string
+var partconvertoutformat string
+var partconvertlength int
+var partconvertmodel string
+var partconvertpfalign string
+
+// partitionconvertCmd represents the partition convert command
+var partitionconvertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Converts a partition file",
+	Long: `Converts a partition file.
+
+Supported formats (--in-format and --out-format) are:
+- raxml (or raxml-ng): MODEL, name = 1-100/3, 101-200
+  Strides may be given with '/' or '\', and models may have parameters
+  between braces (ex: GTR{1/2/1/1/2/1}+FU{0.2/0.3/0.3/0.2}+G4);
+- nexus (or iqtree): NEXUS file with a SETS block, defining a CHARSET per
+  partition, and a CHARPARTITION giving the model of each CHARSET
+  (ex: charpartition mine = GTR+G:part1, HKY:part2;). If the input NEXUS file
+  has a DATA block, partitions are taken from the SETS, ASSUMPTIONS and
+  MRBAYES blocks following it;
+- partitionfinder (or pf): PartitionFinder configuration file, whose
+  [data_blocks] are the partitions. Models of the partitions are not written,
+  and the alignment file name is given with --pf-alignment.
+
+By default, the input format is detected (--in-format auto).
+
+Partitions whose model is not defined in the input file (NEXUS without
+CHARPARTITION, PartitionFinder) are given the model --model.
+
+The length of the alignment is the last site of the partitions, unless the
+alignment is given with -i (or its length with --length). In that case, the
+length of the alignment is checked, and if --model is not given, the default
+model is DNA or LG, depending on the alignment alphabet.
+
+Example of usage:
+
+goalign partition convert --partition partition.txt --out-format nexus -o partition.nex
+goalign split -i align.phy -p --partition partition.cfg
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var informat, outformat int
+		var ps *align.PartitionSet
+		var out string
+		var f outputFile
+
+		if partconvertoutformat == "none" {
+			err = errors.New("--out-format must be specified")
+			io.LogError(err)
+			return
+		}
+		if informat, err = partition.FormatFromString(partconvertinformat); err != nil {
+			io.LogError(err)
+			return
+		}
+		if outformat, err = partition.FormatFromString(partconvertoutformat); err != nil || outformat == partition.FORMAT_AUTO {
+			err = errors.New("Unknown partition output format: " + partconvertoutformat)
+			io.LogError(err)
+			return
+		}
+
+		if cmd.Flags().Changed("align") {
+			var aligns *align.AlignChannel
+			if aligns, err = readalign(infile); err != nil {
+				io.LogError(err)
+				return
+			}
+			al, ok := <-aligns.Achan
+			// Only the first alignment is used, the others are ignored
+			for range aligns.Achan {
+			}
+			if aligns.Err != nil {
+				err = aligns.Err
+				io.LogError(err)
+				return
+			}
+			if !ok {
+				err = errors.New("No alignment found in the input file")
+				io.LogError(err)
+				return
+			}
+			partconvertlength = al.Length()
+			if !cmd.Flags().Changed("model") {
+				partconvertmodel = defaultModel(al)
+			}
+		}
+
+		if ps, err = readPartition(partconvertin, informat, partconvertlength, partconvertmodel); err != nil {
+			io.LogError(err)
+			return
+		}
+		if out, err = partition.Write(ps, outformat, partconvertpfalign); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if f, err = openWriteFile(partconvertout); err != nil {
+			io.LogError(err)
+			return
+		}
+		f.WriteString(out)
+		closeWriteFile(f, partconvertout)
+		return
+	},
+}
+
+func init() {
+	partitionCmd.AddCommand(partitionconvertCmd)
+	partitionconvertCmd.PersistentFlags().StringVar(&partconvertin, "partition", "stdin", "Input partition file")
+	partitionconvertCmd.PersistentFlags().StringVarP(&partconvertout, "output", "o", "stdout", "Output partition file")
+	partitionconvertCmd.PersistentFlags().StringVar(&partconvertinformat, "in-format", "auto", "Input partition format (auto, raxml, nexus, partitionfinder)")
+	partitionconvertCmd.PersistentFlags().StringVar(&partconvertoutformat, "out-format", "none", "Output partition format (raxml, nexus, partitionfinder)")
+	partitionconvertCmd.PersistentFlags().IntVar(&partconvertlength, "length", 0, "Length of the alignment (default: last site of the partitions, or length of the alignment given with -i)")
+	partitionconvertCmd.PersistentFlags().StringVar(&partconvertmodel, "model", "DNA", "Model of the partitions whose model is not defined in the input file")
+	partitionconvertCmd.PersistentFlags().StringVar(&partconvertpfalign, "pf-alignment", "alignment.phy", "Name of the alignment file (only used with PartitionFinder output)")
+}
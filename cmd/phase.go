@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
@@ -19,6 +18,7 @@ var lencutoff float64
 var matchcutoff float64
 var phasereverse bool
 var phasecutend bool
+var phaserefcds string
 
 // translateCmd represents the addid command
 var phaseCmd = &cobra.Command{
@@ -27,6 +27,8 @@ var phaseCmd = &cobra.Command{
 	Long: `Find best Starts and set them as new start positions.
 
 This command "phases" input sequences on the basis on either a set of input sequences, or the longest detected orf.
+If the reference orf file (--ref-orf) is in GenBank or EMBL format, then its annotated CDS 
+are taken as reference orfs (or only the CDS given by --ref-cds).
 To do so, it will:
 
 1. Search for the longest ORF in the dataset if no reference orf(s) is(are) given;
@@ -55,7 +57,7 @@ If input sequences are not nucleotidic, then returns an error.
 Output file is an unaligned set of sequences in fasta.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f, aaf, logf *os.File
+		var f, aaf, logf outputFile
 		var phased chan align.PhasedSequence
 		var inseqs align.SeqBag
 		var reforf align.SeqBag
@@ -94,6 +96,14 @@ Output file is an unaligned set of sequences in fasta.
 				io.LogError(err)
 				return
 			}
+			// Annotated reference: we take its CDS
+			var cds align.SeqBag
+			if cds, err = annotatedCDS(reforf, phaserefcds); err != nil {
+				io.LogError(err)
+				return
+			} else if cds != nil {
+				reforf = cds
+			}
 			if reforf.NbSequences() < 1 {
 				err = fmt.Errorf("Reference ORF file should contain at least one sequence")
 				io.LogError(err)
@@ -190,4 +200,5 @@ func init() {
 	phaseCmd.PersistentFlags().BoolVar(&phasereverse, "reverse", false, "Search ALSO in the reverse strand (in addition to the forward strand)")
 	phaseCmd.PersistentFlags().BoolVar(&phasecutend, "cut-end", false, "Iftrue, then also remove the end of sequences that do not align with orf")
 	phaseCmd.PersistentFlags().StringVar(&orfsequence, "ref-orf", "none", "Reference ORF to phase against (if none is given, then will try to get the longest orf in the input data)")
+	phaseCmd.PersistentFlags().StringVar(&phaserefcds, "ref-cds", "all", "If the reference ORF file is annotated (GenBank or EMBL), name of the CDS to phase against (all: all the CDS)")
 }
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -20,6 +19,8 @@ var phasentCmd = &cobra.Command{
 Unlike goalign phase, it does not take into account translation of input sequences.
 
 This command "phases" input sequences on the basis on either a set of input sequences, or the longest detected orf.
+If the reference orf file (--ref-orf) is in GenBank or EMBL format, then its annotated CDS 
+are taken as reference orfs (or only the CDS given by --ref-cds).
 To do so, it will:
 
 1. Search for the longest ORF in the dataset if no reference orf(s) is(are) given;
@@ -47,7 +48,7 @@ If input sequences are not nucleotidic, then returns an error.
 Output file is an unaligned set of sequences in fasta.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f, aaf, codonf, logf *os.File
+		var f, aaf, codonf, logf outputFile
 		var phased chan align.PhasedSequence
 		var inseqs align.SeqBag
 		var reforf align.SeqBag
@@ -98,6 +99,14 @@ Output file is an unaligned set of sequences in fasta.
 				io.LogError(err)
 				return
 			}
+			// Annotated reference: we take its CDS
+			var cds align.SeqBag
+			if cds, err = annotatedCDS(reforf, phaserefcds); err != nil {
+				io.LogError(err)
+				return
+			} else if cds != nil {
+				reforf = cds
+			}
 			if reforf.NbSequences() < 1 {
 				err = fmt.Errorf("Reference ORF file should contain at least one sequence")
 				io.LogError(err)
@@ -213,4 +222,5 @@ func init() {
 	phasentCmd.PersistentFlags().BoolVar(&phasereverse, "reverse", false, "Search ALSO in the reverse strand (in addition to the forward strand)")
 	phasentCmd.PersistentFlags().BoolVar(&phasecutend, "cut-end", false, "If true, then also remove the end of sequences that do not align with orf")
 	phasentCmd.PersistentFlags().StringVar(&orfsequence, "ref-orf", "none", "Reference ORF to phase against (if none is given, then will try to get the longest orf in the input data)")
+	phasentCmd.PersistentFlags().StringVar(&phaserefcds, "ref-cds", "all", "If the reference ORF file is annotated (GenBank or EMBL), name of the CDS to phase against (all: all the CDS)")
 }
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fastq"
+	"github.com/spf13/cobra"
+)
+
+var qualityOutput string
+var qualityMin int
+
+// qualityCmd represents the quality command
+var qualityCmd = &cobra.Command{
+	Use:   "quality",
+	Short: "Masks, trims or filters sequences using their per-base qualities",
+	Long: `Masks, trims or filters sequences using their per-base qualities.
+
+Input sequences must be in FASTQ format (Phred+33 qualities), and are not 
+considered as aligned. They are processed one by one, without loading the 
+whole file in memory.
+
+Output sequences are written in FASTQ format, with their qualities. To get 
+a fasta file, just pipe the output to goalign reformat fasta --unaligned.
+
+Subcommands:
+- mask  : Replaces bases whose quality is < --min-qual with N;
+- trim  : Removes bases whose quality is < --min-qual at both ends of the sequences;
+- filter: Removes sequences whose mean quality is < --min-mean.
+`,
+}
+
+// processQualityStream reads the input FASTQ sequences one by one, and applies
+// the given function to each of them. Sequences for which the function returns
+// true are written to the output FASTQ file.
+func processQualityStream(process func(s align.Sequence) (keep bool, err error)) (err error) {
+	var f outputFile
+	var seqs *align.SequenceChannel
+	var keep bool
+
+	if f, err = openWriteFile(qualityOutput); err != nil {
+		io.LogError(err)
+		return
+	}
+	defer closeWriteFile(f, qualityOutput)
+
+	if seqs, err = readsequencesstream(infile); err != nil {
+		io.LogError(err)
+		return
+	}
+	defer drainSequences(seqs)
+	w := fastq.NewStreamWriter(f)
+	defer w.Flush()
+	for s := range seqs.Schan {
+		if keep, err = process(s); err != nil {
+			io.LogError(err)
+			return
+		}
+		if keep {
+			if err = w.Write(s); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+	}
+	if err = seqs.Err; err != nil {
+		io.LogError(err)
+	}
+	return
+}
+
+func init() {
+	RootCmd.AddCommand(qualityCmd)
+	qualityCmd.PersistentFlags().StringVarP(&qualityOutput, "output", "o", "stdout", "Output FASTQ file")
+}
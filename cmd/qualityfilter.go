@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+var qualityMinMean float64
+var qualityFilterQuiet bool
+
+// qualityfilterCmd represents the quality filter command
+var qualityfilterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Removes low quality sequences",
+	Long: `Removes low quality sequences.
+
+Removes the sequences whose mean Phred quality (over non gap characters) 
+is < --min-mean.
+
+Example:
+goalign quality filter -i reads.fq --min-mean 25 > filtered.fq
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var mean float64
+		removed := 0
+		if err = processQualityStream(func(s align.Sequence) (keep bool, err error) {
+			if mean, err = s.MeanQuality(); err != nil {
+				return
+			}
+			if mean < qualityMinMean {
+				removed++
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			return
+		}
+		if !qualityFilterQuiet {
+			io.PrintMessage(fmt.Sprintf("Removed sequences=%d", removed))
+		}
+		return
+	},
+}
+
+func init() {
+	qualityCmd.AddCommand(qualityfilterCmd)
+	qualityfilterCmd.PersistentFlags().Float64Var(&qualityMinMean, "min-mean", 20, "Minimum mean Phred quality: sequences having a lower mean quality are removed")
+	qualityfilterCmd.PersistentFlags().BoolVarP(&qualityFilterQuiet, "quiet", "q", false, "Do not print filtering stats")
+}
@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+var qualityMaskQuiet bool
+
+// qualitymaskCmd represents the quality mask command
+var qualitymaskCmd = &cobra.Command{
+	Use:   "mask",
+	Short: "Masks low quality bases",
+	Long: `Masks low quality bases.
+
+Replaces the bases whose Phred quality is < --min-qual with N. Gaps are not
+replaced. Qualities are not modified.
+
+Example:
+goalign quality mask -i reads.fq --min-qual 20 > masked.fq
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var nb int
+		total := 0
+		if err = processQualityStream(func(s align.Sequence) (keep bool, err error) {
+			if nb, err = s.MaskQuality(qualityMin, align.ALL_NUCLE); err != nil {
+				return
+			}
+			total += nb
+			return true, nil
+		}); err != nil {
+			return
+		}
+		if !qualityMaskQuiet {
+			io.PrintMessage(fmt.Sprintf("Masked bases=%d", total))
+		}
+		return
+	},
+}
+
+func init() {
+	qualityCmd.AddCommand(qualitymaskCmd)
+	qualitymaskCmd.PersistentFlags().IntVar(&qualityMin, "min-qual", 20, "Minimum Phred quality: bases having a lower quality are masked")
+	qualitymaskCmd.PersistentFlags().BoolVarP(&qualityMaskQuiet, "quiet", "q", false, "Do not print masking stats")
+}
@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+var qualityTrimMinLength int
+var qualityTrimQuiet bool
+
+// qualitytrimCmd represents the quality trim command
+var qualitytrimCmd = &cobra.Command{
+	Use:   "trim",
+	Short: "Trims low quality ends of sequences",
+	Long: `Trims low quality ends of sequences.
+
+Removes the bases at the start and at the end of each sequence, as long as 
+their Phred quality is < --min-qual. Qualities are trimmed as well.
+
+If --min-length is given, sequences shorter than this length after trimming
+are removed.
+
+Example:
+goalign quality trim -i reads.fq --min-qual 20 --min-length 50 > trimmed.fq
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var start, end int
+		trimmed, removed := 0, 0
+		if err = processQualityStream(func(s align.Sequence) (keep bool, err error) {
+			if start, end, err = s.TrimQuality(qualityMin); err != nil {
+				return
+			}
+			trimmed += start + end
+			if s.Length() < qualityTrimMinLength {
+				removed++
+				return false, nil
+			}
+			return true, nil
+		}); err != nil {
+			return
+		}
+		if !qualityTrimQuiet {
+			io.PrintMessage(fmt.Sprintf("Trimmed bases=%d", trimmed))
+			io.PrintMessage(fmt.Sprintf("Removed sequences=%d", removed))
+		}
+		return
+	},
+}
+
+func init() {
+	qualityCmd.AddCommand(qualitytrimCmd)
+	qualitytrimCmd.PersistentFlags().IntVar(&qualityMin, "min-qual", 20, "Minimum Phred quality: low quality ends are trimmed")
+	qualitytrimCmd.PersistentFlags().IntVar(&qualityTrimMinLength, "min-length", 0, "Minimum length of trimmed sequences: shorter sequences are removed")
+	qualitytrimCmd.PersistentFlags().BoolVarP(&qualityTrimQuiet, "quiet", "q", false, "Do not print trimming stats")
+}
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -19,7 +17,7 @@ var randomCmd = &cobra.Command{
 	Long: `Generate random sequences.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 		var a align.Alignment
 
 		if f, err = openWriteFile(randomOutput); err != nil {
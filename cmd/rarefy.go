@@ -2,9 +2,8 @@ package cmd
 
 import (
 	"bufio"
-	"compress/gzip"
 	"errors"
-	"os"
+	goio "io"
 	"strconv"
 	"strings"
 
@@ -37,12 +36,13 @@ Parameters are:
 from the number of sequences in the output alignment)
 * c: counts associated to each sequence (if the count of a sequence is missing, it 
 is considered as 0). Sum of counts of all sequences must be > n.
-* r: the number of replicates to generate (if r>1, output format will be phylip anyway)
+* r: the number of replicates to generate, written one after the other
+in the output format (see --output-format)
 
-Output: An alignment (phylip or fasta).
+Output: An alignment (in the output format).
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 		var counts map[string]int
 
 		if f, err = openWriteFile(rarefyOutput); err != nil {
@@ -64,9 +64,6 @@ Output: An alignment (phylip or fasta).
 				io.LogError(err)
 				return
 			}
-			if rarefyReplicates > 1 {
-				rootphylip = true
-			}
 			for i := 0; i < rarefyReplicates; i++ {
 				if sample, err = seqs.RarefySeqBag(rarefyNb, counts); err != nil {
 					io.LogError(err)
@@ -84,9 +81,6 @@ Output: An alignment (phylip or fasta).
 			}
 
 			for al := range aligns.Achan {
-				if rarefyReplicates > 1 {
-					rootphylip = true
-				}
 				for i := 0; i < rarefyReplicates; i++ {
 					if sample, err = al.Rarefy(rarefyNb, counts); err != nil {
 						io.LogError(err)
@@ -116,29 +110,16 @@ func init() {
 }
 
 func parseCountFile(file string) (counts map[string]int, err error) {
-	var f *os.File
+	var f goio.Closer
 	var r *bufio.Reader
-	var gr *gzip.Reader
 	var c int
 
 	counts = make(map[string]int)
 
-	if file == "stdin" || file == "-" {
-		f = os.Stdin
-	} else {
-		if f, err = os.Open(file); err != nil {
-			return
-		}
-	}
-
-	if strings.HasSuffix(file, ".gz") {
-		if gr, err = gzip.NewReader(f); err != nil {
-			return
-		}
-		r = bufio.NewReader(gr)
-	} else {
-		r = bufio.NewReader(f)
+	if f, r, err = utils.GetReader(file); err != nil {
+		return
 	}
+	defer f.Close()
 	l, e := utils.Readln(r)
 	for e == nil {
 		cols := strings.Split(l, "\t")
@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"errors"
-	"os"
+	"fmt"
+	"regexp"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
 	"github.com/spf13/cobra"
 )
 
@@ -52,7 +54,7 @@ while ignoring formatting options (phylip, etc.).
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var setregex, setreplace bool
-		var f *os.File
+		var f outputFile
 		var namemap map[string]string
 
 		setregex = cmd.Flags().Changed("regexp")
@@ -84,23 +86,43 @@ while ignoring formatting options (phylip, etc.).
 		}
 
 		if unaligned {
-			var seqs align.SeqBag
+			var r *regexp.Regexp
+			var mapf outputFile
 
-			if seqs, err = readsequences(infile); err != nil {
-				io.LogError(err)
-				return
+			if setregex && !renameCleanNames {
+				if r, err = regexp.Compile(renameRegexp); err != nil {
+					io.LogError(err)
+					return
+				}
 			}
-			if renameCleanNames {
-				seqs.CleanNames(namemap)
-			} else if setregex {
-				if err = seqs.RenameRegexp(renameRegexp, renameReplace, namemap); err != nil {
+			// The mapping between old and new names is written while sequences
+			// are renamed, instead of being kept in memory
+			if setregex || renameCleanNames {
+				if mapf, err = openWriteFile(renameMap); err != nil {
 					io.LogError(err)
 					return
 				}
-			} else {
-				seqs.Rename(namemap)
+				defer closeWriteFile(mapf, renameMap)
+			}
+			// Sequences are renamed one by one, without loading the whole file
+			if err = processSequencesStream(infile, f, func(s align.Sequence, w *fasta.StreamWriter) error {
+				newname, ok := namemap[s.Name()]
+				if renameCleanNames {
+					newname, ok = align.CleanName(s.Name()), true
+				} else if setregex {
+					newname, ok = r.ReplaceAllString(s.Name(), renameReplace), true
+				}
+				if mapf != nil && ok {
+					fmt.Fprintf(mapf, "%s\t%s\n", s.Name(), newname)
+				}
+				if ok {
+					s.SetName(newname)
+				}
+				return w.Write(s)
+			}); err != nil {
+				io.LogError(err)
+				return
 			}
-			writeSequences(seqs, f)
 		} else {
 			var aligns *align.AlignChannel
 			if aligns, err = readalign(infile); err != nil {
@@ -126,7 +148,7 @@ while ignoring formatting options (phylip, etc.).
 			}
 		}
 
-		if (setregex || renameCleanNames) && renameMap != "None" {
+		if !unaligned && (setregex || renameCleanNames) && renameMap != "None" {
 			writeNameMap(namemap, renameMap)
 		}
 
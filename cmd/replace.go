@@ -2,10 +2,12 @@ package cmd
 
 import (
 	"errors"
-	"os"
+	"regexp"
+	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
 	"github.com/spf13/cobra"
 )
 
@@ -22,8 +24,7 @@ If the replacement changes sequence length, then returns an error.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
-		var seqs align.SeqBag
+		var f outputFile
 
 		if !cmd.Flags().Changed("old") || !cmd.Flags().Changed("old") {
 			err = errors.New("--old and --new must be specified")
@@ -37,15 +38,27 @@ If the replacement changes sequence length, then returns an error.
 		defer closeWriteFile(f, replaceOutput)
 
 		if unaligned {
-			if seqs, err = readsequences(infile); err != nil {
-				io.LogError(err)
-				return
+			var r *regexp.Regexp
+
+			if replaceRegexp {
+				if r, err = regexp.Compile(replaceOld); err != nil {
+					io.LogError(err)
+					return
+				}
 			}
-			if err = seqs.Replace(replaceOld, replaceNew, replaceRegexp); err != nil {
+			// Sequences are processed one by one, without loading the whole file
+			if err = processSequencesStream(infile, f, func(s align.Sequence, w *fasta.StreamWriter) error {
+				var newseq string
+				if replaceRegexp {
+					newseq = r.ReplaceAllString(s.Sequence(), replaceNew)
+				} else {
+					newseq = strings.Replace(s.Sequence(), replaceOld, replaceNew, -1)
+				}
+				return w.Write(align.NewSequence(s.Name(), []rune(newseq), s.Comment()))
+			}); err != nil {
 				io.LogError(err)
 				return
 			}
-			writeSequences(seqs, f)
 		} else {
 			if aligns, err = readalign(infile); err != nil {
 				io.LogError(err)
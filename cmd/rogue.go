@@ -15,8 +15,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -46,8 +44,8 @@ S4 12345678    S4 1234567
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var namefile *os.File
-		var f *os.File
+		var namefile outputFile
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -2,23 +2,32 @@ package cmd
 
 import (
 	"bufio"
-	"compress/gzip"
+	"bytes"
 	"errors"
 	"fmt"
 	goio "io"
 	"math/rand"
 	"os"
+	"regexp"
 	"runtime"
 	"strings"
 	"time"
 
 	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/a3m"
 	"github.com/evolbioinfo/goalign/io/clustal"
+	"github.com/evolbioinfo/goalign/io/embl"
 	"github.com/evolbioinfo/goalign/io/fasta"
+	"github.com/evolbioinfo/goalign/io/fastq"
+	"github.com/evolbioinfo/goalign/io/genbank"
+	"github.com/evolbioinfo/goalign/io/maf"
 	"github.com/evolbioinfo/goalign/io/nexus"
 	"github.com/evolbioinfo/goalign/io/paml"
 	"github.com/evolbioinfo/goalign/io/partition"
 	"github.com/evolbioinfo/goalign/io/phylip"
+	"github.com/evolbioinfo/goalign/io/stockholm"
+	"github.com/evolbioinfo/goalign/io/tnt"
 	"github.com/evolbioinfo/goalign/io/utils"
 	"github.com/evolbioinfo/goalign/version"
 	"github.com/fredericlemoine/cobrashell"
@@ -30,12 +39,23 @@ var infile string
 var rootphylip bool
 var rootnexus bool
 var rootclustal bool
+var rootstockholm bool
+var rootgenbank bool
+var rootembl bool
+var rootfastq bool
+var roota2m bool
+var roota3m bool
+var roota3mdiscard bool
+var roota3mmaxgaps float64
+var rootmaf bool
 var rootcpus int
 var rootinputstrict bool = false
 var rootoutputstrict bool = false
 var rootoutputoneline = false
 var rootoutputnoblock = false
 var rootAutoDetectInputFormat bool
+var rootoutputformat string
+var rootcompress string
 var seed int64 = -1
 var unaligned bool
 var ignoreidentical = false
@@ -65,21 +85,51 @@ Input alignment file formats:
 2. Phylip (-p option)
 3. Nexus (-x option)
 4. Clustal (-u option)
-4. Auto detect (--auto-detect option). In that case, it will test input formats in the following order:
-    1. Fasta
-    2. Nexus
-    3. Phylip
-    4. Clustal
-    If none of these formats is recognized, then will exit with an error 
+5. Stockholm (--stockholm option)
+6. GenBank (--genbank option)
+7. EMBL (--embl option)
+8. FASTQ (--fastq option)
+9. A2M/A3M (--a2m or --a3m option)
+10. MAF (--maf option)
+11. Auto detect (--auto-detect option). In that case, the first 64kB of the input are
+    examined, and each known format (Fasta, FASTQ, A3M with #A3M# header, MAF, Stockholm, 
+    GenBank, EMBL, Nexus, Clustal -including MUSCLE and PROBCONS headers- and Phylip) 
+    is given a confidence score. The chosen format and the reason of the choice are 
+    printed on stderr. If no format is recognized, or if several formats are equally 
+    likely, then will exit with an error listing the candidate formats.
+
+Compressed inputs (gzip, bzip2, xz and zstd) are detected by their content (magic bytes), 
+whatever their extension. Outputs are compressed according to their extension (.gz, .bz2, 
+.xz or .zst), or in the format given by --compress (also applies to stdout).
+
+GenBank, EMBL and FASTQ files are also recognized without any option by the commands 
+reading unaligned sequences. GenBank and EMBL feature tables are used by orf, translate, 
+subseq and phase (CDS features). FASTQ qualities are used by the quality commands, 
+and are kept by subseq, trim seq and reformat fastq.
+
+A2M/A3M insert states (lower case characters) are expanded into gap padded 
+columns, unless --a3m-discard-inserts is given. In A2M/A3M output, columns 
+having more than --a3m-max-gaps of gaps are written as insert columns.
+
+Each MAF alignment block is considered as an alignment. Source coordinates of 
+MAF sequences are kept, and blocks may be stitched into a single alignment 
+with goalign stitch.
 
 Please note that in --auto-detect mode, phylip format is considered as not strict!
+
+Output alignment format is by default the same as the input format, unless 
+--output-format is given (fasta, fastq, a2m, a3m, maf, phylip, nexus, clustal, stockholm, paml, tnt).
 `,
-	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) (err error) {
 		runtime.GOMAXPROCS(rootcpus)
 		if seed == -1 {
 			seed = time.Now().UTC().UnixNano()
 		}
 		rand.Seed(seed)
+		if rootoutputformat != "" {
+			_, err = utils.GetWriter(rootoutputformat, writerOptions())
+		}
+		return
 	},
 	Run: func(cmd *cobra.Command, args []string) {
 		s := cobrashell.New()
@@ -92,7 +142,39 @@ Please note that in --auto-detect mode, phylip format is considered as not stric
 	},
 }
 
-// Read sequences (possibly not aligned) from a fasta file
+// sequenceParser is implemented by the parsers of unaligned sequences
+type sequenceParser interface {
+	IgnoreIdentical(ignore bool)
+	ParseUnalign() (align.SeqBag, error)
+}
+
+// streamParser is implemented by the parsers able to stream sequences one by one
+type streamParser interface {
+	ParseStream(seqs *align.SequenceChannel)
+}
+
+// newSequenceParser returns the parser of unaligned sequences: GenBank, EMBL or
+// FASTQ if given by the options or if the input starts like a GenBank, EMBL or
+// FASTQ file, A2M/A3M if given by the options, fasta otherwise.
+func newSequenceParser(r *bufio.Reader) (p sequenceParser) {
+	if rootgenbank || (!rootembl && !rootfastq && utils.IsGenBank(r)) {
+		p = genbank.NewParser(r)
+	} else if rootembl || (!rootfastq && utils.IsEMBL(r)) {
+		p = embl.NewParser(r)
+	} else if rootfastq || utils.IsFastq(r) {
+		p = fastq.NewParser(r)
+	} else if roota2m || roota3m {
+		ap := a3m.NewParser(r)
+		ap.DiscardInserts(roota3mdiscard)
+		p = ap
+	} else {
+		p = fasta.NewParser(r)
+	}
+	p.IgnoreIdentical(ignoreidentical)
+	return
+}
+
+// Read sequences (possibly not aligned) from a fasta, FASTQ, GenBank or EMBL file
 func readsequences(file string) (sequences align.SeqBag, err error) {
 	var fi goio.Closer
 	var r *bufio.Reader
@@ -102,17 +184,151 @@ func readsequences(file string) (sequences align.SeqBag, err error) {
 	}
 	defer fi.Close()
 
-	p := fasta.NewParser(r)
-	p.IgnoreIdentical(ignoreidentical)
-	if sequences, err = p.ParseUnalign(); err != nil {
+	if sequences, err = newSequenceParser(r).ParseUnalign(); err != nil {
+		err = alignio.SetParseErrorFile(err, file)
 		return
 	}
 
 	return
 }
 
-// Read aligned sequences from an input file
+// Read sequences (possibly not aligned) from a fasta or FASTQ file, one by one.
+// Sequences are sent to the returned channel while the file is parsed,
+// so that the whole file is never loaded in memory.
+//
+// The channel must be read until it is closed (see drainSequences), otherwise
+// the goroutines parsing the file are blocked forever.
+func readsequencesstream(file string) (seqs *align.SequenceChannel, err error) {
+	var fi goio.Closer
+	var r *bufio.Reader
+
+	parsed := &align.SequenceChannel{}
+	seqs = &align.SequenceChannel{}
+
+	if fi, r, err = utils.GetReader(file); err != nil {
+		return
+	}
+	parsed.Schan = make(chan align.Sequence, 100)
+	go func() {
+		defer fi.Close()
+		p := newSequenceParser(r)
+		if sp, ok := p.(streamParser); ok {
+			sp.ParseStream(parsed)
+			return
+		}
+		// GenBank, EMBL and A2M/A3M files are not streamed
+		var sb align.SeqBag
+		if sb, parsed.Err = p.ParseUnalign(); parsed.Err == nil {
+			for i := 0; i < sb.NbSequences(); i++ {
+				s, _ := sb.Sequence(i)
+				parsed.Schan <- s
+			}
+		}
+		close(parsed.Schan)
+	}()
+
+	// Parse errors refer to the input file
+	seqs.Schan = make(chan align.Sequence, 100)
+	go func() {
+		for s := range parsed.Schan {
+			seqs.Schan <- s
+		}
+		seqs.Err = alignio.SetParseErrorFile(parsed.Err, file)
+		close(seqs.Schan)
+	}()
+	return
+}
+
+// processSequencesStream reads sequences from the input fasta file one by one,
+// and applies the given function to each of them. The function may write zero,
+// one or several sequences with the given fasta writer, that writes to f.
+//
+// Sequences are not kept in memory, only their names (to rename
+// duplicates, see fasta.Parser.ParseStream).
+func processSequencesStream(file string, f outputFile, process func(s align.Sequence, w *fasta.StreamWriter) error) (err error) {
+	var seqs *align.SequenceChannel
+
+	if seqs, err = readsequencesstream(file); err != nil {
+		return
+	}
+	defer drainSequences(seqs)
+	w := fasta.NewStreamWriter(f)
+	defer w.Flush()
+	for s := range seqs.Schan {
+		if err = process(s, w); err != nil {
+			return
+		}
+	}
+	err = seqs.Err
+	return
+}
+
+// drainSequences reads the remaining sequences of the channel, so that the
+// goroutines parsing the input file end if sequences are not all processed
+// (ex: after an error). Does nothing if the channel is already closed.
+func drainSequences(seqs *align.SequenceChannel) {
+	for range seqs.Schan {
+	}
+}
+
+// openFastaIndex returns an indexed reader of the input file if it is a local
+// fasta file having a samtools index next to it (file.fai, and file.gzi if it
+// is compressed with bgzip, see goalign index), and nil otherwise.
+//
+// The index is not used if another input format is given, if identical
+// sequences are ignored, or if the file is more recent than its index.
+// Indexed sequences are checked as by readalign (characters, and lengths
+// for alignments), errors being located at the header of the offending
+// sequence (see fasta.IndexedReader.SequenceError).
+func openFastaIndex(file string) *fasta.IndexedReader {
+	var fi, fai os.FileInfo
+	var err error
+	var ir *fasta.IndexedReader
+
+	if rootphylip || rootnexus || rootclustal || rootstockholm || rootgenbank || rootembl || rootfastq ||
+		roota2m || roota3m || rootmaf || rootAutoDetectInputFormat || ignoreidentical {
+		return nil
+	}
+	if fi, err = os.Stat(file); err != nil || !fi.Mode().IsRegular() {
+		return nil
+	}
+	if fai, err = os.Stat(file + ".fai"); err != nil {
+		return nil
+	}
+	if fai.ModTime().Before(fi.ModTime()) {
+		alignio.PrintMessage(fmt.Sprintf("Fasta index %s.fai is older than %s, not used", file, file))
+		return nil
+	}
+	if ir, err = fasta.NewIndexedReader(file); err != nil {
+		alignio.PrintMessage(fmt.Sprintf("Fasta index of %s not used: %v", file, err))
+		return nil
+	}
+	return ir
+}
+
+// Read aligned sequences from an input file. Parse errors
+// (see alignio.ParseError) refer to the input file.
 func readalign(file string) (alchan *align.AlignChannel, err error) {
+	var parsed *align.AlignChannel
+
+	if parsed, err = parsealign(file); err != nil {
+		err = alignio.SetParseErrorFile(err, file)
+		return
+	}
+	alchan = &align.AlignChannel{Achan: make(chan align.Alignment, 15)}
+	go func() {
+		for al := range parsed.Achan {
+			alchan.Achan <- al
+		}
+		alchan.Err = alignio.SetParseErrorFile(parsed.Err, file)
+		close(alchan.Achan)
+	}()
+	return
+}
+
+// parsealign parses the aligned sequences of the input file, in the
+// format given by the options, or detected with --auto-detect
+func parsealign(file string) (alchan *align.AlignChannel, err error) {
 	var fi goio.Closer
 	var r *bufio.Reader
 	var format int
@@ -123,7 +339,13 @@ func readalign(file string) (alchan *align.AlignChannel, err error) {
 		return
 	}
 	if rootAutoDetectInputFormat {
-		if alchan, format, err = utils.ParseMultiAlignmentsAuto(fi, r, rootinputstrict); err != nil {
+		var best utils.FormatScore
+		if best, _, err = utils.SniffFormat(r); err != nil {
+			return
+		}
+		format = best.Format
+		alignio.PrintSimpleMessage(fmt.Sprintf("Detected input format: %s", best))
+		if alchan, err = utils.ParseMultiAlignments(fi, r, format, rootinputstrict); err != nil {
 			return
 		}
 		if format == align.FORMAT_PHYLIP {
@@ -132,6 +354,18 @@ func readalign(file string) (alchan *align.AlignChannel, err error) {
 			rootnexus = true
 		} else if format == align.FORMAT_CLUSTAL {
 			rootclustal = true
+		} else if format == align.FORMAT_STOCKHOLM {
+			rootstockholm = true
+		} else if format == align.FORMAT_GENBANK {
+			rootgenbank = true
+		} else if format == align.FORMAT_EMBL {
+			rootembl = true
+		} else if format == align.FORMAT_FASTQ {
+			rootfastq = true
+		} else if format == align.FORMAT_A3M {
+			roota3m = true
+		} else if format == align.FORMAT_MAF {
+			rootmaf = true
 		}
 	} else {
 		if rootphylip {
@@ -142,17 +376,72 @@ func readalign(file string) (alchan *align.AlignChannel, err error) {
 				pp.ParseMultiple(alchan)
 				fi.Close()
 			}()
-		} else if rootnexus {
+		} else if rootstockholm {
+			alchan.Achan = make(chan align.Alignment, 15)
+			go func() {
+				sp := stockholm.NewParser(r)
+				sp.IgnoreIdentical(ignoreidentical)
+				sp.ParseMultiple(alchan)
+				fi.Close()
+			}()
+		} else if rootmaf {
+			alchan.Achan = make(chan align.Alignment, 15)
+			go func() {
+				mp := maf.NewParser(r)
+				mp.IgnoreIdentical(ignoreidentical)
+				mp.ParseMultiple(alchan)
+				fi.Close()
+			}()
+		} else if rootfastq {
 			var al align.Alignment
-			np := nexus.NewParser(r)
-			np.IgnoreIdentical(ignoreidentical)
-			if al, err = np.Parse(); err != nil {
+			fp := fastq.NewParser(r)
+			fp.IgnoreIdentical(ignoreidentical)
+			if al, err = fp.Parse(); err != nil {
 				return
 			}
 			alchan.Achan = make(chan align.Alignment, 1)
 			alchan.Achan <- al
 			fi.Close()
 			close(alchan.Achan)
+		} else if roota2m || roota3m {
+			var al align.Alignment
+			ap := a3m.NewParser(r)
+			ap.IgnoreIdentical(ignoreidentical)
+			ap.DiscardInserts(roota3mdiscard)
+			if al, err = ap.Parse(); err != nil {
+				return
+			}
+			alchan.Achan = make(chan align.Alignment, 1)
+			alchan.Achan <- al
+			fi.Close()
+			close(alchan.Achan)
+		} else if rootgenbank || rootembl {
+			var al align.Alignment
+			var p interface {
+				IgnoreIdentical(bool)
+				Parse() (align.Alignment, error)
+			}
+			if rootgenbank {
+				p = genbank.NewParser(r)
+			} else {
+				p = embl.NewParser(r)
+			}
+			p.IgnoreIdentical(ignoreidentical)
+			if al, err = p.Parse(); err != nil {
+				return
+			}
+			alchan.Achan = make(chan align.Alignment, 1)
+			alchan.Achan <- al
+			fi.Close()
+			close(alchan.Achan)
+		} else if rootnexus {
+			alchan.Achan = make(chan align.Alignment, 15)
+			go func() {
+				np := nexus.NewParser(r)
+				np.IgnoreIdentical(ignoreidentical)
+				np.ParseMultiple(alchan)
+				fi.Close()
+			}()
 		} else if rootclustal {
 			var al align.Alignment
 			cp := clustal.NewParser(r)
@@ -196,16 +485,27 @@ func init() {
 	RootCmd.PersistentFlags().BoolVarP(&rootphylip, "phylip", "p", false, "Alignment is in phylip? default fasta")
 	RootCmd.PersistentFlags().BoolVarP(&rootnexus, "nexus", "x", false, "Alignment is in nexus? default fasta")
 	RootCmd.PersistentFlags().BoolVarP(&rootclustal, "clustal", "u", false, "Alignment is in clustal? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&rootstockholm, "stockholm", false, "Alignment is in stockholm? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&rootgenbank, "genbank", false, "Sequences are in GenBank flat file format? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&rootembl, "embl", false, "Sequences are in EMBL flat file format? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&rootfastq, "fastq", false, "Sequences are in FASTQ format? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&roota2m, "a2m", false, "Alignment is in A2M? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&roota3m, "a3m", false, "Alignment is in A3M? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&rootmaf, "maf", false, "Alignment is in MAF (1 alignment per block)? default fasta")
+	RootCmd.PersistentFlags().BoolVar(&roota3mdiscard, "a3m-discard-inserts", false, "Discards A2M/A3M insert states instead of expanding them into gap padded columns (only used with --a2m/--a3m)")
 	RootCmd.PersistentFlags().IntVarP(&rootcpus, "threads", "t", 1, "Number of threads")
 	RootCmd.PersistentFlags().BoolVar(&ignoreidentical, "ignore-identical", false, "Ignore duplicated sequences that have the same name and same sequences")
 
 	RootCmd.PersistentFlags().Int64Var(&seed, "seed", -1, "Random Seed: -1 = nano seconds since 1970/01/01 00:00:00")
 	RootCmd.PersistentFlags().BoolVar(&rootinputstrict, "input-strict", false, "Strict phylip input format (only used with -p)")
-	RootCmd.PersistentFlags().BoolVar(&rootoutputstrict, "output-strict", false, "Strict phylip output format (only used with -p)")
-	RootCmd.PersistentFlags().BoolVar(&rootoutputoneline, "one-line", false, "Write Phylip sequences on 1 line (only used with -p)")
-	RootCmd.PersistentFlags().BoolVar(&rootoutputnoblock, "no-block", false, "Write Phylip sequences without space separated blocks (only used with -p)")
+	RootCmd.PersistentFlags().BoolVar(&rootoutputstrict, "output-strict", false, "Strict phylip output format (only used with phylip output)")
+	RootCmd.PersistentFlags().BoolVar(&rootoutputoneline, "one-line", false, "Write Phylip sequences on 1 line (only used with phylip output)")
+	RootCmd.PersistentFlags().BoolVar(&rootoutputnoblock, "no-block", false, "Write Phylip sequences without space separated blocks (only used with phylip output)")
+	RootCmd.PersistentFlags().Float64Var(&roota3mmaxgaps, "a3m-max-gaps", a3m.DEFAULT_MAX_GAPS, "Columns having a larger fraction of gaps are written as insert columns (only used with a2m/a3m output)")
+	RootCmd.PersistentFlags().StringVar(&rootoutputformat, "output-format", "", "Output alignment format ("+strings.Join(utils.WriterNames(), ", ")+"), default: same as input format")
+	RootCmd.PersistentFlags().StringVar(&rootcompress, "compress", "", "Compresses output files (gzip, bzip2, xz, zstd or none), default: according to the output file extension (.gz, .bz2, .xz, .zst)")
 
-	RootCmd.PersistentFlags().BoolVar(&rootAutoDetectInputFormat, "auto-detect", false, "Auto detects input format (overrides -p, -x and -u)")
+	RootCmd.PersistentFlags().BoolVar(&rootAutoDetectInputFormat, "auto-detect", false, "Auto detects input format (overrides -p, -x, -u, --stockholm, --genbank, --embl, --fastq, --a2m, --a3m and --maf)")
 
 	RootCmd.SetHelpTemplate(helptemplate)
 }
@@ -215,98 +515,151 @@ func initConfig() {
 
 }
 
-func writeAlign(al align.Alignment, f *os.File) {
-	if rootphylip {
-		f.WriteString(phylip.WriteAlignment(al, rootoutputstrict, rootoutputoneline, rootoutputnoblock))
-	} else if rootnexus {
-		f.WriteString(nexus.WriteAlignment(al))
-	} else if rootclustal {
-		f.WriteString(clustal.WriteAlignment(al))
-	} else {
-		f.WriteString(fasta.WriteAlignment(al))
+// writerOptions returns the output options given on the command line
+func writerOptions() utils.WriterOptions {
+	return utils.WriterOptions{
+		PhylipStrict:  rootoutputstrict,
+		PhylipOneLine: rootoutputoneline,
+		PhylipNoBlock: rootoutputnoblock,
+		A3MMaxGaps:    roota3mmaxgaps,
 	}
 }
 
-func writeAlignString(al align.Alignment) (out string) {
-	if rootphylip {
-		out = phylip.WriteAlignment(al, rootoutputstrict, rootoutputoneline, rootoutputnoblock)
+// outputFormat returns the name of the output alignment format:
+// --output-format if given, otherwise the input format
+func outputFormat() string {
+	if rootoutputformat != "" {
+		return rootoutputformat
+	} else if rootphylip {
+		return utils.FormatName(align.FORMAT_PHYLIP)
 	} else if rootnexus {
-		out = nexus.WriteAlignment(al)
+		return utils.FormatName(align.FORMAT_NEXUS)
 	} else if rootclustal {
-		out = clustal.WriteAlignment(al)
-	} else {
-		out = fasta.WriteAlignment(al)
+		return utils.FormatName(align.FORMAT_CLUSTAL)
+	} else if rootstockholm {
+		return utils.FormatName(align.FORMAT_STOCKHOLM)
+	} else if rootfastq {
+		return utils.FormatName(align.FORMAT_FASTQ)
+	} else if roota2m {
+		return utils.FormatName(align.FORMAT_A2M)
+	} else if roota3m {
+		return utils.FormatName(align.FORMAT_A3M)
+	} else if rootmaf {
+		return utils.FormatName(align.FORMAT_MAF)
 	}
-	return
+	return utils.FormatName(align.FORMAT_FASTA)
 }
 
-func alignExtension() (out string) {
-	if rootphylip {
-		out = ".ph"
-	} else if rootnexus {
-		out = ".nx"
-	} else if rootclustal {
-		out = ".clustal"
-	} else {
-		out = ".fa"
+// alignWriter returns the AlignmentWriter corresponding to the output format
+func alignWriter() utils.AlignmentWriter {
+	w, err := utils.GetWriter(outputFormat(), writerOptions())
+	if err != nil {
+		// Should not happen: output format is checked before running commands
+		alignio.ExitWithMessage(err)
 	}
-	return
+	return w
 }
 
-func writeSequences(seqs align.SeqBag, f *os.File) {
+func writeAlign(al align.Alignment, f outputFile) {
+	f.WriteString(alignWriter().WriteAlignment(al))
+}
+
+func writeAlignString(al align.Alignment) (out string) {
+	return alignWriter().WriteAlignment(al)
+}
+
+func alignExtension() (out string) {
+	return alignWriter().Extension()
+}
+
+func writeSequences(seqs align.SeqBag, f outputFile) {
 	f.WriteString(fasta.WriteAlignment(seqs))
 }
 
-func writeAlignFasta(al align.Alignment, f *os.File) {
+func writeAlignFasta(al align.Alignment, f outputFile) {
 	f.WriteString(fasta.WriteAlignment(al))
 }
 
-func writeAlignPhylip(al align.Alignment, f *os.File) {
+func writeAlignPhylip(al align.Alignment, f outputFile) {
 	f.WriteString(phylip.WriteAlignment(al, rootoutputstrict, rootoutputoneline, rootoutputnoblock))
 }
 
-func writeAlignNexus(al align.Alignment, f *os.File) {
+func writeAlignNexus(al align.Alignment, f outputFile) {
 	f.WriteString(nexus.WriteAlignment(al))
 }
 
-func writeAlignClustal(al align.Alignment, f *os.File) {
+func writeAlignClustal(al align.Alignment, f outputFile) {
 	f.WriteString(clustal.WriteAlignment(al))
 }
 
-func writeAlignPaml(al align.Alignment, f *os.File) {
+func writeAlignStockholm(al align.Alignment, f outputFile) {
+	f.WriteString(stockholm.WriteAlignment(al))
+}
+
+func writeAlignA2M(al align.Alignment, f outputFile) {
+	f.WriteString(a3m.WriteA2M(al, roota3mmaxgaps))
+}
+
+func writeAlignA3M(al align.Alignment, f outputFile) {
+	f.WriteString(a3m.WriteA3M(al, roota3mmaxgaps))
+}
+
+func writeAlignTnt(al align.Alignment, f outputFile) {
+	f.WriteString(tnt.WriteAlignment(al))
+}
+
+func writeAlignPaml(al align.Alignment, f outputFile) {
 	f.WriteString(paml.WriteAlignment(al))
 }
 
-func openWriteFile(file string) (f *os.File, err error) {
+// outputFile is a file opened for writing by openWriteFile
+type outputFile interface {
+	goio.WriteCloser
+	goio.StringWriter
+}
+
+// openWriteFile opens the given output file ("-" or "stdout": standard output,
+// "none": no output). The output is compressed in the format given by
+// --compress, or if not given, in the format corresponding to the extension
+// of the file (.gz, .bz2, .xz or .zst)
+func openWriteFile(file string) (f outputFile, err error) {
+	var of outputFile
+	var compression string
+
 	if file == "stdout" || file == "-" {
-		f = os.Stdout
+		of = os.Stdout
 	} else if file == "none" {
-		f, err = os.OpenFile(os.DevNull, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+		of, err = os.OpenFile(os.DevNull, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
 	} else {
-		f, err = os.Create(file)
+		of, err = os.Create(file)
+	}
+	if err != nil {
+		return
+	}
+	f = of
+
+	if rootcompress != "" {
+		if compression, err = utils.CompressionFromString(rootcompress); err != nil {
+			return
+		}
+	} else if file != "none" {
+		compression = utils.CompressionFromExtension(file)
+	}
+	if compression != utils.COMPRESSION_NONE {
+		f, err = utils.NewCompressedWriter(of, compression)
 	}
 	return
 }
 
 func readMapFile(file string, revert bool) (map[string]string, error) {
 	outmap := make(map[string]string, 0)
-	var mapfile *os.File
+	var mapfile goio.Closer
 	var err error
 	var reader *bufio.Reader
 
-	if mapfile, err = os.Open(file); err != nil {
+	if mapfile, reader, err = utils.GetReader(file); err != nil {
 		return outmap, err
 	}
-
-	if strings.HasSuffix(file, ".gz") {
-		if gr, err2 := gzip.NewReader(mapfile); err2 != nil {
-			return outmap, err2
-		} else {
-			reader = bufio.NewReader(gr)
-		}
-	} else {
-		reader = bufio.NewReader(mapfile)
-	}
 	line, e := utils.Readln(reader)
 	nl := 1
 	for e == nil {
@@ -331,20 +684,78 @@ func readMapFile(file string, revert bool) (map[string]string, error) {
 }
 
 func closeWriteFile(f goio.Closer, filename string) {
-	if filename != "-" && filename != "stdout" && filename != "none" {
+	if f == nil {
+		// Output file was not opened
+		return
+	}
+	if _, compressed := f.(*utils.CompressedWriter); compressed || (filename != "-" && filename != "stdout" && filename != "none") {
 		f.Close()
 	}
 }
 
-func parsePartition(partitionfile string, alilength int) (ps *align.PartitionSet, err error) {
+// DATA (or CHARACTERS) block of NEXUS alignment files
+var nexusDataBlock = regexp.MustCompile(`(?i)begin\s+(data|characters)\s*;`)
+
+// parsePartition parses the partition file of the given alignment. The format of the
+// file is detected (RAxML/RAxML-NG, NEXUS or PartitionFinder, see partition.DetectFormat).
+func parsePartition(partitionfile string, al align.Alignment) (ps *align.PartitionSet, err error) {
+	return readPartition(partitionfile, partition.FORMAT_AUTO, al.Length(), defaultModel(al))
+}
+
+// readPartition parses the partition file in the given format (partition.FORMAT_AUTO: detected).
+// NEXUS files having a DATA block are alignment files: partitions are then
+// taken from the SETS/ASSUMPTIONS/MRBAYES blocks following it.
+//
+// If alilength <= 0, the alignment length is the last site of the partitions.
+// model is given to the partitions whose model is not defined in the file.
+func readPartition(partitionfile string, format int, alilength int, model string) (ps *align.PartitionSet, err error) {
 	var f goio.Closer
 	var r *bufio.Reader
+	var content []byte
 
 	if f, r, err = utils.GetReader(partitionfile); err != nil {
 		return
 	}
 	defer f.Close()
-	p := partition.NewParser(r)
-	ps, err = p.Parse(alilength)
+
+	if format == partition.FORMAT_AUTO {
+		format = partition.DetectFormat(r)
+	}
+	if format == partition.FORMAT_NEXUS {
+		if content, err = goio.ReadAll(r); err != nil {
+			return
+		}
+		r = bufio.NewReader(bytes.NewReader(content))
+		if nexusDataBlock.Match(content) {
+			ps, err = parseNexusPartition(r, alilength)
+			return ps, alignio.SetParseErrorFile(err, partitionfile)
+		}
+	}
+	ps, err = partition.Parse(r, format, alilength, model)
+	return ps, alignio.SetParseErrorFile(err, partitionfile)
+}
+
+// defaultModel returns the model given to the partitions of the
+// alignment, when it is not defined: DNA or LG
+func defaultModel(al align.Alignment) string {
+	if al.Alphabet() == align.AMINOACIDS {
+		return "LG"
+	}
+	return "DNA"
+}
+
+// parseNexusPartition returns the partitions defined in the SETS/ASSUMPTIONS/MRBAYES
+// blocks following the first DATA block of the NEXUS input (see nexus.Parser).
+// If alilength > 0, it must be the length of the NEXUS alignment.
+func parseNexusPartition(r *bufio.Reader, alilength int) (ps *align.PartitionSet, err error) {
+	var al align.Alignment
+	if al, err = nexus.NewParser(r).Parse(); err != nil {
+		return
+	}
+	if ps = al.Partitions(); ps == nil {
+		err = fmt.Errorf("No partition defined in the NEXUS file")
+	} else if alilength > 0 && ps.AliLength() != alilength {
+		err = fmt.Errorf("Length of the NEXUS partitions (%d) is different from the alignment length (%d)", ps.AliLength(), alilength)
+	}
 	return
 }
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -34,7 +32,7 @@ divide the output file with 'goalign divide' for example.
 
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 
 		if f, err = openWriteFile(sampleseqOutput); err != nil {
 			io.LogError(err)
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -24,7 +23,7 @@ and with a given length.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 		var subalign align.Alignment
 
 		if aligns, err = readalign(infile); err != nil {
@@ -40,13 +39,9 @@ and with a given length.
 		}
 
 		var name string = siteout
-		var extension string = "fa"
-		if rootphylip {
-			extension = "phy"
-		}
 		for i := 0; i < sitenb; i++ {
 			if sitenb > 1 {
-				name = fmt.Sprintf("%s_%d.%s", siteout, i, extension)
+				name = fmt.Sprintf("%s_%d%s", siteout, i, alignExtension())
 			}
 			if f, err = openWriteFile(name); err != nil {
 				io.LogError(err)
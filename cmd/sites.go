@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -37,8 +35,8 @@ goalign shuffle sites -i align.fasta -r 0.5
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
-		var nameFile *os.File
+		var f outputFile
+		var nameFile outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -1,8 +1,6 @@
 package cmd
 
 import (
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -17,7 +15,7 @@ var sortCmd = &cobra.Command{
 	Long: `sorts input algignment by sequence name.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 
 		if f, err = openWriteFile(sortOutput); err != nil {
 			io.LogError(err)
@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/spf13/cobra"
 
@@ -20,17 +19,24 @@ var splitCmd = &cobra.Command{
 	Short: "Splits an input alignment given a partition file",
 	Long: `Splits an input alignment given a partition file.
 
+The partition file may be a RAxML/RAxML-NG partition file, a PartitionFinder
+configuration file, an IQ-TREE NEXUS partition file, or a NEXUS alignment file
+defining partitions in SETS, ASSUMPTIONS or MRBAYES blocks (CHARSET,
+CHARPARTITION). Its format is detected (see goalign partition convert). If --partition is not given and the input alignment is
+a NEXUS file defining partitions, they are used directly.
+
 Output alignment files will be in the same format as input alignment, 
 with file names corresponding to partition names.
 
 Example of usage:
 goalign split -i align.phylip --partition partition.txt 
+goalign split -i align.nex -x
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var splitAligns []align.Alignment
 
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -45,7 +51,7 @@ goalign split -i align.phylip --partition partition.txt
 		}
 
 		if splitpartitionstr != "none" {
-			if splitpartition, err = parsePartition(splitpartitionstr, align.Length()); err != nil {
+			if splitpartition, err = parsePartition(splitpartitionstr, align); err != nil {
 				io.LogError(err)
 				return
 			}
@@ -53,7 +59,7 @@ goalign split -i align.phylip --partition partition.txt
 				io.LogError(err)
 				return
 			}
-		} else {
+		} else if splitpartition = align.Partitions(); splitpartition == nil {
 			err = fmt.Errorf("Partition file must be provided")
 			io.LogError(err)
 			return
@@ -82,5 +88,5 @@ func init() {
 	RootCmd.AddCommand(splitCmd)
 
 	splitCmd.PersistentFlags().StringVarP(&splitprefix, "out-prefix", "o", "", "Prefix of output files")
-	splitCmd.PersistentFlags().StringVar(&splitpartitionstr, "partition", "none", "File containing definition of the partitions")
+	splitCmd.PersistentFlags().StringVar(&splitpartitionstr, "partition", "none", "File containing definition of the partitions (RAxML-like or NEXUS)")
 }
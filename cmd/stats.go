@@ -8,12 +8,14 @@ import (
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/evolbioinfo/goalign/io/countprofile"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
 var statpersequences bool
 var statrefsequence string
 var statcountprofile string
+var statsformat string
 
 // statsCmd represents the stats command
 var statsCmd = &cobra.Command{
@@ -58,16 +60,34 @@ site  A C G T
 ...
 n...
 
+If --format is given (tsv, csv or json), statistics are printed as a table
+(tab separated, comma separated, or as a json array of objects), with one 
+record per alignment, or one record per sequence of each alignment 
+with --per-sequences. Each record has an "alignment" field giving the 
+index of its alignment in the input file (0-based). Character counts 
+and frequencies are given in nb_<char> and freq_<char> fields 
+(per sequence: <char> fields).
+
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
+		var t *table.Table
+		var structured bool
 
+		if structured, err = statsStructured(); err != nil {
+			io.LogError(err)
+			return
+		}
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
 			return
 		}
+		stats := make([]align.AlignmentStats, 0)
+		seqstats := make([][]align.SequenceStats, 0)
 		for al := range aligns.Achan {
-			if !statpersequences {
+			if !statpersequences && structured {
+				stats = append(stats, al.Stats())
+			} else if !statpersequences {
 				fmt.Fprintf(os.Stdout, "length\t%d\n", al.Length())
 				fmt.Fprintf(os.Stdout, "nseqs\t%d\n", al.NbSequences())
 				fmt.Fprintf(os.Stdout, "avgalleles\t%.4f\n", al.AvgAllelesPerSite())
@@ -103,18 +123,162 @@ n...
 					}
 					refseq = align.NewSequence("ref", []rune(s), "")
 				}
-				err = printAllSequenceStats(al, refseq, profile)
+				if structured {
+					var st []align.SequenceStats
+					if st, err = al.SequenceStats(refseq, profile); err != nil {
+						io.LogError(err)
+						return
+					}
+					seqstats = append(seqstats, st)
+				} else if err = printAllSequenceStats(al, refseq, profile); err != nil {
+					io.LogError(err)
+					return
+				}
 			}
 		}
 
 		if aligns.Err != nil {
 			err = aligns.Err
 			io.LogError(err)
+			return
+		}
+
+		if structured {
+			if statpersequences {
+				t, err = sequenceStatsTable(seqstats, statrefsequence != "none", statcountprofile != "none")
+			} else {
+				t, err = alignmentStatsTable(stats)
+			}
+			if err != nil {
+				io.LogError(err)
+				return
+			}
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
+			}
 		}
 		return
 	},
 }
 
+// statsStructured returns true if the statistics must be
+// written as a table (--format tsv, csv or json), false if
+// they must be written as text (--format text)
+func statsStructured() (structured bool, err error) {
+	if statsformat == "text" {
+		return false, nil
+	}
+	_, err = table.FormatFromString(statsformat)
+	return err == nil, err
+}
+
+// writeStatsTable writes the given table on stdout,
+// in the format given by --format (tsv, csv or json)
+func writeStatsTable(t *table.Table) (err error) {
+	var format int
+	if format, err = table.FormatFromString(statsformat); err != nil {
+		return
+	}
+	return t.Write(os.Stdout, format)
+}
+
+// alignmentStatsTable returns the table of the general statistics of
+// the alignments: one row per alignment, with character counts and frequencies
+// (nb_<char> and freq_<char> columns, for all characters of all alignments)
+func alignmentStatsTable(stats []align.AlignmentStats) (t *table.Table, err error) {
+	chars := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, st := range stats {
+		for _, c := range st.Characters {
+			if !seen[c.Char] {
+				seen[c.Char] = true
+				chars = append(chars, c.Char)
+			}
+		}
+	}
+	sort.Strings(chars)
+
+	columns := []string{"alignment", "length", "nseqs", "avgalleles", "variable_sites", "alphabet"}
+	for _, c := range chars {
+		columns = append(columns, "nb_"+c, "freq_"+c)
+	}
+	t = table.NewTable(columns...)
+	for i, st := range stats {
+		counts := make(map[string]align.CharCount)
+		for _, c := range st.Characters {
+			counts[c.Char] = c
+		}
+		row := []interface{}{i, st.Length, st.NbSequences, st.AvgAlleles, st.VariableSites, st.Alphabet}
+		for _, c := range chars {
+			row = append(row, counts[c].Count, counts[c].Freq)
+		}
+		if err = t.AddRow(row...); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// sequenceStatsTable returns the table of the statistics of the sequences
+// of the alignments: one row per sequence of each alignment, with the number
+// of occurences of each character (all characters of all alignments).
+// Columns comparing sequences to the reference or to the profile are
+// present only if ref or profile are true.
+func sequenceStatsTable(stats [][]align.SequenceStats, ref, profile bool) (t *table.Table, err error) {
+	chars := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, alstats := range stats {
+		for _, st := range alstats {
+			for c := range st.CharCounts {
+				if !seen[c] {
+					seen[c] = true
+					chars = append(chars, c)
+				}
+			}
+		}
+	}
+	sort.Strings(chars)
+
+	columns := []string{"alignment", "sequence", "gaps", "gapsstart", "gapsend", "gapsuniques"}
+	if profile {
+		columns = append(columns, "gapsnew", "gapsboth")
+	}
+	columns = append(columns, "gapsopenning", "mutuniques")
+	if profile {
+		columns = append(columns, "mutsnew", "mutsboth")
+	}
+	if ref {
+		columns = append(columns, "mutref")
+	}
+	columns = append(columns, "length")
+	columns = append(columns, chars...)
+
+	t = table.NewTable(columns...)
+	for i, alstats := range stats {
+		for _, st := range alstats {
+			row := []interface{}{i, st.Name, st.Gaps, st.GapsStart, st.GapsEnd, st.GapsUnique}
+			if profile {
+				row = append(row, *st.GapsNew, *st.GapsBoth)
+			}
+			row = append(row, st.GapsOpenning, st.MutUnique)
+			if profile {
+				row = append(row, *st.MutNew, *st.MutBoth)
+			}
+			if ref {
+				row = append(row, *st.MutRef)
+			}
+			row = append(row, st.Length)
+			for _, c := range chars {
+				row = append(row, st.CharCounts[c])
+			}
+			if err = t.AddRow(row...); err != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
 func printCharStats(align align.Alignment, only string) {
 	charmap := align.CharStats()
 
@@ -227,30 +391,12 @@ func printSequenceCharStats(sb align.SeqBag, only string) (err error) {
 }
 
 func printAllSequenceStats(al align.Alignment, refSequence align.Sequence, countProfile *align.CountProfile) (err error) {
-	var sequencemap map[rune]int
-
-	var numnewgaps []int // new gaps that are not found in the profile
-	var numnewmuts []int // new mutations that are not found in the profile
-
-	var nummutuniques []int  // mutations that are unique in the given alignment
-	var numgapsuniques []int // gaps that are unique in the given alignment
-
-	var nummutsboth []int // mutations that are unique in the given alignment and not found in the profile
-	var numgapsboth []int // gaps that are unique in the given alignment and not found in the profile
-
-	var nummutations int
-	var gaps int
-	var name string
-	var uniquechars []rune
-
-	uniquechars = al.UniqueCharacters()
+	var stats []align.SequenceStats
 
-	if numgapsuniques, numnewgaps, numgapsboth, err = al.NumGapsUniquePerSequence(countProfile); err != nil {
-		return
-	}
-	if nummutuniques, numnewmuts, nummutsboth, err = al.NumMutationsUniquePerSequence(countProfile); err != nil {
+	if stats, err = al.SequenceStats(refSequence, countProfile); err != nil {
 		return
 	}
+	uniquechars := al.UniqueCharacters()
 
 	fmt.Fprintf(os.Stdout, "sequence")
 	fmt.Fprintf(os.Stdout, "\tgaps")
@@ -276,38 +422,28 @@ func printAllSequenceStats(al align.Alignment, refSequence align.Sequence, count
 	}
 
 	fmt.Fprintf(os.Stdout, "\n")
-	for i, s := range al.Sequences() {
-		if sequencemap, err = al.CharStatsSeq(i); err != nil {
-			return
-		}
-		name = s.Name()
-		gaps = s.NumGaps()
-		fmt.Printf("%s", name)
-		fmt.Printf("\t%d", gaps)
-		fmt.Printf("\t%d", s.NumGapsFromStart())
-		fmt.Printf("\t%d", s.NumGapsFromEnd())
-		fmt.Printf("\t%d", numgapsuniques[i])
+	for _, st := range stats {
+		fmt.Printf("%s", st.Name)
+		fmt.Printf("\t%d", st.Gaps)
+		fmt.Printf("\t%d", st.GapsStart)
+		fmt.Printf("\t%d", st.GapsEnd)
+		fmt.Printf("\t%d", st.GapsUnique)
 		if countProfile != nil {
-			fmt.Printf("\t%d", numnewgaps[i])
-			fmt.Printf("\t%d", numgapsboth[i])
+			fmt.Printf("\t%d", *st.GapsNew)
+			fmt.Printf("\t%d", *st.GapsBoth)
 		}
-		fmt.Printf("\t%d", s.NumGapsOpenning())
-		fmt.Printf("\t%d", nummutuniques[i])
+		fmt.Printf("\t%d", st.GapsOpenning)
+		fmt.Printf("\t%d", st.MutUnique)
 		if countProfile != nil {
-			fmt.Printf("\t%d", numnewmuts[i])
-			fmt.Printf("\t%d", nummutsboth[i])
+			fmt.Printf("\t%d", *st.MutNew)
+			fmt.Printf("\t%d", *st.MutBoth)
 		}
 		if refSequence != nil {
-			if nummutations, err = s.NumMutationsComparedToReferenceSequence(al.Alphabet(), refSequence); err != nil {
-				io.LogError(err)
-				return
-			}
-			fmt.Printf("\t%d", nummutations)
+			fmt.Printf("\t%d", *st.MutRef)
 		}
-		fmt.Printf("\t%d", s.Length()-gaps)
+		fmt.Printf("\t%d", st.Length)
 		for _, k := range uniquechars {
-			nb := sequencemap[k]
-			fmt.Printf("\t%d", nb)
+			fmt.Printf("\t%d", st.CharCounts[string(k)])
 		}
 		fmt.Printf("\n")
 	}
@@ -331,4 +467,10 @@ func init() {
 	statsCmd.PersistentFlags().BoolVar(&statpersequences, "per-sequences", false, "Prints  statistics per alignment sequences")
 	statsCmd.PersistentFlags().StringVar(&statrefsequence, "ref-sequence", "none", "Reference sequence to compare each sequence with (only with --per-sequences")
 	statsCmd.PersistentFlags().StringVar(&statcountprofile, "count-profile", "none", "A profile to compare the alignment with, and to compute statistics faster (only with --per-sequences)")
+	addStatsFormatFlag(statsCmd)
+}
+
+// addStatsFormatFlag adds the --format option to the given command
+func addStatsFormatFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&statsformat, "format", "text", "Output format: text, tsv, csv or json")
 }
@@ -5,6 +5,7 @@ import (
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -14,31 +15,58 @@ var alphabetCmd = &cobra.Command{
 	Short: "Prints the alphabet detected for the input alignment",
 	Long: `Prints  the alphabet detected for the input alignment.
 
+If the input file contains several alignments, prints the alphabet of each of them.
+
+If --format is given (tsv, csv or json), the alphabet is printed as a table 
+(tab separated, comma separated, or as a json array of objects) with 
+one record per alignment having "alignment" (index of the alignment in the 
+input file, 0-based) and "alphabet" fields (only "alphabet" with --unaligned).
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var seqs align.SeqBag
+		var structured bool
+		var t *table.Table
 
+		if structured, err = statsStructured(); err != nil {
+			io.LogError(err)
+			return
+		}
 		if unaligned {
 			if seqs, err = readsequences(infile); err != nil {
 				io.LogError(err)
 				return
 			}
-			fmt.Println(seqs.AlphabetStr())
+			t = table.NewTable("alphabet")
+			if !structured {
+				fmt.Println(seqs.AlphabetStr())
+			} else if err = t.AddRow(seqs.AlphabetStr()); err != nil {
+				io.LogError(err)
+				return
+			}
 		} else {
-
 			if aligns, err = readalign(infile); err != nil {
 				io.LogError(err)
 				return
-			} else {
-
-				al, _ := <-aligns.Achan
-				if aligns.Err != nil {
-					err = aligns.Err
+			}
+			t = table.NewTable("alignment", "alphabet")
+			for al := range aligns.Achan {
+				if !structured {
+					fmt.Println(al.AlphabetStr())
+				} else if err = t.AddRow(len(t.Rows), al.AlphabetStr()); err != nil {
 					io.LogError(err)
 					return
 				}
-				fmt.Println(al.AlphabetStr())
+			}
+			if aligns.Err != nil {
+				err = aligns.Err
+				io.LogError(err)
+				return
+			}
+		}
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
 			}
 		}
 		return
@@ -47,5 +75,6 @@ var alphabetCmd = &cobra.Command{
 
 func init() {
 	alphabetCmd.PersistentFlags().BoolVar(&unaligned, "unaligned", false, "Considers sequences as unaligned and format fasta (phylip, nexus,... options are ignored)")
+	addStatsFormatFlag(alphabetCmd)
 	statsCmd.AddCommand(alphabetCmd)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/evolbioinfo/goalign/io/countprofile"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -43,19 +44,26 @@ var statGapsCmd = &cobra.Command{
 	1...
 	...
 	n...
+
+	If --format is given (tsv, csv or json), counts are printed as a table (tab separated, 
+	comma separated, or as a json array of objects) with one record per sequence of each 
+	alignment, having the fields: alignment (index of the alignment in the input file, 
+	0-based), sequence, and gapsstart, gapsend, gapsuniques (gapsnew and gapsboth with 
+	--count-profile), gapsopenning or gaps.
+
+	If the input file contains several alignments, will process all of them.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var profile *align.CountProfile
+		var structured bool
+		var t *table.Table
 
-		if aligns, err = readalign(infile); err != nil {
+		if structured, err = statsStructured(); err != nil {
 			io.LogError(err)
 			return
 		}
-
-		al, _ := <-aligns.Achan
-		if aligns.Err != nil {
-			err = aligns.Err
+		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
 			return
 		}
@@ -67,39 +75,108 @@ var statGapsCmd = &cobra.Command{
 			}
 		}
 
-		var numnewgaps []int     // new gaps that are not found in the profile
-		var numgapsuniques []int // gaps that are unique in the given alignment
-		var numgapsboth []int    // gaps that are unique in the given alignment and not found in the profile
+		t = newGapStatsTable()
+		nalign := 0
+		for al := range aligns.Achan {
+			var numnewgaps []int     // new gaps that are not found in the profile
+			var numgapsuniques []int // gaps that are unique in the given alignment
+			var numgapsboth []int    // gaps that are unique in the given alignment and not found in the profile
+
+			if statGapsUnique {
+				if numgapsuniques, numnewgaps, numgapsboth, err = al.NumGapsUniquePerSequence(profile); err != nil {
+					io.LogError(err)
+					return
+				}
+			}
 
-		if statGapsUnique {
-			if numgapsuniques, numnewgaps, numgapsboth, err = al.NumGapsUniquePerSequence(profile); err != nil {
-				io.LogError(err)
-				return
+			if structured {
+				if err = addGapStatsRows(t, nalign, al, numgapsuniques, numnewgaps, numgapsboth); err != nil {
+					io.LogError(err)
+					return
+				}
+				nalign++
+				continue
 			}
-		}
 
-		for i, s := range al.Sequences() {
-			if statGapsFromStart {
-				fmt.Printf("%s\t%d\n", s.Name(), s.NumGapsFromStart())
-			} else if statGapsFromEnd {
-				fmt.Printf("%s\t%d\n", s.Name(), s.NumGapsFromEnd())
-			} else if statGapsUnique {
-				fmt.Printf("%s\t%d", s.Name(), numgapsuniques[i])
-				if statGapsProfile != "none" {
-					fmt.Printf("\t%d\t%d", numnewgaps[i], numgapsboth[i])
+			for i, s := range al.Sequences() {
+				if statGapsFromStart {
+					fmt.Printf("%s\t%d\n", s.Name(), s.NumGapsFromStart())
+				} else if statGapsFromEnd {
+					fmt.Printf("%s\t%d\n", s.Name(), s.NumGapsFromEnd())
+				} else if statGapsUnique {
+					fmt.Printf("%s\t%d", s.Name(), numgapsuniques[i])
+					if statGapsProfile != "none" {
+						fmt.Printf("\t%d\t%d", numnewgaps[i], numgapsboth[i])
+					}
+					fmt.Printf("\n")
+				} else if statGapsOpenning {
+					fmt.Printf("%s\t%d\n", s.Name(), s.NumGapsOpenning())
+				} else {
+					fmt.Printf("%s\t%d\n", s.Name(), s.NumGaps())
 				}
-				fmt.Printf("\n")
-			} else if statGapsOpenning {
-				fmt.Printf("%s\t%d\n", s.Name(), s.NumGapsOpenning())
-			} else {
-				fmt.Printf("%s\t%d\n", s.Name(), s.NumGaps())
 			}
+			nalign++
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+			return
 		}
 
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
+			}
+		}
 		return
 	},
 }
 
+// newGapStatsTable returns an empty table of gap counts, whose columns depend
+// on the given options (--from-start, --from-end, --unique, etc.)
+func newGapStatsTable() *table.Table {
+	switch {
+	case statGapsFromStart:
+		return table.NewTable("alignment", "sequence", "gapsstart")
+	case statGapsFromEnd:
+		return table.NewTable("alignment", "sequence", "gapsend")
+	case statGapsUnique && statGapsProfile != "none":
+		return table.NewTable("alignment", "sequence", "gapsuniques", "gapsnew", "gapsboth")
+	case statGapsUnique:
+		return table.NewTable("alignment", "sequence", "gapsuniques")
+	case statGapsOpenning:
+		return table.NewTable("alignment", "sequence", "gapsopenning")
+	default:
+		return table.NewTable("alignment", "sequence", "gaps")
+	}
+}
+
+// addGapStatsRows adds the gap counts of each sequence of the
+// alignment (having index nalign in the input file) to the table
+func addGapStatsRows(t *table.Table, nalign int, al align.Alignment, numgapsuniques, numnewgaps, numgapsboth []int) (err error) {
+	for i, s := range al.Sequences() {
+		switch {
+		case statGapsFromStart:
+			err = t.AddRow(nalign, s.Name(), s.NumGapsFromStart())
+		case statGapsFromEnd:
+			err = t.AddRow(nalign, s.Name(), s.NumGapsFromEnd())
+		case statGapsUnique && statGapsProfile != "none":
+			err = t.AddRow(nalign, s.Name(), numgapsuniques[i], numnewgaps[i], numgapsboth[i])
+		case statGapsUnique:
+			err = t.AddRow(nalign, s.Name(), numgapsuniques[i])
+		case statGapsOpenning:
+			err = t.AddRow(nalign, s.Name(), s.NumGapsOpenning())
+		default:
+			err = t.AddRow(nalign, s.Name(), s.NumGaps())
+		}
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
 func init() {
 	statGapsCmd.PersistentFlags().BoolVar(&statGapsFromStart, "from-start", false, "Count gaps in each sequence from start of sequences (until a non gap character is encountered)")
 	statGapsCmd.PersistentFlags().BoolVar(&statGapsFromEnd, "from-end", false, "Count gaps in each sequence from end of sequences (until a non gap character is encountered)")
@@ -107,5 +184,7 @@ func init() {
 	statGapsCmd.PersistentFlags().BoolVar(&statGapsOpenning, "openning", false, "Count, in each sequence, the number of gaps openning (a strech of gaps is counted once)")
 	statGapsCmd.PersistentFlags().StringVar(&statGapsProfile, "count-profile", "none", "A profile to compare the alignment with, and to compute statistics faster (only with --unique)")
 
+	addStatsFormatFlag(statGapsCmd)
+
 	statsCmd.AddCommand(statGapsCmd)
 }
@@ -6,6 +6,7 @@ import (
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/evolbioinfo/goalign/io/countprofile"
+	"github.com/evolbioinfo/goalign/io/table"
 	"github.com/spf13/cobra"
 )
 
@@ -42,19 +43,25 @@ var statMutationsCmd = &cobra.Command{
 	...
 	n...
 
+	If --format is given (tsv, csv or json), counts are printed as a table (tab separated, 
+	comma separated, or as a json array of objects) with one record per sequence of each 
+	alignment, having the fields: alignment (index of the alignment in the input file, 
+	0-based), sequence, and mutref (--ref-sequence) or mutuniques (--unique, with mutsnew 
+	and mutsboth if --count-profile is given).
+
+	If the input file contains several alignments, will process all of them.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
 		var profile *align.CountProfile
+		var structured bool
+		var t *table.Table
 
-		if aligns, err = readalign(infile); err != nil {
+		if structured, err = statsStructured(); err != nil {
 			io.LogError(err)
 			return
 		}
-
-		al, _ := <-aligns.Achan
-		if aligns.Err != nil {
-			err = aligns.Err
+		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
 			return
 		}
@@ -66,46 +73,13 @@ var statMutationsCmd = &cobra.Command{
 			}
 		}
 
-		var nummutations []int
-		var numnewmuts []int  // new mutations that are not found in the profile
-		var nummutsboth []int // mutations that are unique in the given alignment and not found in the profile
-		var num int
-
 		if statMutationsRef != "none" {
-			var s string
-			var sb align.SeqBag
-			var ok bool
-			// We try to get the sequence from its name in the alignment
-			if s, ok = al.GetSequence(statMutationsRef); !ok {
-				//Else we open the potential file
-				if sb, err = readsequences(statMutationsRef); err != nil {
-					io.LogError(err)
-					return
-				}
-				if sb.NbSequences() < 1 {
-					err = fmt.Errorf("The reference sequence file does not contain any sequence")
-					io.LogError(err)
-					return
-				}
-				s, _ = sb.GetSequenceById(0)
-			}
-			for _, s2 := range al.Sequences() {
-				if num, err = s2.NumMutationsComparedToReferenceSequence(al.Alphabet(), align.NewSequence("ref", []rune(s), "")); err != nil {
-					io.LogError(err)
-					return
-				}
-				fmt.Printf("%s\t%d\n", s2.Name(), num)
-			}
-
+			t = table.NewTable("alignment", "sequence", "mutref")
 		} else if statMutationsUnique {
-			nummutations, numnewmuts, nummutsboth, err = al.NumMutationsUniquePerSequence(profile)
-			for i, s := range al.Sequences() {
-				fmt.Printf("%s\t%d", s.Name(), nummutations[i])
-				if profile != nil {
-					fmt.Printf("\t%d", numnewmuts[i])
-					fmt.Printf("\t%d", nummutsboth[i])
-				}
-				fmt.Printf("\n")
+			if profile != nil {
+				t = table.NewTable("alignment", "sequence", "mutuniques", "mutsnew", "mutsboth")
+			} else {
+				t = table.NewTable("alignment", "sequence", "mutuniques")
 			}
 		} else {
 			err = fmt.Errorf("Mutations should be counted by comparing to a reference sequnce with --ref-sequence")
@@ -113,14 +87,110 @@ var statMutationsCmd = &cobra.Command{
 			return
 		}
 
+		nalign := 0
+		for al := range aligns.Achan {
+			if statMutationsRef != "none" {
+				err = mutationsToReference(t, nalign, al, structured)
+			} else {
+				err = mutationsUnique(t, nalign, al, profile, structured)
+			}
+			if err != nil {
+				io.LogError(err)
+				return
+			}
+			nalign++
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+			return
+		}
+
+		if structured {
+			if err = writeStatsTable(t); err != nil {
+				io.LogError(err)
+			}
+		}
 		return
 	},
 }
 
+// mutationsToReference prints (or adds to the table if structured is true)
+// the number of mutations of each sequence of the alignment (having index
+// nalign in the input file) compared to the reference sequence.
+func mutationsToReference(t *table.Table, nalign int, al align.Alignment, structured bool) (err error) {
+	var s string
+	var sb align.SeqBag
+	var ok bool
+	var num int
+
+	// We try to get the sequence from its name in the alignment
+	if s, ok = al.GetSequence(statMutationsRef); !ok {
+		//Else we open the potential file
+		if sb, err = readsequences(statMutationsRef); err != nil {
+			return
+		}
+		if sb.NbSequences() < 1 {
+			err = fmt.Errorf("The reference sequence file does not contain any sequence")
+			return
+		}
+		s, _ = sb.GetSequenceById(0)
+	}
+	for _, s2 := range al.Sequences() {
+		if num, err = s2.NumMutationsComparedToReferenceSequence(al.Alphabet(), align.NewSequence("ref", []rune(s), "")); err != nil {
+			return
+		}
+		if structured {
+			if err = t.AddRow(nalign, s2.Name(), num); err != nil {
+				return
+			}
+		} else {
+			fmt.Printf("%s\t%d\n", s2.Name(), num)
+		}
+	}
+	return
+}
+
+// mutationsUnique prints (or adds to the table if structured is true)
+// the number of unique mutations of each sequence of the alignment
+// (having index nalign in the input file).
+func mutationsUnique(t *table.Table, nalign int, al align.Alignment, profile *align.CountProfile, structured bool) (err error) {
+	var nummutations []int
+	var numnewmuts []int  // new mutations that are not found in the profile
+	var nummutsboth []int // mutations that are unique in the given alignment and not found in the profile
+
+	if nummutations, numnewmuts, nummutsboth, err = al.NumMutationsUniquePerSequence(profile); err != nil {
+		return
+	}
+	for i, s := range al.Sequences() {
+		if structured {
+			if profile != nil {
+				err = t.AddRow(nalign, s.Name(), nummutations[i], numnewmuts[i], nummutsboth[i])
+			} else {
+				err = t.AddRow(nalign, s.Name(), nummutations[i])
+			}
+			if err != nil {
+				return
+			}
+			continue
+		}
+		fmt.Printf("%s\t%d", s.Name(), nummutations[i])
+		if profile != nil {
+			fmt.Printf("\t%d", numnewmuts[i])
+			fmt.Printf("\t%d", nummutsboth[i])
+		}
+		fmt.Printf("\n")
+	}
+	return
+}
+
 func init() {
 	statMutationsCmd.PersistentFlags().StringVar(&statMutationsRef, "ref-sequence", "none", "Reference sequence to compare each sequence with.")
 	statMutationsCmd.PersistentFlags().BoolVar(&statMutationsUnique, "unique", false, "Count, in each sequence, the number of mutations/characters that are unique in a site")
 	statMutationsCmd.PersistentFlags().StringVar(&statMutationsProfile, "count-profile", "none", "A profile to compare the alignment with, and to compute statistics faster (only with --unique)")
 
+	addStatsFormatFlag(statMutationsCmd)
+
 	statsCmd.AddCommand(statMutationsCmd)
 }
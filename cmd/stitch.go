@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/maf"
+	"github.com/spf13/cobra"
+)
+
+var stitchout string
+var stitchref string
+
+// stitchCmd represents the stitch command
+var stitchCmd = &cobra.Command{
+	Use:   "stitch",
+	Short: "Stitches MAF alignment blocks into a single alignment",
+	Long: `Stitches MAF alignment blocks into a single alignment, 
+ordered by the coordinates of the blocks on a reference sequence.
+
+The reference (--ref) is either a species/assembly name (ex: hg38), or a 
+full MAF source name (ex: hg38.chr1). Blocks that do not contain the reference 
+are ignored. Remaining blocks must all be on the same reference sequence, and 
+must not overlap. Blocks in which the reference is on the '-' strand are 
+reverse complemented.
+
+Output sequences are named after the species (first part of the MAF source 
+names, before the first '.'), with the reference first. Species absent from 
+a block are filled with gaps. If a species has several rows in a block, only 
+the first one (or the reference row) is kept. Reference positions that are 
+not covered by any block are filled with N in the reference, and with gaps 
+in the other species.
+
+Example of usage:
+
+goalign stitch --maf -i blocks.maf --ref hg38 --output-format fasta
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var al align.Alignment
+		var f outputFile
+
+		if stitchref == "" {
+			err = errors.New("--ref must be specified")
+			io.LogError(err)
+			return
+		}
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		if al, err = maf.Stitch(aligns, stitchref); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if f, err = openWriteFile(stitchout); err != nil {
+			io.LogError(err)
+			return
+		}
+		writeAlign(al, f)
+		closeWriteFile(f, stitchout)
+
+		return
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(stitchCmd)
+	stitchCmd.PersistentFlags().StringVarP(&stitchout, "output", "o", "stdout", "Stitched alignment output file")
+	stitchCmd.PersistentFlags().StringVar(&stitchref, "ref", "", "Reference species (ex: hg38) or source sequence (ex: hg38.chr1)")
+}
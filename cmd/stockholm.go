@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/spf13/cobra"
+)
+
+// stockholmCmd : to reformat in stockholm format
+var stockholmCmd = &cobra.Command{
+	Use:   "stockholm",
+	Short: "Reformats an input alignment into Stockholm format",
+	Long: `Reformats an alignment into Stockholm format. 
+It may take a Phylip, Fasta, Nexus, Clustal or Stockholm input alignment.
+
+If the input alignment contains several alignments, will take all of them.
+
+If the input alignment is in Stockholm format, its annotations 
+(#=GF, #=GS, #=GR and #=GC lines) are written as well.
+
+Example of usage:
+
+goalign reformat stockholm -i align.phylip -p
+goalign reformat stockholm -i align.fasta
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var f outputFile
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		if f, err = openWriteFile(reformatOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, reformatOutput)
+
+		for al := range aligns.Achan {
+			if reformatCleanNames {
+				al.CleanNames(nil)
+			}
+			writeAlignStockholm(al, f)
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+		}
+		return
+	},
+}
+
+func init() {
+	reformatCmd.AddCommand(stockholmCmd)
+}
@@ -2,11 +2,11 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
 	"github.com/spf13/cobra"
 )
 
@@ -15,6 +15,7 @@ var subseqstart int
 var subseqlength int
 var subseqstep int
 var subseqrefseq string
+var subseqcds string
 
 // subseqCmd represents the subseq command
 var subseqCmd = &cobra.Command{
@@ -34,6 +35,9 @@ This will extract a sub-alignment going from 10th position, with a length of 10.
 
 The output format is the same than input format.
 
+If the input fasta file has been indexed (goalign index), only the
+required part of each sequence is read from it (not with --ref-seq nor --cds).
+
 If --ref-seq <name> is specified, then the coordinates are considered according the 
 given sequence, and without considering gaps.
 
@@ -77,6 +81,22 @@ it is possible to split alignments afterwards (goalign divide for example).
 
 Not compatible with --ref-seq
 
+Annotated CDS:
+--------------
+If input sequences are in GenBank or EMBL format (--genbank or --embl), 
+--cds <name> extracts the sub-alignment corresponding to the CDS having the 
+given name (gene, locus_tag, protein_id or product qualifier), annotated on 
+the sequence given by --ref-seq (or on the first sequence having this CDS). 
+Intervals of joined CDS are concatenated, and CDS on the reverse strand are 
+reverse complemented, so that the output is in frame. Output sequences are 
+named after the CDS (<sequence name>_<CDS name>).
+
+--cds all extracts one sub-alignment per CDS annotated on the sequence given 
+by --ref-seq (or on the first sequence having CDS). If the output is a file, 
+they are placed in files with "_sub<i>" suffixes, as sliding windows.
+
+Not compatible with --step, --start and --length
+
 Several alignments:
 ------------------
 If several alignments are present in the input file and the output is a file 
@@ -92,13 +112,9 @@ If several alignments are present in the input file and the output is a file
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 		var subalign align.Alignment
 
-		if aligns, err = readalign(infile); err != nil {
-			io.LogError(err)
-			return
-		}
 		if f, err = openWriteFile(subseqout); err != nil {
 			io.LogError(err)
 			return
@@ -114,6 +130,33 @@ If several alignments are present in the input file and the output is a file
 			err = fmt.Errorf("--ref-seq and --step options are not compatible")
 			return
 		}
+		if subseqcds != "none" && (subseqstep > 0 || cmd.Flags().Changed("start") || cmd.Flags().Changed("length")) {
+			err = fmt.Errorf("--cds is not compatible with --step, --start and --length options")
+			return
+		}
+
+		if ir := openFastaIndex(infile); ir != nil && !refseq && subseqcds == "none" {
+			// Only the required parts of the sequences are read from the indexed fasta file
+			var length int
+			defer ir.Close()
+			if length, err = indexedAlignLength(ir); err == nil {
+				f, err = writeSubAligns(f, name, "", extension, length, subseqstart, subseqlength, func(start, len int) (align.Alignment, error) {
+					return subAlignIndexed(ir, start, len)
+				})
+			}
+			if err != nil {
+				err = io.SetParseErrorFile(err, infile)
+				io.LogError(err)
+				return
+			}
+			f.Close()
+			return
+		}
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
 
 		for al := range aligns.Achan {
 			start := subseqstart
@@ -127,30 +170,36 @@ If several alignments are present in the input file and the output is a file
 					return
 				}
 			}
-			if refseq {
-				start, len, err = al.RefCoordinates(subseqrefseq, start, len)
-			}
-			subalignnum := 0
-			for {
-				if subalign, err = al.SubAlign(start, len); err != nil {
+			if subseqcds != "none" {
+				var seqname string
+				var cds []*align.Feature
+				if seqname, cds, err = findCDS(al, subseqcds, subseqrefseq, refseq); err != nil {
 					io.LogError(err)
 					return
 				}
-				writeAlign(subalign, f)
-				start += subseqstep
-				if subseqstep == 0 || (start+len) > al.Length() {
-					break
-				} else {
-					if subseqout != "stdout" && subseqout != "-" {
-						subalignnum++
+				for i, c := range cds {
+					if i > 0 && subseqout != "stdout" && subseqout != "-" {
 						f.Close()
-						n := fmt.Sprintf("%s%s_sub%d%s", name, fileid, subalignnum, extension)
-						if f, err = openWriteFile(n); err != nil {
+						if f, err = openWriteFile(fmt.Sprintf("%s%s_sub%d%s", name, fileid, i, extension)); err != nil {
 							io.LogError(err)
 							return
 						}
 					}
+					if subalign, err = al.SubAlignFeature(seqname, c); err != nil {
+						io.LogError(err)
+						return
+					}
+					writeAlign(subalign, f)
 				}
+				filenum++
+				continue
+			}
+			if refseq {
+				start, len, err = al.RefCoordinates(subseqrefseq, start, len)
+			}
+			if f, err = writeSubAligns(f, name, fileid, extension, al.Length(), start, len, al.SubAlign); err != nil {
+				io.LogError(err)
+				return
 			}
 			filenum++
 		}
@@ -171,4 +220,122 @@ func init() {
 	subseqCmd.PersistentFlags().IntVarP(&subseqlength, "length", "l", 10, "Length of the sub alignment")
 	subseqCmd.PersistentFlags().StringVar(&subseqrefseq, "ref-seq", "none", "Reference sequence on which coordinates are given")
 	subseqCmd.PersistentFlags().IntVar(&subseqstep, "step", 0, "Step: If > 0, then will generate several alignments, for each window of length l, with starts: [start,start+step, ..., end-l]* ")
+	subseqCmd.PersistentFlags().StringVar(&subseqcds, "cds", "none", "Extracts the annotated CDS having this name (all: all the CDS) (GenBank or EMBL input)")
+}
+
+// writeSubAligns writes the sub-alignment of the given start and length, given
+// by subalign, or all the sub-alignments of the sliding windows if --step is
+// given. Each window is written in a "_sub<i>" file, unless output is stdout.
+// It returns the last opened output file.
+func writeSubAligns(f outputFile, name, fileid, extension string, length, start, len int, subalign func(start, len int) (align.Alignment, error)) (outputFile, error) {
+	var sub align.Alignment
+	var err error
+
+	subalignnum := 0
+	for {
+		if sub, err = subalign(start, len); err != nil {
+			return f, err
+		}
+		writeAlign(sub, f)
+		start += subseqstep
+		if subseqstep == 0 || (start+len) > length {
+			break
+		} else {
+			if subseqout != "stdout" && subseqout != "-" {
+				subalignnum++
+				f.Close()
+				n := fmt.Sprintf("%s%s_sub%d%s", name, fileid, subalignnum, extension)
+				if f, err = openWriteFile(n); err != nil {
+					return f, err
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// indexedAlignLength returns the length of the sequences of the indexed
+// fasta file, or an error located at the first sequence that does not
+// have the same length as the previous ones (as the fasta parser does)
+func indexedAlignLength(ir *fasta.IndexedReader) (length int, err error) {
+	length = -1
+	for _, rec := range ir.Index() {
+		if length != -1 && rec.Length != length {
+			err = ir.SequenceError(rec.Name, fmt.Errorf("Sequence %s does not have same length as other sequences", rec.Name))
+			return
+		}
+		length = rec.Length
+	}
+	return
+}
+
+// subAlignIndexed reads the sub-alignment of the given start and length
+// from the indexed fasta file (see align.SubAlign)
+func subAlignIndexed(ir *fasta.IndexedReader, start, length int) (sub align.Alignment, err error) {
+	var header, sequence string
+	var alilength int
+
+	if alilength, err = indexedAlignLength(ir); err != nil {
+		return
+	}
+	if start < 0 || start > alilength {
+		err = fmt.Errorf("Start is outside the alignment")
+		return
+	}
+	if length < 0 {
+		err = fmt.Errorf("Length is negative")
+		return
+	}
+	if start+length < 0 || start+length > alilength {
+		err = fmt.Errorf("Start+Length is outside the alignment")
+		return
+	}
+	sub = align.NewAlign(align.UNKNOWN)
+	for _, name := range ir.Names() {
+		if header, err = ir.Header(name); err != nil {
+			return
+		}
+		if sequence, err = ir.FetchRange(name, start, start+length); err != nil {
+			return
+		}
+		if err = sub.AddSequence(header, sequence, ""); err != nil {
+			err = ir.SequenceError(name, err)
+			return
+		}
+	}
+	sub.AutoAlphabet()
+	return
+}
+
+// findCDS returns the CDS having the given name ("all": all the CDS), annotated
+// on the sequence refseq if userefseq is true, or on the first sequence having
+// it (or having CDS) otherwise. It also returns the name of the sequence on
+// which the CDS are annotated.
+func findCDS(al align.Alignment, name, refseq string, userefseq bool) (seqname string, cds []*align.Feature, err error) {
+	for i := 0; i < al.NbSequences() && len(cds) == 0; i++ {
+		s, _ := al.Sequence(i)
+		if userefseq && s.Name() != refseq {
+			continue
+		}
+		for _, f := range s.Features() {
+			if f.Type == "CDS" && (name == "all" || f.HasName(name)) {
+				seqname, cds = s.Name(), append(cds, f)
+				if name != "all" {
+					break
+				}
+			}
+		}
+	}
+	switch {
+	case len(cds) > 0:
+	case name == "all" && userefseq:
+		err = fmt.Errorf("No CDS annotated on sequence %s", refseq)
+	case name == "all":
+		err = fmt.Errorf("No CDS annotated in the sequences")
+	case userefseq:
+		err = fmt.Errorf("No CDS named %s annotated on sequence %s", name, refseq)
+	default:
+		err = fmt.Errorf("No CDS named %s in the sequences", name)
+	}
+	return
 }
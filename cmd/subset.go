@@ -2,14 +2,14 @@ package cmd
 
 import (
 	"bufio"
-	"compress/gzip"
-	"os"
+	goio "io"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
 	"github.com/evolbioinfo/goalign/io/utils"
 	"github.com/spf13/cobra"
 )
@@ -46,12 +46,14 @@ The output format is the same than input format.
 
 If -f is given, it does not take into account sequence names 
 given in the comand line.
+
+If the input fasta file has been indexed (goalign index), only the
+selected sequences are read from it.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var subset map[string]int
 		var aligns *align.AlignChannel
-		var seqs align.SeqBag
-		var f *os.File
+		var f outputFile
 		var r *regexp.Regexp
 		var indexlist []int
 		var regexps []*regexp.Regexp
@@ -99,27 +101,28 @@ given in the comand line.
 			}
 		}
 
-		if unaligned {
-			if seqs, err = readsequences(infile); err != nil {
+		if ir := openFastaIndex(infile); ir != nil {
+			// Only selected sequences are read from the indexed fasta file
+			defer ir.Close()
+			if err = subsetIndexed(ir, f, subset, regexps, indexlist); err != nil {
+				err = io.SetParseErrorFile(err, infile)
 				io.LogError(err)
 				return
 			}
-			var filtered align.SeqBag = nil
+		} else if unaligned {
 			var i int = 0
-			seqs.Iterate(func(name string, sequence string) bool {
-				if filtered == nil {
-					filtered = align.NewSeqBag(seqs.Alphabet())
-				}
-				ok := matchSeqName(name, i, subset, regexps, regexmatch, indexlist, indices)
-				if !revert && ok {
-					filtered.AddSequence(name, sequence, "")
-				} else if revert && !ok {
-					filtered.AddSequence(name, sequence, "")
+			// Sequences are filtered one by one, without loading the whole file
+			if err = processSequencesStream(infile, f, func(s align.Sequence, w *fasta.StreamWriter) (err error) {
+				ok := matchSeqName(s.Name(), i, subset, regexps, regexmatch, indexlist, indices)
+				if ok != revert {
+					err = w.Write(s)
 				}
 				i++
-				return false
-			})
-			writeSequences(filtered, f)
+				return
+			}); err != nil {
+				io.LogError(err)
+				return
+			}
 		} else {
 			if aligns, err = readalign(infile); err != nil {
 				io.LogError(err)
@@ -141,6 +144,9 @@ given in the comand line.
 					i++
 					return false
 				})
+				if filtered != nil {
+					filtered.SetAnnotations(al.Annotations())
+				}
 				writeAlign(filtered, f)
 			}
 			if aligns.Err != nil {
@@ -152,30 +158,56 @@ given in the comand line.
 	},
 }
 
-func parseNameFile(file string) (subset map[string]int, err error) {
-	var f *os.File
-	var r *bufio.Reader
-	var gr *gzip.Reader
-
-	subset = make(map[string]int)
-
-	if file == "stdin" || file == "-" {
-		f = os.Stdin
-	} else {
-		if f, err = os.Open(file); err != nil {
+// subsetIndexed writes the selected sequences of the indexed fasta file,
+// as an alignment, or one by one if --unaligned is given
+func subsetIndexed(ir *fasta.IndexedReader, f outputFile, subset map[string]int, regexps []*regexp.Regexp, indexlist []int) (err error) {
+	var header string
+	var s align.Sequence
+
+	filtered := align.NewAlign(align.UNKNOWN)
+	w := fasta.NewStreamWriter(f)
+	if !unaligned {
+		// All the sequences must have the same length, as with the fasta parser
+		if _, err = indexedAlignLength(ir); err != nil {
 			return
 		}
 	}
-
-	if strings.HasSuffix(file, ".gz") {
-		if gr, err = gzip.NewReader(f); err != nil {
+	for i, name := range ir.Names() {
+		if header, err = ir.Header(name); err != nil {
 			return
-		} else {
-			r = bufio.NewReader(gr)
 		}
-	} else {
-		r = bufio.NewReader(f)
+		if matchSeqName(header, i, subset, regexps, regexmatch, indexlist, indices) == revert {
+			continue
+		}
+		if s, err = ir.Fetch(name); err != nil {
+			return
+		}
+		if unaligned {
+			if err = w.Write(s); err != nil {
+				return
+			}
+		} else if err = filtered.AddSequence(s.Name(), s.Sequence(), ""); err != nil {
+			return ir.SequenceError(name, err)
+		}
+	}
+	if unaligned {
+		return w.Flush()
+	}
+	filtered.AutoAlphabet()
+	writeAlign(filtered, f)
+	return
+}
+
+func parseNameFile(file string) (subset map[string]int, err error) {
+	var f goio.Closer
+	var r *bufio.Reader
+
+	subset = make(map[string]int)
+
+	if f, r, err = utils.GetReader(file); err != nil {
+		return
 	}
+	defer f.Close()
 
 	l, e := utils.Readln(r)
 	for e == nil {
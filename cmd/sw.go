@@ -2,7 +2,6 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
@@ -14,6 +13,7 @@ var swLog string
 var gapopen, gapextend float64
 var match float64
 var mismatch float64
+var swLinearSpace bool
 
 // translateCmd represents the addid command
 var swCmd = &cobra.Command{
@@ -33,6 +33,10 @@ specified by --gap-extend option (they should be negative).
 
 Input file must be a fasta file containing 2 sequences. Output format may be specified
 by formatting options (-p, -x, etc.)
+
+With --linear-space, the alignment is computed in linear memory (Myers & Miller),
+instead of storing the whole dynamic programming matrix. This is done automatically
+if the product of the sequence lengths exceeds 10,000,000 (e.g. long genomes).
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var seqs align.SeqBag
@@ -40,7 +44,7 @@ by formatting options (-p, -x, etc.)
 		var seq1 align.Sequence
 		var seq2 align.Sequence
 		var ok bool
-		var f, log *os.File
+		var f, log outputFile
 
 		if f, err = openWriteFile(swOutput); err != nil {
 			io.LogError(err)
@@ -80,6 +84,7 @@ by formatting options (-p, -x, etc.)
 		aligner := align.NewPwAligner(seq1, seq2, align.ALIGN_ALGO_SW)
 		aligner.SetGapOpenScore(gapopen)
 		aligner.SetGapExtendScore(gapextend)
+		aligner.SetLinearSpace(swLinearSpace)
 
 		if cmd.Flags().Changed("mismatch") || cmd.Flags().Changed("match") {
 			aligner.SetScore(match, mismatch)
@@ -116,4 +121,5 @@ func init() {
 	swCmd.PersistentFlags().Float64Var(&gapextend, "gap-extend", -0.5, "Score for extending a gap ")
 	swCmd.PersistentFlags().Float64Var(&match, "match", 1.0, "Score for a match (if omitted, then take substitution matrix)")
 	swCmd.PersistentFlags().Float64Var(&mismatch, "mismatch", -1.0, "Score for a mismatch (if omitted, then take substitution matrix)")
+	swCmd.PersistentFlags().BoolVar(&swLinearSpace, "linear-space", false, "Computes the alignment in linear memory")
 }
@@ -1,9 +1,6 @@
 package cmd
 
 import (
-	"fmt"
-	"os"
-
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
 	"github.com/spf13/cobra"
@@ -25,7 +22,7 @@ goalign reformat tnt -i align.fasta
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 
 		if aligns, err = readalign(infile); err != nil {
 			io.LogError(err)
@@ -47,14 +44,7 @@ goalign reformat tnt -i align.fasta
 		if reformatCleanNames {
 			al.CleanNames(nil)
 		}
-		f.WriteString("xread\n\n")
-		f.WriteString("'Tnt input file'\n\n")
-		f.WriteString(fmt.Sprintf("%d %d\n", al.Length(), al.NbSequences()))
-		al.Iterate(func(name string, sequence string) bool {
-			f.WriteString(fmt.Sprintf("%s %s\n", name, sequence))
-			return false
-		})
-		f.WriteString(";\n")
+		writeAlignTnt(al, f)
 
 		return
 	},
@@ -26,7 +26,7 @@ Weights follow a Dirichlet distribution D(n;1,...,1)
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
 		var aligns *align.AlignChannel
-		var f *os.File
+		var f outputFile
 		var weights []float64 = nil
 
 		if aligns, err = readalign(infile); err != nil {
@@ -49,7 +49,6 @@ Weights follow a Dirichlet distribution D(n;1,...,1)
 					io.LogError(err)
 					return
 				}
-				defer f.Close()
 			} else {
 				if f, err = openWriteFile(weightbootOutput); err != nil {
 					io.LogError(err)
@@ -83,6 +82,7 @@ Weights follow a Dirichlet distribution D(n;1,...,1)
 			}
 			f.WriteString("\n")
 			f.WriteString(";\n")
+			closeWriteFile(f, weightbootOutput)
 		}
 		return
 	},
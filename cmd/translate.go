@@ -2,16 +2,17 @@ package cmd
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/evolbioinfo/goalign/align"
 	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/fasta"
 	"github.com/spf13/cobra"
 )
 
 var translatePhase int
 var translateOutput string
 var translateGeneticCode string
+var translateCDS string
 
 // translateCmd represents the addid command
 var translateCmd = &cobra.Command{
@@ -34,9 +35,15 @@ It is possible to specify alternative genetic code with --genetic-code
 
 IUPAC codes are taken into account for the translation. If a codon containing 
 IUPAC code is ambiguous for translation, then a X is added in place of the aminoacid.
+
+If --cds <name|all> is given, input sequences must be annotated (GenBank or EMBL 
+format), and the CDS having the given name (all: all the CDS) are extracted and 
+translated, starting at their codon_start. In that case, the genetic code is given 
+by the /transl_table qualifier of each CDS, unless --genetic-code is specified. 
+Output is in fasta format, and sequences are named <sequence>_<CDS name>.
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 		var geneticcode int
 
 		if f, err = openWriteFile(translateOutput); err != nil {
@@ -58,18 +65,41 @@ IUPAC code is ambiguous for translation, then a X is added in place of the amino
 			return
 		}
 
-		if unaligned {
+		if translateCDS != "none" {
 			var seqs align.SeqBag
-
 			if seqs, err = readsequences(infile); err != nil {
 				io.LogError(err)
 				return
 			}
-			if err = seqs.Translate(translatePhase, geneticcode); err != nil {
+			if seqs, err = translateAnnotatedCDS(seqs, translateCDS, geneticcode, cmd.Flags().Changed("genetic-code")); err != nil {
 				io.LogError(err)
 				return
 			}
 			writeSequences(seqs, f)
+		} else if unaligned {
+			var firststart, laststart int = translatePhase, translatePhase
+			if translatePhase == -1 {
+				firststart, laststart = 0, 2
+			}
+			// Sequences are translated one by one, without loading the whole file
+			if err = processSequencesStream(infile, f, func(s align.Sequence, w *fasta.StreamWriter) (err error) {
+				var tr align.Sequence
+				for phase := firststart; phase <= laststart; phase++ {
+					if tr, err = s.Translate(phase, geneticcode); err != nil {
+						return
+					}
+					if translatePhase == -1 {
+						tr.SetName(fmt.Sprintf("%s_%d", s.Name(), phase))
+					}
+					if err = w.Write(tr); err != nil {
+						return
+					}
+				}
+				return
+			}); err != nil {
+				io.LogError(err)
+				return
+			}
 		} else {
 			var aligns *align.AlignChannel
 			var al align.Alignment
@@ -102,4 +132,45 @@ func init() {
 	translateCmd.PersistentFlags().StringVarP(&translateOutput, "output", "o", "stdout", "Output translated alignment file")
 	translateCmd.PersistentFlags().IntVar(&translatePhase, "phase", 0, "Number of characters to drop from the start of the alignment (if -1: Translate in the 3 phases, from positions 0, 1, and 2)")
 	translateCmd.PersistentFlags().BoolVar(&unaligned, "unaligned", false, "Considers sequences as unaligned and format fasta (phylip, nexus,... options are ignored)")
+	translateCmd.PersistentFlags().StringVar(&translateCDS, "cds", "none", "Translates the annotated CDS having this name (all: all the CDS) instead of the whole sequences (GenBank or EMBL input)")
+}
+
+// translateAnnotatedCDS extracts and translates the CDS annotated in the input sequences
+// and having the given name ("all": all the CDS). If forcecode is false, the genetic code
+// of each CDS is given by its /transl_table qualifier.
+func translateAnnotatedCDS(seqs align.SeqBag, name string, geneticcode int, forcecode bool) (translated align.SeqBag, err error) {
+	var cds, tr align.Sequence
+	var code int
+
+	translated = align.NewSeqBag(align.AMINOACIDS)
+	for i := 0; i < seqs.NbSequences(); i++ {
+		s, _ := seqs.Sequence(i)
+		for _, feat := range s.Features() {
+			if feat.Type != "CDS" || (name != "all" && !feat.HasName(name)) {
+				continue
+			}
+			if code = geneticcode; !forcecode {
+				if code, err = feat.GeneticCode(); err != nil {
+					return
+				}
+			}
+			if cds, err = feat.ExtractCDS(s); err != nil {
+				return
+			}
+			if tr, err = cds.Translate(0, code); err != nil {
+				return
+			}
+			if err = translated.AddSequenceChar(tr.Name(), tr.SequenceChar(), tr.Comment()); err != nil {
+				return
+			}
+		}
+	}
+	if translated.NbSequences() == 0 {
+		if name == "all" {
+			err = fmt.Errorf("No CDS annotated in the sequences")
+		} else {
+			err = fmt.Errorf("No CDS named %s in the sequences", name)
+		}
+	}
+	return
 }
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/table"
+	"github.com/spf13/cobra"
+)
+
+var validateOutput string
+var validateChecks []string
+var validateGeneticCode string
+var validateFormat string
+var validateStrict bool
+
+// validateCmd represents the validate command
+var validateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Checks the input alignment for common problems",
+	Long: `Checks the input alignment for common problems.
+
+Runs the checks given with --checks (comma separated, or flag given several times)
+on all the alignments of the input file, and prints one line per issue found.
+Available checks:
+
+- duplicate-names (error)  : sequence names found several times in the input (goalign
+                             renames such sequences name_0001, name_0002, etc.). With
+                             --ignore-identical, ignored identical sequences are reported
+                             as warnings;
+- phylip-names    (warning): sequence names that are identical once truncated to 10 characters
+                             (strict phylip);
+- clean-names     (error)  : sequence names that are identical once cleaned (see goalign clean
+                             names: special newick characters replaced by "-");
+- alphabet        (error)  : characters that are not IUPAC nucleotides (ACGTURYSWKMBDHVN)
+                             or amino acids (ARNDCQEGHILKMFPSTWYVBZJXUO), gaps (-) or ?,
+                             depending on the alphabet of the alignment. If the alphabet is
+                             not detected, it is also reported;
+- all-gaps        (error)  : sequences made only of gaps;
+- all-n           (warning): sequences made only of N (X for proteins) and gaps;
+- stops           (error)  : internal stop codons (coding nucleotide alignments, each
+                             sequence without gaps being translated from its first
+                             nucleotide). A stop codon ending the sequence is not reported;
+- frameshifts     (warning): frameshifts (coding nucleotide alignments, the first sequence
+                             being the reference).
+
+By default, all checks except stops and frameshifts are run.
+
+The report is written in the format given by --format:
+- text (default): one line per issue:
+  [alignment index] severity check sequence: message (position)
+  followed by a summary line;
+- tsv, csv or json: a table with one record per issue, having the fields alignment,
+  severity, check, sequence, position (0-based, -1 if not relevant) and message.
+
+If errors are found (or warnings, with --strict), goalign validate exits with a non-zero
+status, so that it may be used to stop a pipeline before running tree inference tools.
+
+Example:
+goalign validate -i align.fa
+goalign validate -i align.fa --checks alphabet,stops --format json
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var f outputFile
+		var aligns *align.AlignChannel
+		var geneticcode, format int
+		var issues []align.ValidationIssue
+		var nberrors, nbwarnings int
+
+		structured := validateFormat != "text"
+		if structured {
+			if format, err = table.FormatFromString(validateFormat); err != nil {
+				io.LogError(err)
+				return
+			}
+		}
+
+		switch validateGeneticCode {
+		case "standard":
+			geneticcode = align.GENETIC_CODE_STANDARD
+		case "mitov":
+			geneticcode = align.GENETIC_CODE_VETEBRATE_MITO
+		case "mitoi":
+			geneticcode = align.GENETIC_CODE_INVETEBRATE_MITO
+		default:
+			err = fmt.Errorf("Unknown genetic code : %s", validateGeneticCode)
+			io.LogError(err)
+			return
+		}
+
+		if f, err = openWriteFile(validateOutput); err != nil {
+			io.LogError(err)
+			return
+		}
+		defer closeWriteFile(f, validateOutput)
+
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		t := table.NewTable("alignment", "severity", "check", "sequence", "position", "message")
+		i := 0
+		for al := range aligns.Achan {
+			if issues, err = al.Validate(validateChecks, geneticcode); err != nil {
+				io.LogError(err)
+				return
+			}
+			for _, is := range issues {
+				if is.Severity == align.SEVERITY_ERROR {
+					nberrors++
+				} else {
+					nbwarnings++
+				}
+				if !structured {
+					f.WriteString(validateIssueString(i, is))
+				} else if err = t.AddRow(i, is.Severity, is.Check, is.Sequence, is.Position, is.Message); err != nil {
+					io.LogError(err)
+					return
+				}
+			}
+			i++
+		}
+
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+			return
+		}
+
+		if structured {
+			if err = t.Write(f, format); err != nil {
+				io.LogError(err)
+				return
+			}
+		} else {
+			fmt.Fprintf(f, "%d alignment(s) checked: %d error(s), %d warning(s)\n", i, nberrors, nbwarnings)
+		}
+
+		if nberrors > 0 || (validateStrict && nbwarnings > 0) {
+			// The report is complete: we close it before exiting with an error status
+			closeWriteFile(f, validateOutput)
+			io.ExitWithMessage(fmt.Errorf("Alignment validation failed: %d error(s), %d warning(s)", nberrors, nbwarnings))
+		}
+		return
+	},
+}
+
+func validateIssueString(alignment int, is align.ValidationIssue) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[%d] %s %s", alignment, is.Severity, is.Check)
+	if is.Sequence != "" {
+		fmt.Fprintf(&sb, " %s", is.Sequence)
+	}
+	fmt.Fprintf(&sb, ": %s", is.Message)
+	if is.Position >= 0 {
+		fmt.Fprintf(&sb, " (position %d)", is.Position)
+	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func init() {
+	RootCmd.AddCommand(validateCmd)
+	validateCmd.PersistentFlags().StringVarP(&validateOutput, "output", "o", "stdout", "Validation report output file")
+	validateCmd.PersistentFlags().StringSliceVar(&validateChecks, "checks", align.DefaultValidationChecks, "Checks to run (comma separated, or flag given several times): "+strings.Join(align.ValidationChecks, ", "))
+	validateCmd.PersistentFlags().StringVar(&validateGeneticCode, "genetic-code", "standard", "Genetic Code used by the stops check: standard, mitoi (invertebrate mitochondrial) or mitov (vertebrate mitochondrial)")
+	validateCmd.PersistentFlags().StringVar(&validateFormat, "format", "text", "Report format: text, tsv, csv or json")
+	validateCmd.PersistentFlags().BoolVar(&validateStrict, "strict", false, "Exits with a non-zero status if warnings are found, in addition to errors")
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/vcf"
+	"github.com/spf13/cobra"
+)
+
+var vcfout string
+var vcfrefsequence string
+var vcfgapsasdeletions bool
+var vcfsnpsout string
+var vcfsnppositionsout string
+
+// vcfCmd represents the vcf command
+var vcfCmd = &cobra.Command{
+	Use:   "vcf",
+	Short: "Exports variants of an alignment in VCF format",
+	Long: `Exports variants of a nucleotide alignment in VCF format.
+
+Variants are reported compared to a reference sequence, given with
+--ref-sequence. It will first try to extract a sequence having that name
+from the alignment. If none exist, it will try to open the file with that
+name and take the first sequence. In both cases, the reference sequence
+must be aligned with the alignment (same length).
+
+Each sequence of the alignment (except the reference itself) is a sample,
+with a haploid genotype. Positions (POS) are given on the reference
+sequence without gaps (1-based), and:
+- Sites where the reference has a gap or is not A, C, G or T are not reported;
+- N and IUPAC ambiguities are written as missing genotypes ('.');
+- Gaps are written as missing genotypes, or as deletions if
+  --gaps-as-deletions is given. Deletions are written in normalized form,
+  with the reference base before them (anchor base, or the base after
+  them at the start of the reference): e.g. REF=GT, ALT=G. Overlapping
+  deletions of several samples are written in the same variant.
+
+A variant is reported if at least one sample has an alternative allele.
+
+In addition, like snp-sites:
+- --snps writes the alignment made of the variable sites of the reported
+  variants only (all the sequences, in the output alignment format);
+- --snp-positions writes a tab separated file giving, for each of these
+  sites, its position on the alignment and on the reference (1-based).
+
+Example of usage:
+
+goalign vcf -i align.fa --ref-sequence ref -o variants.vcf --snps snps.fa
+
+`,
+	RunE: func(cmd *cobra.Command, args []string) (err error) {
+		var aligns *align.AlignChannel
+		var ref align.Sequence
+		var sb align.SeqBag
+		var ok bool
+		var samples []string
+		var variants []*align.Variant
+		var f outputFile
+
+		if vcfrefsequence == "" {
+			err = errors.New("--ref-sequence must be specified")
+			io.LogError(err)
+			return
+		}
+		if aligns, err = readalign(infile); err != nil {
+			io.LogError(err)
+			return
+		}
+		al, _ := <-aligns.Achan
+		if aligns.Err != nil {
+			err = aligns.Err
+			io.LogError(err)
+			return
+		}
+
+		// We try to get the sequence from its name in the alignment
+		if ref, ok = al.SequenceByName(vcfrefsequence); !ok {
+			//Else we open the potential file
+			if sb, err = readsequences(vcfrefsequence); err != nil {
+				io.LogError(err)
+				return
+			}
+			if ref, ok = sb.Sequence(0); !ok {
+				err = fmt.Errorf("The reference sequence file does not contain any sequence")
+				io.LogError(err)
+				return
+			}
+		}
+
+		if samples, variants, err = al.Variants(ref, vcfgapsasdeletions); err != nil {
+			io.LogError(err)
+			return
+		}
+
+		if f, err = openWriteFile(vcfout); err != nil {
+			io.LogError(err)
+			return
+		}
+		f.WriteString(vcf.WriteVariants(ref.Name(), ref.Length()-ref.NumGaps(), samples, variants))
+		closeWriteFile(f, vcfout)
+
+		if vcfsnpsout != "none" {
+			var snps align.Alignment
+			sites := make([]int, 0, len(variants))
+			for _, v := range variants {
+				sites = append(sites, v.Sites...)
+			}
+			if snps, err = al.SelectSites(sites); err != nil {
+				io.LogError(err)
+				return
+			}
+			if f, err = openWriteFile(vcfsnpsout); err != nil {
+				io.LogError(err)
+				return
+			}
+			writeAlign(snps, f)
+			closeWriteFile(f, vcfsnpsout)
+		}
+
+		if vcfsnppositionsout != "none" {
+			if f, err = openWriteFile(vcfsnppositionsout); err != nil {
+				io.LogError(err)
+				return
+			}
+			// Ungapped reference position of each site
+			refpos := make([]int, ref.Length())
+			pos := 0
+			for site, c := range ref.SequenceBytes() {
+				refpos[site] = pos
+				if c != align.GAP {
+					pos++
+				}
+			}
+			fmt.Fprintf(f, "alignment\treference\n")
+			for _, v := range variants {
+				for _, site := range v.Sites {
+					fmt.Fprintf(f, "%d\t%d\n", site+1, refpos[site]+1)
+				}
+			}
+			closeWriteFile(f, vcfsnppositionsout)
+		}
+		return
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(vcfCmd)
+	vcfCmd.PersistentFlags().StringVarP(&vcfout, "output", "o", "stdout", "VCF output file")
+	vcfCmd.PersistentFlags().StringVar(&vcfrefsequence, "ref-sequence", "", "Reference sequence: name of a sequence of the alignment, or file containing the aligned reference sequence")
+	vcfCmd.PersistentFlags().BoolVar(&vcfgapsasdeletions, "gaps-as-deletions", false, "Gaps are written as deletions (with their anchor base) instead of missing genotypes")
+	vcfCmd.PersistentFlags().StringVar(&vcfsnpsout, "snps", "none", "Output file of the alignment made of the variable sites only")
+	vcfCmd.PersistentFlags().StringVar(&vcfsnppositionsout, "snp-positions", "none", "Output file of the alignment and reference positions of the variable sites")
+}
@@ -24,7 +24,7 @@ Weights follow a Dirichlet distribtion D(n;1,...,1)
 
 `,
 	RunE: func(cmd *cobra.Command, args []string) (err error) {
-		var f *os.File
+		var f outputFile
 		var aligns align.AlignChannel
 
 		if aligns, err = readalign(infile); err != nil {
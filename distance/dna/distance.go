@@ -503,8 +503,8 @@ func selectedSites(al align.Alignment, weights []float64, removeGappedPositions
 		}
 		selectedSites[l] = true
 		for i := 0; i < al.NbSequences() && removeGappedPositions; i++ {
-			seq, _ := al.GetSequenceCharById(i)
-			if al.AlphabetCharToIndex(seq[l]) == -1 || seq[l] == '*' || seq[l] == '?' || seq[l] == '-' {
+			seq, _ := al.GetSequenceBytesById(i)
+			if al.AlphabetCharToIndex(rune(seq[l])) == -1 || seq[l] == '*' || seq[l] == '?' || seq[l] == '-' {
 				selectedSites[l] = false
 				break
 			}
@@ -521,10 +521,10 @@ func alignmentToCodes(al align.Alignment) (sequencesInCode [][]uint8, err error)
 	// Sequences coded in NT_A-NT_OTHER
 	sequencesInCode = make([][]uint8, al.NbSequences())
 	i = 0
-	al.IterateChar(func(name string, seq []rune) bool {
+	al.IterateBytes(func(name string, seq []byte) bool {
 		sequencesInCode[i] = make([]uint8, al.Length())
 		for l, r := range seq {
-			if sequencesInCode[i][l], err = align.Nt2IndexIUPAC(r); err != nil {
+			if sequencesInCode[i][l], err = align.Nt2IndexIUPAC(rune(r)); err != nil {
 				return true
 			}
 		}
@@ -162,3 +162,24 @@ func Test_countDiffsWithInternalGaps(t *testing.T) {
 		})
 	}
 }
+
+// DistMatrix benchmark on 200 sequences of 10,000 nucleotides.
+// It is here rather than in align_test.go, as the align package
+// can not import the dna package.
+func BenchmarkDistMatrix(b *testing.B) {
+	a, err := align.RandomAlignment(align.NUCLEOTIDS, 10000, 200)
+	if err != nil {
+		b.Fatal(err)
+	}
+	m, err := Model("k2p", true)
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err = DistMatrix(a, nil, m, false, 1.0, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -53,9 +53,9 @@ func (model *ProtDistModel) MLDist(a align.Alignment, weights []float64) (p, q,
 
 	// Alignment of 2 sequences
 	for j = 0; j < a.NbSequences(); j++ { // begin for j->n_otu
-		seq1, _ := a.GetSequenceCharById(j)
+		seq1, _ := a.GetSequenceBytesById(j)
 		for k = j + 1; k < a.NbSequences(); k++ { // begin for k->n_otu
-			seq2, _ := a.GetSequenceCharById(k)
+			seq2, _ := a.GetSequenceBytesById(k)
 			pair := seqpairdist{j, k, seq1, seq2, nil, nil}
 
 			checkAmbiguities(&pair, 1)
@@ -78,8 +78,8 @@ func (model *ProtDistModel) MLDist(a align.Alignment, weights []float64) (p, q,
 						if pair.seq1Ambigu[l] || pair.seq2Ambigu[l] {
 							w = 0.0
 						}
-						state0 = a.AlphabetCharToIndex(pair.seq1[l])
-						state1 = a.AlphabetCharToIndex(pair.seq2[l])
+						state0 = a.AlphabetCharToIndex(rune(pair.seq1[l]))
+						state1 = a.AlphabetCharToIndex(rune(pair.seq2[l]))
 						if (state0 > -1) && (state1 > -1) {
 							Fs.Set(state0, state1, Fs.At(state0, state1)+w)
 							len += w
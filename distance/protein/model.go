@@ -78,10 +78,10 @@ func (model *ProtDistModel) JC69Dist(a align.Alignment, weights []float64, selec
 	for site = 0; site < a.Length(); site += model.stepsize {
 		if selected[site] {
 			for j = 0; j < a.NbSequences()-1; j++ {
-				s1, _ := a.GetSequenceCharById(j)
+				s1, _ := a.GetSequenceBytesById(j)
 				for k = j + 1; k < a.NbSequences(); k++ {
-					s2, _ := a.GetSequenceCharById(k)
-					if (!isAmbigu(s1[site])) && (!isAmbigu(s2[site])) {
+					s2, _ := a.GetSequenceBytesById(k)
+					if (!isAmbigu(rune(s1[site]))) && (!isAmbigu(rune(s2[site]))) {
 						len.Set(j, k, len.At(j, k)+weights[site])
 						len.Set(k, j, weights[site])
 						for n, c1 := range s1[site : site+model.stepsize] {
@@ -9,7 +9,7 @@ import (
 
 type seqpairdist struct {
 	i, j       int
-	seq1, seq2 []rune
+	seq1, seq2 []byte
 	seq1Ambigu []bool
 	seq2Ambigu []bool
 }
@@ -25,8 +25,8 @@ func selectedSites(al align.Alignment, weights []float64, removeGappedPositions
 		}
 		selectedSites[l] = true
 		for i := 0; i < al.NbSequences() && removeGappedPositions; i++ {
-			seq, _ := al.GetSequenceCharById(i)
-			if al.AlphabetCharToIndex(seq[l]) == -1 || seq[l] == '*' || seq[l] == '?' || seq[l] == '-' {
+			seq, _ := al.GetSequenceBytesById(i)
+			if al.AlphabetCharToIndex(rune(seq[l])) == -1 || seq[l] == '*' || seq[l] == '?' || seq[l] == '-' {
 				selectedSites[l] = false
 			}
 		}
@@ -53,11 +53,11 @@ func aaFrequency(a align.Alignment, weights []float64, selected []bool) ([]float
 	}
 
 	// Count occurences of different amino acids
-	a.IterateChar(func(name string, sequence []rune) bool {
+	a.IterateBytes(func(name string, sequence []byte) bool {
 		for j = 0; j < len(sequence); j++ {
 			if selected[j] {
 				w = weights[j]
-				idx := a.AlphabetCharToIndex(sequence[j])
+				idx := a.AlphabetCharToIndex(rune(sequence[j]))
 				if idx >= 0 {
 					num[idx] += w
 				} else {
@@ -119,10 +119,10 @@ func checkAmbiguities(pair *seqpairdist, stepsize int) (ambig []bool) {
 	for j = 0; j < len(pair.seq1); j += stepsize {
 		pair.seq1Ambigu[j] = false
 		pair.seq2Ambigu[j] = false
-		if isAmbigu(pair.seq1[j]) {
+		if isAmbigu(rune(pair.seq1[j])) {
 			pair.seq1Ambigu[j] = true
 		}
-		if isAmbigu(pair.seq2[j]) {
+		if isAmbigu(rune(pair.seq2[j])) {
 			pair.seq2Ambigu[j] = true
 		}
 	}
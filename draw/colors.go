@@ -0,0 +1,119 @@
+package draw
+
+import (
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Colour schemes of residues
+const (
+	SCHEME_AUTO       = iota // SCHEME_NUCLEOTIDE for nucleotide alignments, SCHEME_CLUSTALX otherwise
+	SCHEME_NUCLEOTIDE        // A, C, G and T/U
+	SCHEME_CLUSTALX          // Amino acids, ClustalX colours by residue type
+	SCHEME_ZAPPO             // Amino acids, by physico-chemical properties
+	SCHEME_TAYLOR            // Amino acids, one colour per residue
+	SCHEME_NONE              // No colour
+)
+
+// Colours of nucleotides, as in Jalview
+var nucleotideColors = map[rune]color.RGBA{
+	'A': {0x64, 0xf7, 0x3f, 0xff},
+	'C': {0xff, 0xb3, 0x40, 0xff},
+	'G': {0xeb, 0x41, 0x3c, 0xff},
+	'T': {0x3c, 0x88, 0xee, 0xff},
+	'U': {0x3c, 0x88, 0xee, 0xff},
+}
+
+// Colours of amino acids, as in ClustalX (by residue type,
+// without taking into account the composition of the site)
+var clustalXColors = residueGroupColors(map[string]color.RGBA{
+	"AILMFWV": {0x80, 0xa0, 0xf0, 0xff}, // Hydrophobic
+	"KR":      {0xf0, 0x15, 0x05, 0xff}, // Positive charge
+	"ED":      {0xc0, 0x48, 0xc0, 0xff}, // Negative charge
+	"NQST":    {0x15, 0xc0, 0x15, 0xff}, // Polar
+	"C":       {0xf0, 0x80, 0x80, 0xff}, // Cysteine
+	"G":       {0xf0, 0x90, 0x48, 0xff}, // Glycine
+	"P":       {0xc0, 0xc0, 0x00, 0xff}, // Proline
+	"HY":      {0x15, 0xa4, 0xa4, 0xff}, // Aromatic
+})
+
+// Colours of amino acids, Zappo scheme
+var zappoColors = residueGroupColors(map[string]color.RGBA{
+	"ILVAM": {0xff, 0xaf, 0xaf, 0xff}, // Aliphatic/hydrophobic
+	"FWY":   {0xff, 0xc8, 0x00, 0xff}, // Aromatic
+	"KRH":   {0x64, 0x64, 0xff, 0xff}, // Positive
+	"DE":    {0xff, 0x00, 0x00, 0xff}, // Negative
+	"STNQ":  {0x00, 0xff, 0x00, 0xff}, // Hydrophilic
+	"PG":    {0xff, 0x00, 0xff, 0xff}, // Conformationally special
+	"C":     {0xff, 0xff, 0x00, 0xff}, // Cysteine
+})
+
+// Colours of amino acids, Taylor scheme
+var taylorColors = map[rune]color.RGBA{
+	'A': {0xcc, 0xff, 0x00, 0xff}, 'R': {0x00, 0x00, 0xff, 0xff}, 'N': {0xcc, 0x00, 0xff, 0xff},
+	'D': {0xff, 0x00, 0x00, 0xff}, 'C': {0xff, 0xff, 0x00, 0xff}, 'Q': {0xff, 0x00, 0xcc, 0xff},
+	'E': {0xff, 0x00, 0x66, 0xff}, 'G': {0xff, 0x99, 0x00, 0xff}, 'H': {0x00, 0x66, 0xff, 0xff},
+	'I': {0x66, 0xff, 0x00, 0xff}, 'L': {0x33, 0xff, 0x00, 0xff}, 'K': {0x66, 0x00, 0xff, 0xff},
+	'M': {0x00, 0xff, 0x00, 0xff}, 'F': {0x00, 0xff, 0x66, 0xff}, 'P': {0xff, 0xcc, 0x00, 0xff},
+	'S': {0xff, 0x33, 0x00, 0xff}, 'T': {0xff, 0x66, 0x00, 0xff}, 'W': {0x00, 0xcc, 0xff, 0xff},
+	'Y': {0x00, 0xff, 0xcc, 0xff}, 'V': {0x99, 0xff, 0x00, 0xff},
+}
+
+func residueGroupColors(groups map[string]color.RGBA) map[rune]color.RGBA {
+	colors := make(map[rune]color.RGBA)
+	for residues, c := range groups {
+		for _, r := range residues {
+			colors[r] = c
+		}
+	}
+	return colors
+}
+
+// SchemeFromString returns the colour scheme corresponding to the given
+// name: auto, nucleotide, clustalx, zappo, taylor or none
+func SchemeFromString(scheme string) (int, error) {
+	switch strings.ToLower(scheme) {
+	case "auto":
+		return SCHEME_AUTO, nil
+	case "nucleotide":
+		return SCHEME_NUCLEOTIDE, nil
+	case "clustalx":
+		return SCHEME_CLUSTALX, nil
+	case "zappo":
+		return SCHEME_ZAPPO, nil
+	case "taylor":
+		return SCHEME_TAYLOR, nil
+	case "none":
+		return SCHEME_NONE, nil
+	default:
+		return -1, fmt.Errorf("Unknown colour scheme: %s", scheme)
+	}
+}
+
+// schemeColors returns the colours of the residues in the given scheme
+// (nil for SCHEME_NONE). SCHEME_AUTO depends on the given alphabet.
+func schemeColors(scheme int, nucleotides bool) map[rune]color.RGBA {
+	switch scheme {
+	case SCHEME_AUTO:
+		if nucleotides {
+			return nucleotideColors
+		}
+		return clustalXColors
+	case SCHEME_NUCLEOTIDE:
+		return nucleotideColors
+	case SCHEME_CLUSTALX:
+		return clustalXColors
+	case SCHEME_ZAPPO:
+		return zappoColors
+	case SCHEME_TAYLOR:
+		return taylorColors
+	default:
+		return nil
+	}
+}
+
+// hexColor returns the #rrggbb representation of the colour
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)
+}
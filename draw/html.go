@@ -0,0 +1,234 @@
+package draw
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"image/color"
+	"sort"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+type htmlLayout struct {
+	writer *bufio.Writer
+}
+
+// htmlData is the alignment, as embedded in the html file (json)
+type htmlData struct {
+	Alphabet     string   `json:"alphabet"`
+	Names        []string `json:"names"`
+	Sequences    []string `json:"sequences"`
+	Consensus    string   `json:"consensus"`
+	Conservation string   `json:"conservation"` // Clustal like conservation line: *, :, . or space
+}
+
+// NewHTMLLayout returns a layout that draws the alignment in a self
+// contained html file: javascript and css are embedded in the file,
+// so that it can be viewed without network access.
+func NewHTMLLayout(writer *bufio.Writer) AlignLayout {
+	return &htmlLayout{writer}
+}
+
+/*
+Draw the alignment on the html writer, with:
+  - position rulers,
+  - the majority consensus and the conservation of each site (see SiteConservation),
+  - nucleotide and amino acid colour schemes,
+  - a search field to filter sequences by name.
+
+Does not close the file. The caller must do it.
+*/
+func (layout *htmlLayout) DrawAlign(a align.Alignment) (err error) {
+	var data []byte
+
+	d := htmlData{
+		Alphabet:  a.AlphabetStr(),
+		Names:     make([]string, 0, a.NbSequences()),
+		Sequences: make([]string, 0, a.NbSequences()),
+	}
+	a.Iterate(func(name string, sequence string) bool {
+		d.Names = append(d.Names, name)
+		d.Sequences = append(d.Sequences, sequence)
+		return false
+	})
+	if a.NbSequences() > 0 {
+		d.Consensus, _ = a.Consensus(false).GetSequenceById(0)
+	}
+	d.Conservation = string(conservationLine(a))
+
+	// json.Marshal escapes <, > and &, data can be safely embedded in a script element
+	if data, err = json.Marshal(d); err != nil {
+		return
+	}
+
+	layout.writer.WriteString(htmlHeader)
+	writeHTMLScheme(layout.writer, "nt", nucleotideColors)
+	writeHTMLScheme(layout.writer, "aa", clustalXColors)
+	layout.writer.WriteString(htmlBody)
+	layout.writer.WriteString(`<script type="application/json" id="goalign-data">`)
+	layout.writer.Write(data)
+	layout.writer.WriteString("</script>\n<script>")
+	layout.writer.WriteString(htmlScript)
+	_, err = layout.writer.WriteString("</script>\n</body>\n</html>\n")
+	return
+}
+
+// writeHTMLScheme writes the css rules of the given colour scheme: residues
+// are in elements of class r<character code>, see htmlScript
+func writeHTMLScheme(w *bufio.Writer, scheme string, colors map[rune]color.RGBA) {
+	chars := make([]rune, 0, len(colors))
+	for c := range colors {
+		chars = append(chars, c)
+	}
+	sort.Slice(chars, func(i, j int) bool { return chars[i] < chars[j] })
+	for _, c := range chars {
+		w.WriteString(fmt.Sprintf(".scheme-%s .r%d { background-color: %s; }\n", scheme, c, hexColor(colors[c])))
+	}
+}
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Alignment</title>
+<style>
+body { margin: 0; font-family: sans-serif; font-size: 13px; }
+#controls { padding: 6px 10px; border-bottom: 1px solid #ccc; height: 24px; }
+#controls label { margin-right: 15px; }
+#info { color: #555; }
+#viewer { display: flex; overflow: auto; height: calc(100vh - 37px); }
+#names, #residues { font-family: monospace; font-size: 13px; }
+#names { position: sticky; left: 0; z-index: 2; background: #fff; border-right: 1px solid #ccc; padding-right: 8px; }
+#residues { padding-left: 8px; }
+.row { height: 16px; line-height: 16px; white-space: pre; }
+#names .row { max-width: 300px; overflow: hidden; text-overflow: ellipsis; padding-left: 10px; }
+.ruler { position: sticky; z-index: 1; background: #fff; color: #777; }
+.ruler1 { top: 0; }
+.ruler2 { top: 16px; border-bottom: 1px solid #ccc; }
+.consensus { border-top: 1px solid #ccc; font-weight: bold; }
+.conservation { color: #555; }
+.hidden { display: none; }
+`
+
+const htmlBody = `</style>
+</head>
+<body>
+<div id="controls">
+<label>Colours <select id="scheme">
+<option value="nt">Nucleotides</option>
+<option value="aa">Amino acids (ClustalX)</option>
+<option value="none">None</option>
+</select></label>
+<label>Search <input id="search" type="text" placeholder="Sequence name"></label>
+<span id="info"></span>
+</div>
+<div id="viewer">
+<div id="names"></div>
+<div id="residues"></div>
+</div>
+`
+
+const htmlScript = `
+(function () {
+  var data = JSON.parse(document.getElementById("goalign-data").textContent);
+  var names = document.getElementById("names");
+  var residues = document.getElementById("residues");
+  var scheme = document.getElementById("scheme");
+  var search = document.getElementById("search");
+  var info = document.getElementById("info");
+  var length = data.sequences.length > 0 ? data.sequences[0].length : 0;
+  var rows = [];
+
+  function escapeHTML(s) {
+    return s.replace(/&/g, "&amp;").replace(/</g, "&lt;").replace(/>/g, "&gt;");
+  }
+
+  // Runs of identical residues are put in the same span, of class r<character code>
+  function residueHTML(seq) {
+    var html = [];
+    var i = 0;
+    while (i < seq.length) {
+      var c = seq.charAt(i).toUpperCase();
+      var j = i + 1;
+      while (j < seq.length && seq.charAt(j).toUpperCase() === c) {
+        j++;
+      }
+      html.push('<span class="r' + c.charCodeAt(0) + '">' + escapeHTML(seq.substring(i, j)) + "</span>");
+      i = j;
+    }
+    return html.join("");
+  }
+
+  function addRow(name, seq, cls, colored) {
+    var n = document.createElement("div");
+    var r = document.createElement("div");
+    n.className = "row " + cls;
+    n.textContent = name;
+    n.title = name;
+    r.className = "row " + cls;
+    if (colored) {
+      r.innerHTML = residueHTML(seq);
+    } else {
+      r.textContent = seq;
+    }
+    names.appendChild(n);
+    residues.appendChild(r);
+    return { name: name.toLowerCase(), elements: [n, r] };
+  }
+
+  // Position numbers (1-based, every 10 sites) and ticks
+  function rulers() {
+    var numbers = [];
+    var ticks = [];
+    var p, k, label;
+    for (p = 1; p <= length; p++) {
+      numbers.push(" ");
+      ticks.push(p % 10 === 0 ? "|" : (p % 5 === 0 ? ":" : "."));
+    }
+    if (length > 0) {
+      numbers[0] = "1";
+    }
+    for (p = 10; p <= length; p += 10) {
+      label = String(p);
+      for (k = 0; k < label.length; k++) {
+        if (p - label.length + k >= 0) {
+          numbers[p - label.length + k] = label.charAt(k);
+        }
+      }
+    }
+    addRow("", numbers.join(""), "ruler ruler1", false);
+    addRow("", ticks.join(""), "ruler ruler2", false);
+  }
+
+  function filter() {
+    var q = search.value.toLowerCase();
+    var shown = 0;
+    rows.forEach(function (row) {
+      var hide = q !== "" && row.name.indexOf(q) < 0;
+      row.elements.forEach(function (e) {
+        e.classList.toggle("hidden", hide);
+      });
+      if (!hide) {
+        shown++;
+      }
+    });
+    info.textContent = shown + " / " + rows.length + " sequences, " + length + " sites (" + data.alphabet + ")";
+  }
+
+  rulers();
+  data.names.forEach(function (name, i) {
+    rows.push(addRow(name, data.sequences[i], "sequence", true));
+  });
+  addRow("Consensus", data.consensus, "consensus", true);
+  addRow("Conservation", data.conservation, "conservation", false);
+
+  scheme.value = data.alphabet === "protein" ? "aa" : "nt";
+  residues.className = "scheme-" + scheme.value;
+  scheme.addEventListener("change", function () {
+    residues.className = "scheme-" + scheme.value;
+  });
+  search.addEventListener("input", filter);
+  filter();
+})();
+`
@@ -0,0 +1,69 @@
+package draw
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// htmlTestData returns the json data embedded in the html output
+func htmlTestData(t *testing.T, a align.Alignment) (raw string, d htmlData) {
+	var buf bytes.Buffer
+
+	w := bufio.NewWriter(&buf)
+	if err := NewHTMLLayout(w).DrawAlign(a); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	out := buf.String()
+	start := strings.Index(out, `<script type="application/json" id="goalign-data">`)
+	if start < 0 {
+		t.Fatalf("No json data in html output")
+	}
+	raw = out[start+len(`<script type="application/json" id="goalign-data">`):]
+	raw = raw[:strings.Index(raw, "</script>")]
+	if err := json.Unmarshal([]byte(raw), &d); err != nil {
+		t.Fatalf("Wrong json data: %v", err)
+	}
+	if strings.Contains(out, "http") {
+		t.Errorf("html output should not use the network")
+	}
+	return
+}
+
+func TestHTMLLayout(t *testing.T) {
+	_, d := htmlTestData(t, testImageAlign())
+	if d.Alphabet != "nucleotide" {
+		t.Errorf("Wrong alphabet: %s", d.Alphabet)
+	}
+	if strings.Join(d.Names, ",") != "s1,s2,s3" || strings.Join(d.Sequences, ",") != "ACGT-ACGTA,ACCT-ACGTA,ACGTTACG-A" {
+		t.Errorf("Wrong names or sequences: %v %v", d.Names, d.Sequences)
+	}
+	if d.Consensus != "ACGT-ACGTA" {
+		t.Errorf("Wrong consensus: %s", d.Consensus)
+	}
+	if d.Conservation != "** * *** *" {
+		t.Errorf("Wrong conservation: %q", d.Conservation)
+	}
+}
+
+func TestHTMLLayoutEscape(t *testing.T) {
+	a := align.NewAlign(align.NUCLEOTIDS)
+	a.AddSequence("s<1>", "ACGT", "")
+	a.AddSequence("a&b</script>", "ACGA", "")
+
+	raw, d := htmlTestData(t, a)
+	if strings.ContainsAny(raw, "<>&") {
+		t.Errorf("<, > and & should be escaped in json data: %s", raw)
+	}
+	if !strings.Contains(raw, `"s\u003c1\u003e"`) || !strings.Contains(raw, `"a\u0026b\u003c/script\u003e"`) {
+		t.Errorf("Names are not escaped as expected: %s", raw)
+	}
+	if strings.Join(d.Names, ",") != "s<1>,a&b</script>" {
+		t.Errorf("Wrong names: %v", d.Names)
+	}
+}
@@ -0,0 +1,286 @@
+package draw
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+	"unicode"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Annotation tracks drawn below the sequences
+const (
+	TRACK_CONSENSUS = iota // Majority consensus (gaps included)
+	TRACK_GAPS             // Fraction of gaps of each site
+	TRACK_ENTROPY          // Entropy of each site (gaps excluded), divided by its maximum value (log of the alphabet size)
+)
+
+// Sizes of the drawn elements, in pixels
+const (
+	imageCellWidth   = 10
+	imageCellHeight  = 14
+	imageCharWidth   = 7  // Width of a character of the font
+	imageBaseline    = 11 // Position of the baseline of the characters, from the top of the cell
+	imageMargin      = 5
+	imageBarHeight   = 30 // Height of gaps and entropy tracks
+	imageRulerHeight = 20
+	imageMaxPixels   = 50000000 // Larger images are refused (200MB for png)
+)
+
+var (
+	imageBackground = color.RGBA{0xff, 0xff, 0xff, 0xff}
+	imageForeground = color.RGBA{0x00, 0x00, 0x00, 0xff}
+	imageLight      = color.RGBA{0x80, 0x80, 0x80, 0xff}
+	imageBars       = color.RGBA{0x55, 0x55, 0x55, 0xff}
+	imageBarsBack   = color.RGBA{0xee, 0xee, 0xee, 0xff}
+)
+
+// TrackFromString returns the track corresponding to the given
+// name: consensus, gaps or entropy
+func TrackFromString(track string) (int, error) {
+	switch track {
+	case "consensus":
+		return TRACK_CONSENSUS, nil
+	case "gaps":
+		return TRACK_GAPS, nil
+	case "entropy":
+		return TRACK_ENTROPY, nil
+	default:
+		return -1, fmt.Errorf("Unknown track: %s", track)
+	}
+}
+
+// ImageLayout is a layout drawing a window of the alignment
+// as a static image (see NewSVGLayout and NewPNGLayout)
+type ImageLayout interface {
+	AlignLayout
+	SetScheme(scheme int)        // Colour scheme, SCHEME_AUTO by default
+	SetWindow(start, length int) // Window of the alignment to draw (0-based start), length < 0: until the end
+	SetReference(name string)    // Characters identical to this sequence are drawn as . without colour
+	SetRuler(ruler bool)         // Draws position ticks and numbers above the alignment (default true)
+	SetTracks(tracks ...int)     // Tracks to draw below the sequences, in the given order
+}
+
+// canvas is the device on which an imageLayout draws
+// (coordinates in pixels, from the top left corner)
+type canvas interface {
+	begin(width, height int) error
+	rect(x, y, width, height int, c color.RGBA)
+	line(x1, y1, x2, y2 int, c color.RGBA)
+	// text draws the string with its baseline at y,
+	// starting at x, or centered on x if center is true
+	text(x, y int, s string, c color.RGBA, center bool)
+	end() error
+}
+
+type imageLayout struct {
+	canvas    canvas
+	scheme    int
+	start     int
+	length    int
+	reference string
+	ruler     bool
+	tracks    []int
+}
+
+func newImageLayout(c canvas) *imageLayout {
+	return &imageLayout{
+		canvas: c,
+		scheme: SCHEME_AUTO,
+		start:  0,
+		length: -1,
+		ruler:  true,
+		tracks: nil,
+	}
+}
+
+func (layout *imageLayout) SetScheme(scheme int) {
+	layout.scheme = scheme
+}
+
+func (layout *imageLayout) SetWindow(start, length int) {
+	layout.start = start
+	layout.length = length
+}
+
+func (layout *imageLayout) SetReference(name string) {
+	layout.reference = name
+}
+
+func (layout *imageLayout) SetRuler(ruler bool) {
+	layout.ruler = ruler
+}
+
+func (layout *imageLayout) SetTracks(tracks ...int) {
+	layout.tracks = tracks
+}
+
+/*
+Draw the window of the alignment on the canvas: names on the left,
+sequences coloured with the colour scheme, ruler above and tracks below.
+
+Does not close the file. The caller must do it.
+*/
+func (layout *imageLayout) DrawAlign(a align.Alignment) (err error) {
+	var win align.Alignment
+	var ref []byte
+	var colors map[rune]color.RGBA
+
+	length := layout.length
+	if length < 0 || layout.start+length > a.Length() {
+		length = a.Length() - layout.start
+	}
+
+	// Width of the name column: longest name or track label
+	namewidth := len("Consensus")
+	if a.MaxNameLength() > namewidth {
+		namewidth = a.MaxNameLength()
+	}
+	left := imageMargin + namewidth*imageCharWidth + imageMargin
+	y := imageMargin
+	height := imageMargin + a.NbSequences()*imageCellHeight + imageMargin
+	if layout.ruler {
+		height += imageRulerHeight
+	}
+	for _, t := range layout.tracks {
+		height += layout.trackHeight(t) + imageMargin
+	}
+	width := left + length*imageCellWidth + imageMargin
+	if width*height > imageMaxPixels {
+		return fmt.Errorf("Image would be too large (%dx%d pixels): draw a smaller window of the alignment (--start and --length)", width, height)
+	}
+
+	if win, err = a.SubAlign(layout.start, length); err != nil {
+		return
+	}
+	if layout.reference != "" {
+		var s align.Sequence
+		var ok bool
+		if s, ok = win.GetSequenceByName(layout.reference); !ok {
+			return fmt.Errorf("Reference sequence %s does not exist in the alignment", layout.reference)
+		}
+		ref = []byte(s.Sequence())
+	}
+	colors = schemeColors(layout.scheme, a.Alphabet() == align.NUCLEOTIDS)
+
+	if err = layout.canvas.begin(width, height); err != nil {
+		return
+	}
+	layout.canvas.rect(0, 0, width, height, imageBackground)
+
+	// Ruler: ticks every 5 sites, numbers (1-based) every 10 sites
+	if layout.ruler {
+		for i := 0; i < length; i++ {
+			pos := layout.start + i + 1
+			x := left + i*imageCellWidth + imageCellWidth/2
+			if pos%10 == 0 || i == 0 {
+				layout.canvas.text(x, y+imageBaseline-2, strconv.Itoa(pos), imageForeground, true)
+				layout.canvas.line(x, y+imageRulerHeight-7, x, y+imageRulerHeight-1, imageForeground)
+			} else if pos%5 == 0 {
+				layout.canvas.line(x, y+imageRulerHeight-5, x, y+imageRulerHeight-1, imageLight)
+			}
+		}
+		y += imageRulerHeight
+	}
+
+	// Sequences
+	win.IterateBytes(func(name string, sequence []byte) bool {
+		layout.canvas.text(imageMargin, y+imageBaseline, name, imageForeground, false)
+		diff := ref != nil && name != layout.reference
+		layout.drawResidues(left, y, sequence, ref, diff, colors)
+		y += imageCellHeight
+		return false
+	})
+	y += imageMargin
+
+	// Tracks
+	for _, t := range layout.tracks {
+		switch t {
+		case TRACK_CONSENSUS:
+			cons, _ := win.Consensus(false).GetSequenceById(0)
+			layout.canvas.text(imageMargin, y+imageBaseline, "Consensus", imageForeground, false)
+			layout.drawResidues(left, y, []byte(cons), nil, false, colors)
+		case TRACK_GAPS:
+			layout.canvas.text(imageMargin, y+imageBarHeight/2+imageBaseline/2, "Gaps", imageForeground, false)
+			layout.drawBars(left, y, gapFractions(win))
+		case TRACK_ENTROPY:
+			layout.canvas.text(imageMargin, y+imageBarHeight/2+imageBaseline/2, "Entropy", imageForeground, false)
+			layout.drawBars(left, y, normalizedEntropies(win))
+		}
+		y += layout.trackHeight(t) + imageMargin
+	}
+
+	return layout.canvas.end()
+}
+
+func (layout *imageLayout) trackHeight(track int) int {
+	if track == TRACK_CONSENSUS {
+		return imageCellHeight
+	}
+	return imageBarHeight
+}
+
+// drawResidues draws a row of residues starting at (x,y). If diff is true,
+// residues identical to the reference are drawn as . without colour, as
+// in Alignment.DiffWithFirst
+func (layout *imageLayout) drawResidues(x, y int, sequence, ref []byte, diff bool, colors map[rune]color.RGBA) {
+	for i, c := range sequence {
+		cx := x + i*imageCellWidth
+		if diff && ref[i] == c {
+			layout.canvas.text(cx+imageCellWidth/2, y+imageBaseline, ".", imageLight, true)
+			continue
+		}
+		if col, ok := colors[unicode.ToUpper(rune(c))]; ok {
+			layout.canvas.rect(cx, y, imageCellWidth, imageCellHeight, col)
+		}
+		layout.canvas.text(cx+imageCellWidth/2, y+imageBaseline, string(rune(c)), imageForeground, true)
+	}
+}
+
+// drawBars draws one bar per site, values being between 0 and 1
+func (layout *imageLayout) drawBars(x, y int, values []float64) {
+	layout.canvas.rect(x, y, len(values)*imageCellWidth, imageBarHeight, imageBarsBack)
+	for i, v := range values {
+		if h := int(math.Round(v * imageBarHeight)); h > 0 {
+			layout.canvas.rect(x+i*imageCellWidth+1, y+imageBarHeight-h, imageCellWidth-2, h, imageBars)
+		}
+	}
+}
+
+// gapFractions returns the fraction of gaps of each site of the alignment
+func gapFractions(a align.Alignment) (fractions []float64) {
+	fractions = make([]float64, a.Length())
+	if a.NbSequences() == 0 {
+		return
+	}
+	a.IterateBytes(func(name string, sequence []byte) bool {
+		for i, c := range sequence {
+			if c == align.GAP {
+				fractions[i]++
+			}
+		}
+		return false
+	})
+	for i := range fractions {
+		fractions[i] /= float64(a.NbSequences())
+	}
+	return
+}
+
+// normalizedEntropies returns the entropy of each site of the alignment (gaps
+// excluded), divided by the log of the size of the alphabet, and capped to 1.
+// Sites made only of gaps have an entropy of 0.
+func normalizedEntropies(a align.Alignment) (entropies []float64) {
+	max := math.Log(float64(len(a.AlphabetCharacters())))
+	entropies = make([]float64, a.Length())
+	for i := range entropies {
+		e, _ := a.Entropy(i, true)
+		if math.IsNaN(e) {
+			e = 0
+		}
+		entropies[i] = math.Min(e/max, 1.0)
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package draw
+
+import (
+	"bufio"
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+func testImageAlign() align.Alignment {
+	a := align.NewAlign(align.NUCLEOTIDS)
+	a.AddSequence("s1", "ACGT-ACGTA", "")
+	a.AddSequence("s2", "ACCT-ACGTA", "")
+	a.AddSequence("s3", "ACGTTACG-A", "")
+	return a
+}
+
+func TestSVGLayout(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := bufio.NewWriter(&buf)
+	l := NewSVGLayout(w)
+	l.SetWindow(1, 4)
+	l.SetReference("s1")
+	l.SetTracks(TRACK_CONSENSUS, TRACK_GAPS)
+	if err := l.DrawAlign(testImageAlign()); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	svg := buf.String()
+
+	// Window: CGT-, s2 differs from s1 at the second position only (C),
+	// ruler starts at position 2
+	for _, exp := range []string{
+		`width="118" height="126"`,
+		`<text x="78" y="14" fill="#000000" text-anchor="middle">2</text>`,
+		`<rect x="83" y="39" width="10" height="14" fill="#ffb340"/>`,
+		`<text x="88" y="50" fill="#000000" text-anchor="middle">C</text>`,
+		`<text x="78" y="50" fill="#808080" text-anchor="middle">.</text>`,
+		`>Consensus</text>`,
+		// 2/3 of gaps at the last site
+		`<rect x="104" y="101" width="8" height="20" fill="#555555"/>`,
+	} {
+		if !strings.Contains(svg, exp) {
+			t.Errorf("SVG should contain %s", exp)
+		}
+	}
+	if strings.Count(svg, "<text") != 22 {
+		t.Errorf("Wrong number of texts in SVG: %d", strings.Count(svg, "<text"))
+	}
+
+	l.SetReference("s4")
+	if err := l.DrawAlign(testImageAlign()); err == nil {
+		t.Errorf("Drawing with an unknown reference should return an error")
+	}
+}
+
+func TestPNGLayout(t *testing.T) {
+	var buf bytes.Buffer
+
+	l := NewPNGLayout(&buf)
+	l.SetScheme(SCHEME_NONE)
+	l.SetRuler(false)
+	l.SetTracks(TRACK_ENTROPY)
+	if err := l.DrawAlign(testImageAlign()); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 178 || b.Dy() != 87 {
+		t.Errorf("Wrong image size: %dx%d", b.Dx(), b.Dy())
+	}
+
+	// Too large images are refused, smaller windows are drawn
+	a := align.NewAlign(align.NUCLEOTIDS)
+	a.AddSequence("s1", strings.Repeat("ACGT", 2000000), "")
+	buf.Reset()
+	l = NewPNGLayout(&buf)
+	if err := l.DrawAlign(a); err == nil {
+		t.Errorf("Drawing a too large image should return an error")
+	}
+	l.SetWindow(1000, 100)
+	if err := l.DrawAlign(a); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,252 @@
+package draw
+
+import (
+	"bufio"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+	"unicode"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Sizes of the drawn logo elements, in pixels
+const (
+	logoColumnWidth = 20
+	logoHeight      = 150 // Height of a stack having the maximum information content
+	logoLeft        = 45  // Space for the y axis
+	logoTop         = 10
+	logoBottom      = 40 // Space for the positions
+	logoFontSize    = 100
+	logoCapHeight   = 71.6 // Height of capital letters, in font units, for logoFontSize
+)
+
+// Colours of nucleotides, as in WebLogo
+var logoNucleotideColors = map[rune]color.RGBA{
+	'A': {0x10, 0x96, 0x48, 0xff},
+	'C': {0x25, 0x5c, 0x99, 0xff},
+	'G': {0xf7, 0xb3, 0x2b, 0xff},
+	'T': {0xd6, 0x28, 0x39, 0xff},
+	'U': {0xd6, 0x28, 0x39, 0xff},
+}
+
+// Colours of amino acids by chemical properties, as in WebLogo
+var logoAminoAcidColors = residueGroupColors(map[string]color.RGBA{
+	"GSTYC":    {0x10, 0x96, 0x48, 0xff}, // Polar
+	"NQ":       {0x5e, 0x23, 0x9d, 0xff}, // Neutral
+	"KRH":      {0x25, 0x5c, 0x99, 0xff}, // Basic
+	"DE":       {0xd6, 0x28, 0x39, 0xff}, // Acidic
+	"AVLIPWFM": {0x22, 0x1e, 0x1f, 0xff}, // Hydrophobic
+})
+
+// LogoLayout is a layout drawing the sequence logo of
+// a window of the alignment in svg (see NewLogoLayout)
+type LogoLayout interface {
+	AlignLayout
+	SetScheme(scheme int)         // Colour scheme, SCHEME_AUTO (WebLogo colours) by default
+	SetWindow(start, length int)  // Window of the alignment to draw (0-based start), length < 0: until the end
+	SetReference(name string)     // Positions are given in coordinates of this sequence
+	SetWeights(weights []float64) // Weights of the sequences, in the order of the alignment (nil: no weights)
+	SetErrorBars(errorbars bool)  // Draws small sample error bars (default true)
+}
+
+type logoLayout struct {
+	svg       *svgCanvas
+	scheme    int
+	start     int
+	length    int
+	reference string
+	weights   []float64
+	errorbars bool
+}
+
+// NewLogoLayout returns a layout that draws the sequence logo of the
+// alignment in svg. The height of each stack is the information content
+// of the site, and the height of each letter is proportional to its
+// frequency (see Alignment.Pssm with PSSM_NORM_LOGO).
+func NewLogoLayout(writer *bufio.Writer) LogoLayout {
+	return &logoLayout{
+		svg:       &svgCanvas{writer},
+		scheme:    SCHEME_AUTO,
+		start:     0,
+		length:    -1,
+		errorbars: true,
+	}
+}
+
+func (layout *logoLayout) SetScheme(scheme int) {
+	layout.scheme = scheme
+}
+
+func (layout *logoLayout) SetWindow(start, length int) {
+	layout.start = start
+	layout.length = length
+}
+
+func (layout *logoLayout) SetReference(name string) {
+	layout.reference = name
+}
+
+func (layout *logoLayout) SetWeights(weights []float64) {
+	layout.weights = weights
+}
+
+func (layout *logoLayout) SetErrorBars(errorbars bool) {
+	layout.errorbars = errorbars
+}
+
+/*
+Draw the sequence logo of the window of the alignment.
+
+Error bars represent the small sample correction of the information content:
+e(n) = (s-1)/(2*ln(2)*n), s being the size of the alphabet and n the number of
+sequences (sum of their weights) without gap at the site. They are drawn
+around the top of each stack.
+
+Does not close the file. The caller must do it.
+*/
+func (layout *logoLayout) DrawAlign(a align.Alignment) (err error) {
+	var win align.Alignment
+	var pssm map[rune][]float64
+	var labels []string
+	var colors map[rune]color.RGBA
+
+	length := layout.length
+	if length < 0 || layout.start+length > a.Length() {
+		length = a.Length() - layout.start
+	}
+	if win, err = a.SubAlign(layout.start, length); err != nil {
+		return
+	}
+	if pssm, err = win.WeightedPssm(layout.weights, false, 0, align.PSSM_NORM_LOGO); err != nil {
+		return
+	}
+	if labels, err = layout.positionLabels(a, length); err != nil {
+		return
+	}
+
+	nucleotides := a.Alphabet() == align.NUCLEOTIDS
+	colors = schemeColors(layout.scheme, nucleotides)
+	if layout.scheme == SCHEME_AUTO {
+		colors = logoAminoAcidColors
+		if nucleotides {
+			colors = logoNucleotideColors
+		}
+	}
+	alphabet := win.AlphabetCharacters()
+	maxbits := math.Log2(float64(len(alphabet)))
+	scale := logoHeight / maxbits
+	bottom := logoTop + logoHeight
+	width := logoLeft + length*logoColumnWidth + imageMargin
+	height := bottom + logoBottom
+
+	if err = layout.svg.begin(width, height); err != nil {
+		return
+	}
+	layout.svg.rect(0, 0, width, height, imageBackground)
+
+	// Y axis, in bits
+	layout.svg.line(logoLeft-5, logoTop, logoLeft-5, bottom, imageForeground)
+	for b := 0; b <= int(maxbits); b++ {
+		y := bottom - int(math.Round(float64(b)*scale))
+		layout.svg.line(logoLeft-9, y, logoLeft-5, y, imageForeground)
+		layout.svg.text(logoLeft-16, y+4, strconv.Itoa(b), imageForeground, true)
+	}
+	fmt.Fprintf(layout.svg.writer, "<text transform=\"translate(%d,%d) rotate(-90)\" text-anchor=\"middle\">bits</text>\n", 12, logoTop+logoHeight/2)
+
+	nongaps := nonGapWeights(win, layout.weights)
+	letters := make([]rune, len(alphabet))
+	for i := 0; i < length; i++ {
+		x := logoLeft + i*logoColumnWidth
+		// Letters are stacked by increasing height
+		copy(letters, alphabet)
+		sort.SliceStable(letters, func(k, l int) bool { return pssm[letters[k]][i] < pssm[letters[l]][i] })
+		y := float64(bottom)
+		for _, c := range letters {
+			h := pssm[c][i] * scale
+			if h < 0.5 {
+				continue
+			}
+			col, ok := colors[unicode.ToUpper(c)]
+			if !ok {
+				col = imageForeground
+			}
+			fmt.Fprintf(layout.svg.writer, "<text transform=\"translate(%d,%.2f) scale(1,%.4f)\" textLength=\"%d\" lengthAdjust=\"spacingAndGlyphs\" font-family=\"Arial, Helvetica, sans-serif\" font-weight=\"bold\" font-size=\"%d\" fill=\"%s\">%c</text>\n",
+				x+1, y, h/logoCapHeight, logoColumnWidth-2, logoFontSize, hexColor(col), c)
+			y -= h
+		}
+
+		// Small sample error bar around the top of the stack
+		if layout.errorbars && nongaps[i] > 0 {
+			e := (float64(len(alphabet)) - 1) / (2 * math.Ln2 * nongaps[i]) * scale
+			cx := x + logoColumnWidth/2
+			ytop := int(math.Round(math.Max(y-e, logoTop)))
+			ybot := int(math.Round(math.Min(y+e, float64(bottom))))
+			layout.svg.line(cx, ytop, cx, ybot, imageForeground)
+			layout.svg.line(cx-3, ytop, cx+3, ytop, imageForeground)
+			layout.svg.line(cx-3, ybot, cx+3, ybot, imageForeground)
+		}
+
+		if labels[i] != "" {
+			fmt.Fprintf(layout.svg.writer, "<text transform=\"translate(%d,%d) rotate(-90)\" text-anchor=\"end\" font-size=\"10\">%s</text>\n",
+				x+logoColumnWidth/2+4, bottom+5, labels[i])
+		}
+	}
+	layout.svg.line(logoLeft-5, bottom, width-imageMargin, bottom, imageForeground)
+
+	return layout.svg.end()
+}
+
+// positionLabels returns the 1-based positions of the sites of the window,
+// on the alignment, or on the reference sequence if any (empty label if the
+// reference has a gap at the site)
+func (layout *logoLayout) positionLabels(a align.Alignment, length int) (labels []string, err error) {
+	labels = make([]string, length)
+	if layout.reference == "" {
+		for i := range labels {
+			labels[i] = strconv.Itoa(layout.start + i + 1)
+		}
+		return
+	}
+
+	ref, ok := a.GetSequenceByName(layout.reference)
+	if !ok {
+		err = fmt.Errorf("Reference sequence %s does not exist in the alignment", layout.reference)
+		return
+	}
+	seq := ref.Sequence()
+	pos := 0
+	for i := 0; i < layout.start+length; i++ {
+		if seq[i] == align.GAP {
+			continue
+		}
+		pos++
+		if i >= layout.start {
+			labels[i-layout.start] = strconv.Itoa(pos)
+		}
+	}
+	return
+}
+
+// nonGapWeights returns, for each site, the number of sequences
+// (sum of their weights if not nil) not having a gap
+func nonGapWeights(a align.Alignment, weights []float64) (nongaps []float64) {
+	nongaps = make([]float64, a.Length())
+	i := 0
+	a.IterateBytes(func(name string, sequence []byte) bool {
+		w := 1.0
+		if weights != nil {
+			w = weights[i]
+		}
+		for site, c := range sequence {
+			if c != align.GAP {
+				nongaps[site] += w
+			}
+		}
+		i++
+		return false
+	})
+	return
+}
@@ -0,0 +1,62 @@
+package draw
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLogoLayout(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := bufio.NewWriter(&buf)
+	l := NewLogoLayout(w)
+	l.SetWindow(1, 3)
+	l.SetReference("s3")
+	if err := l.DrawAlign(testImageAlign()); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	svg := buf.String()
+
+	// Window: CGT, CCT, CGT. C and T: 2 bits, G/C: 1.08 bits
+	for _, exp := range []string{
+		`width="110" height="200"`,
+		`fill="#255c99">C</text>`,
+		`fill="#f7b32b">G</text>`,
+		`fill="#d62839">T</text>`,
+		`<text transform="translate(46,160.00) scale(1,2.0950)"`,
+		// Positions on s3 (no gap before the window)
+		`font-size="10">4</text>`,
+	} {
+		if !strings.Contains(svg, exp) {
+			t.Errorf("Logo should contain %s", exp)
+		}
+	}
+	if strings.Count(svg, "font-size=\"100\"") != 4 {
+		t.Errorf("Wrong number of letters in logo: %d", strings.Count(svg, "font-size=\"100\""))
+	}
+
+	// Weights: G of s1 and s3 weighted 0, only C remains at the second site
+	buf.Reset()
+	l.SetReference("")
+	l.SetWeights([]float64{0, 1, 0})
+	l.SetErrorBars(false)
+	if err := l.DrawAlign(testImageAlign()); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	svg = buf.String()
+	if strings.Contains(svg, ">G</text>") {
+		t.Errorf("Logo should not contain G with null weights")
+	}
+	if strings.Contains(svg, "<line x1=\"55\"") {
+		t.Errorf("Logo should not contain error bars")
+	}
+
+	l.SetWeights([]float64{1})
+	if err := l.DrawAlign(testImageAlign()); err == nil {
+		t.Errorf("Drawing with a wrong number of weights should return an error")
+	}
+}
@@ -0,0 +1,61 @@
+package draw
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+type pngCanvas struct {
+	writer io.Writer
+	img    *image.RGBA
+}
+
+// NewPNGLayout returns a layout that draws the alignment in png
+// (characters are drawn with a 7x13 pixels bitmap font)
+func NewPNGLayout(writer io.Writer) ImageLayout {
+	return newImageLayout(&pngCanvas{writer: writer})
+}
+
+func (c *pngCanvas) begin(width, height int) error {
+	c.img = image.NewRGBA(image.Rect(0, 0, width, height))
+	return nil
+}
+
+func (c *pngCanvas) rect(x, y, width, height int, col color.RGBA) {
+	draw.Draw(c.img, image.Rect(x, y, x+width, y+height), image.NewUniform(col), image.Point{}, draw.Src)
+}
+
+// line only draws vertical or horizontal lines
+func (c *pngCanvas) line(x1, y1, x2, y2 int, col color.RGBA) {
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	if y1 > y2 {
+		y1, y2 = y2, y1
+	}
+	c.rect(x1, y1, x2-x1+1, y2-y1+1, col)
+}
+
+func (c *pngCanvas) text(x, y int, s string, col color.RGBA, center bool) {
+	d := &font.Drawer{
+		Dst:  c.img,
+		Src:  image.NewUniform(col),
+		Face: basicfont.Face7x13,
+	}
+	if center {
+		x -= d.MeasureString(s).Round() / 2
+	}
+	d.Dot = fixed.P(x, y)
+	d.DrawString(s)
+}
+
+func (c *pngCanvas) end() error {
+	return png.Encode(c.writer, c.img)
+}
@@ -0,0 +1,45 @@
+package draw
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"image/color"
+)
+
+type svgCanvas struct {
+	writer *bufio.Writer
+}
+
+// NewSVGLayout returns a layout that draws the alignment in svg
+func NewSVGLayout(writer *bufio.Writer) ImageLayout {
+	return newImageLayout(&svgCanvas{writer})
+}
+
+func (c *svgCanvas) begin(width, height int) (err error) {
+	_, err = fmt.Fprintf(c.writer, `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="monospace" font-size="12">
+`, width, height, width, height)
+	return
+}
+
+func (c *svgCanvas) rect(x, y, width, height int, col color.RGBA) {
+	fmt.Fprintf(c.writer, "<rect x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" fill=\"%s\"/>\n", x, y, width, height, hexColor(col))
+}
+
+func (c *svgCanvas) line(x1, y1, x2, y2 int, col color.RGBA) {
+	fmt.Fprintf(c.writer, "<line x1=\"%d\" y1=\"%d\" x2=\"%d\" y2=\"%d\" stroke=\"%s\"/>\n", x1, y1, x2, y2, hexColor(col))
+}
+
+func (c *svgCanvas) text(x, y int, s string, col color.RGBA, center bool) {
+	anchor := ""
+	if center {
+		anchor = ` text-anchor="middle"`
+	}
+	fmt.Fprintf(c.writer, "<text x=\"%d\" y=\"%d\" fill=\"%s\"%s>%s</text>\n", x, y, hexColor(col), anchor, html.EscapeString(s))
+}
+
+func (c *svgCanvas) end() (err error) {
+	_, err = c.writer.WriteString("</svg>\n")
+	return
+}
@@ -0,0 +1,533 @@
+package draw
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// ANSI escape sequences
+const (
+	ansiReset        = "\x1b[0m"
+	ansiReverse      = "\x1b[7m"
+	ansiClearScreen  = "\x1b[H\x1b[2J"
+	ansiAlternateOn  = "\x1b[?1049h" // Switches to the alternate screen of the terminal
+	ansiAlternateOff = "\x1b[?1049l"
+)
+
+const (
+	termDefaultWrap    = 60
+	termNameSeparation = 2 // Spaces between names and residues
+	termFooterRows     = 2 // Consensus and conservation
+	termStatusLines    = 1
+)
+
+// TermLayout is a layout printing the alignment in a terminal, with ANSI
+// colours, as blocks of wrapped sites (see NewTermLayout). It may also
+// display the alignment in an interactive pager (see Page).
+type TermLayout interface {
+	AlignLayout
+	SetScheme(scheme int)      // Colour scheme, SCHEME_AUTO by default
+	SetWrap(wrap int)          // Number of sites per block, <= 0: no wrapping (default 60)
+	SetReference(name string)  // Residues identical to this sequence are printed as .
+	SetDiffOnly(diffonly bool) // Prints only the sites where a sequence differs from the reference
+	SetColors(colors bool)     // Prints ANSI colours (default true)
+	Page(a align.Alignment, commands io.Reader, width, height int) error
+}
+
+type termLayout struct {
+	writer    *bufio.Writer
+	scheme    int
+	wrap      int
+	reference string
+	diffonly  bool
+	colors    bool
+}
+
+// termView is the alignment as displayed in the terminal
+type termView struct {
+	names     []string
+	rows      [][]byte // Sequences, followed by the consensus and the conservation line
+	length    int
+	refname   string
+	ref       []byte
+	columns   []int // Displayed sites of the alignment
+	diffonly  bool
+	colors    map[rune]color.RGBA
+	namewidth int
+}
+
+// NewTermLayout returns a layout that prints the alignment in a terminal:
+// residues are coloured with ANSI (256 colours) escape sequences, and the
+// majority consensus and the conservation of each site (see
+// Alignment.SiteConservation) are printed below the sequences.
+func NewTermLayout(writer *bufio.Writer) TermLayout {
+	return &termLayout{
+		writer: writer,
+		scheme: SCHEME_AUTO,
+		wrap:   termDefaultWrap,
+		colors: true,
+	}
+}
+
+func (layout *termLayout) SetScheme(scheme int) {
+	layout.scheme = scheme
+}
+
+func (layout *termLayout) SetWrap(wrap int) {
+	layout.wrap = wrap
+}
+
+func (layout *termLayout) SetReference(name string) {
+	layout.reference = name
+}
+
+func (layout *termLayout) SetDiffOnly(diffonly bool) {
+	layout.diffonly = diffonly
+}
+
+func (layout *termLayout) SetColors(colors bool) {
+	layout.colors = colors
+}
+
+/*
+Print the alignment as blocks of wrapped sites. Each block has a position
+ruler (or vertical position numbers if only sites differing from the
+reference are printed), one line per sequence, the consensus and the
+conservation line (as in clustal format: * identical, : conserved,
+. semi-conserved).
+
+Does not close the file. The caller must do it.
+*/
+func (layout *termLayout) DrawAlign(a align.Alignment) (err error) {
+	var v *termView
+
+	if v, err = layout.view(a); err != nil {
+		return
+	}
+	if len(v.columns) == 0 {
+		if v.diffonly {
+			_, err = fmt.Fprintf(layout.writer, "No site differs from %s\n", layout.reference)
+		}
+		return
+	}
+	wrap := layout.wrap
+	if wrap <= 0 {
+		wrap = len(v.columns)
+	}
+	for start := 0; start < len(v.columns); start += wrap {
+		end := start + wrap
+		if end > len(v.columns) {
+			end = len(v.columns)
+		}
+		if start > 0 {
+			layout.writer.WriteString("\n")
+		}
+		for _, l := range v.header(start, end) {
+			layout.writer.WriteString(l)
+			layout.writer.WriteString("\n")
+		}
+		for r := range v.rows {
+			v.writeRow(layout.writer, r, start, end)
+			layout.writer.WriteString("\n")
+		}
+	}
+	return
+}
+
+/*
+Display the alignment in an interactive pager, on a terminal of the given
+size. Commands are read from the commands reader, one character each:
+  - h/l or left/right arrows: scroll one site left/right,
+  - k/j or up/down arrows: scroll one sequence up/down,
+  - H/L: scroll one screen left/right, K/J: one screen up/down,
+  - g/G: go to the start/end of the alignment,
+  - :<position> followed by enter: go to the given site (1-based),
+  - /<text> followed by enter: go to the next sequence whose name contains text,
+  - q: quit.
+
+The pager also quits at the end of the commands. The terminal should be in
+non canonical mode for commands to be executed without pressing enter.
+*/
+func (layout *termLayout) Page(a align.Alignment, commands io.Reader, width, height int) (err error) {
+	var v *termView
+	var b byte
+	var prompt []byte
+	var message string
+
+	if v, err = layout.view(a); err != nil {
+		return
+	}
+	in := bufio.NewReader(commands)
+	nbseqs := len(v.names)
+	ncols := width - v.namewidth - termNameSeparation
+	if ncols < 1 {
+		ncols = 1
+	}
+	nrows := height - len(v.header(0, 0)) - termFooterRows - termStatusLines
+	if nrows < 1 {
+		nrows = 1
+	}
+	left, top := 0, 0
+	inprompt := false
+
+	layout.writer.WriteString(ansiAlternateOn)
+	defer func() {
+		layout.writer.WriteString(ansiAlternateOff)
+		layout.writer.Flush()
+	}()
+
+	for {
+		left = clampInt(left, 0, len(v.columns)-ncols)
+		top = clampInt(top, 0, nbseqs-nrows)
+		status := message
+		if inprompt {
+			status = string(prompt)
+		} else if status == "" {
+			status = v.status(left, ncols, top, nrows)
+		}
+		layout.drawScreen(v, left, ncols, top, nrows, status, width)
+		message = ""
+		if err = layout.writer.Flush(); err != nil {
+			return
+		}
+
+		if b, err = in.ReadByte(); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = nil
+			}
+			return
+		}
+
+		if inprompt {
+			switch b {
+			case '\r', '\n':
+				inprompt = false
+				if prompt[0] == ':' {
+					left, message = v.findPosition(string(prompt[1:]), left)
+				} else {
+					top, message = v.findName(string(prompt[1:]), top)
+				}
+			case 0x7f, 0x08: // Backspace
+				prompt = prompt[:len(prompt)-1]
+				inprompt = len(prompt) > 0
+			case 0x1b: // Escape cancels the command
+				inprompt = false
+			default:
+				prompt = append(prompt, b)
+			}
+			continue
+		}
+
+		if b == 0x1b {
+			// Arrows: ESC [ A, B, C or D
+			if b, err = in.ReadByte(); err == nil && b == '[' {
+				b, err = in.ReadByte()
+			}
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					err = nil
+				}
+				return
+			}
+			b = map[byte]byte{'A': 'k', 'B': 'j', 'C': 'l', 'D': 'h'}[b]
+		}
+
+		switch b {
+		case 'q':
+			return
+		case 'h':
+			left--
+		case 'l':
+			left++
+		case 'k':
+			top--
+		case 'j':
+			top++
+		case 'H':
+			left -= ncols
+		case 'L':
+			left += ncols
+		case 'K':
+			top -= nrows
+		case 'J':
+			top += nrows
+		case 'g':
+			left, top = 0, 0
+		case 'G':
+			left = len(v.columns)
+		case ':', '/':
+			inprompt = true
+			prompt = []byte{b}
+		}
+	}
+}
+
+// drawScreen draws the part of the alignment visible in the pager
+func (layout *termLayout) drawScreen(v *termView, left, ncols, top, nrows int, status string, width int) {
+	right := left + ncols
+	if right > len(v.columns) {
+		right = len(v.columns)
+	}
+	layout.writer.WriteString(ansiClearScreen)
+	for _, l := range v.header(left, right) {
+		layout.writer.WriteString(l)
+		layout.writer.WriteString("\n")
+	}
+	for r := top; r < top+nrows && r < len(v.names); r++ {
+		v.writeRow(layout.writer, r, left, right)
+		layout.writer.WriteString("\n")
+	}
+	for r := len(v.names); r < len(v.rows); r++ {
+		v.writeRow(layout.writer, r, left, right)
+		layout.writer.WriteString("\n")
+	}
+	if len(status) > width {
+		status = status[:width]
+	}
+	layout.writer.WriteString(ansiReverse + status + ansiReset)
+}
+
+// view prepares the alignment to print
+func (layout *termLayout) view(a align.Alignment) (v *termView, err error) {
+	v = &termView{
+		names:     make([]string, 0, a.NbSequences()+termFooterRows),
+		rows:      make([][]byte, 0, a.NbSequences()+termFooterRows),
+		length:    a.Length(),
+		refname:   layout.reference,
+		diffonly:  layout.diffonly,
+		namewidth: len("Conservation"),
+	}
+	if layout.colors {
+		v.colors = schemeColors(layout.scheme, a.Alphabet() == align.NUCLEOTIDS)
+	}
+	if layout.reference != "" {
+		s, ok := a.GetSequenceByName(layout.reference)
+		if !ok {
+			err = fmt.Errorf("Reference sequence %s does not exist in the alignment", layout.reference)
+			return
+		}
+		v.ref = []byte(s.Sequence())
+	} else if layout.diffonly {
+		err = errors.New("A reference sequence is needed to print only differing sites")
+		return
+	}
+
+	a.IterateBytes(func(name string, sequence []byte) bool {
+		v.names = append(v.names, name)
+		v.rows = append(v.rows, sequence)
+		if len(name) > v.namewidth {
+			v.namewidth = len(name)
+		}
+		return false
+	})
+	cons := ""
+	if a.NbSequences() > 0 {
+		cons, _ = a.Consensus(false).GetSequenceById(0)
+	}
+	v.rows = append(v.rows, []byte(cons), conservationLine(a))
+
+	v.columns = make([]int, 0, a.Length())
+	for i := 0; i < a.Length(); i++ {
+		if !v.diffonly || v.differs(i) {
+			v.columns = append(v.columns, i)
+		}
+	}
+	return
+}
+
+// differs returns true if a sequence differs from the reference at the site
+func (v *termView) differs(site int) bool {
+	for _, s := range v.rows[:len(v.names)] {
+		if s[site] != v.ref[site] {
+			return true
+		}
+	}
+	return false
+}
+
+// header returns the lines printed above the displayed sites [start,end[.
+// Sites are contiguous: ruler with position numbers (1-based) and ticks;
+// Otherwise: position numbers written vertically, one digit per line.
+func (v *termView) header(start, end int) (lines []string) {
+	pad := strings.Repeat(" ", v.namewidth+termNameSeparation)
+	if v.diffonly {
+		ndigits := 1
+		if len(v.columns) > 0 {
+			ndigits = len(strconv.Itoa(v.columns[len(v.columns)-1] + 1))
+		}
+		for d := 0; d < ndigits; d++ {
+			var sb strings.Builder
+			sb.WriteString(pad)
+			for _, c := range v.columns[start:end] {
+				label := fmt.Sprintf("%*d", ndigits, c+1)
+				sb.WriteByte(label[d])
+			}
+			lines = append(lines, sb.String())
+		}
+		return
+	}
+
+	numbers := []byte(strings.Repeat(" ", end-start))
+	ticks := make([]byte, end-start)
+	for i := range ticks {
+		pos := v.columns[start+i] + 1
+		switch {
+		case pos%10 == 0:
+			ticks[i] = '|'
+		case pos%5 == 0:
+			ticks[i] = ':'
+		default:
+			ticks[i] = '.'
+		}
+		if pos%10 == 0 || i == 0 {
+			label := strconv.Itoa(pos)
+			// Labels end at their position, except the first one
+			from := i - len(label) + 1
+			if i == 0 {
+				from = 0
+			}
+			for k := 0; k < len(label) && from+k < len(numbers); k++ {
+				if from+k >= 0 {
+					numbers[from+k] = label[k]
+				}
+			}
+		}
+	}
+	return []string{strings.TrimRight(pad+string(numbers), " "), pad + string(ticks)}
+}
+
+// writeRow writes the name and the displayed sites [start,end[ of the row.
+func (v *termView) writeRow(w *bufio.Writer, row, start, end int) {
+	var name string
+	var coloured bool
+
+	switch row - len(v.names) {
+	case 0:
+		name = "Consensus"
+	case 1:
+		name = "Conservation"
+	default:
+		name = v.names[row]
+	}
+	fmt.Fprintf(w, "%-*s%s", v.namewidth, name, strings.Repeat(" ", termNameSeparation))
+
+	seq := v.rows[row]
+	// Residues identical to the reference are printed as .
+	diff := v.ref != nil && row < len(v.names) && v.names[row] != v.refname
+	current := ""
+	for _, c := range v.columns[start:end] {
+		r := seq[c]
+		code := ""
+		if diff && r == v.ref[c] {
+			r = '.'
+		} else if row != len(v.rows)-1 {
+			if col, ok := v.colors[unicode.ToUpper(rune(r))]; ok {
+				code = ansiBackground(col)
+			}
+		}
+		if code != current {
+			if coloured {
+				w.WriteString(ansiReset)
+			}
+			w.WriteString(code)
+			coloured = code != ""
+			current = code
+		}
+		w.WriteByte(r)
+	}
+	if coloured {
+		w.WriteString(ansiReset)
+	}
+}
+
+// status returns the status line of the pager
+func (v *termView) status(left, ncols, top, nrows int) string {
+	first, last := 0, 0
+	if len(v.columns) > 0 {
+		right := left + ncols
+		if right > len(v.columns) {
+			right = len(v.columns)
+		}
+		first, last = v.columns[left]+1, v.columns[right-1]+1
+	}
+	bottom := top + nrows
+	if bottom > len(v.names) {
+		bottom = len(v.names)
+	}
+	return fmt.Sprintf("Sites %d-%d/%d, sequences %d-%d/%d | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		first, last, v.length, top+1, bottom, len(v.names))
+}
+
+// findPosition returns the index of the first displayed site at or after
+// the given 1-based position, or left and an error message if the position
+// is not valid
+func (v *termView) findPosition(position string, left int) (int, string) {
+	pos, err := strconv.Atoi(strings.TrimSpace(position))
+	if err != nil || pos < 1 || pos > v.length {
+		return left, fmt.Sprintf("Invalid position: %s", position)
+	}
+	for i, c := range v.columns {
+		if c >= pos-1 {
+			return i, ""
+		}
+	}
+	return left, fmt.Sprintf("No displayed site after position %d", pos)
+}
+
+// findName returns the index of the next sequence (after top, wrapping
+// around) whose name contains text, or top and an error message if none
+func (v *termView) findName(text string, top int) (int, string) {
+	for k := 1; k <= len(v.names); k++ {
+		i := (top + k) % len(v.names)
+		if strings.Contains(v.names[i], text) {
+			return i, ""
+		}
+	}
+	return top, fmt.Sprintf("Sequence not found: %s", text)
+}
+
+// ansiBackground returns the ANSI escape sequence setting the background to
+// the closest colour of the 256 colours palette, with a black foreground
+func ansiBackground(c color.RGBA) string {
+	cube := func(v uint8) int { return (int(v)*5 + 127) / 255 }
+	return fmt.Sprintf("\x1b[30;48;5;%dm", 16+36*cube(c.R)+6*cube(c.G)+cube(c.B))
+}
+
+// conservationLine returns the conservation of each site of the alignment,
+// as in clustal format: * identical, : conserved, . semi-conserved, space
+// otherwise (see Alignment.SiteConservation)
+func conservationLine(a align.Alignment) []byte {
+	conservation := make([]byte, a.Length())
+	for i := range conservation {
+		cons, _ := a.SiteConservation(i)
+		switch cons {
+		case align.POSITION_IDENTICAL:
+			conservation[i] = '*'
+		case align.POSITION_CONSERVED:
+			conservation[i] = ':'
+		case align.POSITION_SEMI_CONSERVED:
+			conservation[i] = '.'
+		default:
+			conservation[i] = ' '
+		}
+	}
+	return conservation
+}
+
+func clampInt(v, min, max int) int {
+	if v > max {
+		v = max
+	}
+	if v < min {
+		v = min
+	}
+	return v
+}
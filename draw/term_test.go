@@ -0,0 +1,115 @@
+package draw
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTermLayout(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := bufio.NewWriter(&buf)
+	l := NewTermLayout(w)
+	l.SetWrap(6)
+	l.SetColors(false)
+	if err := l.DrawAlign(testImageAlign()); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	exp := `              1
+              ....:.
+s1            ACGT-A
+s2            ACCT-A
+s3            ACGTTA
+Consensus     ACGT-A
+Conservation  ** * *
+
+              7 10
+              ...|
+s1            CGTA
+s2            CGTA
+s3            CG-A
+Consensus     CGTA
+Conservation  ** *
+`
+	if buf.String() != exp {
+		t.Errorf("Wrong terminal output:\n%s\nexpected:\n%s", buf.String(), exp)
+	}
+
+	// Only sites differing from s1, with colours
+	buf.Reset()
+	l.SetReference("s1")
+	l.SetDiffOnly(true)
+	l.SetColors(true)
+	if err := l.DrawAlign(testImageAlign()); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	for _, exp := range []string{
+		"              359\n",
+		"\ns1            \x1b[30;48;5;203mG\x1b[0m-\x1b[30;48;5;75mT\x1b[0m\n",
+		"\ns2            \x1b[30;48;5;221mC\x1b[0m..\n",
+		"\ns3            .\x1b[30;48;5;75mT\x1b[0m-\n",
+	} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Errorf("Terminal output should contain %q:\n%q", exp, buf.String())
+		}
+	}
+
+	l.SetReference("")
+	if err := l.DrawAlign(testImageAlign()); err == nil {
+		t.Errorf("Printing differing sites without reference should return an error")
+	}
+}
+
+func TestTermPager(t *testing.T) {
+	var buf bytes.Buffer
+
+	w := bufio.NewWriter(&buf)
+	l := NewTermLayout(w)
+	l.SetColors(false)
+	// Screen of 18 columns (4 sites) and 7 rows (2 sequences)
+	if err := l.Page(testImageAlign(), strings.NewReader("L:8\n/3\nG\x1b[D/s5\nq"), 18, 7); err != nil {
+		t.Fatal(err)
+	}
+	w.Flush()
+	screens := strings.Split(buf.String(), ansiClearScreen)[1:]
+	status := func(screen string) string {
+		return screen[strings.Index(screen, ansiReverse)+len(ansiReverse) : strings.LastIndex(screen, ansiReset)]
+	}
+	for i, exp := range []string{
+		"Sites 1-4/10, sequences 1-2/3 | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		"Sites 5-8/10, sequences 1-2/3 | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		":",
+		":8",
+		"Sites 7-10/10, sequences 1-2/3 | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		"/",
+		"/3",
+		"Sites 7-10/10, sequences 2-3/3 | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		"Sites 7-10/10, sequences 2-3/3 | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		"Sites 6-9/10, sequences 2-3/3 | arrows/hjkl: scroll, HJKL: page, :position, /name, q: quit",
+		"/",
+		"/s",
+		"/s5",
+		"Sequence not found: s5",
+	} {
+		if i >= len(screens) {
+			t.Fatalf("Missing screen %d", i)
+		}
+		// Status lines are truncated to the width of the screen
+		if len(exp) > 18 {
+			exp = exp[:18]
+		}
+		if s := status(screens[i]); s != exp {
+			t.Errorf("Wrong status of screen %d: %q, expected %q", i, s, exp)
+		}
+	}
+	if !strings.Contains(screens[9], "    6\n              ....\ns2            ACGT\ns3            ACG-\nConsensus     ACGT\n") {
+		t.Errorf("Wrong screen:\n%s", screens[9])
+	}
+	if !strings.HasSuffix(buf.String(), ansiAlternateOff) {
+		t.Errorf("Pager should leave the alternate screen")
+	}
+}
@@ -0,0 +1,183 @@
+package a3m
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+// Parser represents an A2M/A3M parser (HMMER, HH-suite).
+//
+// A2M and A3M files are fasta files in which:
+//   - Upper case characters and '-' are match states (aligned columns)
+//   - Lower case characters are insert states (insertions relative to the match columns)
+//   - '.' are gaps in insert columns (A2M only, they are removed in A3M)
+//
+// All the sequences must have the same number of match states, but may have
+// different lengths in A3M. As the parser does not rely on '.' characters,
+// it reads both A2M and A3M files.
+//
+// Lines starting with '#' (ex: #A3M#) are ignored, as well as HH-suite
+// secondary structure pseudo sequences (named ss_* or sa_*).
+type Parser struct {
+	r               *alignio.LineReader
+	ignoreidentical bool
+	discardinserts  bool
+}
+
+// entry is a parsed A2M/A3M sequence, split into its match states
+// and its insertions: inserts[i] is the insertion before the ith match
+// state, inserts[len(match)] the insertion after the last match state.
+type entry struct {
+	name    string
+	line    int // line of the header
+	match   []byte
+	inserts [][]byte
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: alignio.NewLineReader(r), ignoreidentical: false, discardinserts: false}
+}
+
+// If sets to true, then will ignore duplicate sequences that have the same name and the same sequence
+// Otherwise, it just renames them just as the sequences that have same name and different sequences
+func (p *Parser) IgnoreIdentical(ignore bool) {
+	p.ignoreidentical = ignore
+}
+
+// DiscardInserts sets the way insert states are handled:
+//   - If false (default): they are expanded into new columns, padded with gaps
+//     in the sequences that do not have as many insertions
+//   - If true: they are removed, and only match columns are kept
+//
+// In both cases, output characters are upper case.
+func (p *Parser) DiscardInserts(discard bool) {
+	p.discardinserts = discard
+}
+
+// error returns a parse error located at the beginning of the given line
+func (p *Parser) error(line int, message string) error {
+	return alignio.NewParseError(line, 1, "", fmt.Errorf("A3M: %s", message))
+}
+
+// Parse parses an A2M/A3M file as an alignment
+func (p *Parser) Parse() (al align.Alignment, err error) {
+	al = align.NewAlign(align.UNKNOWN)
+	al.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(al)
+	return
+}
+
+// ParseUnalign parses an A2M/A3M file as a set of sequences.
+// Sequences are the same as with Parse (they all have the same length).
+func (p *Parser) ParseUnalign() (sb align.SeqBag, err error) {
+	sb = align.NewSeqBag(align.UNKNOWN)
+	sb.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(sb)
+	return
+}
+
+func (p *Parser) parseGeneric(sb align.SeqBag) (err error) {
+	var entries []*entry
+	var widths []int
+
+	if entries, err = p.parseEntries(); err != nil {
+		return
+	}
+	if len(entries) == 0 {
+		return p.error(p.r.Line(), "no sequence in the input file")
+	}
+
+	// Maximum length of each insertion
+	nmatch := len(entries[0].match)
+	widths = make([]int, nmatch+1)
+	for _, e := range entries {
+		if len(e.match) != nmatch {
+			return p.error(e.line, fmt.Sprintf("sequence %s has %d match states, expected %d", e.name, len(e.match), nmatch))
+		}
+		for i, ins := range e.inserts {
+			if len(ins) > widths[i] {
+				widths[i] = len(ins)
+			}
+		}
+	}
+
+	var seq bytes.Buffer
+	for _, e := range entries {
+		seq.Reset()
+		for i, ins := range e.inserts {
+			if !p.discardinserts {
+				seq.Write(ins)
+				for j := len(ins); j < widths[i]; j++ {
+					seq.WriteByte(align.GAP)
+				}
+			}
+			if i < nmatch {
+				seq.WriteByte(e.match[i])
+			}
+		}
+		if err = sb.AddSequence(e.name, seq.String(), ""); err != nil {
+			return
+		}
+	}
+	sb.AutoAlphabet()
+	return
+}
+
+// parseEntries parses all the sequences of the input and splits
+// them into match and insert states
+func (p *Parser) parseEntries() (entries []*entry, err error) {
+	var line string
+	var e *entry
+	var skip bool
+
+	entries = make([]*entry, 0)
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return
+		}
+		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, ">") {
+			name := strings.TrimSpace(line[1:])
+			if skip = strings.HasPrefix(name, "ss_") || strings.HasPrefix(name, "sa_"); skip {
+				e = nil
+				continue
+			}
+			e = &entry{name: name, line: p.r.Line(), match: make([]byte, 0), inserts: [][]byte{make([]byte, 0)}}
+			entries = append(entries, e)
+			continue
+		}
+		if skip {
+			continue
+		}
+		if e == nil {
+			return nil, p.error(p.r.Line(), "sequence should start with >")
+		}
+		for _, c := range []byte(line) {
+			switch {
+			case c == ' ' || c == '\t':
+			case c == align.POINT:
+				e.addInsert(align.GAP)
+			case c >= 'a' && c <= 'z':
+				e.addInsert(c - 'a' + 'A')
+			default:
+				e.match = append(e.match, c)
+				e.inserts = append(e.inserts, make([]byte, 0))
+			}
+		}
+	}
+}
+
+// addInsert adds the character to the current insertion
+func (e *entry) addInsert(c byte) {
+	e.inserts[len(e.inserts)-1] = append(e.inserts[len(e.inserts)-1], c)
+}
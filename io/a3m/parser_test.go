@@ -0,0 +1,133 @@
+package a3m
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+var a3mstring1 string = `#A3M#
+>s1 query
+ACDEFG
+>ss_pred PSIPRED predicted secondary structure
+CCHHHC
+>s2
+AcC-EF
+G
+>s3
+-CDklmEF-
+`
+
+var a2mstring1 string = `>s1 query
+A.CD...EFG
+>s2
+AcC-...EFG
+>s3
+-.CDklmEF-
+`
+
+func TestParse(t *testing.T) {
+	exp := []string{
+		"A-CD---EFG",
+		"ACC----EFG",
+		"--CDKLMEF-",
+	}
+	for _, in := range []string{a3mstring1, a2mstring1} {
+		al, err := NewParser(strings.NewReader(in)).Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if al.NbSequences() != 3 {
+			t.Fatalf("There should be 3 sequences (%d)", al.NbSequences())
+		}
+		for i, e := range exp {
+			s, _ := al.Sequence(i)
+			if s.Sequence() != e {
+				t.Errorf("Sequence %d is not as expected: %s vs. %s", i, s.Sequence(), e)
+			}
+		}
+		if s, _ := al.Sequence(0); s.Name() != "s1 query" {
+			t.Errorf("Name of sequence 0 is not as expected: %s", s.Name())
+		}
+	}
+}
+
+func TestParseDiscardInserts(t *testing.T) {
+	exp := []string{"ACDEFG", "AC-EFG", "-CDEF-"}
+	p := NewParser(strings.NewReader(a3mstring1))
+	p.DiscardInserts(true)
+	al, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, e := range exp {
+		s, _ := al.Sequence(i)
+		if s.Sequence() != e {
+			t.Errorf("Sequence %d is not as expected: %s vs. %s", i, s.Sequence(), e)
+		}
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, s := range []string{
+		// Different number of match states
+		">s1\nACDEFG\n>s2\nACDEF\n",
+		// Sequence without header
+		"ACDEFG\n",
+		// Empty file
+		"",
+	} {
+		if _, err := NewParser(strings.NewReader(s)).Parse(); err == nil {
+			t.Errorf("There should be an error while reading alignment %d", i)
+		}
+	}
+}
+
+func TestParseError_Line(t *testing.T) {
+	var pe *alignio.ParseError
+
+	for _, test := range []struct {
+		input string
+		line  int
+	}{
+		// Different number of match states: located at the header
+		{">s1\nACDEFG\n>s2\nACDEF\n", 3},
+	} {
+		_, err := NewParser(strings.NewReader(test.input)).Parse()
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+			continue
+		}
+		if pe.Line != test.line || pe.Column != 1 {
+			t.Errorf("Wrong error position: %d:%d, expected %d:1", pe.Line, pe.Column, test.line)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	al, err := NewParser(strings.NewReader(a3mstring1)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expa2m := a2mstring1
+	expa3m := `>s1 query
+ACDEFG
+>s2
+AcC-EFG
+>s3
+-CDklmEF-
+`
+	if out := WriteA2M(al, DEFAULT_MAX_GAPS); out != expa2m {
+		t.Errorf("A2M output is not as expected:\n%s", out)
+	}
+	if out := WriteA3M(al, DEFAULT_MAX_GAPS); out != expa3m {
+		t.Errorf("A3M output is not as expected:\n%s", out)
+	}
+
+	// With maxgaps=1: all columns are match columns
+	if out := WriteA3M(al, 1.0); out != ">s1 query\nA-CD---EFG\n>s2\nACC----EFG\n>s3\n--CDKLMEF-\n" {
+		t.Errorf("A3M output is not as expected:\n%s", out)
+	}
+}
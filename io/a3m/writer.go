@@ -0,0 +1,85 @@
+package a3m
+
+import (
+	"bytes"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Default maximum fraction of gaps of a match column (same as
+// hhmake -M 50): columns having more gaps are written as insert columns
+const DEFAULT_MAX_GAPS = 0.5
+
+// WriteA2M returns the alignment in A2M format.
+//
+// Columns having a fraction of gaps > maxgaps are insert columns: their
+// characters are written in lower case, and their gaps as '.'.
+// Other columns are match columns, written in upper case (gaps as '-').
+func WriteA2M(al align.Alignment, maxgaps float64) string {
+	return write(al, maxgaps, false)
+}
+
+// WriteA3M returns the alignment in A3M format.
+//
+// It is the same as A2M (see WriteA2M), except that the gaps of insert
+// columns are not written: sequences may then have different lengths.
+func WriteA3M(al align.Alignment, maxgaps float64) string {
+	return write(al, maxgaps, true)
+}
+
+func write(al align.Alignment, maxgaps float64, a3m bool) string {
+	var buf bytes.Buffer
+
+	inserts := insertColumns(al, maxgaps)
+	al.IterateBytes(func(name string, seq []byte) bool {
+		buf.WriteString(">")
+		buf.WriteString(name)
+		buf.WriteString("\n")
+		for i, c := range seq {
+			switch {
+			case !inserts[i]:
+				buf.WriteByte(toUpper(c))
+			case c != align.GAP:
+				buf.WriteByte(toLower(c))
+			case !a3m:
+				buf.WriteByte(align.POINT)
+			}
+		}
+		buf.WriteString("\n")
+		return false
+	})
+	return buf.String()
+}
+
+// insertColumns returns, for each column of the alignment, true if its
+// fraction of gaps is > maxgaps
+func insertColumns(al align.Alignment, maxgaps float64) (inserts []bool) {
+	gaps := make([]int, al.Length())
+	inserts = make([]bool, al.Length())
+	al.IterateBytes(func(name string, seq []byte) bool {
+		for i, c := range seq {
+			if c == align.GAP {
+				gaps[i]++
+			}
+		}
+		return false
+	})
+	for i, g := range gaps {
+		inserts[i] = float64(g) > maxgaps*float64(al.NbSequences())
+	}
+	return
+}
+
+func toUpper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func toLower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c - 'A' + 'a'
+	}
+	return c
+}
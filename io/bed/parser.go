@@ -0,0 +1,69 @@
+package bed
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Interval is a region of a BED file: [Start,End[ on sequence Chrom
+// (0-based, end excluded, as in BED files).
+type Interval struct {
+	Chrom string
+	Start int
+	End   int
+}
+
+// Parser represents a BED parser.
+//
+// Only the first 3 columns (chrom, chromStart, chromEnd) are parsed.
+// Empty lines, comments (#), "track" and "browser" lines are ignored.
+type Parser struct {
+	r     *bufio.Reader
+	nline int // number of the last read line
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: bufio.NewReader(r)}
+}
+
+func (p *Parser) error(message string) error {
+	return fmt.Errorf("BED: %s (line %d)", message, p.nline)
+}
+
+// Parse parses all the intervals of the BED input
+func (p *Parser) Parse() (intervals []*Interval, err error) {
+	var line string
+	var eof bool
+
+	intervals = make([]*Interval, 0)
+	for !eof {
+		if line, err = p.r.ReadString('\n'); err == io.EOF {
+			if eof = true; line == "" {
+				break
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		p.nline++
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") || fields[0] == "track" || fields[0] == "browser" {
+			continue
+		}
+		if len(fields) < 3 {
+			return nil, p.error("a BED line should have at least 3 columns")
+		}
+		inter := &Interval{Chrom: fields[0]}
+		if inter.Start, err = strconv.Atoi(fields[1]); err != nil || inter.Start < 0 {
+			return nil, p.error(fmt.Sprintf("wrong start: %s", fields[1]))
+		}
+		if inter.End, err = strconv.Atoi(fields[2]); err != nil || inter.End < inter.Start {
+			return nil, p.error(fmt.Sprintf("wrong end: %s", fields[2]))
+		}
+		intervals = append(intervals, inter)
+	}
+	return intervals, nil
+}
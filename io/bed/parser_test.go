@@ -0,0 +1,22 @@
+package bed
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	intervals, err := NewParser(strings.NewReader("track name=lowcov\n# comment\nchr1\t0\t10\tlow\n\nchr2\t5\t8\n")).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(intervals) != 2 || *intervals[0] != (Interval{"chr1", 0, 10}) || *intervals[1] != (Interval{"chr2", 5, 8}) {
+		t.Errorf("Intervals are not as expected: %v", intervals)
+	}
+
+	for i, s := range []string{"chr1\t0\n", "chr1\ta\t10\n", "chr1\t10\t5\n"} {
+		if _, err = NewParser(strings.NewReader(s)).Parse(); err == nil {
+			t.Errorf("There should be an error while parsing BED %d", i)
+		}
+	}
+}
@@ -13,12 +13,14 @@ import (
 
 // Scanner represents a lexical scanner.
 type Scanner struct {
-	r *bufio.Reader
+	r         *bufio.Reader
+	pos       *alignio.Position
+	line, col int // Position of the last scanned token
 }
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{r: bufio.NewReader(r), pos: alignio.NewPosition()}
 }
 
 // reads the next n runes from the bufferred reader.
@@ -27,6 +29,7 @@ func (s *Scanner) Read(n int) string {
 
 	for i := 0; i < 10; i++ {
 		ch, _, err := s.r.ReadRune()
+		s.pos.Read(ch, err != nil)
 		buf.WriteRune(ch)
 		if err != nil {
 			buf.WriteRune(eof)
@@ -40,6 +43,7 @@ func (s *Scanner) Read(n int) string {
 // Returns the rune(0) if an error occurs (or io.EOF is returned).
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
+	s.pos.Read(ch, err != nil)
 	if err != nil {
 		return eof
 	}
@@ -49,12 +53,19 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
 	_ = s.r.UnreadRune()
+	s.pos.Unread()
+}
+
+// Pos returns the line and column (1-based) of the last scanned token.
+func (s *Scanner) Pos() (line, col int) {
+	return s.line, s.col
 }
 
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan() (tok Token, lit string) {
 	// Read the next rune.
 	ch := s.read()
+	s.line, s.col = s.pos.Last()
 
 	// If we see whitespace then consume all contiguous whitespace.
 	// If we see a letter then consume as an ident or reserved word.
@@ -133,7 +144,8 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 	switch strings.ToUpper(buf.String()) {
 	case "CLUSTAL":
 		return CLUSTAL, buf.String()
-	case "CLUSTALW":
+	case "CLUSTALW", "MUSCLE", "PROBCONS":
+		// Header of Clustal files written by other aligners
 		return CLUSTAL, buf.String()
 	default:
 		return IDENTIFIER, buf.String()
@@ -1,11 +1,11 @@
 package clustal
 
 import (
-	"errors"
 	"fmt"
 	"io"
 
 	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Parser represents a parser.
@@ -13,9 +13,11 @@ type Parser struct {
 	s               *Scanner
 	ignoreidentical bool
 	buf             struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
+		tok  Token  // last read token
+		lit  string // last read literal
+		line int    // line of the last read token
+		col  int    // column of the last read token
+		n    int    // buffer size (max=1)
 	}
 }
 
@@ -41,6 +43,7 @@ func (p *Parser) scan() (tok Token, lit string) {
 
 	// Otherwise read the next token from the scanner.
 	tok, lit = p.s.Scan()
+	p.buf.line, p.buf.col = p.s.Pos()
 
 	// Save it to the buffer in case we unscan later.
 	p.buf.tok, p.buf.lit = tok, lit
@@ -64,6 +67,11 @@ func (p *Parser) scanWithEOL() (tok Token, lit string) {
 // unscan pushes the previously read token back onto the buffer.
 func (p *Parser) unscan() { p.buf.n = 1 }
 
+// errorf returns a parse error located at the last read token
+func (p *Parser) errorf(format string, a ...interface{}) error {
+	return alignio.NewParseError(p.buf.line, p.buf.col, p.buf.lit, fmt.Errorf(format, a...))
+}
+
 // Parse parses a clustal alignment
 func (p *Parser) Parse() (align.Alignment, error) {
 	var nbseq int = 0
@@ -72,7 +80,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 	var seqs []string = make([]string, 0)
 	tok, lit := p.scan()
 	if tok != CLUSTAL {
-		return nil, errors.New("Clustal alignment file must start with 'CLUSTAL'")
+		return nil, p.errorf("Clustal alignment file must start with 'CLUSTAL' (or 'MUSCLE', 'PROBCONS')")
 	}
 	for tok != ENDOFLINE && tok != EOF {
 		tok, lit = p.scanWithEOL()
@@ -87,31 +95,31 @@ func (p *Parser) Parse() (align.Alignment, error) {
 		// Last line of a block
 		if tok == WS {
 			if currentnbseqs == 0 {
-				return nil, errors.New("There should not be a white space here, only at last line of blocks")
+				return nil, p.errorf("There should not be a white space here, only at last line of blocks")
 			}
 			if nbseq != 0 && currentnbseqs != nbseq {
-				return nil, fmt.Errorf("Conservation line: Sequence block nb %d has different number of sequence (%d)",
+				return nil, p.errorf("Conservation line: Sequence block nb %d has different number of sequence (%d)",
 					nblocks, currentnbseqs)
 			}
 			for tok != ENDOFLINE && tok != EOF {
 				tok, lit = p.scan()
 			}
 			if tok != ENDOFLINE {
-				return nil, errors.New("There should be a new line after degree of conservation line")
+				return nil, p.errorf("There should be a new line after degree of conservation line")
 			}
 			tok, lit = p.scanWithEOL()
 			if tok == EOF {
 				break
 			}
 			if tok != ENDOFLINE {
-				return nil, errors.New("There should be a new line after degree of conservation line")
+				return nil, p.errorf("There should be a new line after degree of conservation line")
 			}
 			tok, lit = p.scan()
 			if tok == EOF {
 				break
 			}
 			if nbseq != 0 && currentnbseqs != nbseq {
-				return nil, fmt.Errorf("Sequence block nb %d has different number of sequence (%d)",
+				return nil, p.errorf("Sequence block nb %d has different number of sequence (%d)",
 					nblocks, currentnbseqs)
 			}
 			nbseq = currentnbseqs
@@ -120,17 +128,17 @@ func (p *Parser) Parse() (align.Alignment, error) {
 		}
 
 		if tok != IDENTIFIER && tok != NUMERIC {
-			return nil, errors.New("We expect a sequence identifier here")
+			return nil, p.errorf("We expect a sequence identifier here")
 		}
 		name = lit
 		tok, lit = p.scan()
 		if tok != WS {
-			return nil, errors.New("We expect a whitespace after sequence name")
+			return nil, p.errorf("We expect a whitespace after sequence name")
 		}
 
 		tok, lit = p.scan()
 		if tok != IDENTIFIER {
-			return nil, errors.New("We expect a sequence here")
+			return nil, p.errorf("We expect a sequence here")
 		}
 		seq = lit
 
@@ -142,11 +150,11 @@ func (p *Parser) Parse() (align.Alignment, error) {
 				// skip
 				tok, lit = p.scan()
 			} else {
-				return nil, errors.New("We expect a current length after sequence + whitespace")
+				return nil, p.errorf("We expect a current length after sequence + whitespace")
 			}
 		}
 		if tok != ENDOFLINE {
-			return nil, errors.New("We expect ENDOFLINE after sequence in a block")
+			return nil, p.errorf("We expect ENDOFLINE after sequence in a block")
 		}
 
 		if nblocks == 0 {
@@ -154,7 +162,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 			seqs = append(seqs, seq)
 		} else {
 			if names[currentnbseqs] != name {
-				return nil, fmt.Errorf("Name at block %d line %d does not correspond to name in first block (%s vs. %s)", nblocks, currentnbseqs, names[currentnbseqs], name)
+				return nil, p.errorf("Name at block %d line %d does not correspond to name in first block (%s vs. %s)", nblocks, currentnbseqs, names[currentnbseqs], name)
 			}
 			seqs[currentnbseqs] = seqs[currentnbseqs] + seq
 		}
@@ -162,7 +170,8 @@ func (p *Parser) Parse() (align.Alignment, error) {
 	}
 
 	if len(names) == 0 {
-		return nil, errors.New("No sequences in the alignment")
+		// Located at the end of the input
+		return nil, p.errorf("No sequences in the alignment")
 	}
 
 	for i, n := range names {
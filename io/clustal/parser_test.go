@@ -1,9 +1,12 @@
 package clustal
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Sample file from t-coffee:
@@ -177,3 +180,32 @@ func TestParseError2(t *testing.T) {
 		t.Error("There should be an error while reading this alignment")
 	}
 }
+
+func TestParse_ErrorPosition(t *testing.T) {
+	var pe *alignio.ParseError
+
+	_, err := NewParser(strings.NewReader("CLUSTAL W\n\ns1 ACGT\ns2 ACGT\n     **\n\ns1 ACGT\ns3 ACGT\n")).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 8 {
+		t.Errorf("Wrong error line: %d", pe.Line)
+	}
+
+	_, err = NewParser(strings.NewReader("MSA\n")).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 1 || pe.Column != 1 || pe.Token != "MSA" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+
+	// No sequences: located at the end of the input
+	_, err = NewParser(strings.NewReader("CLUSTAL W\n\n")).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 3 || pe.Column != 1 {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+}
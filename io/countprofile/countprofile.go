@@ -2,9 +2,8 @@ package countprofile
 
 import (
 	"bufio"
-	"compress/gzip"
 	"fmt"
-	"os"
+	"io"
 	"strconv"
 	"strings"
 
@@ -17,31 +16,18 @@ import (
 // site - A B C D G H K M N R...
 // 0 1 2 3 4 0 ...
 func FromFile(file string) (p *align.CountProfile, err error) {
-	var f *os.File
+	var f io.Closer
 	var r *bufio.Reader
-	var gr *gzip.Reader
 	var l string
 	var i int
 	var field string
 
 	p = align.NewCountProfile()
 
-	if file == "stdin" || file == "-" {
-		f = os.Stdin
-	} else {
-		if f, err = os.Open(file); err != nil {
-			return
-		}
-	}
-
-	if strings.HasSuffix(file, ".gz") {
-		if gr, err = gzip.NewReader(f); err != nil {
-			return
-		}
-		r = bufio.NewReader(gr)
-	} else {
-		r = bufio.NewReader(f)
+	if f, r, err = utils.GetReader(file); err != nil {
+		return
 	}
+	defer f.Close()
 
 	// We parse the header
 	if l, err = utils.Readln(r); err != nil {
@@ -0,0 +1,197 @@
+package embl
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+	"github.com/evolbioinfo/goalign/io/genbank"
+)
+
+// Parser represents an EMBL flat file parser.
+type Parser struct {
+	r               *alignio.LineReader
+	ignoreidentical bool
+}
+
+// record is a parsed EMBL entry
+type record struct {
+	id          string
+	accession   string
+	version     string
+	description string
+	sequence    bytes.Buffer
+	features    *genbank.FeatureTableParser
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: alignio.NewLineReader(r), ignoreidentical: false}
+}
+
+// If sets to true, then will ignore duplicate sequences that have the same name and the same sequence
+// Otherwise, it just renames them just as the sequences that have same name and different sequences
+func (p *Parser) IgnoreIdentical(ignore bool) {
+	p.ignoreidentical = ignore
+}
+
+// error returns a parse error located at the last read line
+func (p *Parser) error(message string) error {
+	return p.r.Errorf("EMBL: %s", message)
+}
+
+// Parse parses all the EMBL entries of the input as an alignment.
+// All the sequences must have the same length.
+func (p *Parser) Parse() (al align.Alignment, err error) {
+	al = align.NewAlign(align.UNKNOWN)
+	al.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(al)
+	return
+}
+
+// ParseUnalign parses all the EMBL entries of the input.
+//
+// Each entry gives a sequence:
+//   - Named after its accession (AC) and version (SV), ex: X56734.1
+//   - Whose comment is its description (DE)
+//   - Whose features are those of the feature table (FT)
+//
+// Sequences are converted to upper case.
+func (p *Parser) ParseUnalign() (sb align.SeqBag, err error) {
+	sb = align.NewSeqBag(align.UNKNOWN)
+	sb.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(sb)
+	return
+}
+
+func (p *Parser) parseGeneric(sb align.SeqBag) (err error) {
+	var r *record
+	var features []*align.Feature
+
+	for {
+		if r, err = p.parseRecord(); err != nil || r == nil {
+			break
+		}
+		if r.sequence.Len() == 0 {
+			err = p.error(fmt.Sprintf("entry %s does not have any sequence", r.name()))
+			break
+		}
+		if features, err = r.features.Features(); err != nil {
+			err = p.error(err.Error())
+			break
+		}
+		if err = genbank.AddRecord(sb, r.name(), r.sequence.String(), strings.TrimSuffix(r.description, "."), features); err != nil {
+			break
+		}
+	}
+	if err == nil && sb.NbSequences() == 0 {
+		err = p.error("no entry in the input file")
+	}
+	if err == nil {
+		sb.AutoAlphabet()
+	}
+	return
+}
+
+// name returns the accession.version of the entry, or its ID if
+// there is no accession
+func (r *record) name() string {
+	name := r.accession
+	if name == "" {
+		name = r.id
+	}
+	if r.version != "" {
+		name += "." + r.version
+	}
+	return name
+}
+
+// parseRecord parses the next entry. Returns nil, nil if there are no more entries.
+func (p *Parser) parseRecord() (r *record, err error) {
+	var line string
+
+	// We skip empty lines before the entry
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return
+		}
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+
+	if !strings.HasPrefix(line, "ID ") {
+		return nil, p.error("entry should start with ID")
+	}
+	r = &record{features: genbank.NewFeatureTableParser()}
+	r.parseID(line[2:])
+
+	insequence := false
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return nil, p.error("entry should end with //")
+		} else if err != nil {
+			return
+		}
+		if strings.HasPrefix(line, "//") {
+			return
+		}
+		if insequence {
+			for _, c := range []byte(line) {
+				if c == ' ' || c == '\t' || (c >= '0' && c <= '9') {
+					continue
+				}
+				r.sequence.WriteByte(c)
+			}
+			continue
+		}
+
+		code, value := line, ""
+		if len(line) > 2 {
+			code, value = line[:2], line[2:]
+		}
+		switch code {
+		case "AC":
+			if fields := strings.Split(strings.TrimSpace(value), ";"); r.accession == "" && fields[0] != "" {
+				r.accession = strings.TrimSpace(fields[0])
+			}
+		case "SV":
+			// Old format: SV   X56734.1
+			if fields := strings.Split(strings.TrimSpace(value), "."); len(fields) == 2 {
+				r.version = fields[1]
+			}
+		case "DE":
+			if r.description != "" {
+				r.description += " "
+			}
+			r.description += strings.TrimSpace(value)
+		case "FT":
+			// Same layout as GenBank, with "FT" instead of 2 spaces
+			if err = r.features.AddLine("  " + value); err != nil {
+				return nil, p.error(err.Error())
+			}
+		case "SQ":
+			insequence = true
+		}
+	}
+}
+
+// parseID parses the ID line, ex:
+//
+//	ID   X56734; SV 1; linear; mRNA; STD; PLN; 1859 BP.
+func (r *record) parseID(value string) {
+	fields := strings.Split(value, ";")
+	if id := strings.Fields(fields[0]); len(id) > 0 {
+		r.id = id[0]
+	}
+	if len(fields) > 1 {
+		if sv := strings.Fields(fields[1]); len(sv) == 2 && sv[0] == "SV" {
+			r.version = sv[1]
+		}
+	}
+}
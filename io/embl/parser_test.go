@@ -0,0 +1,107 @@
+package embl
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+var emblstring1 string = `ID   X56734; SV 1; linear; mRNA; STD; PLN; 42 BP.
+XX
+AC   X56734; S46826;
+XX
+DE   Synthetic sequence for
+DE   tests.
+XX
+FH   Key             Location/Qualifiers
+FH
+FT   source          1..42
+FT                   /organism="synthetic construct"
+FT   CDS             join(1..6,
+FT                   7..12)
+FT                   /gene="gA"
+FT   CDS             complement(17..28)
+FT                   /gene="gB"
+XX
+SQ   Sequence 42 BP; 11 A; 10 C; 11 G; 10 T; 0 other;
+     atgaaaccct aaggggtcat ttgggcatcc atgtttgggt aa                           42
+//
+ID   TEST02     standard; DNA; SYN; 12 BP.
+SQ   Sequence 12 BP;
+     acgtacgtac gt                                                            12
+//
+`
+
+func TestParse(t *testing.T) {
+	sb, err := NewParser(strings.NewReader(emblstring1)).ParseUnalign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.NbSequences() != 2 {
+		t.Fatalf("There should be 2 sequences (%d)", sb.NbSequences())
+	}
+	s, ok := sb.GetSequenceByName("X56734.1")
+	if !ok {
+		t.Fatalf("Sequence X56734.1 not found")
+	}
+	if s.Sequence() != "ATGAAACCCTAAGGGGTCATTTGGGCATCCATGTTTGGGTAA" {
+		t.Errorf("Sequence is not as expected: %s", s.Sequence())
+	}
+	if s.Comment() != "Synthetic sequence for tests" {
+		t.Errorf("Comment is not as expected: %s", s.Comment())
+	}
+	if _, ok = sb.GetSequenceByName("TEST02"); !ok {
+		t.Errorf("Sequence TEST02 not found")
+	}
+	if len(s.Features()) != 3 {
+		t.Fatalf("There should be 3 features (%d)", len(s.Features()))
+	}
+	cds, err := sb.CDS("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cds.NbSequences() != 2 {
+		t.Fatalf("There should be 2 CDS (%d)", cds.NbSequences())
+	}
+	if c, _ := cds.GetSequence("X56734.1_gB"); c != "ATGCCCAAATGA" {
+		t.Errorf("CDS gB is not as expected: %s", c)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, s := range []string{
+		// No ID
+		">s1\nACGT\n",
+		// No end of entry
+		"ID   TEST02; SV 1;\nSQ   Sequence 4 BP;\n     acgt\n",
+		// No sequence
+		"ID   TEST02; SV 1;\n//\n",
+	} {
+		if _, err := NewParser(strings.NewReader(s)).ParseUnalign(); err == nil {
+			t.Errorf("There should be an error while reading entry %d", i)
+		}
+	}
+}
+
+func TestParseError_Line(t *testing.T) {
+	var pe *alignio.ParseError
+
+	for _, test := range []struct {
+		input string
+		line  int
+	}{
+		// No end of entry
+		{"ID   TEST02; SV 1;\nSQ   Sequence 4 BP;\n     acgt\n", 3},
+	} {
+		_, err := NewParser(strings.NewReader(test.input)).ParseUnalign()
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+			continue
+		}
+		if pe.Line != test.line || pe.Column != 1 {
+			t.Errorf("Wrong error position: %d:%d, expected %d:1", pe.Line, pe.Column, test.line)
+		}
+	}
+}
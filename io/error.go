@@ -1,6 +1,7 @@
 package io
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -36,3 +37,54 @@ func LogError(err error) {
 	name := strings.Split(fn, "/goalign/")[1]
 	fmt.Fprintf(os.Stderr, "[Error] in %s (line %d), message: %v\n", name, line, err)
 }
+
+// ParseError is an error occuring while parsing an input file. It gives
+// the position (1-based line and column) and the literal of the token
+// where the error has been detected.
+type ParseError struct {
+	File   string // Name of the input file, empty if unknown
+	Line   int    // Line of the offending token
+	Column int    // Column of the offending token
+	Token  string // Offending token
+	Err    error  // Error message
+}
+
+// NewParseError returns a new ParseError, given the position of the
+// offending token
+func NewParseError(line, column int, token string, err error) *ParseError {
+	return &ParseError{Line: line, Column: column, Token: token, Err: err}
+}
+
+// Error returns the error message, prefixed by the position of the
+// offending token ("file:line:column: " or "line l, column c: ").
+func (e *ParseError) Error() string {
+	var b strings.Builder
+
+	if e.File != "" {
+		fmt.Fprintf(&b, "%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+	} else {
+		fmt.Fprintf(&b, "line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	if token := strings.TrimSpace(e.Token); token != "" {
+		if len(token) > 30 {
+			token = token[:30] + "..."
+		}
+		fmt.Fprintf(&b, " (at %q)", token)
+	}
+	return b.String()
+}
+
+// Unwrap returns the underlying error
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// SetParseErrorFile sets the file name of the given error if it
+// is (or wraps) a ParseError, and returns the error.
+func SetParseErrorFile(err error, file string) error {
+	var pe *ParseError
+	if errors.As(err, &pe) && pe.File == "" {
+		pe.File = file
+	}
+	return err
+}
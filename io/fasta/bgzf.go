@@ -0,0 +1,144 @@
+package fasta
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// BgzfBlock is the position of a BGZF block (bgzip compression):
+// offset of the block in the compressed file, and offset of its
+// content in the uncompressed file.
+type BgzfBlock struct {
+	CompressedOffset   int64
+	UncompressedOffset int64
+}
+
+// readBgzf decompresses the BGZF blocks read by r, writes the
+// uncompressed data to w, and returns the positions of the
+// non-empty blocks.
+//
+// Returns an error if the data is not made of BGZF blocks
+// (e.g. plain gzip).
+func readBgzf(r *bufio.Reader, w io.Writer) (blocks []BgzfBlock, err error) {
+	var header [12]byte
+	var extra, cdata []byte
+	var trailer [8]byte
+	var coffset, uoffset int64
+	var bsize int
+	var n int64
+
+	blocks = make([]BgzfBlock, 0)
+	buf := new(bytes.Buffer)
+	for {
+		if _, err = io.ReadFull(r, header[:]); err == io.EOF {
+			return blocks, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("Truncated BGZF block at offset %d", coffset)
+		}
+		if header[0] != 0x1f || header[1] != 0x8b || header[2] != 8 || header[3]&4 == 0 {
+			return nil, fmt.Errorf("Compressed file is not in BGZF format (use bgzip to compress it)")
+		}
+		extra = make([]byte, binary.LittleEndian.Uint16(header[10:12]))
+		if _, err = io.ReadFull(r, extra); err != nil {
+			return nil, fmt.Errorf("Truncated BGZF block at offset %d", coffset)
+		}
+		if bsize = bgzfBlockSize(extra); bsize < 0 {
+			return nil, fmt.Errorf("Compressed file is not in BGZF format (use bgzip to compress it)")
+		}
+		if bsize < len(extra)+20 {
+			return nil, fmt.Errorf("Wrong BGZF block size at offset %d", coffset)
+		}
+		cdata = make([]byte, bsize-len(extra)-20)
+		if _, err = io.ReadFull(r, cdata); err != nil {
+			return nil, fmt.Errorf("Truncated BGZF block at offset %d", coffset)
+		}
+		if _, err = io.ReadFull(r, trailer[:]); err != nil {
+			return nil, fmt.Errorf("Truncated BGZF block at offset %d", coffset)
+		}
+		buf.Reset()
+		fr := flate.NewReader(bytes.NewReader(cdata))
+		if n, err = io.Copy(buf, fr); err != nil {
+			return nil, fmt.Errorf("Error in BGZF block at offset %d: %v", coffset, err)
+		}
+		fr.Close()
+		if uint32(n) != binary.LittleEndian.Uint32(trailer[4:8]) || crc32.ChecksumIEEE(buf.Bytes()) != binary.LittleEndian.Uint32(trailer[0:4]) {
+			return nil, fmt.Errorf("Corrupted BGZF block at offset %d", coffset)
+		}
+		if n > 0 {
+			blocks = append(blocks, BgzfBlock{CompressedOffset: coffset, UncompressedOffset: uoffset})
+			if _, err = w.Write(buf.Bytes()); err != nil {
+				return
+			}
+		}
+		coffset += int64(bsize)
+		uoffset += n
+	}
+}
+
+// bgzfBlockSize returns the total size of the block, given in
+// the BC subfield of the gzip extra field, or -1 if not found
+func bgzfBlockSize(extra []byte) int {
+	for len(extra) >= 4 {
+		slen := int(binary.LittleEndian.Uint16(extra[2:4]))
+		if len(extra) < 4+slen {
+			break
+		}
+		if extra[0] == 'B' && extra[1] == 'C' && slen == 2 {
+			return int(binary.LittleEndian.Uint16(extra[4:6])) + 1
+		}
+		extra = extra[4+slen:]
+	}
+	return -1
+}
+
+// WriteGzi writes the positions of the BGZF blocks in the
+// format of the htslib .gzi index: number of entries followed by
+// compressed and uncompressed offsets of each block, except the
+// first one, as little endian 64 bits integers.
+func WriteGzi(w io.Writer, blocks []BgzfBlock) (err error) {
+	bw := bufio.NewWriter(w)
+	entries := make([]BgzfBlock, 0, len(blocks))
+	for _, b := range blocks {
+		if b.CompressedOffset != 0 || b.UncompressedOffset != 0 {
+			entries = append(entries, b)
+		}
+	}
+	if err = binary.Write(bw, binary.LittleEndian, uint64(len(entries))); err != nil {
+		return
+	}
+	for _, b := range entries {
+		if err = binary.Write(bw, binary.LittleEndian, [2]uint64{uint64(b.CompressedOffset), uint64(b.UncompressedOffset)}); err != nil {
+			return
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadGzi reads a htslib .gzi index. The returned blocks
+// include the first block (offsets 0), in increasing order.
+func ReadGzi(r io.Reader) (blocks []BgzfBlock, err error) {
+	var nb uint64
+	var entry [2]uint64
+
+	br := bufio.NewReader(r)
+	if err = binary.Read(br, binary.LittleEndian, &nb); err != nil {
+		return nil, fmt.Errorf("Malformed gzi index: %v", err)
+	}
+	blocks = []BgzfBlock{{0, 0}}
+	for i := uint64(0); i < nb; i++ {
+		if err = binary.Read(br, binary.LittleEndian, &entry); err != nil {
+			return nil, fmt.Errorf("Malformed gzi index: %v", err)
+		}
+		b := BgzfBlock{CompressedOffset: int64(entry[0]), UncompressedOffset: int64(entry[1])}
+		if b.UncompressedOffset < blocks[len(blocks)-1].UncompressedOffset {
+			return nil, fmt.Errorf("Malformed gzi index: offsets are not sorted")
+		}
+		blocks = append(blocks, b)
+	}
+	return
+}
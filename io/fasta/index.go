@@ -0,0 +1,241 @@
+package fasta
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	aio "github.com/evolbioinfo/goalign/io"
+)
+
+// IndexRecord is an entry of a samtools compatible FASTA index (.fai):
+// one per sequence of the FASTA file.
+//
+// Offsets are given in the uncompressed file, even if the
+// FASTA file is compressed with bgzip.
+type IndexRecord struct {
+	Name      string // Name of the sequence: first word of its header
+	Length    int    // Number of bases of the sequence
+	Offset    int64  // Offset of the first base of the sequence in the file
+	LineBases int    // Number of bases per line
+	LineWidth int    // Number of bytes per line, including end of line characters
+}
+
+// position returns the offset, in the file, of the base at the given
+// position of the sequence (0-based)
+func (r *IndexRecord) position(pos int) int64 {
+	if r.LineBases == 0 {
+		return r.Offset
+	}
+	return r.Offset + int64(pos/r.LineBases)*int64(r.LineWidth) + int64(pos%r.LineBases)
+}
+
+// indexBuilder builds the index of the FASTA content written to it
+// (see io.Writer). All the lines of a sequence, except the last one,
+// must have the same length.
+type indexBuilder struct {
+	records   []*IndexRecord
+	names     map[string]bool
+	cur       *IndexRecord // Current sequence, nil if none, or if duplicated
+	pos       int64        // Current offset in the file
+	header    []byte       // Current header, nil if not in a header line
+	lineBases int          // Number of bases in the current line
+	lineBytes int          // Number of bytes in the current line
+	short     bool         // A line shorter than LineBases has been seen in the current sequence
+	err       error
+}
+
+func newIndexBuilder() *indexBuilder {
+	return &indexBuilder{records: make([]*IndexRecord, 0), names: make(map[string]bool)}
+}
+
+func (b *indexBuilder) Write(p []byte) (n int, err error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	for _, c := range p {
+		switch {
+		case b.header != nil:
+			if c == '\n' {
+				b.startSequence()
+			} else {
+				b.header = append(b.header, c)
+			}
+		case c == '>' && b.lineBytes == 0:
+			b.endSequence()
+			b.header = make([]byte, 0, 100)
+		case c == '\n':
+			b.lineBytes++
+			b.endLine()
+		default:
+			b.lineBytes++
+			if c != '\r' {
+				b.lineBases++
+			}
+		}
+		b.pos++
+		if b.err != nil {
+			return n, b.err
+		}
+		n++
+	}
+	return
+}
+
+// startSequence is called at the end of a header line
+func (b *indexBuilder) startSequence() {
+	header := strings.TrimRight(string(b.header), "\r")
+	b.header = nil
+	b.cur = nil
+	fields := strings.Fields(header)
+	if len(fields) == 0 {
+		b.err = fmt.Errorf("Sequence without name at offset %d", b.pos)
+		return
+	}
+	if b.names[fields[0]] {
+		aio.PrintMessage(fmt.Sprintf("Ignoring duplicate sequence %q at offset %d", fields[0], b.pos))
+		return
+	}
+	b.names[fields[0]] = true
+	b.cur = &IndexRecord{Name: fields[0], Offset: b.pos + 1}
+	b.records = append(b.records, b.cur)
+	b.short = false
+}
+
+// endLine is called at the end of a sequence line
+func (b *indexBuilder) endLine() {
+	defer func() { b.lineBases, b.lineBytes = 0, 0 }()
+
+	if b.cur == nil {
+		if b.lineBases > 0 && len(b.records) == 0 {
+			b.err = fmt.Errorf("Fasta file should start with a >")
+		}
+		return
+	}
+	if b.lineBases == 0 {
+		if b.cur.Length == 0 {
+			// Empty line before the first bases
+			b.cur.Offset += int64(b.lineBytes)
+		} else {
+			// Empty line after the bases: no other sequence line is expected
+			b.short = true
+		}
+		return
+	}
+	switch {
+	case b.cur.LineBases == 0:
+		b.cur.LineBases, b.cur.LineWidth = b.lineBases, b.lineBytes
+	case b.short, b.lineBases > b.cur.LineBases, b.lineBytes-b.lineBases != b.cur.LineWidth-b.cur.LineBases:
+		b.err = fmt.Errorf("Different line length in sequence %q", b.cur.Name)
+		return
+	case b.lineBases < b.cur.LineBases:
+		b.short = true
+	}
+	b.cur.Length += b.lineBases
+}
+
+// endSequence is called at the beginning of a header line, or at the end of the file
+func (b *indexBuilder) endSequence() {
+	b.cur = nil
+}
+
+// close terminates the index at the end of the file
+func (b *indexBuilder) close() ([]*IndexRecord, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	if b.header != nil {
+		b.startSequence()
+	}
+	if b.lineBytes > 0 {
+		// Last line without end of line
+		first := b.cur != nil && b.cur.LineBases == 0
+		b.endLine()
+		if first {
+			b.records[len(b.records)-1].LineWidth++
+		}
+	}
+	b.endSequence()
+	if b.err != nil {
+		return nil, b.err
+	}
+	if len(b.records) == 0 {
+		return nil, fmt.Errorf("Fasta file should start with a >")
+	}
+	return b.records, nil
+}
+
+// BuildIndex builds the samtools compatible index (.fai) of the FASTA
+// file read by r.
+//
+// If the file is compressed with bgzip (BGZF blocks), the offsets of
+// the index are given in the uncompressed file, and the offsets of the
+// BGZF blocks (to be written in a .gzi index) are also returned
+// (nil otherwise). Files compressed with plain gzip cannot be indexed.
+//
+// As with samtools faidx, all the lines of a sequence, except the last
+// one, must have the same length, sequence names are the first word of
+// the headers, and duplicate sequences are ignored.
+func BuildIndex(r io.Reader) (records []*IndexRecord, blocks []BgzfBlock, err error) {
+	var magic []byte
+
+	br := bufio.NewReader(r)
+	b := newIndexBuilder()
+	if magic, _ = br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		if blocks, err = readBgzf(br, b); err != nil {
+			return
+		}
+	} else if _, err = io.Copy(b, br); err != nil {
+		return
+	}
+	records, err = b.close()
+	return
+}
+
+// WriteIndex writes the given index in samtools .fai format:
+// name, length, offset, line bases and line width, tab separated
+func WriteIndex(w io.Writer, records []*IndexRecord) (err error) {
+	bw := bufio.NewWriter(w)
+	for _, r := range records {
+		if _, err = fmt.Fprintf(bw, "%s\t%d\t%d\t%d\t%d\n", r.Name, r.Length, r.Offset, r.LineBases, r.LineWidth); err != nil {
+			return
+		}
+	}
+	return bw.Flush()
+}
+
+// ReadIndex reads a samtools .fai index
+func ReadIndex(r io.Reader) (records []*IndexRecord, err error) {
+	var values [4]int64
+
+	records = make([]*IndexRecord, 0)
+	scanner := bufio.NewScanner(r)
+	nline := 0
+	for scanner.Scan() {
+		nline++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		cols := strings.Split(line, "\t")
+		if len(cols) < 5 {
+			return nil, fmt.Errorf("Fasta index line %d: expecting at least 5 columns, got %d", nline, len(cols))
+		}
+		for i := range values {
+			if values[i], err = strconv.ParseInt(cols[i+1], 10, 64); err != nil || values[i] < 0 {
+				return nil, fmt.Errorf("Fasta index line %d: wrong value %q", nline, cols[i+1])
+			}
+		}
+		records = append(records, &IndexRecord{
+			Name:      cols[0],
+			Length:    int(values[0]),
+			Offset:    values[1],
+			LineBases: int(values[2]),
+			LineWidth: int(values[3]),
+		})
+	}
+	err = scanner.Err()
+	return
+}
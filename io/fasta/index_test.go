@@ -0,0 +1,236 @@
+package fasta
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+var indexfasta string = ">s1 first sequence\nACGTACGTAC\nGTACGTAC\n>s2\nTTTTTGGGGG\nCCCCCAAA\n>  s3\nAAAAACCCCC\nGGGGGTTT\n"
+
+// writeBgzf compresses data in BGZF blocks of at most blocksize
+// uncompressed bytes, followed by the empty EOF block
+func writeBgzf(data []byte, blocksize int) []byte {
+	var out bytes.Buffer
+	block := func(chunk []byte) {
+		var cdata bytes.Buffer
+		fw, _ := flate.NewWriter(&cdata, flate.DefaultCompression)
+		fw.Write(chunk)
+		fw.Close()
+		header := []byte{0x1f, 0x8b, 8, 4, 0, 0, 0, 0, 0, 0xff, 6, 0, 'B', 'C', 2, 0, 0, 0}
+		binary.LittleEndian.PutUint16(header[16:], uint16(len(header)+cdata.Len()+8-1))
+		out.Write(header)
+		out.Write(cdata.Bytes())
+		binary.Write(&out, binary.LittleEndian, crc32.ChecksumIEEE(chunk))
+		binary.Write(&out, binary.LittleEndian, uint32(len(chunk)))
+	}
+	for start := 0; start < len(data); start += blocksize {
+		end := start + blocksize
+		if end > len(data) {
+			end = len(data)
+		}
+		block(data[start:end])
+	}
+	block(nil)
+	return out.Bytes()
+}
+
+func TestBuildIndex(t *testing.T) {
+	expected := []*IndexRecord{
+		{Name: "s1", Length: 18, Offset: 19, LineBases: 10, LineWidth: 11},
+		{Name: "s2", Length: 18, Offset: 43, LineBases: 10, LineWidth: 11},
+		{Name: "s3", Length: 18, Offset: 69, LineBases: 10, LineWidth: 11},
+	}
+	records, blocks, err := BuildIndex(strings.NewReader(indexfasta))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks != nil {
+		t.Errorf("There should not be any BGZF block")
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Wrong index: %v", records)
+	}
+
+	var out bytes.Buffer
+	if err = WriteIndex(&out, records); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "s1\t18\t19\t10\t11\ns2\t18\t43\t10\t11\ns3\t18\t69\t10\t11\n" {
+		t.Errorf("Wrong .fai output: %s", out.String())
+	}
+	read, err := ReadIndex(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, expected) {
+		t.Errorf("Wrong index read: %v", read)
+	}
+
+	// Windows end of lines, no final end of line
+	records, _, err = BuildIndex(strings.NewReader(">s1\r\nACGT\r\nAC\r\n>s2\r\nACGTAC"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []*IndexRecord{
+		{Name: "s1", Length: 6, Offset: 5, LineBases: 4, LineWidth: 6},
+		{Name: "s2", Length: 6, Offset: 20, LineBases: 6, LineWidth: 7},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Wrong index: %v", records)
+	}
+}
+
+func TestBuildIndex_Errors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"ACGT\n>s1\nACGT\n",
+		" >s1\nACGT\n",
+		">s1\nACG\nACGT\n",
+		">s1\nACGT\nAC\nAC\n",
+		">s1\nACGT\n\nACGT\n",
+		">\nACGT\n",
+	} {
+		if _, _, err := BuildIndex(strings.NewReader(input)); err == nil {
+			t.Errorf("There should be an error while indexing %q", input)
+		}
+	}
+
+	// Plain gzip
+	var gz bytes.Buffer
+	gz.Write([]byte{0x1f, 0x8b, 8, 0, 0, 0, 0, 0, 0, 0xff})
+	if _, _, err := BuildIndex(&gz); err == nil {
+		t.Errorf("There should be an error while indexing a plain gzip file")
+	}
+}
+
+func TestIndexedReader(t *testing.T) {
+	for _, compressed := range []bool{false, true} {
+		file := filepath.Join(t.TempDir(), "al.fa")
+		data := []byte(indexfasta)
+		if compressed {
+			data = writeBgzf(data, 7)
+		}
+		if err := os.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		records, blocks, err := BuildIndex(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if compressed != (blocks != nil) {
+			t.Fatalf("Wrong BGZF blocks: %v", blocks)
+		}
+		var fai, gzi bytes.Buffer
+		WriteIndex(&fai, records)
+		os.WriteFile(file+".fai", fai.Bytes(), 0644)
+		if compressed {
+			if len(blocks) != (len(indexfasta)+6)/7 {
+				t.Errorf("Wrong number of BGZF blocks: %d", len(blocks))
+			}
+			WriteGzi(&gzi, blocks)
+			if gzi.Len() != 8+16*(len(blocks)-1) {
+				t.Errorf("Wrong .gzi size: %d", gzi.Len())
+			}
+			read, err := ReadGzi(bytes.NewReader(gzi.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(read, blocks) {
+				t.Errorf("Wrong .gzi read: %v", read)
+			}
+			os.WriteFile(file+".gzi", gzi.Bytes(), 0644)
+		}
+
+		r, err := NewIndexedReader(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(r.Names(), []string{"s1", "s2", "s3"}) {
+			t.Errorf("Wrong names: %v", r.Names())
+		}
+
+		s, err := r.Fetch("s1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.Name() != "s1 first sequence" || s.Sequence() != "ACGTACGTACGTACGTAC" {
+			t.Errorf("Wrong sequence (compressed: %t): %s %s", compressed, s.Name(), s.Sequence())
+		}
+		if s, err = r.Fetch("s3"); err != nil {
+			t.Fatal(err)
+		}
+		if s.Name() != "s3" || s.Sequence() != "AAAAACCCCCGGGGGTTT" {
+			t.Errorf("Wrong sequence (compressed: %t): %s %s", compressed, s.Name(), s.Sequence())
+		}
+
+		for region, expected := range map[string]string{
+			"s2":       "TTTTTGGGGGCCCCCAAA",
+			"s2:9-12":  "GGCC",
+			"s2:10-11": "GC",
+			"s2:16":    "AAA",
+			"s2:16-30": "AAA",
+			"s3:1-1":   "A",
+		} {
+			if s, err = r.FetchRegion(region); err != nil {
+				t.Error(err)
+			} else if s.Sequence() != expected {
+				t.Errorf("Wrong region %s (compressed: %t): %s, expected %s", region, compressed, s.Sequence(), expected)
+			}
+		}
+		for _, region := range []string{"s4", "s4:1-2", "s2:0-2", "s2:5-2", "s2:a-2", "s2:1-2-3"} {
+			if _, err = r.FetchRegion(region); err == nil {
+				t.Errorf("There should be an error with region %s", region)
+			}
+		}
+
+		var seq string
+		if seq, err = r.FetchRange("s1", 8, 12); err != nil {
+			t.Fatal(err)
+		}
+		if seq != "ACGT" {
+			t.Errorf("Wrong range: %s", seq)
+		}
+
+		// Errors are located at the header of the sequence
+		var pe *alignio.ParseError
+		if err = r.SequenceError("s3", fmt.Errorf("wrong sequence")); !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+		} else if pe.Line != 7 || pe.Column != 1 || pe.Token != ">s3" {
+			t.Errorf("Wrong error position (compressed: %t): %d:%d %q", compressed, pe.Line, pe.Column, pe.Token)
+		}
+		r.Close()
+	}
+}
+
+func TestIndexedReader_MissingGzi(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "al.fa.gz")
+	data := writeBgzf([]byte(indexfasta), 100)
+	os.WriteFile(file, data, 0644)
+	records, _, _ := BuildIndex(bytes.NewReader(data))
+	var fai bytes.Buffer
+	WriteIndex(&fai, records)
+	os.WriteFile(file+".fai", fai.Bytes(), 0644)
+
+	if _, err := NewIndexedReader(file); err == nil {
+		t.Errorf("There should be an error: no .gzi index")
+	}
+	if _, err := NewIndexedReader(filepath.Join(t.TempDir(), "none.fa")); err == nil {
+		t.Errorf("There should be an error: no fasta file")
+	}
+	fmt.Fprint(&fai, "s4\t10\n")
+	if _, err := ReadIndex(&fai); err == nil {
+		t.Errorf("There should be an error: malformed .fai index")
+	}
+}
@@ -0,0 +1,328 @@
+package fasta
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+// IndexedReader gives access to the sequences of an indexed FASTA
+// file (samtools .fai index) without parsing the whole file.
+//
+// The FASTA file may be uncompressed, or compressed with bgzip. In
+// the latter case, the .gzi index of the BGZF blocks is also required.
+type IndexedReader struct {
+	file    *os.File
+	records []*IndexRecord
+	names   map[string]*IndexRecord
+	blocks  []BgzfBlock // nil if the FASTA file is not compressed
+}
+
+// NewIndexedReader opens the given FASTA file and its index: file.fai, and
+// file.gzi if the file is compressed with bgzip.
+func NewIndexedReader(file string) (r *IndexedReader, err error) {
+	var fai, gzi *os.File
+	var magic [2]byte
+
+	r = &IndexedReader{names: make(map[string]*IndexRecord)}
+	if r.file, err = os.Open(file); err != nil {
+		return nil, err
+	}
+	if fai, err = os.Open(file + ".fai"); err != nil {
+		r.file.Close()
+		return nil, err
+	}
+	defer fai.Close()
+	if r.records, err = ReadIndex(fai); err != nil {
+		r.file.Close()
+		return nil, err
+	}
+	for _, rec := range r.records {
+		r.names[rec.Name] = rec
+	}
+
+	if n, _ := r.file.ReadAt(magic[:], 0); n == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		if gzi, err = os.Open(file + ".gzi"); err != nil {
+			r.file.Close()
+			return nil, fmt.Errorf("Compressed fasta file requires a .gzi index: %v", err)
+		}
+		defer gzi.Close()
+		if r.blocks, err = ReadGzi(gzi); err != nil {
+			r.file.Close()
+			return nil, err
+		}
+	}
+	return
+}
+
+// Close closes the FASTA file
+func (r *IndexedReader) Close() error {
+	return r.file.Close()
+}
+
+// Index returns the index records, in the order of the FASTA file
+func (r *IndexedReader) Index() []*IndexRecord {
+	return r.records
+}
+
+// Names returns the names of the indexed sequences (first word
+// of the headers), in the order of the FASTA file
+func (r *IndexedReader) Names() (names []string) {
+	names = make([]string, len(r.records))
+	for i, rec := range r.records {
+		names[i] = rec.Name
+	}
+	return
+}
+
+// Record returns the index record of the sequence having the given name
+func (r *IndexedReader) Record(name string) (rec *IndexRecord, err error) {
+	var ok bool
+	if rec, ok = r.names[name]; !ok {
+		err = fmt.Errorf("Sequence %s does not exist in the fasta index", name)
+	}
+	return
+}
+
+// Fetch returns the whole sequence having the given name. The name
+// of the returned sequence is its full header, as with the FASTA parser.
+func (r *IndexedReader) Fetch(name string) (seq align.Sequence, err error) {
+	var rec *IndexRecord
+	var header, sequence string
+
+	if rec, err = r.Record(name); err != nil {
+		return
+	}
+	if header, err = r.Header(name); err != nil {
+		return
+	}
+	if sequence, err = r.FetchRange(name, 0, rec.Length); err != nil {
+		return
+	}
+	seq = align.NewSequence(header, []rune(sequence), "")
+	return
+}
+
+// FetchRegion returns the sequence of the given region, in the
+// samtools format: "name", "name:start" or "name:start-end", with
+// start and end 1-based inclusive. The name of the returned sequence
+// is the region.
+func (r *IndexedReader) FetchRegion(region string) (seq align.Sequence, err error) {
+	var name, sequence string
+	var start, end int
+
+	if name, start, end, err = r.ParseRegion(region); err != nil {
+		return
+	}
+	if _, ok := r.names[region]; ok {
+		return r.Fetch(name)
+	}
+	if sequence, err = r.FetchRange(name, start, end); err != nil {
+		return
+	}
+	seq = align.NewSequence(region, []rune(sequence), "")
+	return
+}
+
+// ParseRegion parses a samtools region ("name", "name:start" or
+// "name:start-end", 1-based inclusive, commas allowed in positions)
+// and returns the name of the sequence and the region as 0-based
+// start inclusive and end exclusive positions.
+//
+// If the whole region is the name of an indexed sequence, it is
+// considered as a sequence name, even if it contains ':'.
+func (r *IndexedReader) ParseRegion(region string) (name string, start, end int, err error) {
+	var rec *IndexRecord
+	var ok bool
+	var pos int
+
+	if rec, ok = r.names[region]; ok {
+		return region, 0, rec.Length, nil
+	}
+	if pos = strings.LastIndex(region, ":"); pos < 0 {
+		err = fmt.Errorf("Sequence %s does not exist in the fasta index", region)
+		return
+	}
+	name = region[:pos]
+	if rec, err = r.Record(name); err != nil {
+		return
+	}
+	positions := strings.Split(strings.Replace(region[pos+1:], ",", "", -1), "-")
+	end = rec.Length
+	if len(positions) > 2 {
+		err = fmt.Errorf("Malformed region %s", region)
+		return
+	}
+	if start, err = strconv.Atoi(positions[0]); err != nil || start < 1 {
+		err = fmt.Errorf("Malformed region %s", region)
+		return
+	}
+	if len(positions) == 2 {
+		if end, err = strconv.Atoi(positions[1]); err != nil || end < start {
+			err = fmt.Errorf("Malformed region %s", region)
+			return
+		}
+	}
+	start--
+	if end > rec.Length {
+		end = rec.Length
+	}
+	if start > end {
+		start = end
+	}
+	return
+}
+
+// FetchRange returns the part of the sequence having the given name
+// between start (0-based inclusive) and end (0-based exclusive).
+// end is truncated to the length of the sequence.
+func (r *IndexedReader) FetchRange(name string, start, end int) (sequence string, err error) {
+	var rec *IndexRecord
+	var data []byte
+
+	if rec, err = r.Record(name); err != nil {
+		return
+	}
+	if start < 0 || end < start {
+		err = fmt.Errorf("Wrong range [%d,%d[ for sequence %s", start, end, name)
+		return
+	}
+	if end > rec.Length {
+		end = rec.Length
+	}
+	if start >= end {
+		return "", nil
+	}
+
+	from, to := rec.position(start), rec.position(end-1)+1
+	if data, err = r.readAt(from, to); err != nil {
+		return
+	}
+	seq := make([]byte, 0, end-start)
+	for _, c := range data {
+		if c != '\n' && c != '\r' {
+			seq = append(seq, c)
+		}
+	}
+	if len(seq) != end-start {
+		err = fmt.Errorf("Sequence %s does not correspond to the fasta index", name)
+		return
+	}
+	sequence = string(seq)
+	return
+}
+
+// Header returns the full header of the sequence having the given
+// name, without the starting '>' and leading spaces: it is the name
+// given to the sequence by the FASTA parser.
+func (r *IndexedReader) Header(name string) (header string, err error) {
+	var rec *IndexRecord
+
+	if rec, err = r.Record(name); err != nil {
+		return
+	}
+	_, header, err = r.header(rec)
+	return
+}
+
+// header returns the offset of the header line of the given record,
+// and the header, without the starting '>' and leading spaces
+func (r *IndexedReader) header(rec *IndexRecord) (offset int64, header string, err error) {
+	var data []byte
+	var start int64
+
+	// The header is the last non empty line before the offset of the sequence
+	end := rec.Offset
+	for size := int64(256); ; size *= 4 {
+		if start = end - size; start < 0 {
+			start = 0
+		}
+		if data, err = r.readAt(start, end); err != nil {
+			return
+		}
+		data = bytes.TrimRight(data, "\r\n")
+		if pos := bytes.LastIndexByte(data, '\n'); pos >= 0 {
+			start += int64(pos + 1)
+			data = data[pos+1:]
+		} else if start > 0 {
+			continue
+		}
+		break
+	}
+	if len(data) == 0 || data[0] != '>' {
+		err = fmt.Errorf("Sequence %s does not correspond to the fasta index", rec.Name)
+		return
+	}
+	return start, strings.TrimLeft(string(data[1:]), " "), nil
+}
+
+// SequenceError returns the given error, concerning the sequence having
+// the given name, located at the header of the sequence (see
+// alignio.ParseError), as the errors of the FASTA parser. The line of
+// the header is computed by reading the file up to the header.
+func (r *IndexedReader) SequenceError(name string, err error) error {
+	var rec *IndexRecord
+	var data []byte
+	var offset int64
+	var header string
+	var e error
+
+	if rec, e = r.Record(name); e != nil {
+		return err
+	}
+	if offset, header, e = r.header(rec); e != nil {
+		return err
+	}
+	line := 1
+	for start := int64(0); start < offset; start += 1 << 20 {
+		end := start + 1<<20
+		if end > offset {
+			end = offset
+		}
+		if data, e = r.readAt(start, end); e != nil {
+			return err
+		}
+		line += bytes.Count(data, []byte{'\n'})
+	}
+	return alignio.NewParseError(line, 1, ">"+header, err)
+}
+
+// readAt reads the file content between the given start (inclusive)
+// and end (exclusive) offsets of the uncompressed file
+func (r *IndexedReader) readAt(start, end int64) (data []byte, err error) {
+	var gz *gzip.Reader
+	var n int
+
+	data = make([]byte, end-start)
+	if r.blocks == nil {
+		if n, err = r.file.ReadAt(data, start); err != nil && !errors.Is(err, io.EOF) {
+			return
+		}
+		return data[:n], nil
+	}
+
+	// BGZF: Last block starting before start
+	i := sort.Search(len(r.blocks), func(i int) bool { return r.blocks[i].UncompressedOffset > start }) - 1
+	block := r.blocks[i]
+	if gz, err = gzip.NewReader(io.NewSectionReader(r.file, block.CompressedOffset, math.MaxInt64-block.CompressedOffset)); err != nil {
+		return
+	}
+	defer gz.Close()
+	if _, err = io.CopyN(io.Discard, gz, start-block.UncompressedOffset); err != nil {
+		return nil, fmt.Errorf("Compressed fasta file does not correspond to its index")
+	}
+	if n, err = io.ReadFull(gz, data); err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return
+	}
+	return data[:n], nil
+}
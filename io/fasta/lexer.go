@@ -4,22 +4,27 @@ import (
 	"bufio"
 	"bytes"
 	"io"
+
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Scanner represents a lexical scanner.
 type Scanner struct {
-	r *bufio.Reader
+	r         *bufio.Reader
+	pos       *alignio.Position
+	line, col int // Position of the last scanned token
 }
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{r: bufio.NewReader(r), pos: alignio.NewPosition()}
 }
 
 // read reads the next rune from the bufferred reader.
 // Returns the rune(0) if an error occurs (or io.EOF is returned).
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
+	s.pos.Read(ch, err != nil)
 	if err != nil {
 		return eof
 	}
@@ -29,12 +34,19 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
 	_ = s.r.UnreadRune()
+	s.pos.Unread()
+}
+
+// Pos returns the line and column (1-based) of the last scanned token.
+func (s *Scanner) Pos() (line, col int) {
+	return s.line, s.col
 }
 
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan() (tok Token, lit string) {
 	// Read the next rune.
 	ch := s.read()
+	s.line, s.col = s.pos.Last()
 
 	// If we see whitespace then consume all contiguous whitespace.
 	// If we see a letter then consume as an ident or reserved word.
@@ -2,12 +2,15 @@ package fasta
 
 import (
 	"bytes"
-	"errors"
+	"fmt"
+	"hash/fnv"
 	"io"
+	"log"
 	"regexp"
 	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Parser represents a parser.
@@ -15,9 +18,11 @@ type Parser struct {
 	s               *Scanner
 	ignoreidentical bool
 	buf             struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
+		tok  Token  // last read token
+		lit  string // last read literal
+		line int    // line of the last read token
+		col  int    // column of the last read token
+		n    int    // buffer size (max=1)
 	}
 }
 
@@ -43,6 +48,7 @@ func (p *Parser) scan() (tok Token, lit string) {
 
 	// Otherwise read the next token from the scanner.
 	tok, lit = p.s.Scan()
+	p.buf.line, p.buf.col = p.s.Pos()
 
 	// Save it to the buffer in case we unscan later.
 	p.buf.tok, p.buf.lit = tok, lit
@@ -52,6 +58,11 @@ func (p *Parser) scan() (tok Token, lit string) {
 // unscan pushes the previously read token back onto the buffer.
 func (p *Parser) unscan() { p.buf.n = 1 }
 
+// errorf returns a parse error located at the last read token
+func (p *Parser) errorf(format string, a ...interface{}) error {
+	return alignio.NewParseError(p.buf.line, p.buf.col, p.buf.lit, fmt.Errorf(format, a...))
+}
+
 // scanIgnoreEndOfLine scans the next EOL token.
 func (p *Parser) scanIgnoreEndOfLine() (tok Token, lit string) {
 	tok, lit = p.scan()
@@ -78,45 +89,108 @@ func (p *Parser) ParseUnalign() (sb align.SeqBag, err error) {
 }
 
 func (p *Parser) parseGeneric(sb align.SeqBag) (err error) {
+	if err = p.parseSequences(func(name, sequence string) error {
+		return sb.AddSequence(name, sequence, "")
+	}); err != nil {
+		return
+	}
+	sb.AutoAlphabet()
+	return
+}
+
+// ParseStream parses a FASTA file sequence by sequence, and sends
+// each sequence to the given SequenceChannel as soon as it is read.
+//
+// Contrary to Parse and ParseUnalign, the whole file is never loaded
+// in memory. Duplicate sequence names are handled as in ParseUnalign:
+// they are renamed with a "_<4 digit index>" suffix, or ignored if
+// IgnoreIdentical is true and sequences are identical (only a hash
+// of sequences is kept for that purpose). The names of all the sequences
+// are kept to detect duplicates, so memory usage still grows with the
+// number of sequences, by the size of their names only.
+//
+// At the end, Schan is closed and Err contains the parsing error, if any.
+func (p *Parser) ParseStream(seqs *align.SequenceChannel) {
+	names := make(map[string]uint64)
+	seqs.Err = p.parseSequences(func(name, sequence string) error {
+		hash := hashSequence(sequence)
+		h, ok := names[name]
+		if ok && p.ignoreidentical && h == hash {
+			log.Print(fmt.Sprintf("Warning: sequence \"%s\" already exists in alignment with the same sequence, ignoring", name))
+			return nil
+		}
+		tmpname := name
+		idx := 0
+		for ok {
+			idx++
+			log.Print(fmt.Sprintf("Warning: sequence \"%s\" already exists in alignment, renamed in \"%s_%04d\"", tmpname, name, idx))
+			tmpname = fmt.Sprintf("%s_%04d", name, idx)
+			_, ok = names[tmpname]
+		}
+		names[tmpname] = hash
+		seqs.Schan <- align.NewSequence(tmpname, []rune(sequence), "")
+		return nil
+	})
+	close(seqs.Schan)
+}
+
+// parseSequences parses all the fasta entries, and calls the
+// given function for each of them, in the order of the file.
+// Stops and returns the error as soon as the function returns an error.
+func (p *Parser) parseSequences(addseq func(name, sequence string) error) (err error) {
 	// The first token should be a ">"
 	tok, lit := p.scanIgnoreEndOfLine()
 	if tok != STARTIDENT {
-		err = errors.New("Fasta file should start with a > ")
+		err = p.errorf("Fasta file should start with a > ")
 		return
 	}
 	p.unscan()
 	var curseq bytes.Buffer
+	var curline, curcol int // Position of the header of the current sequence
 	curname := ""
 	firstSpaces := regexp.MustCompile("^( +)")
+	// Errors on the current sequence are located at its header
+	seqerror := func(err error) error {
+		return alignio.NewParseError(curline, curcol, ">"+curname, err)
+	}
 	for tok != EOF {
 		tok, lit = p.scanIgnoreEndOfLine()
 		switch tok {
 		case STARTIDENT:
+			line, col := p.buf.line, p.buf.col
 			tok, lit = p.scan()
 			if tok != IDENTIFIER {
-				err = errors.New("> should be followed by a sequence identifier")
+				err = p.errorf("> should be followed by a sequence identifier")
 				return
 			}
 			if curseq.Len() > 0 {
-				if err = sb.AddSequence(curname, curseq.String(), ""); err != nil {
+				if err = addseq(curname, curseq.String()); err != nil {
+					err = seqerror(err)
 					return
 				}
 				curseq.Reset()
 			} else if curname != "" {
-				err = errors.New("A Fasta entry has a name but no sequence (" + curname + ")")
+				err = seqerror(fmt.Errorf("A Fasta entry has a name but no sequence (%s)", curname))
 				return
 			}
 			curname = firstSpaces.ReplaceAllString(lit, "")
+			curline, curcol = line, col
 		case IDENTIFIER:
 			curseq.WriteString(strings.Replace(lit, " ", "", -1))
 		case EOF:
 			if curseq.Len() > 0 {
-				if err = sb.AddSequence(curname, curseq.String(), ""); err != nil {
+				if err = addseq(curname, curseq.String()); err != nil {
+					err = seqerror(err)
 					return
 				}
 			}
 		}
 	}
-	sb.AutoAlphabet()
 	return
 }
+
+func hashSequence(sequence string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(sequence))
+	return h.Sum64()
+}
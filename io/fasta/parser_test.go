@@ -2,9 +2,13 @@ package fasta
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 var fastastring string = ">s1\nACGATCGATTACTACTGAC\nACGACTGATCGATCG"
@@ -60,3 +64,64 @@ func TestParse(t *testing.T) {
 		t.Errorf("Alignment has not 1000 sequences : %d", align5.NbSequences())
 	}
 }
+
+func TestParseStream(t *testing.T) {
+	var out bytes.Buffer
+	// Sequences with different lengths and a duplicate name
+	var input string = ">s1\nACGATCGATTACTACTGAC\nACGACTGATCGATCG\n>s2\nACGATC\n>s1\nACG\n"
+	var expected string = ">s1\nACGATCGATTACTACTGACACGACTGATCGATCG\n>s2\nACGATC\n>s1_0001\nACG\n"
+
+	seqs := &align.SequenceChannel{Schan: make(chan align.Sequence, 1)}
+	go NewParser(strings.NewReader(input)).ParseStream(seqs)
+
+	w := NewStreamWriter(&out)
+	nb := 0
+	for s := range seqs.Schan {
+		if err := w.Write(s); err != nil {
+			t.Error(err)
+		}
+		nb++
+	}
+	if seqs.Err != nil {
+		t.Error(seqs.Err)
+	}
+	w.Flush()
+	if nb != 3 {
+		t.Errorf("There should be 3 sequences in the stream: %d", nb)
+	}
+	if out.String() != expected {
+		t.Errorf("Written sequences are not as expected:\n%s\nvs.\n%s", out.String(), expected)
+	}
+
+	seqs = &align.SequenceChannel{Schan: make(chan align.Sequence, 1)}
+	go NewParser(strings.NewReader(fastastring2)).ParseStream(seqs)
+	for range seqs.Schan {
+	}
+	if seqs.Err == nil {
+		t.Errorf("There should be an error while parsing fastastring2")
+	}
+}
+
+func TestParse_ErrorPosition(t *testing.T) {
+	var pe *alignio.ParseError
+
+	_, err := NewParser(strings.NewReader(fastastring4)).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 4 || pe.Column != 1 || pe.Token != ">s2" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+	pe.File = "al.fa"
+	if err.Error() != "al.fa:4:1: Sequence s2 does not have same length as other sequences (at \">s2\")" {
+		t.Errorf("Wrong error message: %s", err.Error())
+	}
+
+	_, err = NewParser(strings.NewReader("\n\nACGT\n")).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 3 || pe.Column != 1 || pe.Token != "ACGT" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+}
@@ -1,7 +1,9 @@
 package fasta
 
 import (
+	"bufio"
 	"bytes"
+	"io"
 
 	"github.com/evolbioinfo/goalign/align"
 )
@@ -19,35 +21,66 @@ func min_int(a int, b int) int {
 
 func WriteAlignment(sb align.SeqBag) string {
 	var buf bytes.Buffer
-	sb.IterateChar(func(name string, seq []rune) bool {
-		buf.WriteString(">")
-		buf.WriteString(name)
-		buf.WriteString("\n")
-		for i := 0; i < len(seq); i++ {
-			if i%FASTA_LINE == 0 && i > 0 {
-				buf.WriteString("\n")
-			}
-			buf.WriteRune(seq[i])
-		}
-		buf.WriteRune('\n')
+	sb.IterateBytes(func(name string, seq []byte) bool {
+		writeSequence(&buf, name, seq)
 		return false
 	})
 	return buf.String()
 }
 
+func writeSequence(buf *bytes.Buffer, name string, seq []byte) {
+	buf.WriteString(">")
+	buf.WriteString(name)
+	buf.WriteString("\n")
+	for i := 0; i < len(seq); i++ {
+		if i%FASTA_LINE == 0 && i > 0 {
+			buf.WriteString("\n")
+		}
+		buf.WriteByte(seq[i])
+	}
+	buf.WriteRune('\n')
+}
+
+// StreamWriter writes sequences in Fasta format one at a time,
+// without keeping them in memory (see Parser.ParseStream).
+// Output is the same as WriteAlignment.
+type StreamWriter struct {
+	w   *bufio.Writer
+	buf bytes.Buffer
+}
+
+// NewStreamWriter returns a new StreamWriter writing to w.
+// Flush must be called once all sequences are written.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes the given sequence
+func (sw *StreamWriter) Write(s align.Sequence) (err error) {
+	sw.buf.Reset()
+	writeSequence(&sw.buf, s.Name(), s.SequenceBytes())
+	_, err = sw.w.Write(sw.buf.Bytes())
+	return
+}
+
+// Flush writes any buffered data to the underlying writer
+func (sw *StreamWriter) Flush() error {
+	return sw.w.Flush()
+}
+
 // Write input alignment as standard fasta sequences
 // It removes "-" characters.
 func WriteSequences(sb align.SeqBag) string {
 	var buf bytes.Buffer
 
-	sb.IterateChar(func(name string, seq []rune) bool {
+	sb.IterateBytes(func(name string, seq []byte) bool {
 		buf.WriteString(">")
 		buf.WriteString(name)
 		buf.WriteString("\n")
 		nbchar := 0
 		for i := 0; i < len(seq); i++ {
 			if seq[i] != '-' {
-				buf.WriteRune(seq[i])
+				buf.WriteByte(seq[i])
 				nbchar++
 				if nbchar == FASTA_LINE {
 					buf.WriteString("\n")
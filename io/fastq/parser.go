@@ -0,0 +1,186 @@
+package fastq
+
+import (
+	"bytes"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+// Qualities are encoded as Phred+33 (Sanger, Illumina 1.8+)
+const PHRED_OFFSET = 33
+
+// Parser represents a FASTQ parser.
+//
+// Each entry is made of a header line starting with '@' (followed by the
+// sequence name), one or several sequence lines, a separator line starting
+// with '+', and one or several quality lines having as many characters as
+// the sequence.
+type Parser struct {
+	r               *alignio.LineReader
+	ignoreidentical bool
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: alignio.NewLineReader(r), ignoreidentical: false}
+}
+
+// If sets to true, then will ignore duplicate sequences that have the same name and the same sequence
+// Otherwise, it just renames them just as the sequences that have same name and different sequences
+func (p *Parser) IgnoreIdentical(ignore bool) {
+	p.ignoreidentical = ignore
+}
+
+// error returns a parse error located at the last read line
+func (p *Parser) error(message string) error {
+	return p.r.Errorf("FASTQ: %s", message)
+}
+
+// Parse parses a FASTQ file as an alignment: all the sequences
+// must have the same length.
+func (p *Parser) Parse() (al align.Alignment, err error) {
+	al = align.NewAlign(align.UNKNOWN)
+	al.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(al)
+	return
+}
+
+// ParseUnalign parses a FASTQ file as unaligned sequences.
+func (p *Parser) ParseUnalign() (sb align.SeqBag, err error) {
+	sb = align.NewSeqBag(align.UNKNOWN)
+	sb.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(sb)
+	return
+}
+
+func (p *Parser) parseGeneric(sb align.SeqBag) (err error) {
+	if err = p.parseSequences(func(name, sequence string, qualities []byte) (err error) {
+		nb := sb.NbSequences()
+		if err = sb.AddSequence(name, sequence, ""); err != nil {
+			return
+		}
+		if sb.NbSequences() > nb {
+			s, _ := sb.Sequence(nb)
+			err = s.SetQualities(qualities)
+		}
+		return
+	}); err != nil {
+		return
+	}
+	if sb.NbSequences() == 0 {
+		return p.error("no sequence in the input file")
+	}
+	sb.AutoAlphabet()
+	return
+}
+
+// ParseStream parses a FASTQ file sequence by sequence, and sends
+// each sequence (with its qualities) to the given SequenceChannel
+// as soon as it is read (see fasta.Parser.ParseStream).
+//
+// At the end, Schan is closed and Err contains the parsing error, if any.
+func (p *Parser) ParseStream(seqs *align.SequenceChannel) {
+	names := make(map[string]uint64)
+	seqs.Err = p.parseSequences(func(name, sequence string, qualities []byte) (err error) {
+		hash := hashSequence(sequence)
+		h, ok := names[name]
+		if ok && p.ignoreidentical && h == hash {
+			log.Print(fmt.Sprintf("Warning: sequence \"%s\" already exists in alignment with the same sequence, ignoring", name))
+			return nil
+		}
+		tmpname := name
+		idx := 0
+		for ok {
+			idx++
+			log.Print(fmt.Sprintf("Warning: sequence \"%s\" already exists in alignment, renamed in \"%s_%04d\"", tmpname, name, idx))
+			tmpname = fmt.Sprintf("%s_%04d", name, idx)
+			_, ok = names[tmpname]
+		}
+		names[tmpname] = hash
+		s := align.NewSequence(tmpname, []rune(sequence), "")
+		if err = s.SetQualities(qualities); err != nil {
+			return
+		}
+		seqs.Schan <- s
+		return nil
+	})
+	close(seqs.Schan)
+}
+
+// parseSequences parses all the FASTQ entries, and calls the
+// given function for each of them, in the order of the file.
+// Stops and returns the error as soon as the function returns an error.
+func (p *Parser) parseSequences(addseq func(name, sequence string, qualities []byte) error) (err error) {
+	var line, name string
+	var seq bytes.Buffer
+	var qual []byte
+
+	for {
+		// Header
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "@") {
+			return p.error("entry should start with @")
+		}
+		name = strings.TrimLeft(line[1:], " ")
+
+		// Sequence, until the + separator
+		seq.Reset()
+		for {
+			if line, err = p.r.ReadLine(); err == io.EOF {
+				return p.error(fmt.Sprintf("entry %s does not have qualities", name))
+			} else if err != nil {
+				return
+			}
+			if strings.HasPrefix(line, "+") {
+				break
+			}
+			seq.WriteString(strings.TrimSpace(line))
+		}
+
+		// Qualities, until we have as many as sequence characters
+		// (quality lines may start with @ or +). There is at least
+		// one quality line, even if the sequence is empty.
+		qual = make([]byte, 0, seq.Len())
+		for {
+			if line, err = p.r.ReadLine(); err == io.EOF {
+				return p.error(fmt.Sprintf("entry %s: less qualities than sequence characters", name))
+			} else if err != nil {
+				return
+			}
+			for _, c := range []byte(strings.TrimSpace(line)) {
+				if c < PHRED_OFFSET || c > '~' {
+					return p.error(fmt.Sprintf("entry %s: wrong quality character '%c'", name, c))
+				}
+				qual = append(qual, c-PHRED_OFFSET)
+			}
+			if len(qual) >= seq.Len() {
+				break
+			}
+		}
+		if len(qual) != seq.Len() {
+			return p.error(fmt.Sprintf("entry %s: %d qualities for %d sequence characters", name, len(qual), seq.Len()))
+		}
+		if err = addseq(name, seq.String(), qual); err != nil {
+			return
+		}
+	}
+}
+
+func hashSequence(sequence string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(sequence))
+	return h.Sum64()
+}
@@ -0,0 +1,146 @@
+package fastq
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+var fastqstring1 string = `@s1 first read
+ACGTACGTAC
++
+II#IIIII!!
+@s2
+ACGTA
+CGTAA
++s2
+@IIII
+IIII5
+
+@s3
+ACGT
++
++III
+`
+
+func TestParse(t *testing.T) {
+	sb, err := NewParser(strings.NewReader(fastqstring1)).ParseUnalign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.NbSequences() != 3 {
+		t.Fatalf("There should be 3 sequences (%d)", sb.NbSequences())
+	}
+	exp := []struct {
+		name, seq string
+		qual      []byte
+	}{
+		{"s1 first read", "ACGTACGTAC", []byte{40, 40, 2, 40, 40, 40, 40, 40, 0, 0}},
+		{"s2", "ACGTACGTAA", []byte{31, 40, 40, 40, 40, 40, 40, 40, 40, 20}},
+		{"s3", "ACGT", []byte{10, 40, 40, 40}},
+	}
+	for i, e := range exp {
+		s, _ := sb.Sequence(i)
+		if s.Name() != e.name || s.Sequence() != e.seq {
+			t.Errorf("Sequence %d is not as expected: %s %s", i, s.Name(), s.Sequence())
+		}
+		if string(s.Qualities()) != string(e.qual) {
+			t.Errorf("Qualities of sequence %d are not as expected: %v vs. %v", i, s.Qualities(), e.qual)
+		}
+	}
+
+	if _, err = NewParser(strings.NewReader(fastqstring1)).Parse(); err == nil {
+		t.Errorf("There should be an error: sequences do not have the same length")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, s := range []string{
+		// Fasta
+		">s1\nACGT\n",
+		// No qualities
+		"@s1\nACGT\n",
+		// Less qualities than sequence characters
+		"@s1\nACGT\n+\nIII\n",
+		// More qualities than sequence characters
+		"@s1\nACGT\n+\nIIIII\n",
+		// Wrong quality character
+		"@s1\nACGT\n+\nII I\n",
+		// Empty file
+		"",
+	} {
+		if _, err := NewParser(strings.NewReader(s)).ParseUnalign(); err == nil {
+			t.Errorf("There should be an error while reading entry %d", i)
+		}
+	}
+}
+
+func TestParseError_Line(t *testing.T) {
+	var pe *alignio.ParseError
+
+	for _, test := range []struct {
+		input string
+		line  int
+	}{
+		// Wrong quality character
+		{"@s1\nACGT\n+\nII I\n", 4},
+	} {
+		_, err := NewParser(strings.NewReader(test.input)).ParseUnalign()
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+			continue
+		}
+		if pe.Line != test.line || pe.Column != 1 {
+			t.Errorf("Wrong error position: %d:%d, expected %d:1", pe.Line, pe.Column, test.line)
+		}
+	}
+}
+
+func TestParseStream(t *testing.T) {
+	seqs := &align.SequenceChannel{Schan: make(chan align.Sequence, 10)}
+	go NewParser(strings.NewReader(fastqstring1 + "@s3\nACGA\n+\nIIII\n")).ParseStream(seqs)
+	names := make([]string, 0)
+	for s := range seqs.Schan {
+		if len(s.Qualities()) != s.Length() {
+			t.Errorf("Sequence %s does not have qualities", s.Name())
+		}
+		names = append(names, s.Name())
+	}
+	if seqs.Err != nil {
+		t.Fatal(seqs.Err)
+	}
+	if strings.Join(names, ",") != "s1 first read,s2,s3,s3_0001" {
+		t.Errorf("Sequence names are not as expected: %v", names)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	sb, err := NewParser(strings.NewReader(fastqstring1)).ParseUnalign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sb.AddSequence("s4", "AC", "")
+	exp := `@s1 first read
+ACGTACGTAC
++
+II#IIIII!!
+@s2
+ACGTACGTAA
++
+@IIIIIIII5
+@s3
+ACGT
++
++III
+@s4
+AC
++
+II
+`
+	if out := WriteAlignment(sb); out != exp {
+		t.Errorf("FASTQ output is not as expected:\n%s", out)
+	}
+}
@@ -0,0 +1,74 @@
+package fastq
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Quality written for sequences that do not have qualities
+// (ex: converted from fasta): Phred 40 ('I')
+const DEFAULT_QUALITY = 40
+
+// WriteAlignment writes the sequences in FASTQ format, with their qualities
+// encoded as Phred+33. Each sequence is written on a single line.
+//
+// Sequences that do not have qualities are written with DEFAULT_QUALITY.
+func WriteAlignment(sb align.SeqBag) string {
+	var buf bytes.Buffer
+	for i := 0; i < sb.NbSequences(); i++ {
+		s, _ := sb.Sequence(i)
+		writeSequence(&buf, s)
+	}
+	return buf.String()
+}
+
+func writeSequence(buf *bytes.Buffer, s align.Sequence) {
+	seq := s.SequenceBytes()
+	qual := s.Qualities()
+	buf.WriteString("@")
+	buf.WriteString(s.Name())
+	buf.WriteString("\n")
+	buf.Write(seq)
+	buf.WriteString("\n+\n")
+	for i := range seq {
+		q := byte(DEFAULT_QUALITY)
+		if qual != nil {
+			q = qual[i]
+		}
+		if q > '~'-PHRED_OFFSET {
+			q = '~' - PHRED_OFFSET
+		}
+		buf.WriteByte(q + PHRED_OFFSET)
+	}
+	buf.WriteString("\n")
+}
+
+// StreamWriter writes sequences in FASTQ format one at a time,
+// without keeping them in memory (see Parser.ParseStream).
+// Output is the same as WriteAlignment.
+type StreamWriter struct {
+	w   *bufio.Writer
+	buf bytes.Buffer
+}
+
+// NewStreamWriter returns a new StreamWriter writing to w.
+// Flush must be called once all sequences are written.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return &StreamWriter{w: bufio.NewWriter(w)}
+}
+
+// Write writes the given sequence
+func (sw *StreamWriter) Write(s align.Sequence) (err error) {
+	sw.buf.Reset()
+	writeSequence(&sw.buf, s)
+	_, err = sw.w.Write(sw.buf.Bytes())
+	return
+}
+
+// Flush writes any buffered data to the underlying writer
+func (sw *StreamWriter) Flush() error {
+	return sw.w.Flush()
+}
@@ -0,0 +1,134 @@
+package genbank
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Feature keys begin before this column, locations and
+// qualifiers begin at this column
+const FEATURE_QUALIFIER_COLUMN = 21
+
+// FeatureTableParser parses the lines of a feature table, given one by one,
+// in the GenBank layout:
+//
+//	CDS             join(266..13468,
+//	                13468..21555)
+//	                /gene="ORF1ab"
+//
+// It is also used for EMBL feature tables, whose lines are the same,
+// except for the "FT" prefix.
+type FeatureTableParser struct {
+	features []*align.Feature
+	key      string
+	location string
+	quals    []string // Raw qualifiers of the current feature
+}
+
+// NewFeatureTableParser returns a new FeatureTableParser
+func NewFeatureTableParser() *FeatureTableParser {
+	return &FeatureTableParser{
+		features: make([]*align.Feature, 0),
+		quals:    make([]string, 0),
+	}
+}
+
+// AddLine adds a line of the feature table
+func (p *FeatureTableParser) AddLine(line string) (err error) {
+	text := strings.TrimSpace(line)
+	if text == "" {
+		return nil
+	}
+	indent := len(line) - len(strings.TrimLeft(line, " "))
+	if indent == 0 {
+		return fmt.Errorf("Feature table line should be indented: %s", text)
+	}
+
+	// New feature
+	if indent < FEATURE_QUALIFIER_COLUMN {
+		if err = p.flush(); err != nil {
+			return
+		}
+		fields := strings.Fields(text)
+		p.key = fields[0]
+		p.location = strings.TrimSpace(strings.TrimPrefix(text, fields[0]))
+		return
+	}
+
+	if p.key == "" {
+		return fmt.Errorf("Feature qualifier or location without feature key: %s", text)
+	}
+
+	nq := len(p.quals)
+	switch {
+	case nq > 0 && openQuote(p.quals[nq-1]):
+		// Continuation of a quoted qualifier value
+		p.quals[nq-1] += " " + text
+	case strings.HasPrefix(text, "/"):
+		p.quals = append(p.quals, text)
+	case nq == 0:
+		// Continuation of the location
+		p.location += text
+	default:
+		p.quals[nq-1] += " " + text
+	}
+	return
+}
+
+// Features returns all the parsed features
+func (p *FeatureTableParser) Features() (features []*align.Feature, err error) {
+	if err = p.flush(); err != nil {
+		return
+	}
+	return p.features, nil
+}
+
+// flush builds the current feature and adds it to the list of features
+func (p *FeatureTableParser) flush() (err error) {
+	var loc align.Location
+
+	if p.key == "" {
+		return nil
+	}
+	if loc, err = ParseLocation(p.location); err != nil {
+		return fmt.Errorf("Feature %s: %v", p.key, err)
+	}
+	f := align.NewFeature(p.key, loc)
+	for _, q := range p.quals {
+		key, value := parseQualifier(q)
+		f.AddQualifier(key, value)
+	}
+	p.features = append(p.features, f)
+
+	p.key = ""
+	p.location = ""
+	p.quals = p.quals[:0]
+	return
+}
+
+// openQuote returns true if the given raw qualifier has
+// a quoted value that is not closed yet
+func openQuote(qualifier string) bool {
+	return strings.Count(qualifier, "\"")%2 == 1
+}
+
+// parseQualifier parses a raw qualifier (ex: /gene="S") and returns its key
+// and its value, without quotes. Escaped quotes ("") are unescaped, and
+// spaces are removed from /translation values.
+func parseQualifier(qualifier string) (key, value string) {
+	qualifier = strings.TrimPrefix(qualifier, "/")
+	idx := strings.Index(qualifier, "=")
+	if idx < 0 {
+		return qualifier, ""
+	}
+	key, value = qualifier[:idx], qualifier[idx+1:]
+	if len(value) >= 2 && strings.HasPrefix(value, "\"") && strings.HasSuffix(value, "\"") {
+		value = strings.Replace(value[1:len(value)-1], "\"\"", "\"", -1)
+	}
+	if key == "translation" {
+		value = strings.Replace(value, " ", "", -1)
+	}
+	return
+}
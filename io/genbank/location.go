@@ -0,0 +1,137 @@
+package genbank
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// locationParser parses feature locations, such as:
+//   - 467
+//   - 340..565
+//   - <345..500, 1..>888
+//   - 123^124
+//   - complement(34..126)
+//   - join(12..78,134..202)
+//   - complement(join(2691..4571,4918..5163))
+//   - join(complement(4918..5163),complement(2691..4571))
+//   - order(...), that is considered as join(...)
+//
+// Locations referring to other entries (ex: J00194.1:100..202)
+// are not supported.
+type locationParser struct {
+	loc string
+	pos int
+}
+
+// ParseLocation parses a GenBank/EMBL feature location.
+// Output coordinates are 0-based (see align.Interval).
+func ParseLocation(location string) (l align.Location, err error) {
+	p := &locationParser{loc: strings.Join(strings.Fields(location), ""), pos: 0}
+	if l, err = p.parse(); err != nil {
+		return
+	}
+	if p.pos != len(p.loc) {
+		return nil, p.error("unexpected character")
+	}
+	return
+}
+
+func (p *locationParser) error(message string) error {
+	return fmt.Errorf("Wrong location %s: %s at position %d", p.loc, message, p.pos+1)
+}
+
+func (p *locationParser) consume(prefix string) bool {
+	if strings.HasPrefix(p.loc[p.pos:], prefix) {
+		p.pos += len(prefix)
+		return true
+	}
+	return false
+}
+
+func (p *locationParser) parse() (l align.Location, err error) {
+	if p.consume("complement(") {
+		if l, err = p.parse(); err != nil {
+			return
+		}
+		if !p.consume(")") {
+			return nil, p.error("missing ')'")
+		}
+		// The complement of a join is the join of the complements, in reverse order
+		c := make(align.Location, len(l))
+		for i, inter := range l {
+			inter.Complement = !inter.Complement
+			c[len(l)-1-i] = inter
+		}
+		return c, nil
+	}
+	if p.consume("join(") || p.consume("order(") {
+		var sub align.Location
+		l = make(align.Location, 0)
+		for {
+			if sub, err = p.parse(); err != nil {
+				return
+			}
+			l = append(l, sub...)
+			if p.consume(")") {
+				return
+			}
+			if !p.consume(",") {
+				return nil, p.error("missing ',' or ')'")
+			}
+		}
+	}
+	return p.parseInterval()
+}
+
+func (p *locationParser) parseInterval() (l align.Location, err error) {
+	var start, end int
+	var inter align.Interval
+
+	inter.PartialStart = p.consume("<")
+	if start, err = p.parseInt(); err != nil {
+		return
+	}
+	if p.consume(":") {
+		return nil, p.error("locations on other entries are not supported")
+	}
+	switch {
+	case p.consume(".."):
+		inter.PartialEnd = p.consume(">")
+		if end, err = p.parseInt(); err != nil {
+			return
+		}
+		inter.Start, inter.End = start-1, end
+	case p.consume("^"):
+		// Site between two bases: empty interval
+		if _, err = p.parseInt(); err != nil {
+			return
+		}
+		inter.Start, inter.End = start, start
+	case p.consume("."):
+		// Single base in a range (old notation): we take the whole range
+		if end, err = p.parseInt(); err != nil {
+			return
+		}
+		inter.Start, inter.End = start-1, end
+	default:
+		inter.Start, inter.End = start-1, start
+	}
+	if inter.Start < 0 || inter.Start > inter.End {
+		return nil, p.error("wrong coordinates")
+	}
+	return align.Location{inter}, nil
+}
+
+func (p *locationParser) parseInt() (v int, err error) {
+	start := p.pos
+	for p.pos < len(p.loc) && p.loc[p.pos] >= '0' && p.loc[p.pos] <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, p.error("number expected")
+	}
+	return strconv.Atoi(p.loc[start:p.pos])
+}
@@ -0,0 +1,246 @@
+package genbank
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+// Parser represents a GenBank flat file parser.
+type Parser struct {
+	r               *alignio.LineReader
+	ignoreidentical bool
+}
+
+// record is a parsed GenBank record
+type record struct {
+	locus      string
+	accession  string
+	version    string
+	definition string
+	sequence   bytes.Buffer
+	features   []*align.Feature
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: alignio.NewLineReader(r), ignoreidentical: false}
+}
+
+// If sets to true, then will ignore duplicate sequences that have the same name and the same sequence
+// Otherwise, it just renames them just as the sequences that have same name and different sequences
+func (p *Parser) IgnoreIdentical(ignore bool) {
+	p.ignoreidentical = ignore
+}
+
+// error returns a parse error located at the last read line
+func (p *Parser) error(message string) error {
+	return p.r.Errorf("GenBank: %s", message)
+}
+
+// Parse parses all the GenBank records of the input as an alignment.
+// All the sequences must have the same length.
+func (p *Parser) Parse() (al align.Alignment, err error) {
+	al = align.NewAlign(align.UNKNOWN)
+	al.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(al)
+	return
+}
+
+// ParseUnalign parses all the GenBank records of the input.
+//
+// Each record gives a sequence:
+//   - Named after its VERSION (or ACCESSION, or LOCUS name if not present)
+//   - Whose comment is its DEFINITION
+//   - Whose features are those of the FEATURES table
+//
+// Sequences are converted to upper case.
+func (p *Parser) ParseUnalign() (sb align.SeqBag, err error) {
+	sb = align.NewSeqBag(align.UNKNOWN)
+	sb.IgnoreIdentical(p.ignoreidentical)
+	err = p.parseGeneric(sb)
+	return
+}
+
+func (p *Parser) parseGeneric(sb align.SeqBag) (err error) {
+	var r *record
+
+	for {
+		if r, err = p.parseRecord(); err != nil || r == nil {
+			break
+		}
+		if r.sequence.Len() == 0 {
+			err = p.error(fmt.Sprintf("record %s does not have any sequence", r.name()))
+			break
+		}
+		if err = AddRecord(sb, r.name(), r.sequence.String(), strings.TrimSuffix(r.definition, "."), r.features); err != nil {
+			break
+		}
+	}
+	if err == nil && sb.NbSequences() == 0 {
+		err = p.error("no record in the input file")
+	}
+	if err == nil {
+		sb.AutoAlphabet()
+	}
+	return
+}
+
+// AddRecord adds the sequence and attaches its features
+// (if the sequence is not ignored, see SeqBag.IgnoreIdentical)
+func AddRecord(sb align.SeqBag, name, sequence, comment string, features []*align.Feature) (err error) {
+	nb := sb.NbSequences()
+	if err = sb.AddSequence(name, strings.ToUpper(sequence), comment); err != nil {
+		return
+	}
+	if sb.NbSequences() > nb {
+		s, _ := sb.Sequence(nb)
+		for _, f := range features {
+			s.AddFeature(f)
+		}
+	}
+	return
+}
+
+// name returns the VERSION of the record, or its ACCESSION or LOCUS name
+// if not present
+func (r *record) name() string {
+	if r.version != "" {
+		return r.version
+	}
+	if r.accession != "" {
+		return r.accession
+	}
+	return r.locus
+}
+
+// parseRecord parses the next record. Returns nil, nil if there are no more records.
+func (p *Parser) parseRecord() (r *record, err error) {
+	var line string
+	var ft *FeatureTableParser
+
+	// We skip empty lines before the record
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return
+		}
+		if strings.TrimSpace(line) != "" {
+			break
+		}
+	}
+
+	if !strings.HasPrefix(line, "LOCUS") {
+		return nil, p.error("record should start with LOCUS")
+	}
+	r = &record{features: make([]*align.Feature, 0)}
+	if fields := strings.Fields(line); len(fields) > 1 {
+		r.locus = fields[1]
+	}
+
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return nil, p.error("record should end with //")
+		} else if err != nil {
+			return
+		}
+		keyword, value := splitKeyword(line)
+		switch keyword {
+		case "//":
+			if ft != nil {
+				if r.features, err = ft.Features(); err != nil {
+					return nil, p.error(err.Error())
+				}
+			}
+			return
+		case "DEFINITION":
+			r.definition = p.continuation(value)
+		case "ACCESSION":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				r.accession = fields[0]
+			}
+		case "VERSION":
+			if fields := strings.Fields(value); len(fields) > 0 {
+				r.version = fields[0]
+			}
+		case "FEATURES":
+			ft = NewFeatureTableParser()
+			if err = p.parseFeatures(ft); err != nil {
+				return
+			}
+		case "ORIGIN":
+			if err = p.parseOrigin(&r.sequence); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// splitKeyword returns the keyword of the line (empty if the line is
+// a continuation line, starting with spaces) and the remaining of the line
+func splitKeyword(line string) (keyword, value string) {
+	if line == "" || line[0] == ' ' {
+		return "", strings.TrimSpace(line)
+	}
+	fields := strings.Fields(line)
+	return fields[0], strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+}
+
+// continuation appends the continuation lines (starting with spaces)
+// to the given value, separated by spaces.
+func (p *Parser) continuation(value string) string {
+	for {
+		line, err := p.r.ReadLine()
+		if err != nil {
+			return value
+		}
+		if line == "" || line[0] != ' ' {
+			p.r.UnreadLine()
+			return value
+		}
+		value += " " + strings.TrimSpace(line)
+	}
+}
+
+// parseFeatures parses the lines of the feature table (until the next keyword)
+func (p *Parser) parseFeatures(ft *FeatureTableParser) (err error) {
+	var line string
+	for {
+		if line, err = p.r.ReadLine(); err != nil {
+			return p.error("unexpected end of file in FEATURES")
+		}
+		if line != "" && line[0] != ' ' {
+			p.r.UnreadLine()
+			return
+		}
+		if err = ft.AddLine(line); err != nil {
+			return p.error(err.Error())
+		}
+	}
+}
+
+// parseOrigin parses the sequence lines (until the end of record //).
+// Positions and spaces are removed.
+func (p *Parser) parseOrigin(seq *bytes.Buffer) (err error) {
+	var line string
+	for {
+		if line, err = p.r.ReadLine(); err != nil {
+			return p.error("unexpected end of file in ORIGIN")
+		}
+		if strings.HasPrefix(line, "//") {
+			p.r.UnreadLine()
+			return
+		}
+		for _, c := range []byte(line) {
+			if c == ' ' || c == '\t' || (c >= '0' && c <= '9') {
+				continue
+			}
+			seq.WriteByte(c)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package genbank
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+var genbankstring1 string = `LOCUS       TEST01                    42 bp    DNA     linear   SYN 01-JAN-2020
+DEFINITION  Synthetic sequence for tests,
+            with two lines.
+ACCESSION   TEST01
+VERSION     TEST01.1
+KEYWORDS    .
+SOURCE      synthetic construct
+  ORGANISM  synthetic construct
+FEATURES             Location/Qualifiers
+     source          1..42
+                     /organism="synthetic construct"
+     gene            1..12
+                     /gene="gA"
+     CDS             join(1..6,
+                     7..12)
+                     /gene="gA"
+                     /product="protein A"
+                     /note="a note on
+                     two lines with ""quotes"""
+                     /translation="MK
+                     P"
+     CDS             complement(17..28)
+                     /gene="gB"
+                     /transl_table=2
+     CDS             <30..42
+                     /locus_tag="L3"
+                     /codon_start=2
+ORIGIN      
+        1 atgaaaccct aaggggtcat ttgggcatcc atgtttgggt aa
+//
+
+LOCUS       TEST02                    12 bp    DNA     linear   SYN 01-JAN-2020
+ACCESSION   TEST02
+ORIGIN      
+        1 acgtacgtac gt
+//
+`
+
+func TestParse(t *testing.T) {
+	sb, err := NewParser(strings.NewReader(genbankstring1)).ParseUnalign()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sb.NbSequences() != 2 {
+		t.Fatalf("There should be 2 sequences (%d)", sb.NbSequences())
+	}
+	s, ok := sb.GetSequenceByName("TEST01.1")
+	if !ok {
+		t.Fatalf("Sequence TEST01.1 not found")
+	}
+	if s.Sequence() != "ATGAAACCCTAAGGGGTCATTTGGGCATCCATGTTTGGGTAA" {
+		t.Errorf("Sequence is not as expected: %s", s.Sequence())
+	}
+	if s.Comment() != "Synthetic sequence for tests, with two lines" {
+		t.Errorf("Comment is not as expected: %s", s.Comment())
+	}
+	if _, ok = sb.GetSequenceByName("TEST02"); !ok {
+		t.Errorf("Sequence TEST02 not found")
+	}
+
+	features := s.Features()
+	if len(features) != 5 {
+		t.Fatalf("There should be 5 features (%d)", len(features))
+	}
+	cds := features[2]
+	if cds.Type != "CDS" || cds.Name() != "gA" || cds.Location.String() != "join(1..6,7..12)" {
+		t.Errorf("CDS is not as expected: %s %s %s", cds.Type, cds.Name(), cds.Location.String())
+	}
+	if v, _ := cds.Qualifier("note"); v != "a note on two lines with \"quotes\"" {
+		t.Errorf("Note is not as expected: %s", v)
+	}
+	if v, _ := cds.Qualifier("translation"); v != "MKP" {
+		t.Errorf("Translation is not as expected: %s", v)
+	}
+
+	exp := []string{"ATGAAACCCTAA", "ATGCCCAAATGA", "ATGTTTGGGTAA"}
+	for i, f := range features[2:] {
+		ex, err := f.ExtractCDS(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ex.Sequence() != exp[i] {
+			t.Errorf("CDS %d is not as expected: %s vs. %s", i, ex.Sequence(), exp[i])
+		}
+	}
+	if code, err := features[3].GeneticCode(); err != nil || code != align.GENETIC_CODE_VETEBRATE_MITO {
+		t.Errorf("Genetic code of gB should be vertebrate mitochondrial (%d, %v)", code, err)
+	}
+
+	cdsseqs, err := sb.CDS("L3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cdsseqs.NbSequences() != 1 {
+		t.Errorf("There should be 1 CDS named L3 (%d)", cdsseqs.NbSequences())
+	}
+	if _, ok = cdsseqs.GetSequenceByName("TEST01.1_L3"); !ok {
+		t.Errorf("CDS TEST01.1_L3 not found")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, s := range []string{
+		// No LOCUS
+		">s1\nACGT\n",
+		// No end of record
+		"LOCUS       TEST02\nORIGIN\n        1 acgt\n",
+		// No sequence
+		"LOCUS       TEST02\n//\n",
+		// Wrong location
+		"LOCUS       TEST02\nFEATURES             Location/Qualifiers\n     CDS             join(1..2\nORIGIN\n        1 acgt\n//\n",
+	} {
+		if _, err := NewParser(strings.NewReader(s)).ParseUnalign(); err == nil {
+			t.Errorf("There should be an error while reading record %d", i)
+		}
+	}
+}
+
+func TestParseError_Line(t *testing.T) {
+	var pe *alignio.ParseError
+
+	for _, test := range []struct {
+		input string
+		line  int
+	}{
+		// Wrong location: detected at the end of the record
+		{"LOCUS       TEST02\nFEATURES             Location/Qualifiers\n     CDS             join(1..2\nORIGIN\n        1 acgt\n//\n", 6},
+		// No end of record, after an unread line
+		{"LOCUS       TEST02\nFEATURES             Location/Qualifiers\n     CDS             1..2\nORIGIN\n        1 acgt\n", 5},
+	} {
+		_, err := NewParser(strings.NewReader(test.input)).ParseUnalign()
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+			continue
+		}
+		if pe.Line != test.line || pe.Column != 1 {
+			t.Errorf("Wrong error position: %d:%d, expected %d:1", pe.Line, pe.Column, test.line)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	tests := []struct {
+		location string
+		want     align.Location
+		wantErr  bool
+	}{
+		{"467", align.Location{{Start: 466, End: 467}}, false},
+		{"340..565", align.Location{{Start: 339, End: 565}}, false},
+		{"<345..>500", align.Location{{Start: 344, End: 500, PartialStart: true, PartialEnd: true}}, false},
+		{"123^124", align.Location{{Start: 123, End: 123}}, false},
+		{"complement(34..126)", align.Location{{Start: 33, End: 126, Complement: true}}, false},
+		{"join(12..78, 134..202)", align.Location{{Start: 11, End: 78}, {Start: 133, End: 202}}, false},
+		{"complement(join(2691..4571,4918..5163))", align.Location{{Start: 4917, End: 5163, Complement: true}, {Start: 2690, End: 4571, Complement: true}}, false},
+		{"join(complement(4918..5163),complement(2691..4571))", align.Location{{Start: 4917, End: 5163, Complement: true}, {Start: 2690, End: 4571, Complement: true}}, false},
+		{"order(1..2,5..6)", align.Location{{Start: 0, End: 2}, {Start: 4, End: 6}}, false},
+		{"J00194.1:100..202", nil, true},
+		{"join(1..2", nil, true},
+		{"10..5", nil, true},
+		{"1..2)", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLocation(tt.location)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLocation(%s) error = %v, wantErr %v", tt.location, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseLocation(%s) = %v, want %v", tt.location, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("ParseLocation(%s) = %v, want %v", tt.location, got, tt.want)
+			}
+		}
+	}
+}
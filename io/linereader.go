@@ -0,0 +1,67 @@
+package io
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// LineReader reads an input line by line, for line based formats
+// (GenBank, EMBL, A3M, MAF, FASTQ). It keeps track of the number
+// of the last read line, in order to report parse errors.
+type LineReader struct {
+	r      *bufio.Reader
+	line   string // last read line
+	nline  int    // number of the last read line
+	unread bool   // if true, next ReadLine returns the last read line
+}
+
+// NewLineReader returns a new LineReader reading from r
+func NewLineReader(r io.Reader) *LineReader {
+	return &LineReader{r: bufio.NewReader(r)}
+}
+
+// ReadLine returns the next line of the input, without the end of line
+// characters. Returns io.EOF if there are no more lines.
+func (l *LineReader) ReadLine() (line string, err error) {
+	if l.unread {
+		l.unread = false
+		l.nline++
+		return l.line, nil
+	}
+	if line, err = l.r.ReadString('\n'); err != nil {
+		if err != io.EOF || line == "" {
+			return
+		}
+		err = nil
+	}
+	l.nline++
+	l.line = strings.TrimRight(line, "\r\n")
+	return l.line, nil
+}
+
+// UnreadLine pushes the last read line back: it will be returned
+// again by the next call to ReadLine
+func (l *LineReader) UnreadLine() {
+	if !l.unread && l.nline > 0 {
+		l.unread = true
+		l.nline--
+	}
+}
+
+// Line returns the number (1-based) of the last read line,
+// 0 if no line has been read
+func (l *LineReader) Line() int {
+	return l.nline
+}
+
+// Errorf returns a parse error located at the beginning of the last
+// read line
+func (l *LineReader) Errorf(format string, a ...interface{}) error {
+	var token string
+	if !l.unread {
+		token = l.line
+	}
+	return NewParseError(l.nline, 1, token, fmt.Errorf(format, a...))
+}
@@ -0,0 +1,199 @@
+package maf
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+// Parser represents a UCSC MAF (Multiple Alignment Format) parser.
+//
+// A MAF file is made of alignment blocks, each starting with an "a" line,
+// followed by:
+//   - "s" lines: one aligned sequence, with its source coordinates
+//     (src start size strand srcSize text)
+//   - "i" lines: information about what is before/after the "s" line
+//     just above (src leftStatus leftCount rightStatus rightCount)
+//   - "e" lines: empty parts of the alignment (src start size strand srcSize status)
+//   - "q" lines: qualities of the "s" line just above
+//
+// Each block gives an alignment, in which:
+//   - Sequences are named after the "s" line sources (ex: hg38.chr1),
+//     and have their source coordinates as align.Region
+//   - "a" line variables (ex: score=12.0) are file annotations
+//   - "i" lines are sequence annotations (feature "i")
+//   - "e" lines are file annotations (feature "e")
+//
+// "q" lines, comment lines (starting with '#'), and the header are ignored.
+type Parser struct {
+	r               *alignio.LineReader
+	ignoreidentical bool
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: alignio.NewLineReader(r), ignoreidentical: false}
+}
+
+// If sets to true, then will ignore duplicate sequences that have the same name and the same sequence
+// Otherwise, it just renames them just as the sequences that have same name and different sequences
+func (p *Parser) IgnoreIdentical(ignore bool) {
+	p.ignoreidentical = ignore
+}
+
+// error returns a parse error located at the last read line
+func (p *Parser) error(message string) error {
+	return p.r.Errorf("MAF: %s", message)
+}
+
+// Parse parses the next alignment block of the MAF input.
+//
+// If there are no more blocks, returns nil,nil.
+func (p *Parser) Parse() (al align.Alignment, err error) {
+	var line string
+	var fields []string
+	var last align.Sequence // Sequence of the last "s" line (nil if ignored)
+	var lastsrc string      // Source of the last "s" line
+
+	// We skip comments and empty lines before the block
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			return nil, nil
+		} else if err != nil {
+			return
+		}
+		if text := strings.TrimSpace(line); text != "" && !strings.HasPrefix(text, "#") {
+			break
+		}
+	}
+
+	fields = strings.Fields(line)
+	if fields[0] != "a" {
+		return nil, p.error("alignment block should start with an 'a' line")
+	}
+	al = align.NewAlign(align.UNKNOWN)
+	al.IgnoreIdentical(p.ignoreidentical)
+	annotations := align.NewAnnotations()
+	for _, f := range fields[1:] {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, p.error(fmt.Sprintf("wrong 'a' line variable: %s", f))
+		}
+		annotations.AddFileAnnotation(kv[0], kv[1])
+	}
+
+	for {
+		if line, err = p.r.ReadLine(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+		if fields = strings.Fields(line); len(fields) == 0 {
+			// End of block
+			break
+		}
+		switch fields[0] {
+		case "a":
+			// Next block without empty line
+			p.r.UnreadLine()
+			return p.endBlock(al, annotations)
+		case "s":
+			if last, err = p.parseSequence(al, fields); err != nil {
+				return nil, err
+			}
+			lastsrc = fields[1]
+		case "i":
+			if len(fields) != 6 {
+				return nil, p.error("'i' line should have 6 fields")
+			}
+			if lastsrc != fields[1] {
+				return nil, p.error(fmt.Sprintf("'i' line of %s does not follow its 's' line", fields[1]))
+			}
+			if last != nil {
+				annotations.AddSequenceAnnotation(last.Name(), "i", strings.Join(fields[2:], " "))
+			}
+		case "e":
+			if len(fields) != 7 {
+				return nil, p.error("'e' line should have 7 fields")
+			}
+			annotations.AddFileAnnotation("e", strings.Join(fields[1:], " "))
+		case "q":
+			// Qualities are ignored
+		default:
+			if !strings.HasPrefix(fields[0], "#") {
+				return nil, p.error(fmt.Sprintf("unexpected line type '%s'", fields[0]))
+			}
+		}
+	}
+	return p.endBlock(al, annotations)
+}
+
+// endBlock checks the parsed block and attaches its annotations
+func (p *Parser) endBlock(al align.Alignment, annotations *align.Annotations) (align.Alignment, error) {
+	if al.NbSequences() == 0 {
+		return nil, p.error("alignment block without any 's' line")
+	}
+	al.SetAnnotations(annotations)
+	al.AutoAlphabet()
+	return al, nil
+}
+
+// parseSequence parses a "s" line and adds the sequence to the alignment.
+// Returns the added sequence (nil if ignored, see IgnoreIdentical).
+func (p *Parser) parseSequence(al align.Alignment, fields []string) (s align.Sequence, err error) {
+	var start, size, srcsize int
+	var region *align.Region
+
+	if len(fields) != 7 {
+		return nil, p.error("'s' line should have 7 fields")
+	}
+	if start, err = strconv.Atoi(fields[2]); err != nil {
+		return nil, p.error(fmt.Sprintf("wrong start: %s", fields[2]))
+	}
+	if size, err = strconv.Atoi(fields[3]); err != nil {
+		return nil, p.error(fmt.Sprintf("wrong size: %s", fields[3]))
+	}
+	if srcsize, err = strconv.Atoi(fields[5]); err != nil {
+		return nil, p.error(fmt.Sprintf("wrong source size: %s", fields[5]))
+	}
+	if len(fields[4]) != 1 {
+		return nil, p.error(fmt.Sprintf("wrong strand: %s", fields[4]))
+	}
+	if region, err = align.NewRegion(fields[1], start, size, fields[4][0], srcsize); err != nil {
+		return nil, p.error(err.Error())
+	}
+	if nb := len(fields[6]) - strings.Count(fields[6], string(align.GAP)); nb != size {
+		return nil, p.error(fmt.Sprintf("sequence %s has %d characters, but its size is %d", fields[1], nb, size))
+	}
+
+	nb := al.NbSequences()
+	if err = al.AddSequence(fields[1], fields[6], ""); err != nil {
+		return nil, p.error(err.Error())
+	}
+	if al.NbSequences() > nb {
+		s, _ = al.Sequence(nb)
+		s.SetRegion(region)
+	}
+	return
+}
+
+// ParseMultiple parses all the alignment blocks of the MAF input,
+// and sends them to the given channel as soon as they are parsed.
+//
+// At the end, Achan is closed and Err contains the parsing error, if any.
+func (p *Parser) ParseMultiple(aligns *align.AlignChannel) {
+	var al align.Alignment
+	var err error
+	al, err = p.Parse()
+	for err == nil && al != nil {
+		aligns.Achan <- al
+		al, err = p.Parse()
+	}
+	aligns.Err = err
+	close(aligns.Achan)
+}
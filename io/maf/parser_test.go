@@ -0,0 +1,150 @@
+package maf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+var mafstring1 string = `##maf version=1 scoring=tba.v8
+# tba.v8 (((human chimp) baboon) (mouse rat))
+
+a score=23262.0
+s hg18.chr7    27578828 38 + 158545518 AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG
+s panTro1.chr6 28741140 38 + 161576975 AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG
+i panTro1.chr6 C 0 C 0
+s mm4.chr6     53215344 38 + 151104725 -AATGGGAATGTTAAGCAAACGA---ATTGTCTCTCAGTGTG
+e rn3.chr4     81344243 40 + 187371129 I
+
+a score=5062.0
+s hg18.chr7    27699739 6 + 158545518 TAAAGA
+s mm4.chr6     53303881 6 + 151104725 TAAAGA
+a score=-10.0
+s mm4.chr6     53310102 5 - 151104725 TAAAG
+s hg18.chr7    27707221 5 + 158545518 TAAAG
+`
+
+func TestParse(t *testing.T) {
+	aligns := &align.AlignChannel{Achan: make(chan align.Alignment, 10)}
+	NewParser(strings.NewReader(mafstring1)).ParseMultiple(aligns)
+	if aligns.Err != nil {
+		t.Fatal(aligns.Err)
+	}
+	blocks := make([]align.Alignment, 0)
+	for al := range aligns.Achan {
+		blocks = append(blocks, al)
+	}
+	if len(blocks) != 3 {
+		t.Fatalf("There should be 3 blocks (%d)", len(blocks))
+	}
+	if blocks[0].NbSequences() != 3 || blocks[0].Length() != 42 {
+		t.Errorf("First block should have 3 sequences of length 42: %d, %d", blocks[0].NbSequences(), blocks[0].Length())
+	}
+	s, _ := blocks[0].Sequence(2)
+	r := s.Region()
+	if s.Name() != "mm4.chr6" || r == nil || r.Start != 53215344 || r.Size != 38 || r.Strand != '+' || r.SourceSize != 151104725 || r.Species() != "mm4" {
+		t.Errorf("Third sequence of first block is not as expected: %s %v", s.Name(), r)
+	}
+	s, _ = blocks[2].Sequence(0)
+	if r = s.Region(); r.Strand != '-' || r.ForwardStart() != 151104725-53310102-5 {
+		t.Errorf("Region is not as expected: %v", r)
+	}
+
+	exp := `##maf version=1
+
+a score=23262.0
+s hg18.chr7    27578828 38 + 158545518 AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG
+s panTro1.chr6 28741140 38 + 161576975 AAA-GGGAATGTTAACCAAATGA---ATTGTCTCTTACGGTG
+i panTro1.chr6 C 0 C 0
+s mm4.chr6     53215344 38 + 151104725 -AATGGGAATGTTAAGCAAACGA---ATTGTCTCTCAGTGTG
+e rn3.chr4 81344243 40 + 187371129 I
+
+`
+	if out := WriteAlignment(blocks[0]); out != exp {
+		t.Errorf("MAF output is not as expected:\n%s", out)
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, s := range []string{
+		// No a line
+		"s hg18.chr7 0 4 + 10 ACGT\n",
+		// Wrong size
+		"a\ns hg18.chr7 0 5 + 10 AC-GT\ns mm4.chr6 0 4 + 10 ACGTA\n",
+		// Wrong coordinates
+		"a\ns hg18.chr7 8 4 + 10 ACGT\n",
+		// Wrong strand
+		"a\ns hg18.chr7 0 4 . 10 ACGT\n",
+		// Different lengths
+		"a\ns hg18.chr7 0 4 + 10 ACGT\ns mm4.chr6 0 5 + 10 ACGTA\n",
+		// i line not following its s line
+		"a\ns hg18.chr7 0 4 + 10 ACGT\ni mm4.chr6 C 0 C 0\n",
+	} {
+		if _, err := NewParser(strings.NewReader(s)).Parse(); err == nil {
+			t.Errorf("There should be an error while reading block %d", i)
+		}
+	}
+}
+
+func TestParseError_Line(t *testing.T) {
+	var pe *alignio.ParseError
+
+	for _, test := range []struct {
+		input string
+		line  int
+	}{
+		// Wrong strand
+		{"a\ns hg18.chr7 0 4 . 10 ACGT\n", 2},
+	} {
+		_, err := NewParser(strings.NewReader(test.input)).Parse()
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+			continue
+		}
+		if pe.Line != test.line || pe.Column != 1 {
+			t.Errorf("Wrong error position: %d:%d, expected %d:1", pe.Line, pe.Column, test.line)
+		}
+	}
+}
+
+func TestStitch(t *testing.T) {
+	aligns := &align.AlignChannel{Achan: make(chan align.Alignment, 10)}
+	go NewParser(strings.NewReader(`##maf version=1
+a
+s hg18.chr1 10 4 + 100 AC-GT
+s mm4.chr2  20 5 + 100 ACTGT
+
+a
+s mm4.chr2  10 3 - 100 AAC
+s hg18.chr1 77 3 - 100 AAC
+s rn3.chr3   0 2 + 100 A-T
+
+a
+s rn3.chr3   0 2 + 100 AT
+`)).ParseMultiple(aligns)
+
+	al, err := Stitch(aligns, "hg18")
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp := map[string]string{
+		"hg18": "AC-GTNNNNNNGTT",
+		"mm4":  "ACTGT------GTT",
+		"rn3":  "-----------A-T",
+	}
+	if al.NbSequences() != 3 {
+		t.Fatalf("There should be 3 sequences (%d)", al.NbSequences())
+	}
+	for name, seq := range exp {
+		if s, ok := al.GetSequence(name); !ok || s != seq {
+			t.Errorf("Sequence %s is not as expected: %s vs. %s", name, s, seq)
+		}
+	}
+	ref, _ := al.Sequence(0)
+	if r := ref.Region(); ref.Name() != "hg18" || r == nil || r.Source != "hg18.chr1" || r.Start != 10 || r.Size != 13 {
+		t.Errorf("Reference region is not as expected: %s %v", ref.Name(), r)
+	}
+}
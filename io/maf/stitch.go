@@ -0,0 +1,197 @@
+package maf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// block is an alignment block reduced to what is needed for stitching
+type block struct {
+	start, end int               // Coordinates of the reference on the '+' strand
+	length     int               // Length of the block
+	rows       map[string][]byte // Sequence of each species
+	species    []string          // Species, in the order of the rows
+}
+
+// Stitch builds a single alignment from the MAF alignment blocks of the
+// channel, ordered by their coordinates on the given reference.
+//
+// The reference is either a species/assembly name (ex: hg38), or a full
+// source name (ex: hg38.chr1). Blocks that do not contain the reference are
+// ignored, and all the remaining blocks must refer to the same reference
+// source sequence, without overlap. Blocks in which the reference is on the
+// '-' strand are reverse complemented.
+//
+// Output sequences are named after the species (ex: hg38, mm10), in the
+// order of their first appearance (reference first). In each block:
+//   - Species absent from the block are filled with gaps
+//   - If a species has several rows, only the first one (or the reference
+//     row) is kept
+//
+// Parts of the reference that are not covered by any block are filled
+// with N in the reference and gaps in the other species, so that the columns
+// of the output alignment correspond to consecutive reference positions.
+// The output reference sequence has the corresponding Region.
+func Stitch(aligns *align.AlignChannel, reference string) (al align.Alignment, err error) {
+	var blocks []*block
+	var b *block
+	var refsource string
+	var refsize int
+
+	species := make([]string, 0) // Reference species first
+	seen := make(map[string]bool)
+
+	blocks = make([]*block, 0)
+	for a := range aligns.Achan {
+		if err != nil {
+			// We consume the channel until the end
+			continue
+		}
+		var ref *align.Region
+		if b, ref, err = newBlock(a, reference); err != nil || b == nil {
+			continue
+		}
+		if refsource == "" {
+			refsource, refsize = ref.Source, ref.SourceSize
+		} else if refsource != ref.Source {
+			err = fmt.Errorf("Reference %s found on several sequences (%s, %s), a full source name (ex: %s) should be given", reference, refsource, ref.Source, refsource)
+			continue
+		}
+		for _, sp := range b.species {
+			if !seen[sp] {
+				seen[sp] = true
+				species = append(species, sp)
+			}
+		}
+		blocks = append(blocks, b)
+	}
+	if err != nil {
+		return
+	}
+	if aligns.Err != nil {
+		return nil, aligns.Err
+	}
+	if len(blocks) == 0 {
+		return nil, fmt.Errorf("No alignment block contains the reference %s", reference)
+	}
+
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].start < blocks[j].start })
+
+	seqs := make([]bytes.Buffer, len(species))
+	for i, b := range blocks {
+		if i > 0 {
+			prev := blocks[i-1]
+			if b.start < prev.end {
+				return nil, fmt.Errorf("Alignment blocks %d-%d and %d-%d overlap on reference %s", prev.start, prev.end, b.start, b.end, refsource)
+			}
+			// Reference positions not covered by any block
+			for j := range species {
+				if j == 0 {
+					seqs[j].Write(bytes.Repeat([]byte{align.ALL_NUCLE}, b.start-prev.end))
+				} else {
+					seqs[j].Write(bytes.Repeat([]byte{align.GAP}, b.start-prev.end))
+				}
+			}
+		}
+		for j, sp := range species {
+			if row, ok := b.rows[sp]; ok {
+				seqs[j].Write(row)
+			} else {
+				seqs[j].Write(bytes.Repeat([]byte{align.GAP}, b.length))
+			}
+		}
+	}
+
+	al = align.NewAlign(align.UNKNOWN)
+	for j, sp := range species {
+		if err = al.AddSequence(sp, seqs[j].String(), ""); err != nil {
+			return
+		}
+	}
+	start, end := blocks[0].start, blocks[len(blocks)-1].end
+	var region *align.Region
+	if region, err = align.NewRegion(refsource, start, end-start, '+', refsize); err != nil {
+		return
+	}
+	ref, _ := al.Sequence(0)
+	ref.SetRegion(region)
+	al.AutoAlphabet()
+	return
+}
+
+// newBlock extracts the rows of the given alignment block.
+// Returns nil if the block does not contain the reference.
+func newBlock(a align.Alignment, reference string) (b *block, ref *align.Region, err error) {
+	var refseq align.Sequence
+
+	for i := 0; i < a.NbSequences() && refseq == nil; i++ {
+		s, _ := a.Sequence(i)
+		if r := s.Region(); r != nil && (r.Source == reference || r.Species() == reference) {
+			refseq, ref = s, r
+		}
+	}
+	if refseq == nil {
+		return nil, nil, nil
+	}
+
+	b = &block{length: a.Length(), rows: make(map[string][]byte), species: make([]string, 0)}
+	for i := -1; i < a.NbSequences(); i++ {
+		// The reference first
+		s := refseq
+		if i >= 0 {
+			s, _ = a.Sequence(i)
+		}
+		sp := speciesName(s)
+		if _, ok := b.rows[sp]; ok {
+			continue
+		}
+		row := append([]byte(nil), s.SequenceBytes()...)
+		if ref.Strand == '-' {
+			if err = reverseComplement(row); err != nil {
+				return nil, nil, fmt.Errorf("Reference %s is on the '-' strand: %v", ref.Source, err)
+			}
+		}
+		b.rows[sp] = row
+		b.species = append(b.species, sp)
+	}
+	if ref.Strand == '-' {
+		ref = ref.ReverseStrand()
+	}
+	b.start, b.end = ref.Start, ref.Start+ref.Size
+	return
+}
+
+// speciesName returns the species of the sequence (see align.Region.Species),
+// or its name if it does not have any Region
+func speciesName(s align.Sequence) string {
+	if r := s.Region(); r != nil {
+		return r.Species()
+	}
+	return s.Name()
+}
+
+// reverseComplement reverse complements the given nucleotide sequence.
+// Gaps are kept, and lower case (soft-masked) characters stay lower case.
+func reverseComplement(seq []byte) (err error) {
+	for i, j := 0, len(seq)-1; i < j; i, j = i+1, j-1 {
+		seq[i], seq[j] = seq[j], seq[i]
+	}
+	for i, c := range seq {
+		if c == align.GAP {
+			continue
+		}
+		r := []rune{unicode.ToUpper(rune(c))}
+		if err = align.Complement(r); err != nil {
+			return
+		}
+		if unicode.IsLower(rune(c)) {
+			r[0] = unicode.ToLower(r[0])
+		}
+		seq[i] = byte(r[0])
+	}
+	return
+}
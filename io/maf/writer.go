@@ -0,0 +1,89 @@
+package maf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+const MAF_HEADER = "##maf version=1"
+
+// WriteAlignment returns the alignment as a MAF file: the MAF header
+// followed by one alignment block (see WriteBlock).
+func WriteAlignment(al align.Alignment) string {
+	return MAF_HEADER + "\n\n" + WriteBlock(al)
+}
+
+// WriteBlock returns the alignment as a MAF alignment block
+// (without the MAF header), followed by an empty line.
+//
+// Sequences having a Region (MAF inputs) are written with their source
+// coordinates. Other sequences are considered as entire source sequences, on
+// the '+' strand, and are named after their name (spaces replaced by '_').
+//
+// "score" and "pass" file annotations are written in the "a" line,
+// "i" sequence annotations and "e" file annotations as "i" and "e" lines.
+func WriteBlock(al align.Alignment) string {
+	var buf bytes.Buffer
+	var regions []*align.Region
+	var srcw, startw, sizew, srcsizew int
+
+	buf.WriteString("a")
+	infos := make(map[string]string)
+	empty := make([]string, 0)
+	if an := al.Annotations(); an != nil {
+		an.IterateFile(func(feature, text string) bool {
+			if feature == "score" || feature == "pass" {
+				buf.WriteString(fmt.Sprintf(" %s=%s", feature, text))
+			} else if feature == "e" {
+				empty = append(empty, text)
+			}
+			return false
+		})
+		an.IterateSequences(func(name, feature, text string) bool {
+			if feature == "i" {
+				infos[name] = text
+			}
+			return false
+		})
+	}
+	buf.WriteString("\n")
+
+	// Coordinates of each sequence, and width of the columns
+	regions = make([]*align.Region, 0, al.NbSequences())
+	for i := 0; i < al.NbSequences(); i++ {
+		s, _ := al.Sequence(i)
+		r := s.Region()
+		if r == nil {
+			size := s.Length() - s.NumGaps()
+			r = &align.Region{Source: strings.Join(strings.Fields(s.Name()), "_"), Start: 0, Size: size, Strand: '+', SourceSize: size}
+		}
+		regions = append(regions, r)
+		srcw = max_int(srcw, len(r.Source))
+		startw = max_int(startw, len(fmt.Sprint(r.Start)))
+		sizew = max_int(sizew, len(fmt.Sprint(r.Size)))
+		srcsizew = max_int(srcsizew, len(fmt.Sprint(r.SourceSize)))
+	}
+
+	for i, r := range regions {
+		s, _ := al.Sequence(i)
+		buf.WriteString(fmt.Sprintf("s %-*s %*d %*d %c %*d %s\n", srcw, r.Source, startw, r.Start, sizew, r.Size, r.Strand, srcsizew, r.SourceSize, s.SequenceBytes()))
+		if info, ok := infos[s.Name()]; ok {
+			buf.WriteString(fmt.Sprintf("i %-*s %s\n", srcw, r.Source, info))
+		}
+	}
+	for _, e := range empty {
+		buf.WriteString("e " + e + "\n")
+	}
+	buf.WriteString("\n")
+	return buf.String()
+}
+
+func max_int(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -12,18 +12,21 @@ import (
 
 // Scanner represents a lexical scanner.
 type Scanner struct {
-	r *bufio.Reader
+	r         *bufio.Reader
+	pos       *aio.Position
+	line, col int // Position of the last scanned token
 }
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{r: bufio.NewReader(r), pos: aio.NewPosition()}
 }
 
 // read reads the next rune from the bufferred reader.
 // Returns the rune(0) if an error occurs (or io.EOF is returned).
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
+	s.pos.Read(ch, err != nil)
 	if err != nil {
 		return eof
 	}
@@ -33,12 +36,19 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
 	_ = s.r.UnreadRune()
+	s.pos.Unread()
+}
+
+// Pos returns the line and column (1-based) of the last scanned token.
+func (s *Scanner) Pos() (line, col int) {
+	return s.line, s.col
 }
 
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan() (tok Token, lit string) {
 	// Read the next rune.
 	ch := s.read()
+	s.line, s.col = s.pos.Last()
 
 	// If we see whitespace then consume all contiguous whitespace.
 	// If we see a letter then consume as an ident or reserved word.
@@ -71,6 +81,8 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 		return ENDOFCOMMAND, string(ch)
 	case '=':
 		return EQUAL, string(ch)
+	case '\'', '"':
+		return s.scanQuoted(ch)
 	}
 
 	s.unread()
@@ -99,6 +111,46 @@ func (s *Scanner) scanWhitespace() (tok Token, lit string) {
 	return WS, buf.String()
 }
 
+// scanQuoted consumes a quoted word, the opening quote being already read,
+// and returns it as an IDENT without the quotes (a doubled quote inside the
+// word stands for the quote itself)
+func (s *Scanner) scanQuoted(quote rune) (tok Token, lit string) {
+	var buf bytes.Buffer
+	for {
+		ch := s.read()
+		if ch == eof {
+			return ILLEGAL, buf.String()
+		}
+		if ch == quote {
+			if ch2 := s.read(); ch2 != quote {
+				if ch2 != eof {
+					s.unread()
+				}
+				return IDENT, buf.String()
+			}
+		}
+		buf.WriteRune(ch)
+	}
+}
+
+// skipComment consumes a comment, the opening '[' being already read,
+// up to its matching ']' (comments may be nested).
+// Returns false if the end of the input is reached before.
+func (s *Scanner) skipComment() bool {
+	depth := 1
+	for depth > 0 {
+		switch s.read() {
+		case eof:
+			return false
+		case '[':
+			depth++
+		case ']':
+			depth--
+		}
+	}
+	return true
+}
+
 // scanIdent consumes the current rune and all contiguous ident runes.
 func (s *Scanner) scanIdent() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
@@ -149,6 +201,12 @@ func (s *Scanner) scanIdent() (tok Token, lit string) {
 			return MISSING, buf.String()
 		case "MATCHCHAR":
 			return MATCHCHAR, buf.String()
+		case "INTERLEAVE":
+			return INTERLEAVE, buf.String()
+		case "EQUATE":
+			return EQUATE, buf.String()
+		case "RESPECTCASE":
+			return RESPECTCASE, buf.String()
 		case "GAP":
 			return GAP, buf.String()
 		case "MATRIX":
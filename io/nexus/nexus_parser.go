@@ -1,10 +1,12 @@
 package nexus
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/evolbioinfo/goalign/align"
 	aio "github.com/evolbioinfo/goalign/io"
@@ -14,10 +16,20 @@ import (
 type Parser struct {
 	s               *Scanner
 	ignoreidentical bool
+	started         bool            // #NEXUS header has been read
+	taxlabels       map[string]bool // Taxa of the last TAXA block (nil if none)
+	taxantax        int64           // Number of taxa of the last TAXA block
+	nblocks         int             // Number of DATA blocks already parsed
+	pendingdata     bool            // BEGIN DATA; of the next DATA block has already been read
+	dataline        int             // Line of the BEGIN token of the current DATA block
+	datacol         int             // Column of the BEGIN token of the current DATA block
+	taxlabelspos    tokenPos        // Position of the TAXLABELS token of the last TAXA block
 	buf             struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
+		tok  Token  // last read token
+		lit  string // last read literal
+		line int    // line of the last read token
+		col  int    // column of the last read token
+		n    int    // buffer size (max=1)
 	}
 }
 
@@ -43,6 +55,7 @@ func (p *Parser) scan() (tok Token, lit string) {
 
 	// Otherwise read the next token from the scanner.
 	tok, lit = p.s.Scan()
+	p.buf.line, p.buf.col = p.s.Pos()
 
 	// Save it to the buffer in case we unscan later.
 	p.buf.tok, p.buf.lit = tok, lit
@@ -53,6 +66,21 @@ func (p *Parser) scan() (tok Token, lit string) {
 // unscan pushes the previously read token back onto the buffer.
 func (p *Parser) unscan() { p.buf.n = 1 }
 
+// errorf returns a parse error located at the last read token
+func (p *Parser) errorf(format string, a ...interface{}) error {
+	return aio.NewParseError(p.buf.line, p.buf.col, p.buf.lit, fmt.Errorf(format, a...))
+}
+
+// located returns the given error located at the last read token,
+// if it is not already a parse error
+func (p *Parser) located(err error) error {
+	var pe *aio.ParseError
+	if err == nil || errors.As(err, &pe) {
+		return err
+	}
+	return aio.NewParseError(p.buf.line, p.buf.col, p.buf.lit, err)
+}
+
 // scanIgnoreWhitespace scans the next non-whitespace token.
 func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string) {
 	tok, lit = p.scan()
@@ -62,29 +90,65 @@ func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string) {
 	return
 }
 
-// Parses Nexus content from the reader
+// Parse parses the next DATA (or CHARACTERS) block of the Nexus input,
+// and returns it as an alignment.
+//
+// TAXA blocks are used to check the names of the following DATA blocks.
+// CHARSET and CHARPARTITION commands of the SETS, ASSUMPTIONS and MRBAYES
+// blocks following a DATA block are attached to its alignment as a
+// PartitionSet (see Alignment.Partitions()). Other blocks are skipped.
+// If there are no more DATA blocks, returns nil,nil (or an error if the
+// input does not contain any DATA block).
 func (p *Parser) Parse() (al align.Alignment, err error) {
-	var nchar, ntax, taxantax int64
-	datatype := "dna"
-	missing := '*'
-	matchchar := '.'
-	gap := '-'
-	var taxlabels map[string]bool = nil
-	var names []string
-	var sequences map[string]string
-
-	// First token should be a "NEXUS" token.
-	tok, lit := p.scanIgnoreWhitespace()
-	if tok != NEXUS {
-		err = fmt.Errorf("found %q, expected #NEXUS", lit)
-		return
+	var d *dataBlock
+	var sets *charSets
+
+	if !p.started {
+		// First token should be a "NEXUS" token,
+		// after possible empty lines and comments
+		tok, lit := p.scanIgnoreWhitespace()
+		for tok == ENDOFLINE || tok == OPENBRACK {
+			if tok == OPENBRACK {
+				if _, _, err = p.consumeComment(tok, lit); err != nil {
+					return
+				}
+			}
+			tok, lit = p.scanIgnoreWhitespace()
+		}
+		if tok != NEXUS {
+			err = p.errorf("found %q, expected #NEXUS", lit)
+			return
+		}
+		p.started = true
 	}
 
 	// Now we can parse the remaining of the file
 	for {
+		if p.pendingdata {
+			p.pendingdata = false
+			if d, err = p.parseData(); err != nil {
+				return
+			}
+			p.nblocks++
+			if p.taxlabels != nil {
+				d.cmdpos[TAXLABELS] = p.taxlabelspos
+			}
+			if al, err = d.alignment(p.ignoreidentical, p.taxlabels); err != nil {
+				// Located at the offending sequence, or
+				// at the beginning of the DATA block
+				var pe *aio.ParseError
+				if !errors.As(err, &pe) {
+					err = aio.NewParseError(p.dataline, p.datacol, "BEGIN", err)
+				}
+				return
+			}
+			sets = newCharSets(al)
+			continue
+		}
+
 		tok, lit := p.scanIgnoreWhitespace()
 		if tok == ILLEGAL {
-			err = fmt.Errorf("found illegal token %q", lit)
+			err = p.errorf("found illegal token %q", lit)
 			return
 		}
 		if tok == EOF {
@@ -103,26 +167,38 @@ func (p *Parser) Parse() (al align.Alignment, err error) {
 
 		// Beginning of a block
 		if tok == BEGIN {
+			line, col := p.buf.line, p.buf.col
 			// Next token should be the name of the block
 			tok2, lit2 := p.scanIgnoreWhitespace()
 			// Then a ;
 			tok3, lit3 := p.scanIgnoreWhitespace()
 			if tok3 != ENDOFCOMMAND {
-				err = fmt.Errorf("found %q, expected ;", lit3)
+				err = p.errorf("found %q, expected ;", lit3)
 				return
 			}
 			switch tok2 {
 			case TAXA:
 				// TAXA BLOCK
-				taxantax, taxlabels, err = p.parseTaxa()
+				if p.taxantax, p.taxlabels, err = p.parseTaxa(); err == nil && int(p.taxantax) != -1 && int(p.taxantax) != len(p.taxlabels) {
+					err = p.errorf("Number of defined taxa in TAXLABELS/DIMENSIONS (%d) is different from length of taxa list (%d)", p.taxantax, len(p.taxlabels))
+				}
 			case TREES:
 				// If an unsupported block is seen, we just skip it
 				aio.PrintMessage("TREE block is not supported in goalign nexus parser, see gotree nexus parser, skipping")
 				err = p.parseUnsupportedBlock()
 			case DATA:
-				// DATA/CHARACTERS BLOCK
-				names, sequences, nchar, ntax, datatype, missing, gap, matchchar, err = p.parseData()
+				// DATA/CHARACTERS BLOCK: parsed at next iteration.
+				// If we already have an alignment, we return it first
+				p.pendingdata = true
+				p.dataline, p.datacol = line, col
+				if al != nil {
+					return attachSets(al, sets)
+				}
 			default:
+				if al != nil && isSetsBlock(lit2) {
+					err = p.parseSets(sets, lit2)
+					break
+				}
 				// If an unsupported block is seen, we just skip it
 				aio.PrintMessage(fmt.Sprintf("Unsupported block %q, skipping", lit2))
 				err = p.parseUnsupportedBlock()
@@ -134,67 +210,147 @@ func (p *Parser) Parse() (al align.Alignment, err error) {
 		}
 	}
 
-	if int(taxantax) != -1 && int(taxantax) != len(taxlabels) {
-		err = fmt.Errorf("Number of defined taxa in TAXLABELS/DIMENSIONS (%d) is different from length of taxa list (%d)", taxantax, len(taxlabels))
-		return
+	if al != nil {
+		return attachSets(al, sets)
+	}
+	if p.nblocks == 0 {
+		err = p.errorf("No sequence in this Nexus file")
 	}
+	return
+}
 
-	// if gap != '-' || missing != '*' {
-	// 	err = fmt.Errorf("We only accept - gaps (have %c) && * missing (have %c) so far", gap, missing)
-	// 	return
-	// }
+// attachSets attaches the partitions defined in the sets
+// blocks following the DATA block of the alignment
+func attachSets(al align.Alignment, sets *charSets) (align.Alignment, error) {
+	ps, err := sets.partitionSet()
+	if err != nil {
+		return nil, err
+	}
+	al.SetPartitions(ps)
+	return al, nil
+}
 
-	if sequences == nil || len(sequences) == 0 {
-		err = fmt.Errorf("No sequence in this Nexus file")
+// ParseMultiple parses all the DATA (or CHARACTERS) blocks of the Nexus
+// input, and sends the corresponding alignments to the given channel.
+//
+// At the end, Achan is closed and Err contains the parsing error, if any.
+func (p *Parser) ParseMultiple(aligns *align.AlignChannel) {
+	var al align.Alignment
+	var err error
+	al, err = p.Parse()
+	for err == nil && al != nil {
+		aligns.Achan <- al
+		al, err = p.Parse()
+	}
+	aligns.Err = err
+	close(aligns.Achan)
+}
+
+// alignment builds the alignment corresponding to the parsed DATA block.
+//
+// Each character of the matrix is translated in a single pass:
+// EQUATE symbols are first replaced by their definition, then GAP
+// characters are replaced by '-', MISSING characters by '*', and
+// MATCHCHAR characters by the character of the first sequence.
+// Unless RESPECTCASE is given, symbols are case insensitive.
+func (d *dataBlock) alignment(ignoreidentical bool, taxlabels map[string]bool) (al align.Alignment, err error) {
+	if len(d.names) == 0 {
+		err = d.commandError(MATRIX, fmt.Errorf("No sequence in this Nexus file"))
 		return
 	}
 
 	// We initialize alignment structure using goalign structure
-	if names != nil && sequences != nil {
-		al = align.NewAlign(align.AlphabetFromString(datatype))
-		al.IgnoreIdentical(p.ignoreidentical)
-		if al.Alphabet() == align.UNKNOWN {
-			err = fmt.Errorf("Unknown datatype: %q", datatype)
-			return
-		}
-		if len(names) != int(ntax) && ntax != -1 {
-			err = fmt.Errorf("Number of taxa in alignment (%d)  does not correspond to definition %d", len(names), ntax)
+	al = align.NewAlign(align.AlphabetFromString(d.datatype))
+	al.IgnoreIdentical(ignoreidentical)
+	if al.Alphabet() == align.UNKNOWN {
+		err = d.commandError(DATATYPE, fmt.Errorf("Unknown datatype: %q", d.datatype))
+		return
+	}
+	if len(d.names) != int(d.ntax) && d.ntax != -1 {
+		err = d.commandError(DIMENSIONS, fmt.Errorf("Number of taxa in alignment (%d)  does not correspond to definition %d", len(d.names), d.ntax))
+		return
+	}
+	var first []rune
+	for i, name := range d.names {
+		seq := []rune(d.sequences[name])
+		if len(seq) != int(d.nchar) && d.nchar != -1 {
+			err = d.sequenceError(name, fmt.Errorf("Number of character in sequence #%d (%d) does not correspond to definition %d", i, len(seq), d.nchar))
 			return
 		}
-		for i, name := range names {
-			seq, _ := sequences[name]
-			if len(seq) != int(nchar) && nchar != -1 {
-				err = fmt.Errorf("Number of character in sequence #%d (%d) does not correspond to definition %d", i, len(seq), nchar)
-				return
-			}
-			seq = strings.Replace(seq, string(gap), string(align.GAP), -1)
-			seq = strings.Replace(seq, string(missing), string(align.OTHER), -1)
-			seq = strings.Replace(seq, string(matchchar), string(align.POINT), -1)
-			if err = al.AddSequence(name, seq, ""); err != nil {
-				return
+		for j, c := range seq {
+			if e, ok := d.equate[d.symbol(c)]; ok {
+				c = e
 			}
-		}
-		// We check that tax labels are the same as alignment sequence names
-		if taxlabels != nil {
-			al.Iterate(func(name string, sequence string) bool {
-				if _, ok := taxlabels[name]; !ok {
-					err = fmt.Errorf("Sequence name %s in the alignment is not defined in the TAXLABELS block", name)
+			switch d.symbol(c) {
+			case d.symbol(d.gap):
+				c = align.GAP
+			case d.symbol(d.missing):
+				c = align.OTHER
+			case d.symbol(d.matchchar):
+				if i > 0 && j < len(first) {
+					c = first[j]
+				} else {
+					c = align.POINT
 				}
-				return false
-			})
-			if err != nil {
-				return nil, err
 			}
-			if al.NbSequences() != len(taxlabels) {
-				err = fmt.Errorf("Some taxa names defined in TAXLABELS are not present in the alignment")
-				return
+			seq[j] = c
+		}
+		if i == 0 {
+			first = seq
+		}
+		if err = al.AddSequenceChar(name, seq, ""); err != nil {
+			err = d.sequenceError(name, err)
+			return
+		}
+	}
+	// We check that tax labels are the same as alignment sequence names
+	if taxlabels != nil {
+		al.Iterate(func(name string, sequence string) bool {
+			if _, ok := taxlabels[name]; !ok {
+				err = d.sequenceError(name, fmt.Errorf("Sequence name %s in the alignment is not defined in the TAXLABELS block", name))
 			}
+			return false
+		})
+		if err != nil {
+			return nil, err
+		}
+		if al.NbSequences() != len(taxlabels) {
+			err = d.commandError(TAXLABELS, fmt.Errorf("Some taxa names defined in TAXLABELS are not present in the alignment"))
+			return
 		}
-		al.ReplaceMatchChars()
 	}
 	return
 }
 
+// sequenceError returns the given error located at the first occurrence
+// of the name of the given sequence in the matrix
+func (d *dataBlock) sequenceError(name string, err error) error {
+	pos, ok := d.namepos[name]
+	if !ok {
+		return err
+	}
+	return aio.NewParseError(pos[0], pos[1], name, err)
+}
+
+// commandError returns the given error located at the given command
+// (or value) token, if it has been seen
+func (d *dataBlock) commandError(tok Token, err error) error {
+	pos, ok := d.cmdpos[tok]
+	if !ok {
+		return err
+	}
+	return aio.NewParseError(pos.line, pos.col, pos.lit, err)
+}
+
+// symbol returns the character as compared to the symbols of the block:
+// as is if RESPECTCASE is given, in upper case otherwise
+func (d *dataBlock) symbol(c rune) rune {
+	if d.respectcase {
+		return c
+	}
+	return unicode.ToUpper(c)
+}
+
 // Parse taxa block
 func (p *Parser) parseTaxa() (int64, map[string]bool, error) {
 	taxlabels := make(map[string]bool)
@@ -207,15 +363,15 @@ func (p *Parser) parseTaxa() (int64, map[string]bool, error) {
 		case ENDOFLINE:
 			continue
 		case ILLEGAL:
-			err = fmt.Errorf("found illegal token %q", lit)
+			err = p.errorf("found illegal token %q", lit)
 			stoptaxa = true
 		case EOF:
-			err = fmt.Errorf("End of file within a TAXA block (no END;)")
+			err = p.errorf("End of file within a TAXA block (no END;)")
 			stoptaxa = true
 		case END:
 			tok2, _ := p.scanIgnoreWhitespace()
 			if tok2 != ENDOFCOMMAND {
-				err = fmt.Errorf("End token without ;")
+				err = p.errorf("End token without ;")
 			}
 			stoptaxa = true
 		case DIMENSIONS:
@@ -229,12 +385,12 @@ func (p *Parser) parseTaxa() (int64, map[string]bool, error) {
 				case NTAX:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after NTAX, got %q", lit3)
+						err = p.errorf("Expecting '=' after NTAX, got %q", lit3)
 						stopdimensions = true
 					}
 					tok4, lit4 := p.scanIgnoreWhitespace()
 					if tok4 != NUMERIC {
-						err = fmt.Errorf("Expecting Integer value after 'NTAX=', got %q", lit4)
+						err = p.errorf("Expecting Integer value after 'NTAX=', got %q", lit4)
 						stopdimensions = true
 					}
 					ntax, err = strconv.ParseInt(lit4, 10, 64)
@@ -252,6 +408,7 @@ func (p *Parser) parseTaxa() (int64, map[string]bool, error) {
 				}
 			}
 		case TAXLABELS:
+			p.taxlabelspos = tokenPos{p.buf.line, p.buf.col, lit}
 			stoplabels := false
 			for !stoplabels {
 				tok2, lit2 := p.scanIgnoreWhitespace()
@@ -263,7 +420,7 @@ func (p *Parser) parseTaxa() (int64, map[string]bool, error) {
 				case ENDOFLINE:
 					continue
 				default:
-					err = fmt.Errorf("Unknown token %q (%v) in taxlabel list", lit2, tok2)
+					err = p.errorf("Unknown token %q (%v) in taxlabel list", lit2, tok2)
 					stoplabels = true
 				}
 			}
@@ -286,36 +443,65 @@ func (p *Parser) parseTaxa() (int64, map[string]bool, error) {
 	return ntax, taxlabels, err
 }
 
+// dataBlock is the content of a DATA (or CHARACTERS) block
+// tokenPos is the position (line and column) and the literal of a token
+type tokenPos struct {
+	line, col int
+	lit       string
+}
+
+type dataBlock struct {
+	names       []string           // Sequence names, in the order of the matrix
+	namepos     map[string][2]int  // Line and column of the first occurrence of each name in the matrix
+	cmdpos      map[Token]tokenPos // Position of the commands (DIMENSIONS, MATRIX) and values (DATATYPE, EQUATE)
+	sequences   map[string]string  // Sequences, by name
+	nchar, ntax int64              // Dimensions (-1 if not given)
+	datatype    string
+	missing     rune
+	gap         rune
+	matchchar   rune
+	interleave  bool          // Interleaved matrix
+	respectcase bool          // Case sensitive symbols
+	equate      map[rune]rune // EQUATE symbols (see symbol)
+}
+
 // DATA / Characters BLOCK
-func (p *Parser) parseData() (names []string, sequences map[string]string, nchar, ntax int64, datatype string, missing, gap, matchchar rune, err error) {
-	datatype = "dna"
-	missing = '*'
-	matchchar = '.'
-	gap = '-'
+func (p *Parser) parseData() (d *dataBlock, err error) {
+	var equate string
+	d = &dataBlock{
+		names:     make([]string, 0),
+		namepos:   make(map[string][2]int),
+		cmdpos:    make(map[Token]tokenPos),
+		sequences: make(map[string]string),
+		nchar:     -1,
+		ntax:      -1,
+		datatype:  "dna",
+		missing:   '*',
+		gap:       '-',
+		matchchar: '.',
+		equate:    make(map[rune]rune),
+	}
 	stopdata := false
-	sequences = make(map[string]string)
-	names = make([]string, 0)
-	nchar = -1
-	ntax = -1
 	for !stopdata {
 		tok, lit := p.scanIgnoreWhitespace()
 		switch tok {
 		case ENDOFLINE:
 			break
 		case ILLEGAL:
-			err = fmt.Errorf("found illegal token %q", lit)
+			err = p.errorf("found illegal token %q", lit)
 			stopdata = true
 		case EOF:
-			err = fmt.Errorf("End of file within a TAXA block (no END;)")
+			err = p.errorf("End of file within a TAXA block (no END;)")
 			stopdata = true
 		case END:
 			tok2, _ := p.scanIgnoreWhitespace()
 			if tok2 != ENDOFCOMMAND {
-				err = fmt.Errorf("End token without ;")
+				err = p.errorf("End token without ;")
 			}
 			stopdata = true
 		case DIMENSIONS:
 			// Dimensions of the data: nchar , ntax
+			d.cmdpos[DIMENSIONS] = tokenPos{p.buf.line, p.buf.col, lit}
 			stopdimensions := false
 			for !stopdimensions {
 				tok2, lit2 := p.scanIgnoreWhitespace()
@@ -325,30 +511,30 @@ func (p *Parser) parseData() (names []string, sequences map[string]string, nchar
 				case NTAX:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after NTAX, got %q", lit3)
+						err = p.errorf("Expecting '=' after NTAX, got %q", lit3)
 						stopdimensions = true
 					}
 					tok4, lit4 := p.scanIgnoreWhitespace()
 					if tok4 != NUMERIC {
-						err = fmt.Errorf("Expecting Integer value after 'NTAX=', got %q", lit4)
+						err = p.errorf("Expecting Integer value after 'NTAX=', got %q", lit4)
 						stopdimensions = true
 					}
-					ntax, err = strconv.ParseInt(lit4, 10, 64)
+					d.ntax, err = strconv.ParseInt(lit4, 10, 64)
 					if err != nil {
 						stopdimensions = true
 					}
 				case NCHAR:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after NTAX, got %q", lit3)
+						err = p.errorf("Expecting '=' after NTAX, got %q", lit3)
 						stopdimensions = true
 					}
 					tok4, lit4 := p.scanIgnoreWhitespace()
 					if tok4 != NUMERIC {
-						err = fmt.Errorf("Expecting Integer value after 'NTAX=', got %q", lit4)
+						err = p.errorf("Expecting Integer value after 'NTAX=', got %q", lit4)
 						stopdimensions = true
 					}
-					nchar, err = strconv.ParseInt(lit4, 10, 64)
+					d.nchar, err = strconv.ParseInt(lit4, 10, 64)
 					if err != nil {
 						stopdimensions = true
 					}
@@ -363,7 +549,7 @@ func (p *Parser) parseData() (names []string, sequences map[string]string, nchar
 				}
 			}
 		case FORMAT:
-			// Format of the data bock: datatype, missing, gap
+			// Format of the data bock: datatype, missing, gap, matchchar, interleave, equate, respectcase
 			stopformat := false
 			for !stopformat {
 				tok2, lit2 := p.scanIgnoreWhitespace()
@@ -374,129 +560,132 @@ func (p *Parser) parseData() (names []string, sequences map[string]string, nchar
 				case DATATYPE:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after DATATYPE, got %q", lit3)
+						err = p.errorf("Expecting '=' after DATATYPE, got %q", lit3)
 						stopformat = true
 					} else {
 						tok4, lit4 := p.scanIgnoreWhitespace()
 						if tok4 == IDENT {
-							datatype = lit4
+							d.datatype = lit4
+							d.cmdpos[DATATYPE] = tokenPos{p.buf.line, p.buf.col, lit4}
 						} else {
-							err = fmt.Errorf("Expecting identifier after 'DATATYPE=', got %q", lit4)
+							err = p.errorf("Expecting identifier after 'DATATYPE=', got %q", lit4)
 							stopformat = true
 						}
 					}
 				case MISSING:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after MISSING, got %q", lit3)
+						err = p.errorf("Expecting '=' after MISSING, got %q", lit3)
 						stopformat = true
 					} else {
 						tok4, lit4 := p.scanIgnoreWhitespace()
 						if tok4 != IDENT {
-							err = fmt.Errorf("Expecting Integer value after 'MISSING=', got %q", lit4)
+							err = p.errorf("Expecting Integer value after 'MISSING=', got %q", lit4)
 							stopformat = true
 						} else {
 							if len(lit4) != 1 {
-								err = fmt.Errorf("Expecting a single character after MISSING=', got %q", lit4)
+								err = p.errorf("Expecting a single character after MISSING=', got %q", lit4)
 								stopformat = true
 							} else {
-								missing = []rune(lit4)[0]
+								d.missing = []rune(lit4)[0]
 							}
 						}
 					}
 				case GAP:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after GAP, got %q", lit3)
+						err = p.errorf("Expecting '=' after GAP, got %q", lit3)
 						stopformat = true
 					} else {
 						tok4, lit4 := p.scanIgnoreWhitespace()
 						if tok4 != IDENT {
-							err = fmt.Errorf("Expecting an identifier after 'GAP=', got %q", lit4)
+							err = p.errorf("Expecting an identifier after 'GAP=', got %q", lit4)
 							stopformat = true
 						} else {
 							if len(lit4) != 1 {
-								err = fmt.Errorf("Expecting a single character after GAP=', got %q", lit4)
+								err = p.errorf("Expecting a single character after GAP=', got %q", lit4)
 								stopformat = true
 							} else {
-								gap = []rune(lit4)[0]
+								d.gap = []rune(lit4)[0]
 							}
 						}
 					}
 				case MATCHCHAR:
 					tok3, lit3 := p.scanIgnoreWhitespace()
 					if tok3 != EQUAL {
-						err = fmt.Errorf("Expecting '=' after MATCHCHAR, got %q", lit3)
+						err = p.errorf("Expecting '=' after MATCHCHAR, got %q", lit3)
 						stopformat = true
 					} else {
 						tok4, lit4 := p.scanIgnoreWhitespace()
 						if tok4 != IDENT {
-							err = fmt.Errorf("Expecting character value after 'MATCHCHAR=', got %q", lit4)
+							err = p.errorf("Expecting character value after 'MATCHCHAR=', got %q", lit4)
 							stopformat = true
 						} else {
 							if len(lit4) != 1 {
-								err = fmt.Errorf("Expecting a single character after MATCHCHAR=', got %q", lit4)
+								err = p.errorf("Expecting a single character after MATCHCHAR=', got %q", lit4)
 								stopformat = true
 							} else {
-								matchchar = []rune(lit4)[0]
+								d.matchchar = []rune(lit4)[0]
 							}
 						}
 					}
+				case INTERLEAVE:
+					// interleave, or interleave=yes|no
+					d.interleave = true
+					if tok3, _ := p.scanIgnoreWhitespace(); tok3 != EQUAL {
+						p.unscan()
+					} else {
+						_, lit4 := p.scanIgnoreWhitespace()
+						switch strings.ToLower(lit4) {
+						case "yes":
+						case "no":
+							d.interleave = false
+						default:
+							err = p.errorf("Expecting yes or no after 'INTERLEAVE=', got %q", lit4)
+							stopformat = true
+						}
+					}
+				case RESPECTCASE:
+					d.respectcase = true
+				case EQUATE:
+					tok3, lit3 := p.scanIgnoreWhitespace()
+					if tok3 != EQUAL {
+						err = p.errorf("Expecting '=' after EQUATE, got %q", lit3)
+						stopformat = true
+					} else if tok4, lit4 := p.scanIgnoreWhitespace(); tok4 != IDENT {
+						err = p.errorf("Expecting a quoted list of symbols after 'EQUATE=', got %q", lit4)
+						stopformat = true
+					} else {
+						equate = equate + " " + lit4
+						if _, ok := d.cmdpos[EQUATE]; !ok {
+							d.cmdpos[EQUATE] = tokenPos{p.buf.line, p.buf.col, lit4}
+						}
+					}
 				default:
-					if err = p.parseUnsupportedKey(lit2); err != nil {
+					if strings.EqualFold(lit2, "transpose") {
+						err = p.errorf("Transposed matrices are not supported")
 						stopformat = true
+					} else {
+						if err = p.parseUnsupportedKey(lit2); err != nil {
+							stopformat = true
+						}
+						aio.PrintMessage(fmt.Sprintf("Unsupported key %q in %q command, skipping", lit2, lit))
 					}
-					aio.PrintMessage(fmt.Sprintf("Unsupported key %q in %q command, skipping", lit2, lit))
 				}
 				if err != nil {
 					stopdata = true
 				}
 			}
-		case MATRIX:
-			// Character matrix (Alignmemnt)
-			// So far: Does not handle interleave case...
-			stopmatrix := false
-			for !stopmatrix {
-				tok2, lit2 := p.scanIgnoreWhitespace()
-				switch tok2 {
-				case OPENBRACK:
-					if tok2, lit2, err = p.consumeComment(tok2, lit2); err != nil {
-						stopmatrix = true
-					}
-				case IDENT, NUMERIC:
-					// We remove whitespaces in sequences if any
-					// and take into account possibly interleaved
-					// sequences
-					stopseq := false
-					name := lit2
-					sequence := ""
-					for !stopseq {
-						tok3, lit3 := p.scanIgnoreWhitespace()
-						switch tok3 {
-						case IDENT:
-							sequence = sequence + lit3
-						case ENDOFLINE:
-							stopseq = true
-						default:
-							err = fmt.Errorf("Expecting sequence after sequence identifier (%q) in Matrix block, got %q", lit2, lit3)
-							stopseq = true
-						}
-					}
-					if err != nil {
-						stopmatrix = true
-					} else {
-						addseq(sequences, &names, sequence, name)
-					}
-				case ENDOFLINE:
-					break
-				case ENDOFCOMMAND:
-					stopmatrix = true
-				default:
-					err = fmt.Errorf(fmt.Sprintf("Expecting sequence identifier in Matrix block, got %q", lit2))
-					stopmatrix = true
+			if err == nil {
+				if err = d.parseEquate(equate); err != nil {
+					err = d.commandError(EQUATE, err)
+					stopdata = true
 				}
 			}
-			if err != nil {
+		case MATRIX:
+			// Character matrix (Alignmemnt)
+			d.cmdpos[MATRIX] = tokenPos{p.buf.line, p.buf.col, lit}
+			if err = p.parseMatrix(d); err != nil {
 				stopdata = true
 			}
 		case OPENBRACK:
@@ -514,6 +703,125 @@ func (p *Parser) parseData() (names []string, sequences map[string]string, nchar
 	return
 }
 
+// parseMatrix parses the MATRIX command of a DATA block.
+//
+// If the matrix is interleaved, each line starts with a sequence name,
+// followed by a part of its sequence. Otherwise, each sequence name is
+// followed by its whole sequence, possibly on several lines (up to NCHAR
+// characters, if given). In both cases, sequence characters may be
+// separated by whitespaces, and comments may appear anywhere.
+func (p *Parser) parseMatrix(d *dataBlock) (err error) {
+	var name string
+	linestart := true
+	for {
+		tok, lit := p.scanIgnoreWhitespace()
+		switch tok {
+		case OPENBRACK:
+			if _, _, err = p.consumeComment(tok, lit); err != nil {
+				return
+			}
+			continue
+		case ENDOFLINE:
+			linestart = true
+			continue
+		case ENDOFCOMMAND:
+			return
+		case EOF, ILLEGAL, EQUAL, CLOSEBRACK:
+			return p.errorf("Expecting sequence identifier or characters in Matrix block, got %q", lit)
+		}
+
+		if d.isName(name, lit, linestart, p.taxlabels) {
+			name = lit
+			if _, ok := d.namepos[name]; !ok {
+				d.namepos[name] = [2]int{p.buf.line, p.buf.col}
+			}
+			addseq(d.sequences, &d.names, "", name)
+		} else {
+			if lit, err = d.polymorphisms(lit); err != nil {
+				return p.located(err)
+			}
+			addseq(d.sequences, &d.names, lit, name)
+		}
+		linestart = false
+	}
+}
+
+// isName returns true if the given word of the matrix is a sequence name,
+// false if it is a part of the sequence of the current sequence.
+func (d *dataBlock) isName(current, word string, linestart bool, taxlabels map[string]bool) bool {
+	if current == "" {
+		return true
+	}
+	if d.interleave || d.nchar == -1 {
+		return linestart
+	}
+	if int64(len(d.sequences[current])) >= d.nchar {
+		return true
+	}
+	// Sequence not complete: it continues on the next line, unless the
+	// line starts with a known sequence name (non declared interleaved matrix)
+	_, known := d.sequences[word]
+	return linestart && (known || taxlabels[word])
+}
+
+// polymorphisms replaces sets of nucleotides ((AG) or {AG}) of the given
+// sequence part by their IUPAC code.
+func (d *dataBlock) polymorphisms(seq string) (string, error) {
+	if !strings.ContainsAny(seq, "({") {
+		return seq, nil
+	}
+	var buf strings.Builder
+	for i := 0; i < len(seq); i++ {
+		if seq[i] != '(' && seq[i] != '{' {
+			buf.WriteByte(seq[i])
+			continue
+		}
+		end := strings.IndexAny(seq[i:], ")}")
+		if end < 0 {
+			return "", fmt.Errorf("Unmatched %c in sequence %q", seq[i], seq)
+		}
+		if align.AlphabetFromString(d.datatype) != align.NUCLEOTIDS {
+			return "", fmt.Errorf("Polymorphisms (%s) are only supported for nucleotide data", seq[i:i+end+1])
+		}
+		nt, err := align.Nts2IUPAC([]rune(seq[i+1 : i+end]))
+		if err != nil {
+			return "", err
+		}
+		buf.WriteRune(nt)
+		i += end
+	}
+	return buf.String(), nil
+}
+
+// parseEquate parses the symbol definitions of EQUATE values
+// (ex: "R=(AG) Y={CT} X=N"). Definitions must be a single symbol,
+// or, for nucleotide data, a set of nucleotides (replaced by its IUPAC code).
+func (d *dataBlock) parseEquate(equate string) (err error) {
+	var nt string
+	defs := strings.Join(strings.Fields(equate), "")
+	for len(defs) > 0 {
+		if len(defs) < 3 || defs[1] != '=' {
+			return fmt.Errorf("Malformed EQUATE definition: %q", defs)
+		}
+		symbol := rune(defs[0])
+		if defs[2] == '(' || defs[2] == '{' {
+			end := strings.IndexAny(defs, ")}")
+			if end < 0 {
+				return fmt.Errorf("Malformed EQUATE definition: %q", defs)
+			}
+			if nt, err = d.polymorphisms(defs[2 : end+1]); err != nil {
+				return
+			}
+			defs = defs[end+1:]
+		} else {
+			nt = defs[2:3]
+			defs = defs[3:]
+		}
+		d.equate[d.symbol(symbol)] = []rune(nt)[0]
+	}
+	return
+}
+
 // Just skip the current command
 func (p *Parser) parseUnsupportedCommand() (err error) {
 	// Unsupported data command
@@ -522,10 +830,10 @@ func (p *Parser) parseUnsupportedCommand() (err error) {
 		tok, lit := p.scanIgnoreWhitespace()
 		switch tok {
 		case ILLEGAL:
-			err = fmt.Errorf("found illegal token %q", lit)
+			err = p.errorf("found illegal token %q", lit)
 			stopunsupported = true
 		case EOF:
-			err = fmt.Errorf("End of file within a command (no;)")
+			err = p.errorf("End of file within a command (no;)")
 			stopunsupported = true
 		case ENDOFCOMMAND:
 			stopunsupported = true
@@ -534,16 +842,16 @@ func (p *Parser) parseUnsupportedCommand() (err error) {
 	return
 }
 
-// Just skip the current key
+// Just skip the current key: key=value, or key alone (ex: nolabels)
 func (p *Parser) parseUnsupportedKey(key string) (err error) {
 	// Unsupported token
-	tok, lit := p.scanIgnoreWhitespace()
+	tok, _ := p.scanIgnoreWhitespace()
 	if tok != EQUAL {
-		err = fmt.Errorf("Expecting '=' after %s, got %q", key, lit)
+		p.unscan()
 	} else {
 		tok2, lit2 := p.scanIgnoreWhitespace()
 		if tok2 != IDENT && tok2 != NUMERIC {
-			err = fmt.Errorf("Expecting an identifier after '%s=', got %q", key, lit2)
+			err = p.errorf("Expecting an identifier after '%s=', got %q", key, lit2)
 		}
 	}
 	return
@@ -557,15 +865,15 @@ func (p *Parser) parseUnsupportedBlock() error {
 		tok, lit := p.scanIgnoreWhitespace()
 		switch tok {
 		case ILLEGAL:
-			err = fmt.Errorf("found illegal token %q", lit)
+			err = p.errorf("found illegal token %q", lit)
 			stopunsupported = true
 		case EOF:
-			err = fmt.Errorf("End of file within a block (no END;)")
+			err = p.errorf("End of file within a block (no END;)")
 			stopunsupported = true
 		case END:
 			tok2, _ := p.scanIgnoreWhitespace()
 			if tok2 != ENDOFCOMMAND {
-				err = fmt.Errorf("End token without ;")
+				err = p.errorf("End token without ;")
 			}
 			stopunsupported = true
 		}
@@ -589,16 +897,14 @@ func addseq(sequences map[string]string, names *[]string, sequence string, name
 // Consumes comment inside brakets [comment] if the given current token is a [.
 // At the end returns the matching ] token and lit.
 // If the given token is not a [, then returns the input token and lit
+// Comments may be nested, and may contain quotes.
 func (p *Parser) consumeComment(curtoken Token, curlit string) (outtoken Token, outlit string, err error) {
-	outtoken = curtoken
-	curlit = curlit
+	outtoken, outlit = curtoken, curlit
 	if curtoken == OPENBRACK {
-		for outtoken != CLOSEBRACK {
-			outtoken, outlit = p.scanIgnoreWhitespace()
-			if outtoken == EOF || outtoken == ILLEGAL {
-				err = fmt.Errorf("Unmatched bracket")
-			}
+		if !p.s.skipComment() {
+			err = p.errorf("Unmatched bracket")
 		}
+		outtoken, outlit = CLOSEBRACK, "]"
 	}
 	return
 }
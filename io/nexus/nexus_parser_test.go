@@ -1,9 +1,12 @@
 package nexus_test
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
+	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
 	"github.com/evolbioinfo/goalign/io/nexus"
 )
 
@@ -50,3 +53,268 @@ EOF
 		}
 	}
 }
+
+func TestParser_ParseFormats(t *testing.T) {
+	innexus := []string{
+		// Interleaved matrix, with comments
+		`#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=3 NCHAR=8;
+  FORMAT DATATYPE=DNA INTERLEAVE=YES GAP=- MISSING=?;
+  MATRIX
+  [first part [nested]]
+  s1 ACGT
+  s2 AC-T
+  'seq 3' AC?T [it's a comment]
+
+  s1 ACGT
+  s2 ACGA
+  'seq 3' ACGG
+  ;
+END;
+`,
+		// Sequential matrix, sequences on several lines
+		`#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=3 NCHAR=8;
+  FORMAT DATATYPE=DNA NOLABELS=NO GAP=- MISSING=?;
+  MATRIX
+  s1 ACGT
+     ACGT
+  s2 AC-T ACGA
+  'seq 3'
+  AC?T
+  ACGG
+  ;
+END;
+`,
+		// Matchchar, missing, equate and respectcase
+		`#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=3 NCHAR=8;
+  FORMAT DATATYPE=DNA RESPECTCASE MISSING=. MATCHCHAR=x EQUATE="J=- Z=A";
+  MATRIX
+  s1 ACGTACGT
+  s2 xxJxxxxZ
+  'seq 3' xx.xxxGG
+  ;
+END;
+`,
+	}
+	exp := map[string]string{"s1": "ACGTACGT", "s2": "AC-TACGA", "seq 3": "AC*TACGG"}
+	for i, in := range innexus {
+		al, err := nexus.NewParser(strings.NewReader(in)).Parse()
+		if err != nil {
+			t.Errorf("Nexus %d: parser error: %v", i, err)
+			continue
+		}
+		for name, seq := range exp {
+			if s, ok := al.GetSequence(name); !ok || s != seq {
+				t.Errorf("Nexus %d: sequence %s is not as expected: %s vs. %s", i, name, s, seq)
+			}
+		}
+	}
+
+	// Matchchar is case sensitive with respectcase only
+	al, err := nexus.NewParser(strings.NewReader(`#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=2 NCHAR=4;
+  FORMAT DATATYPE=DNA MATCHCHAR=x;
+  MATRIX
+  s1 ACGT
+  s2 X{AG}xx
+  ;
+END;
+`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := al.GetSequence("s2"); s != "ARGT" {
+		t.Errorf("Sequence s2 is not as expected: %s vs. ARGT", s)
+	}
+}
+
+func TestParser_ParseMultiple(t *testing.T) {
+	aligns := &align.AlignChannel{Achan: make(chan align.Alignment, 10)}
+	nexus.NewParser(strings.NewReader(`#NEXUS
+BEGIN TAXA;
+  DIMENSIONS NTAX=2;
+  TAXLABELS s1 s2;
+END;
+BEGIN CHARACTERS;
+  DIMENSIONS NCHAR=4;
+  FORMAT DATATYPE=DNA;
+  MATRIX
+  s1 ACGT
+  s2 ACGA
+  ;
+END;
+BEGIN TREES;
+  TREE t1 = (s1,s2);
+END;
+BEGIN CHARACTERS;
+  DIMENSIONS NCHAR=2;
+  FORMAT DATATYPE=PROTEIN;
+  MATRIX
+  s1 WR
+  s2 WK
+  ;
+END;
+`)).ParseMultiple(aligns)
+	if aligns.Err != nil {
+		t.Fatal(aligns.Err)
+	}
+	als := make([]align.Alignment, 0)
+	for al := range aligns.Achan {
+		als = append(als, al)
+	}
+	if len(als) != 2 {
+		t.Fatalf("There should be 2 alignments (%d)", len(als))
+	}
+	if als[0].Length() != 4 || als[0].Alphabet() != align.NUCLEOTIDS {
+		t.Errorf("First alignment is not as expected: length %d, alphabet %s", als[0].Length(), als[0].AlphabetStr())
+	}
+	if als[1].Length() != 2 || als[1].Alphabet() != align.AMINOACIDS {
+		t.Errorf("Second alignment is not as expected: length %d, alphabet %s", als[1].Length(), als[1].AlphabetStr())
+	}
+}
+
+func TestParser_ParseSets(t *testing.T) {
+	matrix := `#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=2 NCHAR=12;
+  FORMAT DATATYPE=DNA;
+  MATRIX
+  s1 ACGTACGTACGT
+  s2 ACGTACGTACGA
+  ;
+END;
+`
+	tests := []struct {
+		name  string
+		sets  string
+		parts string
+	}{
+		{"charsets", `BEGIN SETS;
+  CHARSET gene1 = 1-6;
+  CHARSET gene2 (CHARACTERS) = 7 - .;
+END;
+`, "DNA,gene1=1-6\nDNA,gene2=7-12\n"},
+		{"charpartition", `BEGIN ASSUMPTIONS;
+  CHARSET gene1 = 1-6;
+  CHARSET codon3 = 3-12\3;
+  CHARPARTITION * bycodon = first : 1-12\3, second:2-12\3, third:codon3;
+END;
+`, "DNA,first=1,4,7,10\nDNA,second=2,5,8,11\nDNA,third=3,6,9,12\n"},
+		{"mrbayes", `BEGIN MRBAYES;
+  charset g1 = 1-4;
+  charset g2 = 5-12;
+  partition bygene = 2: g1, g2;
+  partition half = 2: 1-6, 7-12;
+  set partition = half;
+  lset nst=6;
+END;
+`, "DNA,part1=1-6\nDNA,part2=7-12\n"},
+		{"overlapping charsets", `BEGIN SETS;
+  CHARSET gene1 = 1-6;
+  CHARSET gene2 = 5-12;
+END;
+`, ""},
+	}
+	for _, test := range tests {
+		al, err := nexus.NewParser(strings.NewReader(matrix + test.sets)).Parse()
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		parts := ""
+		if ps := al.Partitions(); ps != nil {
+			parts = ps.String()
+		}
+		if parts != test.parts {
+			t.Errorf("%s: partitions are not as expected:\n%s\nvs.\n%s", test.name, parts, test.parts)
+		}
+	}
+
+	if _, err := nexus.NewParser(strings.NewReader(matrix + "BEGIN SETS;\n  CHARSET gene1 = 1-13;\nEND;\n")).Parse(); err == nil {
+		t.Errorf("A character set outside the alignment should produce an error")
+	}
+}
+
+func TestWriteAlignment_Sets(t *testing.T) {
+	al, err := nexus.NewParser(strings.NewReader(`#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=2 NCHAR=10;
+  FORMAT DATATYPE=DNA;
+  MATRIX
+  s1 ACGTACGTAC
+  s2 ACGTACGTAA
+  ;
+END;
+BEGIN SETS;
+  CHARSET codon12 = 1-10\3 2-10\3;
+  CHARSET 'codon 3' = 3 6 9;
+  CHARPARTITION bycodon = p12:codon12, p3:'codon 3';
+END;
+`)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `#NEXUS
+begin data;
+dimensions ntax=2 nchar=10;
+format datatype=dna;
+matrix
+s1 ACGTACGTAC
+s2 ACGTACGTAA
+;
+end;
+begin sets;
+charset p12 = 1-2 4-5 7-8 10;
+charset p3 = 3-9\3;
+charpartition partitions = p12:p12, p3:p3;
+end;
+`
+	out := nexus.WriteAlignment(al)
+	if out != expected {
+		t.Fatalf("Nexus output is not as expected:\n%s\nvs.\n%s", out, expected)
+	}
+	al2, err := nexus.NewParser(strings.NewReader(out)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if al2.Partitions().String() != al.Partitions().String() {
+		t.Errorf("Partitions are not the same after writing/parsing:\n%s\nvs.\n%s", al2.Partitions().String(), al.Partitions().String())
+	}
+}
+
+func TestParser_ErrorPosition(t *testing.T) {
+	var pe *alignio.ParseError
+
+	tests := []struct {
+		input        string
+		line, column int
+		token        string
+	}{
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\n  format datatype=dna;\nmatrix\ns1 ACGT\ns2 AC{G\n;\nend;\n", 7, 4, "AC{G"},
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\n  format datatype=dna missing 1;\nend;\n", 4, 31, "1"},
+		{"#NEXUS\n\nbegin data;\n  dimensions ntax=2 nchar=4;\nmatrix\ns1 ACGT\n  s2 ACG\n;\nend;\n", 7, 3, "s2"},
+		{"#NEXUS\n\nbegin data;\n  dimensions ntax=3 nchar=4;\nmatrix\ns1 ACGT\ns2 ACGT\n;\nend;\n", 4, 3, "dimensions"},
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\nmatrix\n;\nend;\n", 4, 1, "matrix"},
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\n  format datatype=foo;\nmatrix\ns1 ACGT\ns2 ACGT\n;\nend;\n", 4, 19, "foo"},
+		{"#NEXUS\nbegin taxa;\n  dimensions ntax=3;\n  taxlabels s1 s2 s3;\nend;\nbegin data;\n  dimensions nchar=4;\nmatrix\ns1 ACGT\ns2 ACGT\n;\nend;\n", 4, 3, "taxlabels"},
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\n  format datatype=protein;\nmatrix\ns1 ACGT\ns2 AC(AG)T\n;\nend;\n", 7, 4, "AC(AG)T"},
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\n  format datatype=dna equate=\"R=(AG\";\nmatrix\ns1 ACGT\ns2 ACGR\n;\nend;\n", 4, 30, "R=(AG"},
+		{"#NEXUS\nbegin data;\n  dimensions ntax=2 nchar=4;\n  format datatype=dna equate=\"R\";\nmatrix\ns1 ACGT\ns2 ACGR\n;\nend;\n", 4, 30, "R"},
+		{"#NEXUS\nbegin taxa;\n  dimensions ntax=2;\n  taxlabels s1 s2;\nend;\nbegin data;\n  dimensions nchar=4;\nmatrix\ns1 ACGT\ns3 ACGT\n;\nend;\n", 10, 1, "s3"},
+	}
+	for _, test := range tests {
+		_, err := nexus.NewParser(strings.NewReader(test.input)).Parse()
+		if !errors.As(err, &pe) {
+			t.Errorf("Parse error expected, got %v", err)
+			continue
+		}
+		if pe.Line != test.line || pe.Column != test.column || pe.Token != test.token {
+			t.Errorf("Wrong error position: %d:%d %q, expected %d:%d %q", pe.Line, pe.Column, pe.Token, test.line, test.column, test.token)
+		}
+	}
+}
@@ -29,11 +29,14 @@ const (
 	NTAX       // Dimensions : Number of taxa
 	NCHAR      // Dimensions : Length of alignment
 
-	FORMAT    // Format
-	DATATYPE  // Format datatype=dna
-	MISSING   // Format missing=?  missing char
-	GAP       // Format gap=- gap character
-	MATCHCHAR // Format matchchar=.  matching character compared to first seq
+	FORMAT      // Format
+	DATATYPE    // Format datatype=dna
+	MISSING     // Format missing=?  missing char
+	GAP         // Format gap=- gap character
+	MATCHCHAR   // Format matchchar=.  matching character compared to first seq
+	INTERLEAVE  // Format interleave[=yes|no] interleaved matrix
+	EQUATE      // Format equate="R=(AG) ..." symbol definitions
+	RESPECTCASE // Format respectcase: symbols are case sensitive
 
 	MATRIX // Matrix
 	END    // End
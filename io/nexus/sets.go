@@ -0,0 +1,373 @@
+package nexus
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	aio "github.com/evolbioinfo/goalign/io"
+)
+
+// Whitespaces around '-' and '\' in character set definitions
+var rangeSpaces = regexp.MustCompile(`\s*([-\\])\s*`)
+
+// charSets are the character sets and partitions defined in the
+// SETS, ASSUMPTIONS and MRBAYES blocks following a DATA block.
+type charSets struct {
+	nchar      int                   // Length of the alignment of the DATA block
+	model      string                // Model given to the partitions (DNA or LG)
+	sets       map[string][]int      // CHARSET sites (0-based), by name
+	setnames   []string              // CHARSET names, in order of definition
+	partitions map[string]*partition // CHARPARTITION / MrBayes partitions, by name
+	first      string                // Name of the first defined partition
+	selected   string                // Partition selected with MrBayes "set partition="
+}
+
+// partition is a CHARPARTITION: a list of named subsets of sites
+type partition struct {
+	names []string
+	sites [][]int
+}
+
+func newCharSets(al align.Alignment) *charSets {
+	model := "DNA"
+	if al.Alphabet() == align.AMINOACIDS {
+		model = "LG"
+	}
+	return &charSets{
+		nchar:      al.Length(),
+		model:      model,
+		sets:       make(map[string][]int),
+		setnames:   make([]string, 0),
+		partitions: make(map[string]*partition),
+	}
+}
+
+// isSetsBlock returns true if the block may contain character sets
+func isSetsBlock(name string) bool {
+	return strings.EqualFold(name, "SETS") || strings.EqualFold(name, "ASSUMPTIONS") || strings.EqualFold(name, "MRBAYES")
+}
+
+// parseSets parses a SETS, ASSUMPTIONS or MRBAYES block.
+//
+// Supported commands are:
+//   - CHARSET name = 1-500\3 501-. gene2;
+//   - CHARPARTITION name = p1:1-500, p2:gene2;
+//   - partition name = 2: gene1, gene2; (MrBayes)
+//   - set partition = name; (MrBayes)
+//
+// Other commands are skipped.
+func (p *Parser) parseSets(cs *charSets, block string) (err error) {
+	for {
+		var words []string
+		var tok Token
+		if tok, words, err = p.parseCommandWords(); err != nil {
+			return
+		}
+		if tok == END {
+			return
+		}
+		if len(words) == 0 {
+			continue
+		}
+		switch strings.ToLower(words[0]) {
+		case "charset":
+			err = cs.parseCharSet(words[1:])
+		case "charpartition":
+			err = cs.parseCharPartition(words[1:])
+		case "partition":
+			err = cs.parseMrBayesPartition(words[1:])
+		case "set":
+			for i := 1; i+2 < len(words); i++ {
+				if strings.EqualFold(words[i], "partition") && words[i+1] == "=" {
+					cs.selected = unquote(words[i+2])
+				}
+			}
+		default:
+			aio.PrintMessage(fmt.Sprintf("Unsupported command %q in block %s, skipping", words[0], block))
+		}
+		if err != nil {
+			return p.located(err)
+		}
+	}
+}
+
+// parseCommandWords returns the words of the next command of the block,
+// up to its ';' (comments and end of lines are skipped).
+// Returns the END token if the block is finished.
+func (p *Parser) parseCommandWords() (tok Token, words []string, err error) {
+	var lit string
+	words = make([]string, 0)
+	for {
+		tok, lit = p.scanIgnoreWhitespace()
+		switch tok {
+		case ENDOFCOMMAND:
+			return
+		case END:
+			if len(words) == 0 {
+				if tok2, _ := p.scanIgnoreWhitespace(); tok2 != ENDOFCOMMAND {
+					err = p.errorf("End token without ;")
+				}
+				return
+			}
+			words = append(words, lit)
+		case EOF:
+			err = p.errorf("End of file within a block (no END;)")
+			return
+		case ILLEGAL:
+			err = p.errorf("found illegal token %q", lit)
+			return
+		case OPENBRACK:
+			if _, _, err = p.consumeComment(tok, lit); err != nil {
+				return
+			}
+		case ENDOFLINE:
+		case IDENT:
+			if strings.ContainsAny(lit, " \t") {
+				// Was a quoted word
+				lit = nexusName(lit)
+			}
+			words = append(words, lit)
+		default:
+			words = append(words, lit)
+		}
+	}
+}
+
+// definition returns the name and the definition (after '=') of
+// a CHARSET or CHARPARTITION command.
+// '*' (default set) and qualifiers in parentheses are ignored
+func definition(words []string) (name, def string, err error) {
+	for i, w := range words {
+		if w == "=" {
+			if name == "" {
+				err = fmt.Errorf("Missing name before '='")
+			}
+			def = strings.Join(words[i+1:], " ")
+			return
+		}
+		if w != "*" && !strings.HasPrefix(w, "(") {
+			name = unquote(w)
+		}
+	}
+	err = fmt.Errorf("Expecting '=' in definition of %q", name)
+	return
+}
+
+func (cs *charSets) parseCharSet(words []string) (err error) {
+	var name, def string
+	var sites []int
+	if name, def, err = definition(words); err != nil {
+		return
+	}
+	if sites, err = cs.parseSites(def); err != nil {
+		return
+	}
+	if _, ok := cs.sets[name]; !ok {
+		cs.setnames = append(cs.setnames, name)
+	}
+	cs.sets[name] = sites
+	return
+}
+
+func (cs *charSets) parseCharPartition(words []string) (err error) {
+	var name, def string
+	if name, def, err = definition(words); err != nil {
+		return
+	}
+	part := &partition{names: make([]string, 0), sites: make([][]int, 0)}
+	for _, subset := range splitQuoted(def, ",") {
+		var sites []int
+		kv := splitQuoted(subset, ":")
+		if len(kv) != 2 {
+			return fmt.Errorf("Malformed CHARPARTITION %s subset: %q", name, subset)
+		}
+		if sites, err = cs.parseSites(kv[1]); err != nil {
+			return
+		}
+		part.names = append(part.names, unquote(kv[0]))
+		part.sites = append(part.sites, sites)
+	}
+	cs.addPartition(name, part)
+	return
+}
+
+// parseMrBayesPartition parses a MrBayes partition command:
+// partition name = 2: gene1, gene2;
+func (cs *charSets) parseMrBayesPartition(words []string) (err error) {
+	var name, def string
+	if name, def, err = definition(words); err != nil {
+		return
+	}
+	kv := splitQuoted(def, ":")
+	if len(kv) != 2 {
+		return fmt.Errorf("Malformed partition %s: %q", name, def)
+	}
+	subsets := splitQuoted(kv[1], ",")
+	if n, err2 := strconv.Atoi(kv[0]); err2 != nil || n != len(subsets) {
+		return fmt.Errorf("Partition %s: the number of subsets (%d) is not %s", name, len(subsets), kv[0])
+	}
+	part := &partition{names: make([]string, 0), sites: make([][]int, 0)}
+	for i, subset := range subsets {
+		var sites []int
+		if sites, err = cs.parseSites(subset); err != nil {
+			return
+		}
+		if subset = unquote(subset); cs.sets[subset] == nil {
+			subset = fmt.Sprintf("part%d", i+1)
+		}
+		part.names = append(part.names, subset)
+		part.sites = append(part.sites, sites)
+	}
+	cs.addPartition(name, part)
+	return
+}
+
+func (cs *charSets) addPartition(name string, part *partition) {
+	if cs.first == "" {
+		cs.first = name
+	}
+	cs.partitions[name] = part
+}
+
+// parseSites parses a list of character ranges or character set names
+// (ex: 1-500\3 501-. gene2), and returns the corresponding sites (0-based),
+// in increasing order
+func (cs *charSets) parseSites(def string) (sites []int, err error) {
+	var start, end, step int
+	seen := make(map[int]bool)
+	sites = make([]int, 0)
+	for _, elt := range splitQuoted(rangeSpaces.ReplaceAllString(def, "$1"), " \t") {
+		if set, ok := cs.sets[unquote(elt)]; ok {
+			for _, s := range set {
+				if !seen[s] {
+					seen[s] = true
+					sites = append(sites, s)
+				}
+			}
+			continue
+		}
+		if strings.EqualFold(elt, "all") {
+			elt = "1-."
+		}
+		step = 1
+		if idx := strings.Index(elt, "\\"); idx >= 0 {
+			if step, err = strconv.Atoi(elt[idx+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("Wrong step in character range %q", elt)
+			}
+			elt = elt[:idx]
+		}
+		bounds := strings.SplitN(elt, "-", 2)
+		if start, err = cs.position(bounds[0]); err != nil {
+			return
+		}
+		end = start
+		if len(bounds) == 2 {
+			if end, err = cs.position(bounds[1]); err != nil {
+				return
+			}
+		}
+		if start > end {
+			return nil, fmt.Errorf("Wrong character range %q", elt)
+		}
+		for s := start; s <= end; s += step {
+			if !seen[s] {
+				seen[s] = true
+				sites = append(sites, s)
+			}
+		}
+	}
+	sort.Ints(sites)
+	return
+}
+
+// position returns the 0-based position corresponding to the given
+// character number ('.' being the last character)
+func (cs *charSets) position(pos string) (p int, err error) {
+	if pos == "." {
+		return cs.nchar - 1, nil
+	}
+	if p, err = strconv.Atoi(pos); err != nil {
+		return -1, fmt.Errorf("Unknown character set or wrong character number: %q", pos)
+	}
+	if p < 1 || p > cs.nchar {
+		return -1, fmt.Errorf("Character %d is outside the alignment (length %d)", p, cs.nchar)
+	}
+	return p - 1, nil
+}
+
+// partitionSet returns the PartitionSet corresponding to the parsed sets:
+//   - The partition selected with MrBayes "set partition=", if any
+//   - Otherwise the first defined CHARPARTITION (or MrBayes partition)
+//   - Otherwise the CHARSETs, if they do not overlap
+//
+// Returns nil if none apply.
+func (cs *charSets) partitionSet() (ps *align.PartitionSet, err error) {
+	var part *partition
+	var ok bool
+	if part, ok = cs.partitions[cs.selected]; !ok {
+		part, ok = cs.partitions[cs.first]
+	}
+	if !ok {
+		if len(cs.setnames) == 0 {
+			return nil, nil
+		}
+		part = &partition{names: cs.setnames, sites: make([][]int, 0)}
+		for _, name := range cs.setnames {
+			part.sites = append(part.sites, cs.sets[name])
+		}
+	}
+
+	ps = align.NewPartitionSet(cs.nchar)
+	for i, name := range part.names {
+		for _, s := range part.sites[i] {
+			if err = ps.AddRange(name, cs.model, s, s, 1); err != nil {
+				if !ok {
+					// Overlapping charsets: they do not define partitions
+					return nil, nil
+				}
+				return
+			}
+		}
+	}
+	return
+}
+
+// splitQuoted splits s around the given separator characters, except
+// inside quoted words. Pieces are trimmed, and empty pieces are removed
+func splitQuoted(s, seps string) (pieces []string) {
+	var quote rune
+	start := 0
+	pieces = make([]string, 0)
+	for i, c := range s {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case strings.ContainsRune(seps, c):
+			if piece := strings.TrimSpace(s[start:i]); piece != "" {
+				pieces = append(pieces, piece)
+			}
+			start = i + 1
+		}
+	}
+	if piece := strings.TrimSpace(s[start:]); piece != "" {
+		pieces = append(pieces, piece)
+	}
+	return
+}
+
+// unquote removes the quotes around the given word, if any
+func unquote(w string) string {
+	if len(w) >= 2 && (w[0] == '\'' || w[0] == '"') && w[len(w)-1] == w[0] {
+		q := string(w[0])
+		return strings.Replace(w[1:len(w)-1], q+q, q, -1)
+	}
+	return w
+}
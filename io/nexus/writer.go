@@ -3,6 +3,7 @@ package nexus
 import (
 	"bytes"
 	"fmt"
+	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
 )
@@ -37,6 +38,52 @@ func WriteAlignment(al align.Alignment) string {
 	})
 	buf.WriteString(";\n")
 	buf.WriteString("end;\n")
+	if ps := al.Partitions(); ps != nil {
+		buf.WriteString(WriteSets(ps))
+	}
+
+	return buf.String()
+}
 
+// WriteSets returns a SETS block defining a CHARSET for each partition
+// of the given PartitionSet, and a CHARPARTITION grouping them.
+func WriteSets(ps *align.PartitionSet) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("begin sets;\n")
+	parts := make([]string, 0, ps.NPartitions())
+	for i := 0; i < ps.NPartitions(); i++ {
+		name := nexusName(ps.PartitionName(i))
+		buf.WriteString(fmt.Sprintf("charset %s = %s;\n", name, ranges(ps, i)))
+		parts = append(parts, name+":"+name)
+	}
+	buf.WriteString(fmt.Sprintf("charpartition partitions = %s;\n", strings.Join(parts, ", ")))
+	buf.WriteString("end;\n")
 	return buf.String()
 }
+
+// ranges returns the NEXUS definition of the given partition:
+// runs of sites separated by a constant step are written as start-end\step
+func ranges(ps *align.PartitionSet, code int) string {
+	elts := make([]string, 0)
+	for _, r := range ps.Ranges(code) {
+		switch {
+		case r.Start == r.End:
+			elts = append(elts, fmt.Sprintf("%d", r.Start+1))
+		case r.Step == 1:
+			elts = append(elts, fmt.Sprintf("%d-%d", r.Start+1, r.End+1))
+		default:
+			elts = append(elts, fmt.Sprintf("%d-%d\\%d", r.Start+1, r.End+1, r.Step))
+		}
+	}
+	return strings.Join(elts, " ")
+}
+
+// nexusName quotes the given name if it contains
+// characters that are not allowed in NEXUS words
+func nexusName(name string) string {
+	if name != "" && !strings.ContainsAny(name, " \t'\"[]();,=:-\\*") {
+		return name
+	}
+	return "'" + strings.Replace(name, "'", "''", -1) + "'"
+}
@@ -0,0 +1,201 @@
+package partition
+
+import (
+	"bufio"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Partition file formats
+const (
+	FORMAT_AUTO            = iota // Detected from the content of the file
+	FORMAT_RAXML                  // RAxML/RAxML-NG: MODEL, name = 1-100/3
+	FORMAT_NEXUS                  // IQ-TREE NEXUS: CHARSET/CHARPARTITION in a SETS block
+	FORMAT_PARTITIONFINDER        // PartitionFinder .cfg: [data_blocks] section
+)
+
+// Lines specific to PartitionFinder configuration files
+var pfLine = regexp.MustCompile(`(?im)^\s*(\[data_blocks\]|(alignment|branchlengths|models|model_selection|search)\s*=)`)
+
+// Whitespaces around '-' and '\' in range definitions
+var rangeSpaces = regexp.MustCompile(`\s*([-\\])\s*`)
+
+// partRange is a range of sites of a partition, as read in a partition file:
+// from start to end (0-based, included, -1 meaning the last site of
+// the alignment), every step sites.
+type partRange struct {
+	name  string
+	model string
+	start int
+	end   int
+	step  int
+}
+
+// FormatFromString returns the partition format corresponding to the given name:
+// raxml (or raxml-ng), nexus (or iqtree), partitionfinder (or pf), auto
+func FormatFromString(format string) (int, error) {
+	switch strings.ToLower(format) {
+	case "auto":
+		return FORMAT_AUTO, nil
+	case "raxml", "raxml-ng", "raxmlng":
+		return FORMAT_RAXML, nil
+	case "nexus", "iqtree", "iq-tree":
+		return FORMAT_NEXUS, nil
+	case "partitionfinder", "pf", "cfg":
+		return FORMAT_PARTITIONFINDER, nil
+	default:
+		return -1, fmt.Errorf("Unknown partition format: %s", format)
+	}
+}
+
+// DetectFormat returns the format of the partition file read by r,
+// given its first bytes (the reader is not consumed):
+//   - FORMAT_NEXUS if it starts with #NEXUS
+//   - FORMAT_PARTITIONFINDER if it has a [data_blocks] section or
+//     PartitionFinder options (alignment = ..., models = ..., etc.)
+//   - FORMAT_RAXML otherwise
+func DetectFormat(r *bufio.Reader) int {
+	head, _ := r.Peek(r.Size())
+	content := strings.TrimSpace(string(head))
+	if len(content) >= 6 && strings.EqualFold(content[:6], "#NEXUS") {
+		return FORMAT_NEXUS
+	}
+	if pfLine.MatchString(content) {
+		return FORMAT_PARTITIONFINDER
+	}
+	return FORMAT_RAXML
+}
+
+// Parse parses the partition file read by r, in the given format
+// (FORMAT_AUTO: see DetectFormat). model is the model given to the
+// partitions whose model is not defined in the file (NEXUS files
+// without CHARPARTITION, PartitionFinder files).
+//
+// If alignmentLength <= 0, the length of the alignment is the
+// last site of the partitions.
+func Parse(r *bufio.Reader, format int, alignmentLength int, model string) (ps *align.PartitionSet, err error) {
+	var ranges []partRange
+
+	if format == FORMAT_AUTO {
+		format = DetectFormat(r)
+	}
+	switch format {
+	case FORMAT_RAXML:
+		return NewParser(r).Parse(alignmentLength)
+	case FORMAT_NEXUS:
+		ranges, err = parseNexus(r, model)
+	case FORMAT_PARTITIONFINDER:
+		ranges, err = parsePartitionFinder(r, model)
+	default:
+		err = fmt.Errorf("Unknown partition format: %d", format)
+	}
+	if err != nil {
+		return
+	}
+	return build(ranges, alignmentLength)
+}
+
+// Write returns the given PartitionSet in the given format.
+// alignment is the name of the alignment file (only used
+// in PartitionFinder format).
+func Write(ps *align.PartitionSet, format int, alignment string) (string, error) {
+	switch format {
+	case FORMAT_RAXML:
+		return WriteRaxml(ps), nil
+	case FORMAT_NEXUS:
+		return WriteNexus(ps), nil
+	case FORMAT_PARTITIONFINDER:
+		return WritePartitionFinder(ps, alignment), nil
+	default:
+		return "", fmt.Errorf("Unknown partition output format: %d", format)
+	}
+}
+
+// build returns the PartitionSet corresponding to the given ranges.
+// If alignmentLength <= 0, the length of the alignment is the last
+// site of the ranges.
+func build(ranges []partRange, alignmentLength int) (ps *align.PartitionSet, err error) {
+	if alignmentLength <= 0 {
+		for _, r := range ranges {
+			if r.end == -1 {
+				return nil, fmt.Errorf("'.' (last site) cannot be used if the alignment length is not known")
+			}
+			if r.end+1 > alignmentLength {
+				alignmentLength = r.end + 1
+			}
+		}
+	}
+	ps = align.NewPartitionSet(alignmentLength)
+	for _, r := range ranges {
+		if r.end == -1 {
+			r.end = alignmentLength - 1
+		}
+		if err = ps.AddRange(r.name, r.model, r.start, r.end, r.step); err != nil {
+			return nil, err
+		}
+	}
+	return
+}
+
+// parseSpec parses a list of site ranges (ex: "1-100\3 101-. 205", commas being
+// accepted as separators) of the partition with the given name and model.
+// Elements that are names of previously defined sets are replaced by their ranges.
+func parseSpec(name, model, spec string, sets map[string][]partRange) (ranges []partRange, err error) {
+	var start, end, step int
+	ranges = make([]partRange, 0)
+	spec = rangeSpaces.ReplaceAllString(spec, "$1")
+	for _, elt := range strings.FieldsFunc(spec, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r' }) {
+		if set, ok := sets[elt]; ok {
+			for _, r := range set {
+				ranges = append(ranges, partRange{name, model, r.start, r.end, r.step})
+			}
+			continue
+		}
+		step = 1
+		if idx := strings.IndexAny(elt, "\\/"); idx >= 0 {
+			if step, err = strconv.Atoi(elt[idx+1:]); err != nil || step <= 0 {
+				return nil, fmt.Errorf("Wrong stride in range %q of partition %s", elt, name)
+			}
+			elt = elt[:idx]
+		}
+		bounds := strings.SplitN(elt, "-", 2)
+		if start, err = strconv.Atoi(bounds[0]); err != nil || start < 1 {
+			return nil, fmt.Errorf("Wrong range %q of partition %s", elt, name)
+		}
+		end = start
+		if len(bounds) == 2 {
+			if bounds[1] == "." {
+				// Last site: end-1 == -1
+				end = 0
+			} else if end, err = strconv.Atoi(bounds[1]); err != nil || end < start {
+				return nil, fmt.Errorf("Wrong range %q of partition %s", elt, name)
+			}
+		}
+		ranges = append(ranges, partRange{name, model, start - 1, end - 1, step})
+	}
+	if len(ranges) == 0 {
+		err = fmt.Errorf("No site defined for partition %s", name)
+	}
+	return
+}
+
+// rangesString returns the ranges of the partition associated to the given
+// index, separated by sep: start-end\step, start-end, or site (1-based)
+func rangesString(ps *align.PartitionSet, code int, sep string) string {
+	elts := make([]string, 0)
+	for _, r := range ps.Ranges(code) {
+		switch {
+		case r.Start == r.End:
+			elts = append(elts, fmt.Sprintf("%d", r.Start+1))
+		case r.Step == 1:
+			elts = append(elts, fmt.Sprintf("%d-%d", r.Start+1, r.End+1))
+		default:
+			elts = append(elts, fmt.Sprintf("%d-%d\\%d", r.Start+1, r.End+1, r.Step))
+		}
+	}
+	return strings.Join(elts, sep)
+}
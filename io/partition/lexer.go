@@ -5,22 +5,27 @@ import (
 	"bytes"
 	"io"
 	"strconv"
+
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Scanner represents a lexical scanner.
 type Scanner struct {
-	r *bufio.Reader
+	r         *bufio.Reader
+	pos       *alignio.Position
+	line, col int // Position of the last scanned token
 }
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{r: bufio.NewReader(r), pos: alignio.NewPosition()}
 }
 
 // read reads the next rune from the bufferred reader.
 // Returns the rune(0) if an error occurs (or io.EOF is returned).
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
+	s.pos.Read(ch, err != nil)
 	if err != nil {
 		return eof
 	}
@@ -30,12 +35,22 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
 	_ = s.r.UnreadRune()
+	s.pos.Unread()
+}
+
+// Pos returns the line and column (1-based) of the last scanned token.
+func (s *Scanner) Pos() (line, col int) {
+	return s.line, s.col
 }
 
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan() (tok Token, lit string) {
 	// Read the next rune.
 	ch := s.read()
+	for isWhiteSpace(ch) {
+		ch = s.read()
+	}
+	s.line, s.col = s.pos.Last()
 
 	if isEndOfLine(ch) {
 		if isCR(ch) {
@@ -47,10 +62,6 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 		return ENDOFLINE, ""
 	}
 
-	for isWhiteSpace(ch) {
-		ch = s.read()
-	}
-
 	switch ch {
 	case eof:
 		return EOF, ""
@@ -60,7 +71,7 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 		return EQUAL, string(ch)
 	case '-':
 		return RANGE, string(ch)
-	case '/':
+	case '/', '\\':
 		return MODULO, string(ch)
 	}
 
@@ -76,16 +87,30 @@ func (s *Scanner) Scan() (tok Token, lit string) {
 }
 
 // scanIdent consumes the current rune and all contiguous ident runes.
+// Characters between braces are part of the ident, whatever they are
+// (ex: RAxML-NG model GTR{1/2/1/1/2/1}+FU{0.2/0.3/0.3/0.2}+G).
 func (s *Scanner) scanIdent() (tok Token, lit string) {
 	// Create a buffer and read the current character into it.
 	var buf bytes.Buffer
-	buf.WriteRune(s.read())
+	var braces int
+	ch := s.read()
+	buf.WriteRune(ch)
+	if ch == '{' {
+		braces++
+	}
 
 	// Read every subsequent ident character into the buffer.
 	// Non-ident characters and EOF will cause the loop to exit.
 	for {
 		if ch := s.read(); ch == eof {
 			break
+		} else if ch == '{' || (braces > 0 && ch != '\n' && ch != '\r') {
+			if ch == '{' {
+				braces++
+			} else if ch == '}' {
+				braces--
+			}
+			_, _ = buf.WriteRune(ch)
 		} else if !isIdent(ch) {
 			s.unread()
 			break
@@ -0,0 +1,162 @@
+package partition
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Comments of NEXUS files
+var nexusComment = regexp.MustCompile(`\[[^\]]*\]`)
+
+// parseNexus parses the SETS (or ASSUMPTIONS) blocks of a NEXUS
+// partition file, as used by IQ-TREE:
+//
+//	#nexus
+//	begin sets;
+//	  charset part1 = 1-100\3 2-100\3;
+//	  charset part2 = 101-.;
+//	  charpartition mine = HKY+G:part1, GTR+I+G:part2;
+//	end;
+//
+// If there is a CHARPARTITION, its subsets are the partitions, named after
+// their CHARSETs (joined with '_' if several), with the model given before
+// ':' (or the given model if none). Otherwise, the CHARSETs are the partitions,
+// with the given model. Other blocks (ex: DATA) are skipped.
+func parseNexus(r io.Reader, model string) (ranges []partRange, err error) {
+	var b []byte
+	var name, def string
+	var setranges []partRange
+	var inblock, found bool
+
+	if b, err = io.ReadAll(r); err != nil {
+		return
+	}
+	content := strings.TrimSpace(nexusComment.ReplaceAllString(string(b), ""))
+	if len(content) >= 6 && strings.EqualFold(content[:6], "#NEXUS") {
+		content = content[6:]
+	}
+
+	sets := make(map[string][]partRange)
+	setnames := make([]string, 0)
+	ranges = make([]partRange, 0)
+	for _, command := range strings.Split(content, ";") {
+		fields := strings.Fields(command)
+		if len(fields) == 0 {
+			continue
+		}
+		switch strings.ToLower(fields[0]) {
+		case "begin":
+			inblock = len(fields) > 1 && (strings.EqualFold(fields[1], "sets") || strings.EqualFold(fields[1], "assumptions"))
+		case "end", "endblock":
+			inblock = false
+		case "charset":
+			if !inblock {
+				continue
+			}
+			if name, def, err = nexusDefinition(command); err != nil {
+				return
+			}
+			if strings.Contains(def, ":") {
+				return nil, fmt.Errorf("CHARSET %s: partitions of several alignment files are not supported", name)
+			}
+			if setranges, err = parseSpec(name, model, def, sets); err != nil {
+				return
+			}
+			if _, ok := sets[name]; !ok {
+				setnames = append(setnames, name)
+			}
+			sets[name] = setranges
+		case "charpartition":
+			if !inblock || found {
+				continue
+			}
+			if _, def, err = nexusDefinition(command); err != nil {
+				return
+			}
+			found = true
+			for _, subset := range splitOutsideBraces(def, ',') {
+				m := model
+				if idx := strings.LastIndex(subset, ":"); idx >= 0 {
+					m = strings.TrimSpace(subset[:idx])
+					subset = subset[idx+1:]
+				}
+				if setranges, err = parseSpec(strings.Join(strings.Fields(subset), "_"), m, subset, sets); err != nil {
+					return
+				}
+				ranges = append(ranges, setranges...)
+			}
+		}
+	}
+
+	if !found {
+		for _, name := range setnames {
+			ranges = append(ranges, sets[name]...)
+		}
+	}
+	if len(ranges) == 0 {
+		err = fmt.Errorf("No CHARSET defined in the NEXUS partition file")
+	}
+	return
+}
+
+// nexusDefinition returns the name and the definition (after '=')
+// of a CHARSET or CHARPARTITION command.
+// '*' (default set), qualifiers in parentheses and quotes are ignored.
+func nexusDefinition(command string) (name, def string, err error) {
+	kv := strings.SplitN(command, "=", 2)
+	fields := strings.Fields(kv[0])
+	if len(kv) != 2 || len(fields) < 2 {
+		err = fmt.Errorf("Malformed NEXUS command: %s", strings.TrimSpace(command))
+		return
+	}
+	for _, f := range fields[1:] {
+		if f != "*" && !strings.HasPrefix(f, "(") {
+			name = strings.Trim(f, "'\"")
+		}
+	}
+	def = kv[1]
+	return
+}
+
+// splitOutsideBraces splits s around the separators that are not inside
+// braces (ex: IQ-TREE model parameters GTR{1,2,1,1,2}+F{0.2,0.3,0.3,0.2})
+func splitOutsideBraces(s string, sep rune) (pieces []string) {
+	var braces int
+	start := 0
+	pieces = make([]string, 0)
+	for i, c := range s {
+		switch {
+		case c == '{':
+			braces++
+		case c == '}':
+			braces--
+		case c == sep && braces == 0:
+			pieces = append(pieces, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(pieces, s[start:])
+}
+
+// WriteNexus returns the given PartitionSet as a NEXUS partition
+// file, as used by IQ-TREE: a CHARSET per partition, and a
+// CHARPARTITION giving the model of each CHARSET.
+func WriteNexus(ps *align.PartitionSet) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("#nexus\n")
+	buf.WriteString("begin sets;\n")
+	parts := make([]string, 0, ps.NPartitions())
+	for i := 0; i < ps.NPartitions(); i++ {
+		buf.WriteString(fmt.Sprintf("  charset %s = %s;\n", ps.PartitionName(i), rangesString(ps, i, " ")))
+		parts = append(parts, ps.ModeleName(i)+":"+ps.PartitionName(i))
+	}
+	buf.WriteString(fmt.Sprintf("  charpartition partitions = %s;\n", strings.Join(parts, ", ")))
+	buf.WriteString("end;\n")
+	return buf.String()
+}
@@ -1,21 +1,23 @@
 package partition
 
 import (
-	"errors"
 	"fmt"
 	"io"
 	"strconv"
 
 	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Parser represents a parser.
 type Parser struct {
 	s   *Scanner
 	buf struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
+		tok  Token  // last read token
+		lit  string // last read literal
+		line int    // line of the last read token
+		col  int    // column of the last read token
+		n    int    // buffer size (max=1)
 	}
 }
 
@@ -35,6 +37,7 @@ func (p *Parser) scan() (tok Token, lit string) {
 
 	// Otherwise read the next token from the scanner.
 	tok, lit = p.s.Scan()
+	p.buf.line, p.buf.col = p.s.Pos()
 
 	// Save it to the buffer in case we unscan later.
 	p.buf.tok, p.buf.lit = tok, lit
@@ -44,24 +47,40 @@ func (p *Parser) scan() (tok Token, lit string) {
 // unscan pushes the previously read token back onto the buffer.
 func (p *Parser) unscan() { p.buf.n = 1 }
 
-// Parse parses a partition file
+// errorf returns a parse error located at the last read token
+func (p *Parser) errorf(format string, a ...interface{}) error {
+	return alignio.NewParseError(p.buf.line, p.buf.col, p.buf.lit, fmt.Errorf(format, a...))
+}
+
+// Parse parses a partition file in RAxML/RAxML-NG format:
+//
+//	MODEL, name = 1-100/3, 101-200
+//
+// Strides may be given with '/' or '\\', and model names may contain
+// parameters between braces (ex: GTR{1/2/1/1/2/1}+FU{0.2/0.3/0.3/0.2}+G4).
+//
+// If alignmentLength <= 0, the length of the alignment is the last site
+// of the partitions.
 func (p *Parser) Parse(alignmentLength int) (partitionSet *align.PartitionSet, err error) {
-	partitionSet = align.NewPartitionSet(alignmentLength)
-	err = p.parse(partitionSet)
-	return
+	var ranges []partRange
+	if ranges, err = p.parse(); err != nil {
+		return
+	}
+	return build(ranges, alignmentLength)
 }
 
-func (p *Parser) parse(ps *align.PartitionSet) (err error) {
+func (p *Parser) parse() (ranges []partRange, err error) {
 	// The first token should be a ">"
 	tok, lit := p.scan()
 	if tok != IDENTIFIER {
-		err = errors.New("Partition should start with a Model name")
+		err = p.errorf("Partition should start with a Model name")
 		return
 	}
 	p.unscan()
 
 	var start, end, modulo int64
 	var modeleName, partitionName string
+	ranges = make([]partRange, 0)
 
 	for tok != EOF {
 		tok, lit = p.scan()
@@ -70,27 +89,27 @@ func (p *Parser) parse(ps *align.PartitionSet) (err error) {
 			modeleName = lit
 			tok, lit = p.scan()
 			if tok != SEPARATOR {
-				err = fmt.Errorf("Modele name should be followed by ',' : %s", modeleName)
+				err = p.errorf("Modele name should be followed by ',' : %s", modeleName)
 				return
 			}
 
 			tok, lit = p.scan()
 			partitionName = lit
 			if tok != IDENTIFIER {
-				err = fmt.Errorf("Modele name should be followed by ',' then the name of the partition: %s", lit)
+				err = p.errorf("Modele name should be followed by ',' then the name of the partition: %s", lit)
 				return
 			}
 
 			tok, lit = p.scan()
 			if tok != EQUAL {
-				err = fmt.Errorf("Partition name should be followed by '=' : [%s|%s]", partitionName, lit)
+				err = p.errorf("Partition name should be followed by '=' : [%s|%s]", partitionName, lit)
 				return
 			}
 			// Parse intervals
 			tok, lit = p.scan()
 			for tok != ENDOFLINE && tok != EOF {
 				if tok != DECIMAL {
-					err = fmt.Errorf("Interval definition should start with a number : [%s]", lit)
+					err = p.errorf("Interval definition should start with a number : [%s]", lit)
 					return
 				}
 				start, _ = strconv.ParseInt(lit, 10, 64)
@@ -99,7 +118,7 @@ func (p *Parser) parse(ps *align.PartitionSet) (err error) {
 				if tok == RANGE {
 					tok, lit = p.scan()
 					if tok != DECIMAL {
-						err = fmt.Errorf("Interval definition '-' should be followed by an integer value : [%s]", lit)
+						err = p.errorf("Interval definition '-' should be followed by an integer value : [%s]", lit)
 						return
 					}
 					end, _ = strconv.ParseInt(lit, 10, 64)
@@ -108,7 +127,7 @@ func (p *Parser) parse(ps *align.PartitionSet) (err error) {
 				if tok == MODULO {
 					tok, lit = p.scan()
 					if tok != DECIMAL {
-						err = fmt.Errorf("there should be an integer value after '/': [%s]", lit)
+						err = p.errorf("there should be an integer value after '/': [%s]", lit)
 						return
 					}
 					modulo, _ = strconv.ParseInt(lit, 10, 64)
@@ -117,14 +136,12 @@ func (p *Parser) parse(ps *align.PartitionSet) (err error) {
 					modulo = 1
 				}
 
-				if err = ps.AddRange(partitionName, modeleName, int(start)-1, int(end)-1, int(modulo)); err != nil {
-					return
-				}
+				ranges = append(ranges, partRange{partitionName, modeleName, int(start) - 1, int(end) - 1, int(modulo)})
 
 				if tok == SEPARATOR {
 					tok, lit = p.scan()
 				} else if tok != ENDOFLINE && tok != EOF {
-					err = fmt.Errorf("there should be a separator (or EOL or EOF) after interval definition : [%s]", lit)
+					err = p.errorf("there should be a separator (or EOL or EOF) after interval definition : [%s]", lit)
 					return
 				}
 			}
@@ -0,0 +1,114 @@
+package partition
+
+import (
+	"bufio"
+	"errors"
+	"strings"
+	"testing"
+
+	alignio "github.com/evolbioinfo/goalign/io"
+)
+
+func TestParse_Formats(t *testing.T) {
+	expected := "GTR+G,p1=1-4,10-11\nHKY,p2=5-9\n"
+	tests := []struct {
+		name   string
+		format int
+		input  string
+	}{
+		{"raxml", FORMAT_RAXML, "GTR+G, p1 = 1-4, 10-11\nHKY, p2 = 5-9\n"},
+		{"raxml braces", FORMAT_RAXML, "GTR{1/2/1/1/2/1}+FU{0.2/0.3/0.3/0.2}, p1 = 1-4, 10-11\nHKY, p2 = 5-9\n"},
+		{"nexus", FORMAT_NEXUS, `#nexus
+begin sets; [IQ-TREE]
+  charset p1 = 1-4 10-11;
+  charset p2 = 5-9;
+  charpartition mine = GTR+G:p1, HKY:p2;
+end;
+`},
+		{"partitionfinder", FORMAT_PARTITIONFINDER, `## ALIGNMENT FILE ##
+alignment = align.phy;
+models = all;
+
+[data_blocks]
+p1 = 1-4 10-11;
+p2 = 5-9;
+
+[schemes]
+search = greedy;
+`},
+	}
+
+	for _, test := range tests {
+		r := bufio.NewReader(strings.NewReader(test.input))
+		if format := DetectFormat(r); format != test.format {
+			t.Errorf("%s: detected format %d, expected %d", test.name, format, test.format)
+		}
+		ps, err := Parse(r, FORMAT_AUTO, 11, "HKY")
+		if err != nil {
+			t.Fatalf("%s: %v", test.name, err)
+		}
+		exp := expected
+		switch test.name {
+		case "raxml braces":
+			exp = strings.Replace(exp, "GTR+G", "GTR{1/2/1/1/2/1}+FU{0.2/0.3/0.3/0.2}", 1)
+		case "partitionfinder":
+			exp = strings.Replace(exp, "GTR+G", "HKY", 1)
+		}
+		if ps.String() != exp {
+			t.Errorf("%s: partitions are not as expected:\n%s\nvs.\n%s", test.name, ps.String(), exp)
+		}
+	}
+}
+
+func TestParse_Strides(t *testing.T) {
+	// Length inferred from the last site
+	ps, err := Parse(bufio.NewReader(strings.NewReader("DNA, c12 = 1-12\\3, 2-12/3\nDNA, c3 = 3-12\\3\n")), FORMAT_RAXML, 0, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps.AliLength() != 12 {
+		t.Errorf("Alignment length should be 12 (%d)", ps.AliLength())
+	}
+
+	expected := map[int]string{
+		FORMAT_RAXML:           "DNA, c12 = 1-2, 4-5, 7-8, 10-11\nDNA, c3 = 3-12\\3\n",
+		FORMAT_NEXUS:           "#nexus\nbegin sets;\n  charset c12 = 1-2 4-5 7-8 10-11;\n  charset c3 = 3-12\\3;\n  charpartition partitions = DNA:c12, DNA:c3;\nend;\n",
+		FORMAT_PARTITIONFINDER: "c12 = 1-2 4-5 7-8 10-11;\nc3 = 3-12\\3;\n",
+	}
+	for format, exp := range expected {
+		out, err := Write(ps, format, "align.phy")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(out, exp) {
+			t.Errorf("Output in format %d is not as expected:\n%s\nvs.\n%s", format, out, exp)
+		}
+		// Writing then parsing gives the same partitions
+		ps2, err := Parse(bufio.NewReader(strings.NewReader(out)), FORMAT_AUTO, 12, "DNA")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ps2.String() != ps.String() {
+			t.Errorf("Partitions in format %d are not the same after writing/parsing:\n%s\nvs.\n%s", format, ps2.String(), ps.String())
+		}
+	}
+
+	if _, err = Parse(bufio.NewReader(strings.NewReader("[data_blocks]\np1 = 1-.;\n")), FORMAT_AUTO, 0, "DNA"); err == nil {
+		t.Errorf("'.' without alignment length should produce an error")
+	}
+	if _, err = Parse(bufio.NewReader(strings.NewReader("DNA, p1 = 1-10\nDNA, p2 = 10-12\n")), FORMAT_AUTO, 12, "DNA"); err == nil {
+		t.Errorf("Overlapping partitions should produce an error")
+	}
+}
+
+func TestParse_ErrorPosition(t *testing.T) {
+	var pe *alignio.ParseError
+
+	_, err := NewParser(strings.NewReader("DNA, p1 = 1-10\nDNA, p2 = 11-20, x\n")).Parse(-1)
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 2 || pe.Column != 18 || pe.Token != "x" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+}
@@ -0,0 +1,96 @@
+package partition
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Section header of PartitionFinder configuration files
+var pfSection = regexp.MustCompile(`^\s*\[\s*(\w+)\s*\]`)
+
+// parsePartitionFinder parses the [data_blocks] section of a PartitionFinder
+// configuration file (.cfg):
+//
+//	[data_blocks]
+//	Gene1_pos1 = 1-789\3;
+//	Gene1_pos2 = 2-789\3 790-.;
+//
+// Data blocks are the partitions, with the given model (PartitionFinder
+// configuration files do not assign models to data blocks). Other sections
+// and '#' comments are skipped.
+func parsePartitionFinder(r io.Reader, model string) (ranges []partRange, err error) {
+	var blockranges []partRange
+	var blocks strings.Builder
+	var section string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		if m := pfSection.FindStringSubmatch(line); m != nil {
+			section = strings.ToLower(m[1])
+			continue
+		}
+		if section == "data_blocks" {
+			blocks.WriteString(line)
+			blocks.WriteString("\n")
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+
+	ranges = make([]partRange, 0)
+	for _, block := range strings.Split(blocks.String(), ";") {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		kv := strings.SplitN(block, "=", 2)
+		name := strings.TrimSpace(kv[0])
+		if len(kv) != 2 || name == "" {
+			return nil, fmt.Errorf("Malformed PartitionFinder data block: %s", strings.TrimSpace(block))
+		}
+		if blockranges, err = parseSpec(name, model, kv[1], nil); err != nil {
+			return
+		}
+		ranges = append(ranges, blockranges...)
+	}
+	if len(ranges) == 0 {
+		err = fmt.Errorf("No data block defined in the PartitionFinder file")
+	}
+	return
+}
+
+// WritePartitionFinder returns the given PartitionSet as a PartitionFinder
+// configuration file, with a data block per partition, and default options
+// (models of the partitions are not written). alignment is the name
+// of the alignment file.
+func WritePartitionFinder(ps *align.PartitionSet, alignment string) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("## ALIGNMENT FILE ##\n")
+	buf.WriteString(fmt.Sprintf("alignment = %s;\n\n", alignment))
+	buf.WriteString("## BRANCHLENGTHS: linked | unlinked ##\n")
+	buf.WriteString("branchlengths = linked;\n\n")
+	buf.WriteString("## MODELS OF EVOLUTION: all | allx | mrbayes | beast | gamma | gammai | <list> ##\n")
+	buf.WriteString("models = all;\n\n")
+	buf.WriteString("# MODEL SELECTION: AIC | AICc | BIC #\n")
+	buf.WriteString("model_selection = aicc;\n\n")
+	buf.WriteString("## DATA BLOCKS: see manual for how to define ##\n")
+	buf.WriteString("[data_blocks]\n")
+	for i := 0; i < ps.NPartitions(); i++ {
+		buf.WriteString(fmt.Sprintf("%s = %s;\n", ps.PartitionName(i), rangesString(ps, i, " ")))
+	}
+	buf.WriteString("\n## SCHEMES, search: all | user | greedy | rcluster | rclusterf | kmeans ##\n")
+	buf.WriteString("[schemes]\n")
+	buf.WriteString("search = greedy;\n")
+	return buf.String()
+}
@@ -10,7 +10,7 @@ const (
 	SEPARATOR        // field separator : ,
 	EQUAL            // Separator between model name and definition
 	RANGE            // When defining a range ex 1-500
-	MODULO           // Take one site every x sites: '/'
+	MODULO           // Take one site every x sites: '/' or '\\'
 	DECIMAL          // Decimal
 	ENDOFLINE        // End of line token
 	EOF              // End of File
@@ -21,7 +21,7 @@ func isEndOfLine(ch rune) bool {
 }
 
 func isWhiteSpace(ch rune) bool {
-	return ch == ' '
+	return ch == ' ' || ch == '\t'
 }
 
 func isCR(ch rune) bool {
@@ -33,5 +33,5 @@ func isNL(ch rune) bool {
 }
 
 func isIdent(ch rune) bool {
-	return ch != '\n' && ch != '\r' && ch != ',' && ch != '-' && ch != '/' && ch != '=' && ch != ' '
+	return ch != '\n' && ch != '\r' && ch != ',' && ch != '-' && ch != '/' && ch != '\\' && ch != '=' && !isWhiteSpace(ch)
 }
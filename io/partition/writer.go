@@ -0,0 +1,18 @@
+package partition
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// WriteRaxml returns the given PartitionSet as a RAxML/RAxML-NG
+// partition file: one line per partition, MODEL, name = 1-100\3, 101-200
+func WriteRaxml(ps *align.PartitionSet) string {
+	var buf bytes.Buffer
+	for i := 0; i < ps.NPartitions(); i++ {
+		buf.WriteString(fmt.Sprintf("%s, %s = %s\n", ps.ModeleName(i), ps.PartitionName(i), rangesString(ps, i, ", ")))
+	}
+	return buf.String()
+}
@@ -12,20 +12,27 @@ import (
 
 // Scanner represents a lexical scanner.
 type Scanner struct {
-	r *bufio.Reader
+	r         *bufio.Reader
+	pos       *alignio.Position
+	line, col int // Position of the last scanned token
 }
 
 // NewScanner returns a new instance of Scanner.
 func NewScanner(r io.Reader) *Scanner {
-	return &Scanner{r: bufio.NewReader(r)}
+	return &Scanner{r: bufio.NewReader(r), pos: alignio.NewPosition()}
 }
 
 // reads the next n runes from the bufferred reader.
+// They are considered as a token starting at the first rune (see Pos).
 func (s *Scanner) Read(n int) string {
 	var buf bytes.Buffer
 
 	for i := 0; i < 10; i++ {
 		ch, _, err := s.r.ReadRune()
+		s.pos.Read(ch, err != nil)
+		if i == 0 {
+			s.line, s.col = s.pos.Last()
+		}
 		buf.WriteRune(ch)
 		if err != nil {
 			buf.WriteRune(eof)
@@ -39,6 +46,7 @@ func (s *Scanner) Read(n int) string {
 // Returns the rune(0) if an error occurs (or io.EOF is returned).
 func (s *Scanner) read() rune {
 	ch, _, err := s.r.ReadRune()
+	s.pos.Read(ch, err != nil)
 	if err != nil {
 		return eof
 	}
@@ -48,12 +56,19 @@ func (s *Scanner) read() rune {
 // unread places the previously read rune back on the reader.
 func (s *Scanner) unread() {
 	_ = s.r.UnreadRune()
+	s.pos.Unread()
+}
+
+// Pos returns the line and column (1-based) of the last scanned token.
+func (s *Scanner) Pos() (line, col int) {
+	return s.line, s.col
 }
 
 // Scan returns the next token and literal value.
 func (s *Scanner) Scan() (tok Token, lit string) {
 	// Read the next rune.
 	ch := s.read()
+	s.line, s.col = s.pos.Last()
 
 	// If we see whitespace then consume all contiguous whitespace.
 	// If we see a letter then consume as an ident or reserved word.
@@ -2,13 +2,13 @@ package phylip
 
 import (
 	"bytes"
-	"errors"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
 
 	"github.com/evolbioinfo/goalign/align"
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 // Parser represents a parser.
@@ -17,9 +17,11 @@ type Parser struct {
 	strict          bool
 	ignoreidentical bool
 	buf             struct {
-		tok Token  // last read token
-		lit string // last read literal
-		n   int    // buffer size (max=1)
+		tok  Token  // last read token
+		lit  string // last read literal
+		line int    // line of the last read token
+		col  int    // column of the last read token
+		n    int    // buffer size (max=1)
 	}
 }
 
@@ -45,6 +47,7 @@ func (p *Parser) scan() (tok Token, lit string) {
 
 	// Otherwise read the next token from the scanner.
 	tok, lit = p.s.Scan()
+	p.buf.line, p.buf.col = p.s.Pos()
 
 	// Save it to the buffer in case we unscan later.
 	p.buf.tok, p.buf.lit = tok, lit
@@ -68,6 +71,11 @@ func (p *Parser) scanWithEOL() (tok Token, lit string) {
 // unscan pushes the previously read token back onto the buffer.
 func (p *Parser) unscan() { p.buf.n = 1 }
 
+// errorf returns a parse error located at the last read token
+func (p *Parser) errorf(format string, a ...interface{}) error {
+	return alignio.NewParseError(p.buf.line, p.buf.col, p.buf.lit, fmt.Errorf(format, a...))
+}
+
 // Parse parses a phylip alignment
 func (p *Parser) Parse() (align.Alignment, error) {
 	var nbseq int64 = 0
@@ -86,49 +94,50 @@ func (p *Parser) Parse() (align.Alignment, error) {
 
 	// The first token different from WS and EOL should be a Number
 	if tok != NUMERIC {
-		return nil, errors.New("Phylip file must begin with the number of sequences : " + fmt.Sprintf("%d", tok))
+		return nil, p.errorf("Phylip file must begin with the number of sequences : %d", tok)
 	} else {
 		nbseq, err = strconv.ParseInt(lit, 10, 64)
 		if err != nil {
-			return nil, errors.New("The numeric is not parsable: " + lit)
+			return nil, p.errorf("The numeric is not parsable: %s", lit)
 		}
 		if nbseq == 0 {
-			return nil, errors.New("No sequences in the alignment")
+			return nil, p.errorf("No sequences in the alignment")
 		}
 		if nbseq < 0 {
-			return nil, fmt.Errorf("Wrong number of sequences in the alignment: %d", nbseq)
+			return nil, p.errorf("Wrong number of sequences in the alignment: %d", nbseq)
 		}
 	}
 
 	names := make([]string, nbseq)
 	seqs := make([]*bytes.Buffer, nbseq)
+	starts := make([][2]int, nbseq) // Line and column of each sequence name
 
 	tok, lit = p.scan()
 	if tok != WS {
-		return nil, errors.New("There should be a whitespace between number of sequences and length")
+		return nil, p.errorf("There should be a whitespace between number of sequences and length")
 	}
 
 	// The second token, after a WS, should be a Number
 	tok, lit = p.scan()
 	if tok != NUMERIC {
-		return nil, errors.New("Phylip file must begin with the number of sequences and their length")
+		return nil, p.errorf("Phylip file must begin with the number of sequences and their length")
 	} else {
 		lenseq, err = strconv.ParseInt(lit, 10, 64)
 		if err != nil {
-			return nil, errors.New("The numeric is not parsable: " + lit)
+			return nil, p.errorf("The numeric is not parsable: %s", lit)
 		}
 		if lenseq == 0 {
-			return nil, errors.New("0 Length sequences defined in the header")
+			return nil, p.errorf("0 Length sequences defined in the header")
 		}
 		if nbseq < 0 {
-			return nil, fmt.Errorf("Wrong sequence length in the header: %d", lenseq)
+			return nil, p.errorf("Wrong sequence length in the header: %d", lenseq)
 		}
 	}
 
 	// Then a \n
 	tok, lit = p.scan()
 	if tok != ENDOFLINE {
-		return nil, errors.New("Bad Phylip format, \n missing after header")
+		return nil, p.errorf("Bad Phylip format, \n missing after header")
 	}
 
 	// Then names of the sequences and sequences
@@ -136,11 +145,13 @@ func (p *Parser) Parse() (align.Alignment, error) {
 		if p.strict {
 			// if strict
 			name := p.s.Read(10)
+			p.buf.line, p.buf.col = p.s.Pos()
+			p.buf.lit = strings.TrimRight(name, string(eof))
 			if []rune(name)[len(name)-1] == eof {
-				return nil, fmt.Errorf("Bad Phylip format, less sequences in the file than indicated in the header : %d vs. %d", nbseq, i)
+				return nil, p.errorf("Bad Phylip format, less sequences in the file than indicated in the header : %d vs. %d", nbseq, i)
 			}
 			if name == "" {
-				return nil, fmt.Errorf("Bad Phylip format, we should have an sequence identifier after the header : %s", lit)
+				return nil, p.errorf("Bad Phylip format, we should have an sequence identifier after the header : %s", lit)
 			}
 			// We remove spaces from names...
 			name = strings.Replace(name, " ", "", -1)
@@ -148,13 +159,14 @@ func (p *Parser) Parse() (align.Alignment, error) {
 		} else {
 			tok, lit = p.scan()
 			if tok == EOF {
-				return nil, fmt.Errorf("Bad Phylip format, less sequences in the file than indicated in the header : %d vs. %d", nbseq, i)
+				return nil, p.errorf("Bad Phylip format, less sequences in the file than indicated in the header : %d vs. %d", nbseq, i)
 			}
 			if tok != IDENTIFIER && tok != NUMERIC {
-				return nil, fmt.Errorf("Bad Phylip format, we should have an sequence identifier after the header : %s", lit)
+				return nil, p.errorf("Bad Phylip format, we should have an sequence identifier after the header : %s", lit)
 			}
 			names[i] = lit
 		}
+		starts[i] = [2]int{p.buf.line, p.buf.col}
 
 		tok, lit = p.scan()
 		seqs[i] = new(bytes.Buffer)
@@ -164,7 +176,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 				seqs[i].WriteString(lit)
 			case WS:
 			default:
-				return nil, errors.New("Bad Phylip format, Unexpected character :" + lit)
+				return nil, p.errorf("Bad Phylip format, Unexpected character :%s", lit)
 			}
 			tok, lit = p.scan()
 		}
@@ -176,7 +188,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 		tok, lit = p.scan()
 		p.unscan()
 	} else if int(lenseq) != seqs[0].Len() {
-		return nil, errors.New("Bad Phylip Format : Should have a blank line here")
+		return nil, p.errorf("Bad Phylip Format : Should have a blank line here")
 	}
 	// All sequences are completely parsed
 	// If there are several alignments in the file, we should
@@ -198,7 +210,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 			if tok != IDENTIFIER {
 				// fmt.Println("Block: ")
 				// fmt.Println(b)
-				return nil, errors.New("Bad Phylip format, we should have a sequence block here")
+				return nil, p.errorf("Bad Phylip format, we should have a sequence block here")
 			}
 			for tok != ENDOFLINE {
 				switch tok {
@@ -206,7 +218,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 					seqs[i].WriteString(lit)
 				case WS:
 				default:
-					return nil, errors.New("Bad Phylip format, Unexpected character :" + lit)
+					return nil, p.errorf("Bad Phylip format, Unexpected character :%s", lit)
 				}
 				tok, lit = p.scan()
 			}
@@ -216,7 +228,7 @@ func (p *Parser) Parse() (align.Alignment, error) {
 			tok, lit = p.scan()
 			p.unscan()
 		} else if int(lenseq) != seqs[0].Len() {
-			return nil, errors.New("Bad Phylip Format : Should have a blank line here")
+			return nil, p.errorf("Bad Phylip Format : Should have a blank line here")
 		}
 		b++
 	}
@@ -228,7 +240,8 @@ func (p *Parser) Parse() (align.Alignment, error) {
 	for i, name := range names {
 		seq := seqs[i].String()
 		if int(lenseq) != len(seq) {
-			return nil, errors.New("Bad Phylip format : Length of sequences does not correspond to header")
+			return nil, alignio.NewParseError(starts[i][0], starts[i][1], name,
+				fmt.Errorf("Bad Phylip format : Length of sequence %s (%d) does not correspond to header (%d)", name, len(seq), lenseq))
 		}
 		al.AddSequence(name, seq, "")
 	}
@@ -1,9 +1,12 @@
 package phylip
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
+
+	alignio "github.com/evolbioinfo/goalign/io"
 )
 
 var phylipstring1 string = "2 20\nseq1 ACGATCGATC\nseq2 AGCTCGTCGA\n\nACGCTAGCTA\nGATCGCTAGC\n"
@@ -106,3 +109,41 @@ func TestParseStrict(t *testing.T) {
 	// 	t.Error("Alignment has not 1000 sequences : " + fmt.Sprintf("%d", align5.NbSequences()))
 	// }
 }
+
+func TestParse_ErrorPosition(t *testing.T) {
+	var pe *alignio.ParseError
+
+	_, err := NewParser(strings.NewReader("3 10\nseq1 ACGATCGATC\nseq2 AGCTCGTCGA\n"), false).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 4 || pe.Column != 1 {
+		t.Errorf("Wrong error position: %d:%d", pe.Line, pe.Column)
+	}
+
+	_, err = NewParser(strings.NewReader("2 x10\nseq1 ACGATCGATC\nseq2 AGCTCGTCGA\n"), false).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 1 || pe.Column != 3 || pe.Token != "x10" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+
+	// Strict mode: located at the missing sequence name
+	_, err = NewParser(strings.NewReader("3 10\nseq1      ACGATCGATC\nseq2      AGCTCGTCGA\n"), true).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 4 || pe.Column != 1 || pe.Token != "" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+
+	// Sequence shorter than the header: located at its name
+	_, err = NewParser(strings.NewReader("2 10\nseq1 ACGATCGATC\nseq2 AGCTCGTCG\n"), false).Parse()
+	if !errors.As(err, &pe) {
+		t.Fatalf("Parse error expected, got %v", err)
+	}
+	if pe.Line != 3 || pe.Column != 1 || pe.Token != "seq2" {
+		t.Errorf("Wrong error position: %d:%d %q", pe.Line, pe.Column, pe.Token)
+	}
+}
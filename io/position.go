@@ -0,0 +1,38 @@
+package io
+
+// Position tracks the position (1-based line and column) of the
+// runes read by a scanner, in order to report parse errors.
+type Position struct {
+	line, col         int // Position of the next rune to read
+	lastline, lastcol int // Position of the last read rune
+}
+
+// NewPosition returns a Position at the beginning of the input
+func NewPosition() *Position {
+	return &Position{line: 1, col: 1, lastline: 1, lastcol: 1}
+}
+
+// Read updates the position after reading the rune ch. If eof is
+// true, the end of the input has been reached and ch is ignored.
+func (p *Position) Read(ch rune, eof bool) {
+	p.lastline, p.lastcol = p.line, p.col
+	if eof {
+		return
+	}
+	if ch == '\n' {
+		p.line++
+		p.col = 1
+	} else {
+		p.col++
+	}
+}
+
+// Unread updates the position after unreading the last read rune
+func (p *Position) Unread() {
+	p.line, p.col = p.lastline, p.lastcol
+}
+
+// Last returns the line and column of the last read rune
+func (p *Position) Last() (line, col int) {
+	return p.lastline, p.lastcol
+}
@@ -0,0 +1,243 @@
+package stockholm
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Parser represents a parser.
+type Parser struct {
+	s               *Scanner
+	ignoreidentical bool
+	buf             struct {
+		tok Token  // last read token
+		lit string // last read literal
+		n   int    // buffer size (max=1)
+	}
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{s: NewScanner(r), ignoreidentical: false}
+}
+
+// If sets to true, then will ignore duplicate sequences that have the same name and the same sequence
+// Otherwise, it just renames them just as the sequences that have same name and different sequences
+func (p *Parser) IgnoreIdentical(ignore bool) {
+	p.ignoreidentical = ignore
+}
+
+// scan returns the next token from the underlying scanner.
+// If a token has been unscanned then read that instead.
+func (p *Parser) scan() (tok Token, lit string) {
+	// If we have a token on the buffer, then return it.
+	if p.buf.n != 0 {
+		p.buf.n = 0
+		return p.buf.tok, p.buf.lit
+	}
+
+	// Otherwise read the next token from the scanner.
+	tok, lit = p.s.Scan()
+
+	// Save it to the buffer in case we unscan later.
+	p.buf.tok, p.buf.lit = tok, lit
+	return
+}
+
+// unscan pushes the previously read token back onto the buffer.
+func (p *Parser) unscan() { p.buf.n = 1 }
+
+// scanIgnoreWhitespace scans the next non-whitespace token.
+func (p *Parser) scanIgnoreWhitespace() (tok Token, lit string) {
+	tok, lit = p.scan()
+	if tok == WS {
+		tok, lit = p.scan()
+	}
+	return
+}
+
+// scanRestOfLine returns the remaining of the current line
+// (without leading and trailing spaces), and consumes the end of line
+func (p *Parser) scanRestOfLine() string {
+	var buf bytes.Buffer
+	tok, lit := p.scan()
+	for tok != ENDOFLINE && tok != EOF {
+		buf.WriteString(lit)
+		tok, lit = p.scan()
+	}
+	if tok == EOF {
+		p.unscan()
+	}
+	return strings.TrimSpace(buf.String())
+}
+
+// scanWord scans the next non-whitespace word of the current line.
+// Returns an error if the line ends before.
+func (p *Parser) scanWord(what string) (string, error) {
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok == ENDOFLINE || tok == EOF {
+		return "", fmt.Errorf("Stockholm: %s is missing", what)
+	}
+	return lit, nil
+}
+
+// scanEndOfLine checks that nothing else than whitespaces remain on the current line
+func (p *Parser) scanEndOfLine(what string) error {
+	tok, lit := p.scanIgnoreWhitespace()
+	if tok == EOF {
+		p.unscan()
+		return nil
+	}
+	if tok != ENDOFLINE {
+		return fmt.Errorf("Stockholm: unexpected token '%s' after %s", lit, what)
+	}
+	return nil
+}
+
+// Parse parses a stockholm alignment.
+//
+// Sequences and annotations split in several blocks are concatenated.
+// '.' characters in sequences are considered as gaps.
+// #=GF, #=GC, #=GS and #=GR lines are stored as alignment Annotations.
+//
+// If the input is empty, returns nil,nil.
+func (p *Parser) Parse() (al align.Alignment, err error) {
+	var names []string = make([]string, 0)
+	var seqs map[string]*bytes.Buffer = make(map[string]*bytes.Buffer)
+	var annotations *align.Annotations = align.NewAnnotations()
+	var name, feature, text, seq string
+
+	// We skip all WS and EOL at the beginning
+	tok, lit := p.scan()
+	for tok == WS || tok == ENDOFLINE {
+		tok, lit = p.scan()
+	}
+	if tok == EOF {
+		return nil, nil
+	}
+
+	if tok != COMMENT || lit != "#" {
+		return nil, errors.New("Stockholm file must start with '# STOCKHOLM 1.0'")
+	}
+	if tok, lit = p.scanIgnoreWhitespace(); tok != IDENTIFIER || lit != "STOCKHOLM" {
+		return nil, errors.New("Stockholm file must start with '# STOCKHOLM 1.0'")
+	}
+	p.scanRestOfLine()
+
+	for {
+		tok, lit = p.scanIgnoreWhitespace()
+		switch tok {
+		case ENDOFLINE:
+			continue
+		case EOF:
+			return nil, errors.New("Stockholm alignment must end with '//'")
+		case COMMENT:
+			p.scanRestOfLine()
+		case GF:
+			if feature, err = p.scanWord("#=GF feature"); err != nil {
+				return
+			}
+			annotations.AddFileAnnotation(feature, p.scanRestOfLine())
+		case GS:
+			if name, err = p.scanWord("#=GS sequence name"); err != nil {
+				return
+			}
+			if feature, err = p.scanWord("#=GS feature"); err != nil {
+				return
+			}
+			annotations.AddSequenceAnnotation(name, feature, p.scanRestOfLine())
+		case GC:
+			if feature, err = p.scanWord("#=GC feature"); err != nil {
+				return
+			}
+			if text, err = p.scanWord("#=GC annotation"); err != nil {
+				return
+			}
+			if err = p.scanEndOfLine("#=GC annotation"); err != nil {
+				return
+			}
+			annotations.AddColumnAnnotation(feature, []rune(text))
+		case GR:
+			if name, err = p.scanWord("#=GR sequence name"); err != nil {
+				return
+			}
+			if feature, err = p.scanWord("#=GR feature"); err != nil {
+				return
+			}
+			if text, err = p.scanWord("#=GR annotation"); err != nil {
+				return
+			}
+			if err = p.scanEndOfLine("#=GR annotation"); err != nil {
+				return
+			}
+			annotations.AddResidueAnnotation(name, feature, []rune(text))
+		case IDENTIFIER:
+			name = lit
+			if seq, err = p.scanWord(fmt.Sprintf("Sequence of %s", name)); err != nil {
+				return
+			}
+			if err = p.scanEndOfLine(fmt.Sprintf("sequence of %s", name)); err != nil {
+				return
+			}
+			b, ok := seqs[name]
+			if !ok {
+				b = new(bytes.Buffer)
+				seqs[name] = b
+				names = append(names, name)
+			}
+			b.WriteString(strings.Replace(seq, string(align.POINT), string(align.GAP), -1))
+		case END:
+			p.scanRestOfLine()
+			return p.buildAlignment(names, seqs, annotations)
+		default:
+			return nil, fmt.Errorf("Stockholm: unexpected token '%s'", lit)
+		}
+	}
+}
+
+// ParseMultiple parses all the stockholm alignments of the input
+// (ex: Pfam files contain several alignments) and sends them to the
+// given AlignChannel. At the end, Achan is closed and Err contains
+// the parsing error, if any.
+func (p *Parser) ParseMultiple(aligns *align.AlignChannel) {
+	var al align.Alignment
+	var err error
+	al, err = p.Parse()
+	for err == nil && al != nil {
+		aligns.Achan <- al
+		al, err = p.Parse()
+	}
+	aligns.Err = err
+
+	close(aligns.Achan)
+	return
+}
+
+func (p *Parser) buildAlignment(names []string, seqs map[string]*bytes.Buffer, annotations *align.Annotations) (al align.Alignment, err error) {
+	if len(names) == 0 {
+		return nil, errors.New("No sequences in the alignment")
+	}
+
+	for _, n := range names {
+		s := seqs[n].String()
+		if al == nil {
+			al = align.NewAlign(align.DetectAlphabet(s))
+			al.IgnoreIdentical(p.ignoreidentical)
+		}
+		if err = al.AddSequence(n, s, ""); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = annotations.CheckLength(al.Length()); err != nil {
+		return nil, err
+	}
+	al.SetAnnotations(annotations)
+
+	return al, nil
+}
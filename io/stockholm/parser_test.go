@@ -0,0 +1,167 @@
+package stockholm
+
+import (
+	"strings"
+	"testing"
+)
+
+// Example adapted from the Stockholm format specification
+// Two blocks, several annotations
+var stockholmstring1 string = `# STOCKHOLM 1.0
+#=GF ID    UPSK
+#=GF SE    Predicted; Infernal
+#=GF SS    Published; PMID 9223489
+#=GF CC    This is a comment
+#=GF CC    on two lines
+
+#=GS 1     DE first sequence
+#=GS 2     DE second sequence
+
+1          ACGACGACGACG.
+#=GR 1 SS  <<<...>>>....
+2          ..GGGAAAUCC..
+#=GC SS_cons <<<<...>>>>..
+#=GC RF    xxxxxxxxxxxx.
+
+1          ACGACGACGACG
+#=GR 1 SS  <<<...>>>...
+2          ..GGGAAAUCC.
+#=GC SS_cons <<<<...>>>>.
+#=GC RF    xxxxxxxxxxxx
+//
+`
+
+// Two alignments
+var stockholmstring2 string = `# STOCKHOLM 1.0
+s1 ACGT
+s2 AC-T
+//
+# STOCKHOLM 1.0
+s1 ACG
+s2 A-G
+s3 AAG
+//
+`
+
+// Annotation with a wrong length
+var stockholmstring3 string = `# STOCKHOLM 1.0
+s1 ACGT
+s2 AC-T
+#=GC SS_cons ...
+//
+`
+
+// Missing end of alignment
+var stockholmstring4 string = `# STOCKHOLM 1.0
+s1 ACGT
+s2 AC-T
+`
+
+// Missing header
+var stockholmstring5 string = `s1 ACGT
+s2 AC-T
+//
+`
+
+func TestParse(t *testing.T) {
+	al, err := NewParser(strings.NewReader(stockholmstring1)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if al.Length() != 25 {
+		t.Errorf("Alignment length is not 25 (%d)", al.Length())
+	}
+	if al.NbSequences() != 2 {
+		t.Errorf("There are not 2 sequences in the alignment (%d)", al.NbSequences())
+	}
+	if s, _ := al.GetSequence("2"); s != "--GGGAAAUCC----GGGAAAUCC-" {
+		t.Errorf("Sequence 2 is not as expected: %s", s)
+	}
+	ss, ok := al.Annotations().ColumnAnnotation("SS_cons")
+	if !ok {
+		t.Fatalf("SS_cons annotation not found")
+	}
+	if string(ss) != "<<<<...>>>>..<<<<...>>>>." {
+		t.Errorf("SS_cons annotation is not as expected: %s", string(ss))
+	}
+	nbcc := 0
+	al.Annotations().IterateFile(func(feature, text string) bool {
+		if feature == "CC" {
+			nbcc++
+		}
+		return false
+	})
+	if nbcc != 2 {
+		t.Errorf("There should be 2 CC annotations (%d)", nbcc)
+	}
+}
+
+func TestParseMultiple(t *testing.T) {
+	p := NewParser(strings.NewReader(stockholmstring2))
+	al, err := p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if al.NbSequences() != 2 || al.Length() != 4 {
+		t.Errorf("First alignment does not have the expected dimensions (%d,%d)", al.NbSequences(), al.Length())
+	}
+	al, err = p.Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if al.NbSequences() != 3 || al.Length() != 3 {
+		t.Errorf("Second alignment does not have the expected dimensions (%d,%d)", al.NbSequences(), al.Length())
+	}
+	al, err = p.Parse()
+	if err != nil || al != nil {
+		t.Errorf("There should not be a third alignment")
+	}
+}
+
+func TestParseError(t *testing.T) {
+	for i, s := range []string{stockholmstring3, stockholmstring4, stockholmstring5} {
+		if _, err := NewParser(strings.NewReader(s)).Parse(); err == nil {
+			t.Errorf("There should be an error while reading alignment %d", i)
+		}
+	}
+}
+
+func TestWriteAlignment(t *testing.T) {
+	al, err := NewParser(strings.NewReader(stockholmstring1)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sub, err := al.SubAlign(2, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# STOCKHOLM 1.0
+#=GF ID UPSK
+#=GF SE Predicted; Infernal
+#=GF SS Published; PMID 9223489
+#=GF CC This is a comment
+#=GF CC on two lines
+
+#=GS 1 DE first sequence
+#=GS 2 DE second sequence
+
+1            GACGACGAC
+#=GR 1 SS    <...>>>..
+2            GGGAAAUCC
+#=GC SS_cons <<...>>>>
+#=GC RF      xxxxxxxxx
+//
+`
+	if out := WriteAlignment(sub); out != expected {
+		t.Errorf("Written alignment is not as expected:\n%s\nvs.\n%s", out, expected)
+	}
+
+	al2, err := NewParser(strings.NewReader(WriteAlignment(al))).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !al2.Identical(al) {
+		t.Errorf("Alignment should be identical after write and read")
+	}
+}
@@ -0,0 +1,39 @@
+package stockholm
+
+type Token int64
+
+var eof = rune(0)
+
+const (
+	ILLEGAL    Token = iota
+	IDENTIFIER       // Sequence name, sequence, feature or annotation
+	ENDOFLINE        // End of line token
+	COMMENT          // Any word starting with '#' that is not a markup: Header "# STOCKHOLM 1.0" or comment
+	GF               // "#=GF" : Per file annotation
+	GC               // "#=GC" : Per column annotation
+	GS               // "#=GS" : Per sequence annotation
+	GR               // "#=GR" : Per residue annotation
+	END              // End of alignment: "//"
+	EOF              // End of File
+	WS               // Whitespace
+)
+
+func isEndOfLine(ch rune) bool {
+	return ch == '\n' || ch == '\r'
+}
+
+func isCR(ch rune) bool {
+	return ch == '\r'
+}
+
+func isNL(ch rune) bool {
+	return ch == '\n'
+}
+
+func isIdent(ch rune) bool {
+	return !isWhitespace(ch) && !isEndOfLine(ch)
+}
+
+func isWhitespace(ch rune) bool {
+	return ch == ' ' || ch == '\t'
+}
@@ -0,0 +1,105 @@
+package stockholm
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// WriteAlignment writes the alignment in Stockholm format, in a single block.
+//
+// Annotations attached to the alignment are written as #=GF, #=GS, #=GR and #=GC lines.
+// Per-sequence and per-residue annotations of sequences that are not in the
+// alignment, as well as per-column and per-residue annotations that do not have
+// the same length as the alignment, are not written.
+func WriteAlignment(al align.Alignment) string {
+	var buf bytes.Buffer
+	an := al.Annotations()
+
+	// Get the length of the longest sequence or markup name
+	maxnamelength := 0
+	names := make(map[string]bool)
+	al.IterateBytes(func(name string, seq []byte) bool {
+		maxnamelength = max_int(maxnamelength, len(name))
+		names[name] = true
+		return false
+	})
+	an.IterateResidues(func(name, feature string, annot []rune) bool {
+		if names[name] && len(annot) == al.Length() {
+			maxnamelength = max_int(maxnamelength, len("#=GR ")+len(name)+1+len(feature))
+		}
+		return false
+	})
+	an.IterateColumns(func(feature string, annot []rune) bool {
+		if len(annot) == al.Length() {
+			maxnamelength = max_int(maxnamelength, len("#=GC ")+len(feature))
+		}
+		return false
+	})
+
+	buf.WriteString("# STOCKHOLM 1.0\n")
+
+	nbgf := 0
+	an.IterateFile(func(feature, text string) bool {
+		buf.WriteString(fmt.Sprintf("#=GF %s %s\n", feature, text))
+		nbgf++
+		return false
+	})
+	if nbgf > 0 {
+		buf.WriteRune('\n')
+	}
+
+	nbgs := 0
+	an.IterateSequences(func(name, feature, text string) bool {
+		if names[name] {
+			buf.WriteString(fmt.Sprintf("#=GS %s %s %s\n", name, feature, text))
+			nbgs++
+		}
+		return false
+	})
+	if nbgs > 0 {
+		buf.WriteRune('\n')
+	}
+
+	al.IterateBytes(func(name string, seq []byte) bool {
+		writePadded(&buf, name, maxnamelength)
+		buf.Write(seq)
+		buf.WriteRune('\n')
+		an.IterateResidues(func(rname, feature string, annot []rune) bool {
+			if rname == name && len(annot) == al.Length() {
+				writePadded(&buf, fmt.Sprintf("#=GR %s %s", rname, feature), maxnamelength)
+				buf.WriteString(string(annot))
+				buf.WriteRune('\n')
+			}
+			return false
+		})
+		return false
+	})
+
+	an.IterateColumns(func(feature string, annot []rune) bool {
+		if len(annot) == al.Length() {
+			writePadded(&buf, fmt.Sprintf("#=GC %s", feature), maxnamelength)
+			buf.WriteString(string(annot))
+			buf.WriteRune('\n')
+		}
+		return false
+	})
+
+	buf.WriteString("//\n")
+	return buf.String()
+}
+
+func writePadded(buf *bytes.Buffer, name string, length int) {
+	buf.WriteString(name)
+	for i := len(name); i < length+1; i++ {
+		buf.WriteRune(' ')
+	}
+}
+
+func max_int(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
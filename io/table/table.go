@@ -0,0 +1,146 @@
+package table
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Table output formats
+const (
+	FORMAT_TSV = iota
+	FORMAT_CSV
+	FORMAT_JSON
+)
+
+// Table is a list of records (rows), having the same named fields (columns).
+// Values may be strings, integers or floats.
+type Table struct {
+	Columns []string
+	Rows    [][]interface{}
+}
+
+// FormatFromString returns the table format corresponding to the
+// given name: tsv, csv or json
+func FormatFromString(format string) (int, error) {
+	switch strings.ToLower(format) {
+	case "tsv":
+		return FORMAT_TSV, nil
+	case "csv":
+		return FORMAT_CSV, nil
+	case "json":
+		return FORMAT_JSON, nil
+	default:
+		return -1, fmt.Errorf("Unknown output format: %s", format)
+	}
+}
+
+// NewTable returns an empty table having the given columns
+func NewTable(columns ...string) *Table {
+	return &Table{
+		Columns: columns,
+		Rows:    make([][]interface{}, 0),
+	}
+}
+
+// AddRow adds a row to the table. There must be one value per column.
+func (t *Table) AddRow(values ...interface{}) error {
+	if len(values) != len(t.Columns) {
+		return fmt.Errorf("Row has %d values, but the table has %d columns", len(values), len(t.Columns))
+	}
+	t.Rows = append(t.Rows, values)
+	return nil
+}
+
+// Write writes the table in the given format:
+//   - FORMAT_TSV: a header line with the column names, and one tab separated line per row
+//   - FORMAT_CSV: same as FORMAT_TSV, comma separated (RFC 4180)
+//   - FORMAT_JSON: an array of objects, one per row, whose keys are the column
+//     names, in the order of the columns (NaN and infinite values are written as null)
+func (t *Table) Write(w io.Writer, format int) (err error) {
+	switch format {
+	case FORMAT_TSV:
+		return t.writeSeparated(w, '\t')
+	case FORMAT_CSV:
+		return t.writeSeparated(w, ',')
+	case FORMAT_JSON:
+		return t.writeJSON(w)
+	default:
+		return fmt.Errorf("Unknown output format: %d", format)
+	}
+}
+
+func (t *Table) writeSeparated(w io.Writer, sep rune) (err error) {
+	cw := csv.NewWriter(w)
+	cw.Comma = sep
+	if err = cw.Write(t.Columns); err != nil {
+		return
+	}
+	record := make([]string, len(t.Columns))
+	for _, row := range t.Rows {
+		for i, v := range row {
+			record[i] = valueString(v)
+		}
+		if err = cw.Write(record); err != nil {
+			return
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (t *Table) writeJSON(w io.Writer) (err error) {
+	var b []byte
+	bw := bufio.NewWriter(w)
+
+	bw.WriteString("[")
+	for r, row := range t.Rows {
+		if r > 0 {
+			bw.WriteString(",")
+		}
+		bw.WriteString("\n  {")
+		for i, v := range row {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+			if b, err = json.Marshal(t.Columns[i]); err != nil {
+				return
+			}
+			bw.Write(b)
+			bw.WriteString(": ")
+			if f, ok := v.(float64); ok && (math.IsNaN(f) || math.IsInf(f, 0)) {
+				v = nil
+			}
+			if b, err = json.Marshal(v); err != nil {
+				return
+			}
+			bw.Write(b)
+		}
+		bw.WriteString("}")
+	}
+	if len(t.Rows) > 0 {
+		bw.WriteString("\n")
+	}
+	bw.WriteString("]\n")
+	return bw.Flush()
+}
+
+// valueString returns the string representation of the given value
+// (floats are written with the smallest number of digits necessary)
+func valueString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,58 @@
+package table
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func testTable() *Table {
+	t := NewTable("alignment", "name", "value")
+	t.AddRow(0, "seq 1", 0.5)
+	t.AddRow(1, "seq,2", math.NaN())
+	return t
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"tsv", "alignment\tname\tvalue\n0\tseq 1\t0.5\n1\tseq,2\tNaN\n"},
+		{"csv", "alignment,name,value\n0,seq 1,0.5\n1,\"seq,2\",NaN\n"},
+		{"json", "[\n  {\"alignment\": 0, \"name\": \"seq 1\", \"value\": 0.5},\n  {\"alignment\": 1, \"name\": \"seq,2\", \"value\": null}\n]\n"},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		format, err := FormatFromString(test.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = testTable().Write(&buf, format); err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != test.expected {
+			t.Errorf("%s: expected %q, got %q", test.format, test.expected, buf.String())
+		}
+	}
+}
+
+func TestWrite_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := NewTable("a", "b").Write(&buf, FORMAT_JSON); err != nil {
+		t.Fatal(err)
+	}
+	if buf.String() != "[]\n" {
+		t.Errorf("Expected an empty json array, got %q", buf.String())
+	}
+}
+
+func TestAddRow(t *testing.T) {
+	if err := NewTable("a", "b").AddRow(1); err == nil {
+		t.Errorf("An error was expected when the number of values is not the number of columns")
+	}
+	if _, err := FormatFromString("xml"); err == nil {
+		t.Errorf("An error was expected with an unknown format")
+	}
+}
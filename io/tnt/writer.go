@@ -0,0 +1,23 @@
+package tnt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// WriteAlignment writes the alignment as TNT input data (xread)
+func WriteAlignment(al align.Alignment) string {
+	var buf bytes.Buffer
+
+	buf.WriteString("xread\n\n")
+	buf.WriteString("'Tnt input file'\n\n")
+	buf.WriteString(fmt.Sprintf("%d %d\n", al.Length(), al.NbSequences()))
+	al.Iterate(func(name string, sequence string) bool {
+		buf.WriteString(fmt.Sprintf("%s %s\n", name, sequence))
+		return false
+	})
+	buf.WriteString(";\n")
+	return buf.String()
+}
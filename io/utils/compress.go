@@ -0,0 +1,163 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	dbzip2 "github.com/dsnet/compress/bzip2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// Compression formats
+const (
+	COMPRESSION_NONE  = ""
+	COMPRESSION_GZIP  = "gzip"
+	COMPRESSION_BZIP2 = "bzip2"
+	COMPRESSION_XZ    = "xz"
+	COMPRESSION_ZSTD  = "zstd"
+)
+
+// Magic bytes and file extensions of compression formats
+var compressionFormats = []struct {
+	name      string
+	magic     []byte
+	extension string
+}{
+	{COMPRESSION_GZIP, []byte{0x1f, 0x8b}, ".gz"},
+	{COMPRESSION_BZIP2, []byte("BZh"), ".bz2"},
+	{COMPRESSION_XZ, []byte{0xfd, '7', 'z', 'X', 'Z', 0x00}, ".xz"},
+	{COMPRESSION_ZSTD, []byte{0x28, 0xb5, 0x2f, 0xfd}, ".zst"},
+}
+
+// Compression returns the name of the compression format of the input
+// (gzip, bzip2, xz, zstd), given its magic bytes, or "" if it is not
+// compressed. It does not consume the reader.
+func Compression(r *bufio.Reader) string {
+	b, _ := r.Peek(6)
+	for _, c := range compressionFormats {
+		if !bytes.HasPrefix(b, c.magic) {
+			continue
+		}
+		// bzip2 magic is followed by the block size ('1' to '9')
+		if c.name == COMPRESSION_BZIP2 && (len(b) < 4 || b[3] < '1' || b[3] > '9') {
+			continue
+		}
+		return c.name
+	}
+	return COMPRESSION_NONE
+}
+
+// CompressionFromString returns the compression format corresponding to
+// the given name: gzip (or gz), bzip2 (or bz2), xz, zstd (or zst), and
+// none (or empty string) for no compression.
+func CompressionFromString(name string) (string, error) {
+	switch strings.ToLower(name) {
+	case "", "none":
+		return COMPRESSION_NONE, nil
+	case "gzip", "gz":
+		return COMPRESSION_GZIP, nil
+	case "bzip2", "bz2":
+		return COMPRESSION_BZIP2, nil
+	case "xz":
+		return COMPRESSION_XZ, nil
+	case "zstd", "zst":
+		return COMPRESSION_ZSTD, nil
+	default:
+		return COMPRESSION_NONE, fmt.Errorf("Unknown compression format: %s", name)
+	}
+}
+
+// CompressionFromExtension returns the compression format corresponding
+// to the extension of the given file name (.gz, .bz2, .xz, .zst),
+// or "" if the extension is not a compression extension.
+func CompressionFromExtension(file string) string {
+	for _, c := range compressionFormats {
+		if strings.HasSuffix(file, c.extension) {
+			return c.name
+		}
+	}
+	return COMPRESSION_NONE
+}
+
+// decompress returns a buffered reader decompressing the given reader,
+// if its first bytes are the magic bytes of a compression format.
+// Otherwise returns the reader itself.
+func decompress(r *bufio.Reader) (*bufio.Reader, error) {
+	var dr io.Reader
+	var err error
+
+	switch Compression(r) {
+	case COMPRESSION_GZIP:
+		dr, err = gzip.NewReader(r)
+	case COMPRESSION_BZIP2:
+		dr = bzip2.NewReader(r)
+	case COMPRESSION_XZ:
+		dr, err = xz.NewReader(r)
+	case COMPRESSION_ZSTD:
+		// Synchronous decoding: no go routine to release
+		dr, err = zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	default:
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return bufio.NewReaderSize(dr, SNIFF_SIZE), nil
+}
+
+// CompressedWriter compresses everything written to it into an
+// underlying writer.
+type CompressedWriter struct {
+	*bufio.Writer
+	compressor io.WriteCloser
+	file       io.Writer
+}
+
+// NewCompressedWriter returns a writer compressing its input into
+// file, in the given compression format (gzip, bzip2, xz or zstd).
+//
+// Closing the CompressedWriter flushes and terminates the compressed
+// stream, and then closes file if it is an io.Closer (except os.Stdout).
+func NewCompressedWriter(file io.Writer, compression string) (cw *CompressedWriter, err error) {
+	var compressor io.WriteCloser
+
+	switch compression {
+	case COMPRESSION_GZIP:
+		compressor = gzip.NewWriter(file)
+	case COMPRESSION_BZIP2:
+		compressor, err = dbzip2.NewWriter(file, nil)
+	case COMPRESSION_XZ:
+		compressor, err = xz.NewWriter(file)
+	case COMPRESSION_ZSTD:
+		compressor, err = zstd.NewWriter(file)
+	default:
+		err = fmt.Errorf("Unknown compression format: %q", compression)
+	}
+	if err != nil {
+		return
+	}
+	cw = &CompressedWriter{bufio.NewWriter(compressor), compressor, file}
+	return
+}
+
+// Close flushes the buffered data, terminates the compressed stream, and
+// closes the underlying file (except os.Stdout)
+func (cw *CompressedWriter) Close() (err error) {
+	if err = cw.Flush(); err != nil {
+		return
+	}
+	if err = cw.compressor.Close(); err != nil {
+		return
+	}
+	if c, ok := cw.file.(io.Closer); ok && cw.file != os.Stdout {
+		err = c.Close()
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestCompression(t *testing.T) {
+	tests := map[string]string{
+		"\x1f\x8b\x08\x00":     "gzip",
+		"BZh91AY":              "bzip2",
+		"BZh\nACGT":            "",
+		"BZh":                  "",
+		"\xfd7zXZ\x00\x00":     "xz",
+		"\x28\xb5\x2f\xfd\x00": "zstd",
+		">s1\nACGT\n":          "",
+		"":                     "",
+	}
+	for input, expected := range tests {
+		if c := Compression(bufio.NewReader(strings.NewReader(input))); c != expected {
+			t.Errorf("Compression of %q: expected %q, got %q", input, expected, c)
+		}
+	}
+}
+
+func TestCompressedWriter(t *testing.T) {
+	input := ">s1\nACGTACGT\n>s2\nACGTTCGT\n"
+	for _, c := range []string{COMPRESSION_GZIP, COMPRESSION_BZIP2, COMPRESSION_XZ, COMPRESSION_ZSTD} {
+		var buf bytes.Buffer
+		var r *bufio.Reader
+		var out []byte
+
+		w, err := NewCompressedWriter(&buf, c)
+		if err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+		w.WriteString(input)
+		if err = w.Close(); err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+
+		br := bufio.NewReader(&buf)
+		if comp := Compression(br); comp != c {
+			t.Errorf("%s: compression detected as %q", c, comp)
+		}
+		if r, err = decompress(br); err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+		if out, err = io.ReadAll(r); err != nil {
+			t.Fatalf("%s: %v", c, err)
+		}
+		if string(out) != input {
+			t.Errorf("%s: expected %q, got %q", c, input, out)
+		}
+	}
+
+	if _, err := NewCompressedWriter(&bytes.Buffer{}, "lz4"); err == nil {
+		t.Errorf("An error was expected with an unknown compression format")
+	}
+}
+
+func TestCompressionFromExtension(t *testing.T) {
+	tests := map[string]string{
+		"al.fa.gz":  COMPRESSION_GZIP,
+		"al.fa.bz2": COMPRESSION_BZIP2,
+		"al.fa.xz":  COMPRESSION_XZ,
+		"al.fa.zst": COMPRESSION_ZSTD,
+		"al.fa":     COMPRESSION_NONE,
+		"stdout":    COMPRESSION_NONE,
+	}
+	for file, expected := range tests {
+		if c := CompressionFromExtension(file); c != expected {
+			t.Errorf("Compression of %s: expected %q, got %q", file, expected, c)
+		}
+	}
+}
+
+func TestCompressionFromString(t *testing.T) {
+	for name, expected := range map[string]string{"gz": COMPRESSION_GZIP, "BZIP2": COMPRESSION_BZIP2, "xz": COMPRESSION_XZ, "zst": COMPRESSION_ZSTD, "none": COMPRESSION_NONE} {
+		if c, err := CompressionFromString(name); err != nil || c != expected {
+			t.Errorf("Compression %s: expected %q, got %q (%v)", name, expected, c, err)
+		}
+	}
+	if _, err := CompressionFromString("lz4"); err == nil {
+		t.Errorf("An error was expected with an unknown compression format")
+	}
+}
@@ -2,62 +2,110 @@ package utils
 
 import (
 	"bufio"
+	"fmt"
 	"io"
 
 	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io/a3m"
 	"github.com/evolbioinfo/goalign/io/clustal"
+	"github.com/evolbioinfo/goalign/io/embl"
 	"github.com/evolbioinfo/goalign/io/fasta"
+	"github.com/evolbioinfo/goalign/io/fastq"
+	"github.com/evolbioinfo/goalign/io/genbank"
+	"github.com/evolbioinfo/goalign/io/maf"
 	"github.com/evolbioinfo/goalign/io/nexus"
 	"github.com/evolbioinfo/goalign/io/phylip"
+	"github.com/evolbioinfo/goalign/io/stockholm"
 )
 
+const stockholmHeader = "# STOCKHOLM"
+const genbankHeader = "LOCUS"
+const emblHeader = "ID   "
+const a3mHeader = "#A3M#"
+const mafHeader = "##maf"
+
+// IsGenBank returns true if the reader starts with a GenBank LOCUS line
+// It does not consume the reader
+func IsGenBank(r *bufio.Reader) bool {
+	b, _ := r.Peek(len(genbankHeader))
+	return string(b) == genbankHeader
+}
+
+// IsFastq returns true if the reader starts with a FASTQ header (@)
+// It does not consume the reader
+func IsFastq(r *bufio.Reader) bool {
+	b, _ := r.Peek(1)
+	return len(b) == 1 && b[0] == '@'
+}
+
+// IsEMBL returns true if the reader starts with an EMBL ID line
+// It does not consume the reader
+func IsEMBL(r *bufio.Reader) bool {
+	b, _ := r.Peek(len(emblHeader))
+	return string(b) == emblHeader
+}
+
 // Parses the input buffer while automatically
-// detecting the format between Newick, Phylip, and Nexus
+// detecting the format between Fasta, FASTQ, A3M (with #A3M# header), MAF, Stockholm, GenBank, EMBL, Nexus, Clustal and Phylip
+// (see SniffFormat).
 //
 // If several alignments are present in the onput file, only the first will be
 // parsed.
 //
-// Returned format may be align.FORMAT_PHYLIP, align.FORMAT_FASTA, or align.FORMAT_NEXUS
+// Returned format may be align.FORMAT_PHYLIP, align.FORMAT_FASTA, align.FORMAT_NEXUS,
+// align.FORMAT_CLUSTAL, align.FORMAT_STOCKHOLM, align.FORMAT_GENBANK, align.FORMAT_EMBL,
+// align.FORMAT_FASTQ, align.FORMAT_A3M or align.FORMAT_MAF
 //
 // rootinpustrict: In the case of phylip detected format: should we consider it as strict or not?
 //
 // There is no new go routine here because only 1 alignment is parsed. No need to give a closer.
 // If the reader comes from a file, the file must be closed in the calling function.
 func ParseAlignmentAuto(r *bufio.Reader, rootinputstrict bool) (al align.Alignment, format int, err error) {
-	var firstbyte byte
+	var best FormatScore
 
-	if firstbyte, err = r.ReadByte(); err != nil {
-		return
-	}
-
-	if err = r.UnreadByte(); err != nil {
+	if best, _, err = SniffFormat(r); err != nil {
 		return
 	}
+	format = best.Format
+	al, err = ParseAlignment(r, format, rootinputstrict)
+	return
+}
 
-	// First test Fasta format
-	if firstbyte == '>' {
-		format = align.FORMAT_FASTA
+// ParseAlignment parses the first alignment of the input buffer, in the given format
+// (align.FORMAT_FASTA, align.FORMAT_PHYLIP, etc.).
+//
+// rootinpustrict: In the case of phylip format: should we consider it as strict or not?
+func ParseAlignment(r *bufio.Reader, format int, rootinputstrict bool) (al align.Alignment, err error) {
+	switch format {
+	case align.FORMAT_FASTA:
 		al, err = fasta.NewParser(r).Parse()
-	} else if firstbyte == '#' {
-		if al, err = nexus.NewParser(r).Parse(); err != nil {
-			return
-		}
-		format = align.FORMAT_NEXUS
-	} else if firstbyte == 'C' {
-		if al, err = clustal.NewParser(r).Parse(); err != nil {
-			return
-		}
-		format = align.FORMAT_CLUSTAL
-	} else {
-		// Finally test Phylip
-		format = align.FORMAT_PHYLIP
+	case align.FORMAT_FASTQ:
+		al, err = fastq.NewParser(r).Parse()
+	case align.FORMAT_A2M, align.FORMAT_A3M:
+		al, err = a3m.NewParser(r).Parse()
+	case align.FORMAT_MAF:
+		al, err = maf.NewParser(r).Parse()
+	case align.FORMAT_STOCKHOLM:
+		al, err = stockholm.NewParser(r).Parse()
+	case align.FORMAT_GENBANK:
+		al, err = genbank.NewParser(r).Parse()
+	case align.FORMAT_EMBL:
+		al, err = embl.NewParser(r).Parse()
+	case align.FORMAT_NEXUS:
+		al, err = nexus.NewParser(r).Parse()
+	case align.FORMAT_CLUSTAL:
+		al, err = clustal.NewParser(r).Parse()
+	case align.FORMAT_PHYLIP:
 		al, err = phylip.NewParser(r, rootinputstrict).Parse()
+	default:
+		err = fmt.Errorf("Unknown alignment format: %d", format)
 	}
 	return
 }
 
 // Parses the input buffer while automatically
-// detecting the format between Newick, Phylip, and Nexus
+// detecting the format between Fasta, FASTQ, A3M (with #A3M# header), MAF, Stockholm, GenBank, EMBL, Nexus, Clustal and Phylip
+// (see SniffFormat).
 //
 // If several alignments are present in the input file, they are queued in the channel
 //
@@ -68,62 +116,61 @@ func ParseAlignmentAuto(r *bufio.Reader, rootinputstrict bool) (al align.Alignme
 // If the alignment comes from a file for exemple, the file will be closed by this function, so no need to
 // do it in the calling function
 func ParseMultiAlignmentsAuto(f io.Closer, r *bufio.Reader, rootinputstrict bool) (alchan *align.AlignChannel, format int, err error) {
+	var best FormatScore
+
+	if best, _, err = SniffFormat(r); err != nil {
+		return
+	}
+	format = best.Format
+	alchan, err = ParseMultiAlignments(f, r, format, rootinputstrict)
+	return
+}
+
+// ParseMultiAlignments parses the input buffer in the given format (align.FORMAT_FASTA,
+// align.FORMAT_PHYLIP, etc.). Phylip, Nexus, Stockholm and MAF inputs may contain
+// several alignments: they are queued in the channel by a go routine.
+// For other formats, the single alignment is parsed and queued in the channel.
+//
+// rootinpustrict: In the case of phylip format: should we consider it as strict or not?
+//
+// If there is something to close ( f!=nil) after the parsing (like input file, etc.), f will be closed
+// after parsing is finished.
+func ParseMultiAlignments(f io.Closer, r *bufio.Reader, format int, rootinputstrict bool) (alchan *align.AlignChannel, err error) {
 	var al align.Alignment
-	var firstbyte byte
 
 	alchan = &align.AlignChannel{}
 
-	if firstbyte, err = r.ReadByte(); err != nil {
-		return
+	var parseMultiple func(alchan *align.AlignChannel)
+	switch format {
+	case align.FORMAT_MAF:
+		parseMultiple = maf.NewParser(r).ParseMultiple
+	case align.FORMAT_STOCKHOLM:
+		parseMultiple = stockholm.NewParser(r).ParseMultiple
+	case align.FORMAT_NEXUS:
+		parseMultiple = nexus.NewParser(r).ParseMultiple
+	case align.FORMAT_PHYLIP:
+		parseMultiple = phylip.NewParser(r, rootinputstrict).ParseMultiple
 	}
 
-	if err = r.UnreadByte(); err != nil {
-		return
-	}
-	// First test Fasta format
-	if firstbyte == '>' {
-		if al, err = fasta.NewParser(r).Parse(); err != nil {
-			return
-		}
-		format = align.FORMAT_FASTA
-		alchan.Achan = make(chan align.Alignment, 1)
-		alchan.Achan <- al
-		if f != nil {
-			f.Close()
-		}
-		close(alchan.Achan)
-	} else if firstbyte == '#' {
-		if al, err = nexus.NewParser(r).Parse(); err != nil {
-			return
-		}
-		format = align.FORMAT_NEXUS
-		alchan.Achan = make(chan align.Alignment, 1)
-		alchan.Achan <- al
-		if f != nil {
-			f.Close()
-		}
-		close(alchan.Achan)
-	} else if firstbyte == 'C' {
-		if al, err = clustal.NewParser(r).Parse(); err != nil {
-			return
-		}
-		format = align.FORMAT_CLUSTAL
-		alchan.Achan = make(chan align.Alignment, 1)
-		alchan.Achan <- al
-		if f != nil {
-			f.Close()
-		}
-		close(alchan.Achan)
-	} else {
-		format = align.FORMAT_PHYLIP
-		// Finally test Phylip
+	if parseMultiple != nil {
 		alchan.Achan = make(chan align.Alignment, 15)
 		go func() {
-			phylip.NewParser(r, rootinputstrict).ParseMultiple(alchan)
+			parseMultiple(alchan)
 			if f != nil {
 				f.Close()
 			}
 		}()
+		return
+	}
+
+	if al, err = ParseAlignment(r, format, rootinputstrict); err != nil {
+		return
+	}
+	alchan.Achan = make(chan align.Alignment, 1)
+	alchan.Achan <- al
+	if f != nil {
+		f.Close()
 	}
+	close(alchan.Achan)
 	return
 }
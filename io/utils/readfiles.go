@@ -3,6 +3,7 @@ package utils
 import (
 	"bufio"
 	"compress/gzip"
+	"fmt"
 	"io"
 	"net/http"
 	"os"
@@ -23,7 +24,10 @@ func OpenFile(inputfile string) (*os.File, error) {
 	return infile, nil
 }
 
-/* Returns the opened file and a buffered reader (gzip or not) for the file */
+/* Returns the opened file and a buffered reader (decompressed or not) for the file.
+Compressed inputs (gzip, bzip2, xz and zstd) are detected by their magic bytes,
+whatever their extension (stdin and http(s) inputs included).
+The buffer of the reader is large enough for SniffFormat (SNIFF_SIZE). */
 func GetReader(inputfile string) (io.Closer, *bufio.Reader, error) {
 	var reader *bufio.Reader
 
@@ -35,6 +39,10 @@ func GetReader(inputfile string) (io.Closer, *bufio.Reader, error) {
 		if res, err = http.Get(inputfile); err != nil {
 			return nil, nil, err
 		}
+		if res.StatusCode < 200 || res.StatusCode > 299 {
+			res.Body.Close()
+			return nil, nil, fmt.Errorf("Could not read %s: %s", inputfile, res.Status)
+		}
 		f = res.Body
 	} else {
 		if f, err = OpenFile(inputfile); err != nil {
@@ -42,14 +50,8 @@ func GetReader(inputfile string) (io.Closer, *bufio.Reader, error) {
 		}
 	}
 
-	if GzipExtension(inputfile) {
-		if gr, err := gzip.NewReader(f); err != nil {
-			return nil, nil, err
-		} else {
-			reader = bufio.NewReader(gr)
-		}
-	} else {
-		reader = bufio.NewReader(f)
+	if reader, err = decompress(bufio.NewReaderSize(f, SNIFF_SIZE)); err != nil {
+		return nil, nil, err
 	}
 	return f, reader, nil
 }
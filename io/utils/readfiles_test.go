@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetReader_HTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/align.fa" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, ">s1\nACGT\n")
+	}))
+	defer server.Close()
+
+	f, r, err := GetReader(server.URL + "/align.fa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(r)
+	f.Close()
+	if string(b) != ">s1\nACGT\n" {
+		t.Errorf("Wrong content: %q", string(b))
+	}
+
+	if _, _, err = GetReader(server.URL + "/missing.fa"); err == nil {
+		t.Errorf("There should be an error: status is 404")
+	}
+}
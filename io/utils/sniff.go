@@ -0,0 +1,252 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+// Minimum score for a format to be chosen by SniffFormat
+const MIN_SNIFF_SCORE = 0.5
+
+// Size of the input prefix examined by SniffFormat
+// (readers returned by GetReader have at least this size)
+const SNIFF_SIZE = 64 * 1024
+
+var phylipHeader = regexp.MustCompile(`^\s*(\d+)\s+(\d+)(\s+\S+)*\s*$`)
+var phylipSequence = regexp.MustCompile(`^\s*\S+\s+[A-Za-z\-\*\?\.~ ]+$`)
+var sequenceLine = regexp.MustCompile(`^[A-Za-z\-\*\?\.~ ]*$`)
+var clustalHeader = regexp.MustCompile(`^(CLUSTAL|MUSCLE|PROBCONS)`)
+var nexusBlock = regexp.MustCompile(`(?i)begin\s+(data|characters)\s*;`)
+
+// FormatScore is the confidence that an input is in a given format
+type FormatScore struct {
+	Format int     // align.FORMAT_FASTA, align.FORMAT_PHYLIP, etc.
+	Score  float64 // Between 0 (not this format) and 1 (certainly this format)
+	Reason string  // Why the input may be in this format
+}
+
+func (fs FormatScore) String() string {
+	return fmt.Sprintf("%s (score %.2f: %s)", InputFormatName(fs.Format), fs.Score, fs.Reason)
+}
+
+// InputFormatName returns the name of the given input format
+// (align.FORMAT_FASTA, align.FORMAT_GENBANK, etc.)
+func InputFormatName(format int) string {
+	switch format {
+	case align.FORMAT_GENBANK:
+		return "genbank"
+	case align.FORMAT_EMBL:
+		return "embl"
+	default:
+		return FormatName(format)
+	}
+}
+
+// SniffFormat detects the format of the input alignment, given its first
+// bytes (up to the size of the reader buffer). It does not consume the reader.
+//
+// Each known format (Fasta, FASTQ, A3M with #A3M# header, MAF, Stockholm,
+// GenBank, EMBL, Nexus, Clustal and Phylip) is given a score between 0 and 1,
+// and the format with the best score is returned, with the scores of all the
+// candidate formats (score > 0), in decreasing order.
+//
+// An error is returned if the input is compressed (not supported here), if
+// no format has a score >= MIN_SNIFF_SCORE, or if several formats have
+// the best score (ambiguous input). In both last cases, the error lists
+// the candidate formats.
+func SniffFormat(r *bufio.Reader) (best FormatScore, candidates []FormatScore, err error) {
+	prefix, _ := r.Peek(r.Size())
+	if c := Compression(r); c != "" {
+		err = fmt.Errorf("Input is compressed (%s), and cannot be read", c)
+		return
+	}
+	if bytes.IndexByte(prefix, 0) >= 0 {
+		err = fmt.Errorf("Input is a binary file")
+		return
+	}
+
+	lines := sniffLines(prefix, len(prefix) == r.Size())
+	if len(lines) == 0 {
+		err = fmt.Errorf("Input is empty")
+		return
+	}
+
+	candidates = make([]FormatScore, 0)
+	for _, sniffer := range []func([]string) FormatScore{
+		sniffFasta, sniffFastq, sniffA3M, sniffMAF, sniffStockholm,
+		sniffGenBank, sniffEMBL, sniffNexus, sniffClustal, sniffPhylip,
+	} {
+		if fs := sniffer(lines); fs.Score > 0 {
+			candidates = append(candidates, fs)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+
+	list := make([]string, len(candidates))
+	for i, c := range candidates {
+		list[i] = c.String()
+	}
+	switch {
+	case len(candidates) == 0 || candidates[0].Score < MIN_SNIFF_SCORE:
+		err = fmt.Errorf("Unable to detect input format, candidates: [%s]", strings.Join(list, ", "))
+	case len(candidates) > 1 && candidates[1].Score == candidates[0].Score:
+		err = fmt.Errorf("Ambiguous input format, candidates: [%s]", strings.Join(list, ", "))
+	default:
+		best = candidates[0]
+	}
+	return
+}
+
+// sniffLines returns the lines of the prefix, without the empty lines
+// at the beginning, and without the last line if it may be incomplete
+func sniffLines(prefix []byte, full bool) (lines []string) {
+	text := strings.TrimPrefix(string(prefix), "\uFEFF")
+	lines = strings.Split(strings.Replace(text, "\r", "", -1), "\n")
+	if full && len(lines) > 1 {
+		lines = lines[:len(lines)-1]
+	}
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	return
+}
+
+func sniffFasta(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_FASTA}
+	if strings.HasPrefix(lines[0], ">") {
+		fs.Score, fs.Reason = 0.8, "first line starts with '>'"
+		if len(lines) > 1 && strings.TrimSpace(lines[1]) != "" && sequenceLine.MatchString(lines[1]) {
+			fs.Score, fs.Reason = 1.0, "header line starting with '>' followed by a sequence line"
+		}
+	}
+	return fs
+}
+
+func sniffFastq(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_FASTQ}
+	if strings.HasPrefix(lines[0], "@") {
+		fs.Score, fs.Reason = 0.5, "first line starts with '@'"
+		if len(lines) > 3 && strings.HasPrefix(lines[2], "+") && len(lines[1]) == len(lines[3]) {
+			fs.Score, fs.Reason = 1.0, "@header, sequence, '+' and quality lines"
+		}
+	}
+	return fs
+}
+
+func sniffA3M(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_A3M}
+	if strings.HasPrefix(lines[0], a3mHeader) {
+		fs.Score, fs.Reason = 1.0, "starts with "+a3mHeader
+	}
+	return fs
+}
+
+func sniffMAF(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_MAF}
+	if strings.HasPrefix(lines[0], mafHeader) {
+		fs.Score, fs.Reason = 1.0, "starts with "+mafHeader
+		return fs
+	}
+	for i, l := range lines {
+		if strings.HasPrefix(l, "#") || strings.TrimSpace(l) == "" {
+			continue
+		}
+		if (strings.HasPrefix(l, "a ") || l == "a") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "s ") {
+			fs.Score, fs.Reason = 0.7, "'a' line followed by 's' lines"
+		}
+		break
+	}
+	return fs
+}
+
+func sniffStockholm(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_STOCKHOLM}
+	if strings.HasPrefix(lines[0], stockholmHeader) {
+		fs.Score, fs.Reason = 1.0, "starts with "+stockholmHeader
+	}
+	return fs
+}
+
+func sniffGenBank(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_GENBANK}
+	if strings.HasPrefix(lines[0], genbankHeader) {
+		fs.Score, fs.Reason = 1.0, "starts with "+genbankHeader
+	}
+	return fs
+}
+
+func sniffEMBL(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_EMBL}
+	if strings.HasPrefix(lines[0], emblHeader) {
+		fs.Score, fs.Reason = 1.0, "starts with an ID line"
+	}
+	return fs
+}
+
+func sniffNexus(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_NEXUS}
+	text := strings.TrimSpace(skipNexusComments(strings.Join(lines, "\n")))
+	if len(text) >= 6 && strings.EqualFold(text[:6], "#NEXUS") {
+		fs.Score, fs.Reason = 1.0, "starts with #NEXUS"
+		if !strings.HasPrefix(strings.TrimSpace(lines[0]), "#") {
+			fs.Reason = "starts with #NEXUS, after comments"
+		}
+	} else if nexusBlock.MatchString(text) {
+		fs.Score, fs.Reason = 0.4, "has a BEGIN DATA block, but no #NEXUS header"
+	}
+	return fs
+}
+
+// skipNexusComments removes the [comments] (possibly nested)
+// at the beginning of the given text
+func skipNexusComments(text string) string {
+	for {
+		text = strings.TrimSpace(text)
+		if !strings.HasPrefix(text, "[") {
+			return text
+		}
+		depth := 0
+		end := -1
+		for i, c := range text {
+			if c == '[' {
+				depth++
+			} else if c == ']' {
+				if depth--; depth == 0 {
+					end = i
+					break
+				}
+			}
+		}
+		if end == -1 {
+			return text
+		}
+		text = text[end+1:]
+	}
+}
+
+func sniffClustal(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_CLUSTAL}
+	if m := clustalHeader.FindString(lines[0]); m != "" {
+		fs.Score, fs.Reason = 1.0, "first line starts with "+m
+	} else if strings.Contains(lines[0], "multiple sequence alignment") {
+		fs.Score, fs.Reason = 0.3, "first line contains 'multiple sequence alignment', but does not start with CLUSTAL"
+	}
+	return fs
+}
+
+func sniffPhylip(lines []string) FormatScore {
+	fs := FormatScore{Format: align.FORMAT_PHYLIP}
+	if phylipHeader.MatchString(lines[0]) {
+		fs.Score, fs.Reason = 0.7, "first line gives the numbers of sequences and sites"
+		if len(lines) > 1 && phylipSequence.MatchString(lines[1]) {
+			fs.Score, fs.Reason = 0.9, "first line gives the numbers of sequences and sites, followed by a sequence"
+		}
+	}
+	return fs
+}
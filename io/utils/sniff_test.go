@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+func TestSniffFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		format int
+	}{
+		{"fasta", ">s1\nACGT\n>s2\nACGA\n", align.FORMAT_FASTA},
+		{"fasta with BOM and blank lines", "\uFEFF\n\n>s1\nACGT\n", align.FORMAT_FASTA},
+		{"fastq", "@s1\nACGT\n+\nIIII\n", align.FORMAT_FASTQ},
+		{"a3m", "#A3M#\n>s1\nACGT\n", align.FORMAT_A3M},
+		{"maf", "##maf version=1\na score=0\ns hg18.chr1 0 4 + 100 ACGT\n", align.FORMAT_MAF},
+		{"maf without header", "a score=0\ns hg18.chr1 0 4 + 100 ACGT\n", align.FORMAT_MAF},
+		{"stockholm", "# STOCKHOLM 1.0\ns1 ACGT\n//\n", align.FORMAT_STOCKHOLM},
+		{"nexus", "#NEXUS\nbegin data;\nend;\n", align.FORMAT_NEXUS},
+		{"nexus with leading comment", "[Generated by a tool]\n#NEXUS\nbegin data;\nend;\n", align.FORMAT_NEXUS},
+		{"clustal", "CLUSTAL W (1.83) multiple sequence alignment\n\ns1 ACGT\n", align.FORMAT_CLUSTAL},
+		{"muscle", "MUSCLE (3.8) multiple sequence alignment\n\ns1 ACGT\n", align.FORMAT_CLUSTAL},
+		{"probcons", "PROBCONS version 1.12 multiple sequence alignment\n\ns1 ACGT\n", align.FORMAT_CLUSTAL},
+		{"phylip", "   2   4\ns1 ACGT\ns2 ACGA\n", align.FORMAT_PHYLIP},
+	}
+
+	for _, test := range tests {
+		best, _, err := SniffFormat(bufio.NewReader(strings.NewReader(test.input)))
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.name, err)
+			continue
+		}
+		if best.Format != test.format {
+			t.Errorf("%s: expected format %s, got %s", test.name, InputFormatName(test.format), best)
+		}
+	}
+}
+
+func TestSniffFormat_NotConsumed(t *testing.T) {
+	r := bufio.NewReader(strings.NewReader("[comment]\n#NEXUS\n"))
+	if _, _, err := SniffFormat(r); err != nil {
+		t.Error(err)
+	}
+	if b, _ := r.Peek(9); string(b) != "[comment]" {
+		t.Errorf("SniffFormat should not consume the reader, got %q", b)
+	}
+}
+
+func TestSniffFormat_Errors(t *testing.T) {
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Write([]byte(">s1\nACGT\n"))
+	gw.Close()
+
+	tests := []struct {
+		name  string
+		input string
+		msg   string
+	}{
+		{"empty", "\n\n", "Input is empty"},
+		{"binary", "ACGT\x00\x01", "binary"},
+		{"gzip", buf.String(), "compressed (gzip)"},
+		{"unknown", "Hello world\n", "Unable to detect input format, candidates: []"},
+		{"unknown with candidates", "Some multiple sequence alignment\n", "candidates: [clustal (score 0.30"},
+	}
+
+	for _, test := range tests {
+		_, _, err := SniffFormat(bufio.NewReader(strings.NewReader(test.input)))
+		if err == nil {
+			t.Errorf("%s: an error was expected", test.name)
+		} else if !strings.Contains(err.Error(), test.msg) {
+			t.Errorf("%s: expected error containing %q, got %q", test.name, test.msg, err.Error())
+		}
+	}
+}
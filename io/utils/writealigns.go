@@ -0,0 +1,157 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io/a3m"
+	"github.com/evolbioinfo/goalign/io/clustal"
+	"github.com/evolbioinfo/goalign/io/fasta"
+	"github.com/evolbioinfo/goalign/io/fastq"
+	"github.com/evolbioinfo/goalign/io/maf"
+	"github.com/evolbioinfo/goalign/io/nexus"
+	"github.com/evolbioinfo/goalign/io/paml"
+	"github.com/evolbioinfo/goalign/io/phylip"
+	"github.com/evolbioinfo/goalign/io/stockholm"
+	"github.com/evolbioinfo/goalign/io/tnt"
+)
+
+// AlignmentWriter writes alignments in a given output format
+type AlignmentWriter interface {
+	// WriteAlignment returns the alignment formatted as a string
+	WriteAlignment(al align.Alignment) string
+	// Extension returns the usual file extension of the format, with the leading "."
+	Extension() string
+}
+
+// WriterOptions gathers format specific output options.
+// Writers just ignore the options that do not concern them.
+type WriterOptions struct {
+	PhylipStrict  bool    // Strict phylip output (10 character names)
+	PhylipOneLine bool    // Phylip sequences written on one line
+	PhylipNoBlock bool    // Phylip sequences written without space separated blocks
+	A3MMaxGaps    float64 // A2M/A3M: columns having a larger fraction of gaps are insert columns
+}
+
+// WriterBuilder builds an AlignmentWriter using the given options
+type WriterBuilder func(opts WriterOptions) AlignmentWriter
+
+var writers map[string]WriterBuilder = make(map[string]WriterBuilder)
+
+// simpleWriter is an AlignmentWriter for formats that do not have any option
+type simpleWriter struct {
+	write     func(al align.Alignment) string
+	extension string
+}
+
+func (w *simpleWriter) WriteAlignment(al align.Alignment) string {
+	return w.write(al)
+}
+
+func (w *simpleWriter) Extension() string {
+	return w.extension
+}
+
+// phylipWriter writes alignments in phylip, with its specific options
+type phylipWriter struct {
+	opts WriterOptions
+}
+
+func (w *phylipWriter) WriteAlignment(al align.Alignment) string {
+	return phylip.WriteAlignment(al, w.opts.PhylipStrict, w.opts.PhylipOneLine, w.opts.PhylipNoBlock)
+}
+
+func (w *phylipWriter) Extension() string {
+	return ".ph"
+}
+
+func init() {
+	RegisterWriter("fasta", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{func(al align.Alignment) string { return fasta.WriteAlignment(al) }, ".fa"}
+	})
+	RegisterWriter("fastq", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{func(al align.Alignment) string { return fastq.WriteAlignment(al) }, ".fq"}
+	})
+	RegisterWriter("phylip", func(opts WriterOptions) AlignmentWriter {
+		return &phylipWriter{opts}
+	})
+	RegisterWriter("nexus", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{nexus.WriteAlignment, ".nx"}
+	})
+	RegisterWriter("clustal", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{clustal.WriteAlignment, ".clustal"}
+	})
+	RegisterWriter("a2m", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{func(al align.Alignment) string { return a3m.WriteA2M(al, opts.A3MMaxGaps) }, ".a2m"}
+	})
+	RegisterWriter("a3m", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{func(al align.Alignment) string { return a3m.WriteA3M(al, opts.A3MMaxGaps) }, ".a3m"}
+	})
+	RegisterWriter("maf", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{maf.WriteAlignment, ".maf"}
+	})
+	RegisterWriter("stockholm", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{stockholm.WriteAlignment, ".sto"}
+	})
+	RegisterWriter("paml", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{paml.WriteAlignment, ".paml"}
+	})
+	RegisterWriter("tnt", func(opts WriterOptions) AlignmentWriter {
+		return &simpleWriter{tnt.WriteAlignment, ".tnt"}
+	})
+}
+
+// RegisterWriter registers a new output format under the given name
+// (case insensitive). If a format with the same name already exists, it is replaced.
+func RegisterWriter(name string, builder WriterBuilder) {
+	writers[strings.ToLower(name)] = builder
+}
+
+// GetWriter returns the AlignmentWriter registered under the given name (case insensitive).
+// Returns an error if the format does not exist.
+func GetWriter(name string, opts WriterOptions) (w AlignmentWriter, err error) {
+	builder, ok := writers[strings.ToLower(name)]
+	if !ok {
+		err = fmt.Errorf("Unknown output format '%s', should be one of: %s", name, strings.Join(WriterNames(), ", "))
+		return
+	}
+	w = builder(opts)
+	return
+}
+
+// WriterNames returns the sorted names of all the registered output formats
+func WriterNames() (names []string) {
+	names = make([]string, 0, len(writers))
+	for n := range writers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return
+}
+
+// FormatName returns the name of the output format corresponding to
+// the given input format (align.FORMAT_FASTA, align.FORMAT_PHYLIP, etc.)
+func FormatName(format int) string {
+	switch format {
+	case align.FORMAT_PHYLIP:
+		return "phylip"
+	case align.FORMAT_NEXUS:
+		return "nexus"
+	case align.FORMAT_CLUSTAL:
+		return "clustal"
+	case align.FORMAT_STOCKHOLM:
+		return "stockholm"
+	case align.FORMAT_FASTQ:
+		return "fastq"
+	case align.FORMAT_A2M:
+		return "a2m"
+	case align.FORMAT_A3M:
+		return "a3m"
+	case align.FORMAT_MAF:
+		return "maf"
+	default:
+		return "fasta"
+	}
+}
@@ -0,0 +1,244 @@
+package vcf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io/bed"
+)
+
+// edit is a change of the reference sequence in one sample
+type edit struct {
+	pos     int      // Position on the reference (0-based)
+	ref     string   // Reference allele
+	alleles []string // Distinct alleles of the sample (upper case)
+	missing bool     // Genotype is missing
+}
+
+// Consensus builds an alignment from a reference sequence and from the
+// genotypes of the samples of the given VCF files.
+//
+// Only the records whose CHROM is the first word of the reference sequence
+// name, and whose FILTER is PASS or '.', are taken into account. Gaps of the
+// reference sequence are removed, and the reference alleles must match the
+// reference sequence (case insensitive). If a sample is present in several
+// VCF files, all its records are applied.
+//
+// For each sample, the genotypes are applied to the reference sequence:
+//   - Alleles having the same length as the reference allele (SNPs, MNPs)
+//     are applied as is
+//   - Other alleles (indels) are applied only if indels is true, otherwise
+//     they are considered as the reference allele. Deletions are written as
+//     gaps, and insertions are added after the reference allele, all the
+//     other sequences being padded with gaps so that the alignment stays
+//     column-consistent
+//   - Symbolic (<...>) and '*' alleles are considered as the reference allele
+//   - Missing genotypes are written as N
+//   - Heterozygous genotypes are written with the IUPAC code of their
+//     alleles, or as N if alleles do not have the same length
+//   - Records overlapping a record already applied to the sample are ignored
+//
+// Then, positions of the given BED intervals (ex: low coverage regions) are
+// masked with N in all samples.
+//
+// The output alignment contains the reference sequence first,
+// followed by the samples, in their order of appearance.
+func Consensus(ref align.Sequence, vcfs []*VCF, mask []*bed.Interval, indels bool) (al align.Alignment, err error) {
+	var chrom string
+
+	if fields := strings.Fields(ref.Name()); len(fields) > 0 {
+		chrom = fields[0]
+	}
+	refseq := bytes.Replace(ref.SequenceBytes(), []byte{align.GAP}, nil, -1)
+
+	samples := make([]string, 0)
+	edits := make(map[string][]*edit)
+	for _, v := range vcfs {
+		for _, s := range v.Samples {
+			if _, ok := edits[s]; !ok {
+				samples = append(samples, s)
+				edits[s] = make([]*edit, 0)
+			}
+		}
+		for _, rec := range v.Records {
+			if rec.Chrom != chrom || (rec.Filter != "PASS" && rec.Filter != ".") {
+				continue
+			}
+			end := rec.Pos + len(rec.Ref)
+			if end > len(refseq) {
+				return nil, fmt.Errorf("VCF record %s:%d is outside the reference sequence (length %d)", rec.Chrom, rec.Pos+1, len(refseq))
+			}
+			if !strings.EqualFold(rec.Ref, string(refseq[rec.Pos:end])) {
+				return nil, fmt.Errorf("VCF record %s:%d: reference allele %s does not match the reference sequence (%s)", rec.Chrom, rec.Pos+1, rec.Ref, refseq[rec.Pos:end])
+			}
+			for i, s := range v.Samples {
+				if e := newEdit(rec, rec.Genotypes[i], indels); e != nil {
+					edits[s] = append(edits[s], e)
+				}
+			}
+		}
+	}
+
+	seqs := make([][]byte, len(samples))
+	inserts := make([][][]byte, len(samples))
+	for i, s := range samples {
+		seqs[i], inserts[i] = applyEdits(refseq, edits[s])
+		for _, inter := range mask {
+			if inter.Chrom != chrom {
+				continue
+			}
+			for p := inter.Start; p < inter.End && p < len(refseq); p++ {
+				seqs[i][p] = align.ALL_NUCLE
+				for k := range inserts[i][p] {
+					inserts[i][p][k] = align.ALL_NUCLE
+				}
+			}
+		}
+	}
+
+	// Insertions: we pad all the sequences with gaps
+	maxins := make([]int, len(refseq))
+	for i := range samples {
+		for p, ins := range inserts[i] {
+			if len(ins) > maxins[p] {
+				maxins[p] = len(ins)
+			}
+		}
+	}
+
+	al = align.NewAlign(align.UNKNOWN)
+	if err = al.AddSequence(ref.Name(), string(padInserts(refseq, nil, maxins)), ref.Comment()); err != nil {
+		return
+	}
+	for i, s := range samples {
+		if err = al.AddSequence(s, string(padInserts(seqs[i], inserts[i], maxins)), ""); err != nil {
+			return
+		}
+	}
+	al.AutoAlphabet()
+	return
+}
+
+// newEdit returns the change of the reference sequence corresponding to
+// the given genotype of the given record (nil if no change)
+func newEdit(rec *Record, genotype []int, indels bool) *edit {
+	e := &edit{pos: rec.Pos, ref: rec.Ref, alleles: make([]string, 0)}
+	ref := strings.ToUpper(rec.Ref)
+	for _, g := range genotype {
+		if g < 0 {
+			continue
+		}
+		a := ref
+		if g > 0 {
+			a = strings.ToUpper(rec.Alts[g-1])
+		}
+		if a == "*" || a == "." || strings.HasPrefix(a, "<") || (!indels && len(a) != len(ref)) {
+			a = ref
+		}
+		found := false
+		for _, a2 := range e.alleles {
+			found = found || a2 == a
+		}
+		if !found {
+			e.alleles = append(e.alleles, a)
+		}
+	}
+	if len(e.alleles) == 0 {
+		e.missing = true
+	} else if len(e.alleles) == 1 && e.alleles[0] == ref {
+		return nil
+	}
+	return e
+}
+
+// applyEdits applies the given edits to the reference sequence, and returns
+// the edited sequence, and the insertions after each reference position
+func applyEdits(refseq []byte, edits []*edit) (seq []byte, inserts [][]byte) {
+	seq = append([]byte(nil), refseq...)
+	inserts = make([][]byte, len(refseq))
+
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].pos < edits[j].pos })
+	end := 0
+	for _, e := range edits {
+		if e.pos < end {
+			// Overlaps a previous edit
+			continue
+		}
+		end = e.pos + len(e.ref)
+		switch {
+		case e.missing:
+			fill(seq[e.pos:end], align.ALL_NUCLE)
+		case len(e.alleles) == 1:
+			a := e.alleles[0]
+			if len(a) <= len(e.ref) {
+				copy(seq[e.pos:], a)
+				fill(seq[e.pos+len(a):end], align.GAP)
+			} else {
+				copy(seq[e.pos:end], a)
+				inserts[end-1] = append(inserts[end-1], a[len(e.ref):]...)
+			}
+		default:
+			for i := 0; i < len(e.ref); i++ {
+				chars := make([]byte, 0, len(e.alleles))
+				for _, a := range e.alleles {
+					if len(a) != len(e.ref) {
+						chars = nil
+						break
+					}
+					chars = append(chars, a[i])
+				}
+				seq[e.pos+i] = ambiguity(chars)
+			}
+		}
+	}
+	return
+}
+
+// padInserts returns the sequence with its insertions after each
+// position, padded with gaps up to the maximum insertion length
+func padInserts(seq []byte, inserts [][]byte, maxins []int) []byte {
+	var buf bytes.Buffer
+	for p, c := range seq {
+		buf.WriteByte(c)
+		nins := 0
+		if inserts != nil {
+			buf.Write(inserts[p])
+			nins = len(inserts[p])
+		}
+		buf.Write(bytes.Repeat([]byte{align.GAP}, maxins[p]-nins))
+	}
+	return buf.Bytes()
+}
+
+// ambiguity returns the IUPAC code corresponding to the given nucleotides,
+// N if the nucleotides are not known or if chars is empty
+func ambiguity(chars []byte) byte {
+	var code uint8
+	for _, c := range chars {
+		nt, err := align.Nt2IndexIUPAC(rune(c))
+		if err != nil {
+			return align.ALL_NUCLE
+		}
+		code |= nt
+	}
+	for r, nts := range align.IupacCode {
+		var code2 uint8
+		for _, nt := range nts {
+			idx, _ := align.Nt2IndexIUPAC(nt)
+			code2 |= idx
+		}
+		if code2 == code {
+			return byte(r)
+		}
+	}
+	return align.ALL_NUCLE
+}
+
+func fill(seq []byte, c byte) {
+	for i := range seq {
+		seq[i] = c
+	}
+}
@@ -0,0 +1,168 @@
+package vcf
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// VCF is the content of a VCF file: its samples and its records.
+// Meta-information lines (starting with "##") are not kept.
+type VCF struct {
+	Samples []string
+	Records []*Record
+}
+
+// Record is a line of a VCF file
+type Record struct {
+	Chrom     string
+	Pos       int      // Position of the reference allele (0-based)
+	Ref       string   // Reference allele
+	Alts      []string // Alternative alleles (ALT), empty if ALT is '.'
+	Filter    string   // FILTER column ("PASS", ".", or filters that failed)
+	Genotypes [][]int  // Alleles of the GT field of each sample: 0=Ref, i=Alts[i-1], -1=missing
+}
+
+// Parser represents a VCF parser.
+//
+// Only the columns needed to reconstruct sequences are parsed: CHROM, POS,
+// REF, ALT, FILTER, and the GT field of the samples (genotypes of any ploidy,
+// phased or not). Samples without GT field have missing genotypes.
+type Parser struct {
+	r     *bufio.Reader
+	nline int // number of the last read line
+}
+
+// NewParser returns a new instance of Parser.
+func NewParser(r io.Reader) *Parser {
+	return &Parser{r: bufio.NewReader(r)}
+}
+
+func (p *Parser) error(message string) error {
+	return fmt.Errorf("VCF: %s (line %d)", message, p.nline)
+}
+
+// Parse parses the whole VCF input
+func (p *Parser) Parse() (v *VCF, err error) {
+	var line string
+	var header, eof bool
+	var rec *Record
+
+	v = &VCF{Samples: make([]string, 0), Records: make([]*Record, 0)}
+	for !eof {
+		if line, err = p.r.ReadString('\n'); err == io.EOF {
+			if eof = true; line == "" {
+				break
+			}
+		} else if err != nil {
+			return nil, err
+		}
+		p.nline++
+		line = strings.TrimRight(line, "\r\n")
+		switch {
+		case strings.HasPrefix(line, "##") || strings.TrimSpace(line) == "":
+		case strings.HasPrefix(line, "#"):
+			fields := strings.Split(line, "\t")
+			if len(fields) < 8 || fields[0] != "#CHROM" {
+				return nil, p.error("malformed header line")
+			}
+			if len(fields) > 9 {
+				v.Samples = append(v.Samples, fields[9:]...)
+			}
+			header = true
+		default:
+			if !header {
+				return nil, p.error("record before the #CHROM header line")
+			}
+			if rec, err = p.parseRecord(line, len(v.Samples)); err != nil {
+				return nil, err
+			}
+			v.Records = append(v.Records, rec)
+		}
+	}
+	if !header {
+		return nil, p.error("no #CHROM header line")
+	}
+	return v, nil
+}
+
+func (p *Parser) parseRecord(line string, nsamples int) (rec *Record, err error) {
+	fields := strings.Split(line, "\t")
+	if nsamples > 0 && len(fields) != 9+nsamples {
+		return nil, p.error(fmt.Sprintf("record should have %d columns (%d)", 9+nsamples, len(fields)))
+	} else if nsamples == 0 && len(fields) < 8 {
+		return nil, p.error(fmt.Sprintf("record should have at least 8 columns (%d)", len(fields)))
+	}
+
+	rec = &Record{Chrom: fields[0], Ref: fields[3], Alts: make([]string, 0), Filter: fields[6]}
+	if rec.Pos, err = strconv.Atoi(fields[1]); err != nil || rec.Pos < 1 {
+		return nil, p.error(fmt.Sprintf("wrong position: %s", fields[1]))
+	}
+	rec.Pos--
+	if rec.Ref == "" || rec.Ref == "." {
+		return nil, p.error("missing reference allele")
+	}
+	if fields[4] != "." {
+		rec.Alts = strings.Split(fields[4], ",")
+	}
+
+	if nsamples == 0 {
+		return rec, nil
+	}
+	gtindex := -1
+	for i, f := range strings.Split(fields[8], ":") {
+		if f == "GT" {
+			gtindex = i
+		}
+	}
+	rec.Genotypes = make([][]int, nsamples)
+	for i, sample := range fields[9:] {
+		gt := "."
+		if values := strings.Split(sample, ":"); gtindex >= 0 && gtindex < len(values) {
+			gt = values[gtindex]
+		}
+		if rec.Genotypes[i], err = p.parseGenotype(gt, len(rec.Alts)); err != nil {
+			return nil, err
+		}
+	}
+	return rec, nil
+}
+
+// parseGenotype parses a GT field value (ex: 0/1, 1|1, 1, ./.)
+func (p *Parser) parseGenotype(gt string, nalts int) (alleles []int, err error) {
+	fields := strings.FieldsFunc(gt, func(r rune) bool { return r == '/' || r == '|' })
+	alleles = make([]int, len(fields))
+	for i, f := range fields {
+		if f == "." {
+			alleles[i] = -1
+			continue
+		}
+		if alleles[i], err = strconv.Atoi(f); err != nil || alleles[i] < 0 || alleles[i] > nalts {
+			return nil, p.error(fmt.Sprintf("wrong genotype: %s", gt))
+		}
+	}
+	if len(alleles) == 0 {
+		alleles = []int{-1}
+	}
+	return
+}
+
+// SetSample makes a VCF file without sample column (sites only)
+// a VCF file of a single sample with the given name, carrying
+// the first alternative allele of all the records.
+// Does nothing if the VCF already has samples.
+func (v *VCF) SetSample(name string) {
+	if len(v.Samples) > 0 {
+		return
+	}
+	v.Samples = []string{name}
+	for _, rec := range v.Records {
+		if len(rec.Alts) > 0 {
+			rec.Genotypes = [][]int{{1}}
+		} else {
+			rec.Genotypes = [][]int{{0}}
+		}
+	}
+}
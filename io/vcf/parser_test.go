@@ -0,0 +1,99 @@
+package vcf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/evolbioinfo/goalign/align"
+	"github.com/evolbioinfo/goalign/io/bed"
+)
+
+var vcfstring1 string = `##fileformat=VCFv4.2
+##contig=<ID=ref,length=12>
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	s1	s2	s3
+ref	2	.	C	T	.	PASS	.	GT	1	0/1	.
+ref	4	.	TA	T	.	.	.	GT:DP	1:10	0:12	0:3
+ref	7	.	A	ATT,G	.	.	.	GT	0	1	2
+ref	9	.	T	C	.	LowQual	.	GT	1	1	1
+other	1	.	A	C	.	.	.	GT	1	1	1
+`
+
+func TestParse(t *testing.T) {
+	v, err := NewParser(strings.NewReader(vcfstring1)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(v.Samples) != "[s1 s2 s3]" || len(v.Records) != 5 {
+		t.Fatalf("VCF is not as expected: %v, %d records", v.Samples, len(v.Records))
+	}
+	r := v.Records[2]
+	if r.Chrom != "ref" || r.Pos != 6 || r.Ref != "A" || fmt.Sprint(r.Alts) != "[ATT G]" || fmt.Sprint(r.Genotypes) != "[[0] [1] [2]]" {
+		t.Errorf("Record is not as expected: %v", *r)
+	}
+	if g := v.Records[0].Genotypes; fmt.Sprint(g) != "[[1] [0 1] [-1]]" {
+		t.Errorf("Genotypes are not as expected: %v", g)
+	}
+
+	for i, s := range []string{
+		// No header
+		"ref	2	.	C	T	.	PASS	.\n",
+		// Wrong position
+		"#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO\nref	a	.	C	T	.	PASS	.\n",
+		// Wrong genotype
+		"#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	s1\nref	2	.	C	T	.	PASS	.	GT	2\n",
+		// Missing sample
+		"#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	s1	s2\nref	2	.	C	T	.	PASS	.	GT	1\n",
+	} {
+		if _, err = NewParser(strings.NewReader(s)).Parse(); err == nil {
+			t.Errorf("There should be an error while parsing VCF %d", i)
+		}
+	}
+}
+
+func TestConsensus(t *testing.T) {
+	v, err := NewParser(strings.NewReader(vcfstring1)).Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := align.NewSequence("ref chromosome", []rune("ACGTACAGTACG"), "")
+	mask := []*bed.Interval{{Chrom: "ref", Start: 10, End: 12}, {Chrom: "other", Start: 0, End: 2}}
+
+	exp := map[string]string{
+		"ref chromosome": "ACGTACA--GTACG",
+		"s1":             "ATGT-CA--GTANN",
+		"s2":             "AYGTACATTGTANN",
+		"s3":             "ANGTACG--GTANN",
+	}
+	al, err := Consensus(ref, []*VCF{v}, mask, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, seq := range exp {
+		if s, ok := al.GetSequence(name); !ok || s != seq {
+			t.Errorf("Sequence %s is not as expected: %s vs. %s", name, s, seq)
+		}
+	}
+
+	// Without indels
+	exp = map[string]string{
+		"ref chromosome": "ACGTACAGTACG",
+		"s1":             "ATGTACAGTACG",
+		"s2":             "AYGTACAGTACG",
+		"s3":             "ANGTACGGTACG",
+	}
+	if al, err = Consensus(ref, []*VCF{v}, nil, false); err != nil {
+		t.Fatal(err)
+	}
+	for name, seq := range exp {
+		if s, ok := al.GetSequence(name); !ok || s != seq {
+			t.Errorf("Sequence %s is not as expected: %s vs. %s", name, s, seq)
+		}
+	}
+
+	// Wrong reference allele
+	ref = align.NewSequence("ref", []rune("AAGTACAGTACG"), "")
+	if _, err = Consensus(ref, []*VCF{v}, nil, false); err == nil {
+		t.Errorf("There should be an error: reference allele does not match")
+	}
+}
@@ -0,0 +1,47 @@
+package vcf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/evolbioinfo/goalign/align"
+)
+
+const VCF_VERSION = "VCFv4.2"
+
+// WriteVariants returns the given variants (see align.Alignment.Variants) in
+// VCF format, with haploid genotypes.
+//
+// chrom is the name of the reference sequence (CHROM column, spaces replaced
+// by '_'), and length its ungapped length. Positions are 1-based reference
+// positions of the first reference base of the variants (the anchor base
+// of deletions), and missing genotypes are written as '.'.
+func WriteVariants(chrom string, length int, samples []string, variants []*align.Variant) string {
+	var buf bytes.Buffer
+
+	chrom = strings.Join(strings.Fields(chrom), "_")
+	buf.WriteString("##fileformat=" + VCF_VERSION + "\n")
+	buf.WriteString("##source=goalign\n")
+	buf.WriteString(fmt.Sprintf("##contig=<ID=%s,length=%d>\n", chrom, length))
+	buf.WriteString("##FORMAT=<ID=GT,Number=1,Type=String,Description=\"Genotype\">\n")
+	buf.WriteString("#CHROM\tPOS\tID\tREF\tALT\tQUAL\tFILTER\tINFO\tFORMAT")
+	for _, s := range samples {
+		buf.WriteString("\t" + s)
+	}
+	buf.WriteString("\n")
+
+	for _, v := range variants {
+		buf.WriteString(fmt.Sprintf("%s\t%d\t.\t%s\t%s", chrom, v.RefPos+1, v.Ref, strings.Join(v.Alts, ",")))
+		buf.WriteString("\t.\t.\t.\tGT")
+		for _, g := range v.Genotypes {
+			if g < 0 {
+				buf.WriteString("\t.")
+			} else {
+				buf.WriteString(fmt.Sprintf("\t%d", g))
+			}
+		}
+		buf.WriteString("\n")
+	}
+	return buf.String()
+}
@@ -584,6 +584,499 @@ ${GOALIGN} reformat clustal -i input | sed 's/ (goalign version.*//g'> result
 diff -q -b result expected
 rm -f expected result input
 
+echo "->goalign reformat stockholm"
+cat > input <<EOF
+# STOCKHOLM 1.0
+#=GF ID test
+#=GS s1 DE first sequence
+
+s1 ACGU.A
+#=GR s1 SS <<..>>
+s2 AC-U.A
+#=GC SS_cons <<..>>
+
+s1 AC
+#=GR s1 SS ..
+s2 AG
+#=GC SS_cons ..
+//
+EOF
+cat > expected <<EOF
+# STOCKHOLM 1.0
+#=GF ID test
+
+#=GS s1 DE first sequence
+
+s1           ACGU-AAC
+#=GR s1 SS   <<..>>..
+s2           AC-U-AAG
+#=GC SS_cons <<..>>..
+//
+EOF
+${GOALIGN} reformat stockholm -i input --stockholm > result
+diff -q -b result expected
+rm -f expected result input
+
+echo "->goalign subseq stockholm auto"
+cat > input <<EOF
+# STOCKHOLM 1.0
+s1 ACGU.A
+#=GR s1 SS <<..>>
+s2 AC-U.A
+#=GC SS_cons <<..>>
+#=GC RF xxxx.x
+//
+EOF
+cat > expected <<EOF
+# STOCKHOLM 1.0
+s1           CGU
+#=GR s1 SS   <..
+s2           C-U
+#=GC SS_cons <..
+#=GC RF      xxx
+//
+EOF
+${GOALIGN} subseq -i input --auto-detect -s 1 -l 3 > result
+diff -q -b result expected
+rm -f expected result input
+
+echo "->goalign orf genbank"
+cat > input <<EOF
+LOCUS       TEST01                    42 bp    DNA     linear   SYN 01-JAN-2020
+DEFINITION  Synthetic sequence.
+ACCESSION   TEST01
+VERSION     TEST01.1
+FEATURES             Location/Qualifiers
+     CDS             join(1..6,
+                     7..12)
+                     /gene="gA"
+                     /product="protein A"
+     CDS             complement(17..28)
+                     /gene="gB"
+                     /transl_table=2
+     CDS             <30..42
+                     /locus_tag="L3"
+                     /codon_start=2
+ORIGIN      
+        1 atgaaaccct aaggggtcat ttgggcatcc atgtttgggt aa
+//
+EOF
+cat > expected <<EOF
+>TEST01.1_gA
+ATGAAACCCTAA
+>TEST01.1_gB
+ATGCCCAAATGA
+>TEST01.1_L3
+ATGTTTGGGTAA
+EOF
+${GOALIGN} orf -i input > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign translate --cds genbank"
+cat > expected <<EOF
+>TEST01.1_gB
+MPKW
+EOF
+${GOALIGN} translate --cds gB -i input > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign subseq --cds genbank"
+cat > expected <<EOF
+>TEST01.1_gB
+ATGCCCAAATGA
+EOF
+${GOALIGN} subseq --genbank --cds gB -i input > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign subseq --cds all genbank"
+cat > expected <<EOF
+>TEST01.1_gA
+ATGAAACCCTAA
+EOF
+cat > expected_sub1 <<EOF
+>TEST01.1_gB
+ATGCCCAAATGA
+EOF
+cat > expected_sub2 <<EOF
+>TEST01.1_L3
+ATGTTTGGGTAA
+EOF
+${GOALIGN} subseq --genbank --cds all -i input -o result.fa
+diff -q -b result.fa expected
+diff -q -b result_sub1.fa expected_sub1
+diff -q -b result_sub2.fa expected_sub2
+rm -f expected expected_sub1 expected_sub2 result.fa result_sub1.fa result_sub2.fa input
+
+echo "->goalign reformat fasta embl"
+cat > input <<EOF
+ID   X56734; SV 1; linear; mRNA; STD; PLN; 12 BP.
+XX
+AC   X56734;
+XX
+DE   Synthetic sequence.
+XX
+FT   CDS             1..12
+FT                   /gene="gA"
+SQ   Sequence 12 BP; 4 A; 3 C; 1 G; 4 T; 0 other;
+     atgaaaccct aa                                                            12
+//
+EOF
+cat > expected <<EOF
+>X56734.1
+ATGAAACCCTAA
+EOF
+${GOALIGN} reformat fasta --auto-detect -i input > result
+diff -q -b result expected
+rm -f expected result input
+
+echo "->goalign quality mask"
+cat > input <<EOF
+@r1
+ACGTACGTAC
++
+II#IIIII!!
+@r2
+ACGTACGTAA
++
+#IIIIIIII5
+EOF
+cat > expected <<EOF
+@r1
+ACNTACGTNN
++
+II#IIIII!!
+@r2
+NCGTACGTAA
++
+#IIIIIIII5
+EOF
+${GOALIGN} quality mask -i input --min-qual 20 -q > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign quality trim"
+cat > expected <<EOF
+@r1
+ACGTACGT
++
+II#IIIII
+@r2
+CGTACGTAA
++
+IIIIIIII5
+EOF
+${GOALIGN} quality trim -i input --min-qual 10 -q > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign quality filter"
+cat > expected <<EOF
+@r2
+ACGTACGTAA
++
+#IIIIIIII5
+EOF
+${GOALIGN} quality filter -i input --min-mean 30 -q > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign subseq fastq"
+cat > expected <<EOF
+@r1
+CGTA
++
+I#II
+@r2
+CGTA
++
+IIII
+EOF
+${GOALIGN} subseq --fastq -i input -s 1 -l 4 > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign trim seq fastq"
+cat > expected <<EOF
+@r1
+GTACGTAC
++
+#IIIII!!
+@r2
+GTACGTAA
++
+IIIIIII5
+EOF
+${GOALIGN} trim seq --auto-detect -i input -n 2 -s > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign reformat fasta fastq"
+cat > expected <<EOF
+>r1
+ACGTACGTAC
+>r2
+ACGTACGTAA
+EOF
+${GOALIGN} reformat fasta --unaligned -i input > result
+diff -q -b result expected
+${GOALIGN} reformat fastq -i expected > result
+cat > expected <<EOF
+@r1
+ACGTACGTAC
++
+IIIIIIIIII
+@r2
+ACGTACGTAA
++
+IIIIIIIIII
+EOF
+diff -q -b result expected
+rm -f expected result input
+
+echo "->goalign reformat a3m"
+cat > input <<EOF
+#A3M#
+>s1
+ACDEFG
+>s2
+AcC-EF
+G
+>s3
+-CDklmEF-
+EOF
+cat > expected <<EOF
+>s1
+A-CD---EFG
+>s2
+ACC----EFG
+>s3
+--CDKLMEF-
+EOF
+${GOALIGN} reformat fasta --a3m -i input > result
+diff -q -b result expected
+rm -f expected result
+
+cat > expected <<EOF
+>s1
+ACDEFG
+>s2
+AC-EFG
+>s3
+-CDEF-
+EOF
+${GOALIGN} reformat fasta --a3m --a3m-discard-inserts -i input > result
+diff -q -b result expected
+rm -f expected result
+
+cat > expected <<EOF
+>s1
+A.CD...EFG
+>s2
+AcC-...EFG
+>s3
+-.CDklmEF-
+EOF
+${GOALIGN} reformat a2m --auto-detect -i input > result
+diff -q -b result expected
+cat > expected <<EOF
+>s1
+ACDEFG
+>s2
+AcC-EFG
+>s3
+-CDklmEF-
+EOF
+${GOALIGN} reformat a3m --a2m -i result > result2
+diff -q -b result2 expected
+rm -f expected result result2 input
+
+echo "->goalign stitch maf"
+cat > input <<EOF
+##maf version=1
+a score=10.0
+s hg18.chr1 10 4 + 100 AC-GT
+s mm4.chr2  20 5 + 100 ACTGT
+
+a score=5.0
+s mm4.chr2  10 3 - 100 AAC
+s hg18.chr1 77 3 - 100 AAC
+s rn3.chr3   0 2 + 100 A-T
+EOF
+cat > expected <<EOF
+##maf version=1
+
+a
+s hg18.chr1 10 13 + 100 AC-GTNNNNNNGTT
+s mm4        0  8 +   8 ACTGT------GTT
+s rn3        0  2 +   2 -----------A-T
+
+EOF
+${GOALIGN} stitch --maf -i input --ref hg18 > result
+diff -q -b result expected
+cat > expected <<EOF
+>hg18
+AC-GTNNNNNNGTT
+>mm4
+ACTGT------GTT
+>rn3
+-----------A-T
+EOF
+${GOALIGN} stitch --auto-detect -i input --ref hg18.chr1 --output-format fasta > result
+diff -q -b result expected
+rm -f expected result
+
+echo "->goalign reformat maf"
+cat > expected <<EOF
+##maf version=1
+
+a score=10.0
+s hg18.chr1 10 4 + 100 AC-GT
+s mm4.chr2  20 5 + 100 ACTGT
+
+a score=5.0
+s mm4.chr2  10 3 - 100 AAC
+s hg18.chr1 77 3 - 100 AAC
+s rn3.chr3   0 2 + 100 A-T
+
+EOF
+${GOALIGN} reformat maf --maf -i input > result
+diff -q -b result expected
+rm -f expected result input
+
+echo "->goalign vcf"
+cat > input <<EOF
+>ref
+AC-GTNAC
+>s1
+ACTGTAAC
+>s2
+GCAG-CRC
+>s3
+aC-GAGCC
+EOF
+cat > expected <<EOF
+##fileformat=VCFv4.2
+##source=goalign
+##contig=<ID=ref,length=7>
+##FORMAT=<ID=GT,Number=1,Type=String,Description="Genotype">
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	s1	s2	s3
+ref	1	.	A	G	.	.	.	GT	0	1	0
+ref	3	.	GT	G,GA	.	.	.	GT	0	1	2
+ref	6	.	A	C	.	.	.	GT	0	.	1
+EOF
+cat > expected.snps <<EOF
+>ref
+ATA
+>s1
+ATA
+>s2
+G-R
+>s3
+aAC
+EOF
+cat > expected.pos <<EOF
+alignment	reference
+1	1
+5	4
+7	6
+EOF
+${GOALIGN} vcf -i input --ref-sequence ref --gaps-as-deletions --snps result.snps --snp-positions result.pos > result
+diff -q -b result expected
+diff -q -b result.snps expected.snps
+diff -q -b result.pos expected.pos
+rm -f expected result input expected.snps result.snps expected.pos result.pos
+
+echo "->goalign build vcf"
+cat > input <<EOF
+>ref chromosome
+ACGTACAGTACG
+EOF
+cat > s1.vcf <<EOF
+##fileformat=VCFv4.2
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO
+ref	2	.	C	T	.	PASS	.
+ref	7	.	A	ATT	.	.	.
+EOF
+cat > samples.vcf <<EOF
+##fileformat=VCFv4.2
+#CHROM	POS	ID	REF	ALT	QUAL	FILTER	INFO	FORMAT	s2	s3
+ref	4	.	TA	T	.	.	.	GT	0/1	1/1
+ref	9	.	T	C	.	.	.	GT	./.	0|1
+EOF
+printf "ref\t10\t12\n" > lowcov.bed
+cat > expected <<EOF
+>ref chromosome
+ACGTACA--GTACG
+>s1
+ATGTACATTGTANN
+>s2
+ACGNNCA--GNANN
+>s3
+ACGT-CA--GYANN
+EOF
+${GOALIGN} build vcf -i input --vcf s1.vcf,samples.vcf --indels --mask lowcov.bed > result
+diff -q -b result expected
+rm -f expected result input s1.vcf samples.vcf lowcov.bed
+
+echo "->goalign reformat phylip nexus interleaved/multiple blocks"
+cat > input <<EOF
+#NEXUS
+BEGIN DATA;
+  DIMENSIONS NTAX=2 NCHAR=8;
+  FORMAT DATATYPE=DNA INTERLEAVE MISSING=? MATCHCHAR=.;
+  MATRIX
+  s1 ACGT
+  s2 ..?.
+  s1 ACGT
+  s2 .T..
+  ;
+END;
+BEGIN DATA;
+  DIMENSIONS NTAX=2 NCHAR=3;
+  FORMAT DATATYPE=DNA EQUATE="R=(AG)";
+  MATRIX
+  s1 AC
+     G
+  s2 AC{AG}
+  ;
+END;
+EOF
+cat > expected <<EOF
+   2   8
+s1  ACGTACGT
+s2  AC*TATGT
+   2   3
+s1  ACG
+s2  ACR
+EOF
+${GOALIGN} reformat phylip -x -i input > result
+diff -q -b result expected
+rm -f expected result input
+
+echo "->goalign subseq --output-format"
+cat > input <<EOF
+   2   6
+s1  ACGTAC
+s2  AC-TAA
+EOF
+cat > expected <<EOF
+#NEXUS
+begin data;
+dimensions ntax=2 nchar=3;
+format datatype=dna;
+matrix
+s1 CGT
+s2 C-T
+;
+end;
+EOF
+${GOALIGN} subseq -i input -p -s 1 -l 3 --output-format nexus > result
+diff -q -b result expected
+rm -f expected result input
+
 echo "->goalign reformat tnt"
 cat > expected <<EOF
 xread
@@ -3771,6 +4264,506 @@ diff -q -b exp_p1 p1.fa
 diff -q -b exp_p2 p2.fa
 rm -f input exp_p1 exp_p2 partitions p1.fa p2.fa
 
+echo "->goalign split nexus sets"
+cat > input <<EOF
+#NEXUS
+begin data;
+dimensions ntax=3 nchar=11;
+format datatype=dna;
+matrix
+s1 AAAACCCCCGG
+s2 AAAACCCCCGG
+s3 AAAACCCCCGT
+;
+end;
+begin sets;
+charset p1 = 1-4 10-11;
+charset p2 = 5-9;
+end;
+EOF
+cat > exp_p1 <<EOF
+>s1
+AAAAGG
+>s2
+AAAAGG
+>s3
+AAAAGT
+EOF
+cat > exp_p2 <<EOF
+>s1
+CCCCC
+>s2
+CCCCC
+>s3
+CCCCC
+EOF
+${GOALIGN} split -i input -x --output-format fasta --out-prefix ./
+diff -q -b exp_p1 p1.fa
+diff -q -b exp_p2 p2.fa
+rm -f input exp_p1 exp_p2 p1.fa p2.fa
+
+echo "->goalign concat nexus sets"
+cat > input1 <<EOF
+#NEXUS
+begin data;
+dimensions ntax=2 nchar=6;
+format datatype=dna;
+matrix
+s1 AAACCC
+s2 AAACCG
+;
+end;
+begin sets;
+charset g1 = 1-3;
+charset g2 = 4-6;
+end;
+EOF
+cat > input2.nx <<EOF
+#NEXUS
+begin data;
+dimensions ntax=2 nchar=4;
+format datatype=dna;
+matrix
+s1 TTTT
+s2 TTTA
+;
+end;
+EOF
+cat > expected <<EOF
+#NEXUS
+begin data;
+dimensions ntax=2 nchar=10;
+format datatype=dna;
+matrix
+s1 AAACCCTTTT
+s2 AAACCGTTTA
+;
+end;
+begin sets;
+charset g1 = 1-3;
+charset g2 = 4-6;
+charset input2 = 7-10;
+charpartition partitions = g1:g1, g2:g2, input2:input2;
+end;
+EOF
+cat > expected.part <<EOF
+DNA,g1=1-3
+DNA,g2=4-6
+DNA,input2=7-10
+EOF
+${GOALIGN} concat -i input1 -x input2.nx --out-partition result.part > result
+diff -q -b expected result
+diff -q -b expected.part result.part
+rm -f input1 input2.nx expected expected.part result result.part
+
+echo "->goalign partition convert"
+cat > input <<EOF
+GTR+G, p1 = 1-12\3, 2-12\3
+HKY, p2 = 3-12/3
+EOF
+cat > expected.nex <<EOF
+#nexus
+begin sets;
+  charset p1 = 1-2 4-5 7-8 10-11;
+  charset p2 = 3-12\3;
+  charpartition partitions = GTR+G:p1, HKY:p2;
+end;
+EOF
+cat > expected.raxml <<EOF
+GTR+G, p1 = 1-2, 4-5, 7-8, 10-11
+HKY, p2 = 3-12\3
+EOF
+cat > expected.cfg <<EOF
+## ALIGNMENT FILE ##
+alignment = align.phy;
+
+## BRANCHLENGTHS: linked | unlinked ##
+branchlengths = linked;
+
+## MODELS OF EVOLUTION: all | allx | mrbayes | beast | gamma | gammai | <list> ##
+models = all;
+
+# MODEL SELECTION: AIC | AICc | BIC #
+model_selection = aicc;
+
+## DATA BLOCKS: see manual for how to define ##
+[data_blocks]
+p1 = 1-2 4-5 7-8 10-11;
+p2 = 3-12\3;
+
+## SCHEMES, search: all | user | greedy | rcluster | rclusterf | kmeans ##
+[schemes]
+search = greedy;
+EOF
+${GOALIGN} partition convert --partition input --out-format nexus -o result.nex
+diff -q -b expected.nex result.nex
+${GOALIGN} partition convert --partition result.nex --out-format raxml > result.raxml
+diff -q -b expected.raxml result.raxml
+${GOALIGN} partition convert --partition input --out-format pf --pf-alignment align.phy > result.cfg
+diff -q -b expected.cfg result.cfg
+printf "" > empty.phy
+${GOALIGN} partition convert -p -i empty.phy --partition input --out-format raxml > result.raxml 2> result.log && echo "Error: conversion should fail" && exit 1
+grep -q "No alignment found in the input file" result.log
+rm -f input empty.phy expected.nex expected.raxml expected.cfg result.nex result.raxml result.cfg result.log
+
+echo "->goalign reformat auto sniffing"
+cat > input <<EOF
+MUSCLE (3.8) multiple sequence alignment
+
+
+s1      ACGTACGTAC
+s2      ACGTTCGTAC
+        **** *****
+EOF
+cat > expected <<EOF
+>s1
+ACGTACGTAC
+>s2
+ACGTTCGTAC
+EOF
+cat > expected.log <<EOF
+Detected input format: clustal (score 1.00: first line starts with MUSCLE)
+EOF
+${GOALIGN} reformat fasta --auto-detect -i input > result 2> result.log
+diff -q -b result expected
+diff -q -b result.log expected.log
+printf "[Written by a tool]\n" > input
+${GOALIGN} reformat nexus -i expected >> input
+${GOALIGN} reformat fasta --auto-detect -i input > result 2> /dev/null
+diff -q -b result expected
+gzip -c expected > input
+${GOALIGN} reformat fasta -i input > result
+diff -q -b result expected
+printf "ACGT\nACGT\n" > input
+${GOALIGN} reformat fasta --auto-detect -i input > result 2> result.log && echo "Error: unknown format should fail" && exit 1
+grep -q "Unable to detect input format, candidates" result.log
+rm -f input expected expected.log result result.log
+
+echo "->goalign reformat compressed input/output"
+cat > expected <<EOF
+>Seq0000
+GATTA
+>Seq0001
+ATTTG
+>Seq0002
+CCGTA
+EOF
+for ext in gz bz2 xz zst
+do
+    ${GOALIGN} reformat fasta -i expected -o result.${ext}
+    ${GOALIGN} reformat fasta -i result.${ext} > result
+    diff -q -b result expected
+    cat result.${ext} | ${GOALIGN} reformat fasta > result
+    diff -q -b result expected
+done
+gzip -dc result.gz > result
+diff -q -b result expected
+for c in gzip bzip2 xz zstd
+do
+    ${GOALIGN} reformat fasta -i expected --compress ${c} | ${GOALIGN} reformat fasta > result
+    diff -q -b result expected
+done
+${GOALIGN} reformat fasta -i expected --compress none -o result.gz
+diff -q -b result.gz expected
+rm -f expected result result.gz result.bz2 result.xz result.zst
+
+echo "->goalign stats --format"
+cat > expected <<EOF
+[
+  {"alignment": 0, "length": 10, "nseqs": 3, "avgalleles": 2.3, "variable_sites": 9, "alphabet": "nucleotide", "nb_A": 9, "freq_A": 0.3, "nb_C": 5, "freq_C": 0.16666666666666666, "nb_G": 8, "freq_G": 0.26666666666666666, "nb_T": 8, "freq_T": 0.26666666666666666}
+]
+EOF
+cat > expected.csv <<EOF
+alignment,sequence,gaps,gapsstart,gapsend,gapsuniques,gapsopenning,mutuniques,length,A,C,G,T
+0,Seq0000,0,0,0,0,0,5,10,3,0,2,5
+0,Seq0001,0,0,0,0,0,7,10,2,4,3,1
+0,Seq0002,0,0,0,0,0,5,10,4,1,3,2
+EOF
+cat > expected.tsv <<EOF
+alignment	nseqs
+0	3
+1	3
+EOF
+${GOALIGN} random -n 3 -l 10 --seed 10 | ${GOALIGN} stats --format json > result
+diff -q -b result expected
+${GOALIGN} random -n 3 -l 10 --seed 10 | ${GOALIGN} stats --per-sequences --format csv > result.csv
+diff -q -b result.csv expected.csv
+(${GOALIGN} random -n 3 -l 10 --seed 10 -p ; ${GOALIGN} random -n 3 -l 10 --seed 11 -p) | ${GOALIGN} stats nseq -p --format tsv > result.tsv
+diff -q -b result.tsv expected.tsv
+cat > expected.tsv <<EOF
+alignment	sequence	gaps
+0	Seq0000	2
+0	Seq0001	0
+1	Seq0000	0
+1	Seq0001	1
+EOF
+(printf ' 2 4\nSeq0000 A--C\nSeq0001 ACGT\n' ; printf ' 2 4\nSeq0000 ACGT\nSeq0001 AC-T\n') | ${GOALIGN} stats gaps -p --format tsv > result.tsv
+diff -q -b result.tsv expected.tsv
+cat > expected.tsv <<EOF
+alignment	alphabet
+0	nucleotide
+1	nucleotide
+EOF
+(printf ' 2 4\nSeq0000 A--C\nSeq0001 ACGT\n' ; printf ' 2 4\nSeq0000 ACGT\nSeq0001 AC-T\n') | ${GOALIGN} stats alphabet -p --format tsv > result.tsv
+diff -q -b result.tsv expected.tsv
+rm -f expected expected.csv expected.tsv result result.csv result.tsv
+
+echo "->goalign index"
+cat > input <<EOF
+>s1 first
+ACGTACGTAC
+GTAC
+>s2
+TTTTTGGGGG
+CCCC
+>s3
+AAAAACCCCC
+GGGG
+EOF
+cat > expected.fai <<EOF
+s1	14	10	10	11
+s2	14	30	10	11
+s3	14	50	10	11
+EOF
+cat > expected <<EOF
+>s1 first
+ACGTACGTACGTAC
+>s3
+AAAAACCCCCGGGG
+EOF
+cat > expected.sub <<EOF
+>s1 first
+TACGT
+>s2
+GGGCC
+>s3
+CCCGG
+EOF
+${GOALIGN} index -i input
+diff -q -b input.fai expected.fai
+${GOALIGN} subset -i input "s1 first" s3 > result
+diff -q -b result expected
+${GOALIGN} subseq -i input -s 7 -l 5 > result.sub
+diff -q -b result.sub expected.sub
+rm -f input input.fai expected expected.fai expected.sub result result.sub
+
+echo "->goalign index errors"
+printf ">s1\nACGT\n>s2\nACG\n" > input
+${GOALIGN} index -i input
+${GOALIGN} subset -i input s1 > result 2> result.log && echo "Error: subset should fail" && exit 1
+grep -q 'input:3:1: Sequence s2 does not have same length as other sequences (at ">s2")' result.log
+${GOALIGN} subseq -i input -s 1 -l 2 > result 2> result.log && echo "Error: subseq should fail" && exit 1
+grep -q 'input:3:1: Sequence s2 does not have same length as other sequences (at ">s2")' result.log
+rm -f input input.fai result result.log
+
+echo "->goalign parse error positions"
+printf "3 10\nseq1 ACGATCGATC\nseq2 AGCTCGTCGA\n" > input
+${GOALIGN} reformat fasta -p -i input > result 2> result.log && echo "Error: parsing should fail" && exit 1
+grep -q "input:4:1: Bad Phylip format, less sequences" result.log
+printf ">s1\nACGT\n>s2\nACG\n" > input
+${GOALIGN} reformat phylip -i input > result 2> result.log && echo "Error: parsing should fail" && exit 1
+grep -q 'input:3:1: Sequence s2 does not have same length as other sequences (at ">s2")' result.log
+printf "DNA, p1 = 1-4\nDNA, p2 = 5-8, x\n" > input.part
+${GOALIGN} random -n 2 -l 8 --seed 10 | ${GOALIGN} split --partition input.part > result 2> result.log && echo "Error: parsing should fail" && exit 1
+grep -q 'input.part:2:16: Interval definition should start with a number' result.log
+rm -f input input.part result result.log
+
+echo "->goalign validate"
+cat > input <<EOF
+>s1
+ACGTACGT
+>s1
+ACGTAC*T
+>s(1)
+--------
+>s-1-
+NNNNNNNN
+EOF
+cat > expected <<EOF
+[0] error duplicate-names s1_0001: Sequence name s1 is duplicated (renamed s1_0001)
+[0] error clean-names s-1-: Sequence names s(1) and s-1- are both s-1- after name cleaning
+[0] error alphabet s1_0001: Characters outside the nucleotide alphabet: * (position 6)
+[0] error all-gaps s(1): Sequence is only made of gaps
+[0] warning all-n s-1-: Sequence is only made of N
+1 alignment(s) checked: 4 error(s), 1 warning(s)
+EOF
+${GOALIGN} validate -i input > result 2> result.log && echo "Error: validation should fail" && exit 1
+diff -q -b expected result
+cat > expected <<EOF
+alignment	severity	check	sequence	position	message
+0	warning	all-n	s-1-	-1	Sequence is only made of N
+EOF
+${GOALIGN} validate -i input --checks all-n --format tsv > result
+diff -q -b expected result
+${GOALIGN} validate -i input --checks all-n --strict > result 2> result.log && echo "Error: validation should fail" && exit 1
+printf ">s1\nATGAAACCCTAA\n>s2\nATGAAA---TGA\n" > input
+${GOALIGN} validate -i input --checks stops,duplicate-names > result
+printf ">s1\nATGAAACCCTAA\n>s1_0001\nATGTAG---TGA\n" > input
+${GOALIGN} validate -i input --checks stops,duplicate-names > result 2> result.log && echo "Error: validation should fail" && exit 1
+grep -q "error stops s1_0001: Stop codon in frame at position 6" result
+if grep -q "duplicate-names" result; then echo "Error: s1_0001 is not a duplicate"; exit 1; fi
+rm -f input expected result result.log
+
+echo "->goalign draw html"
+cat > input <<EOF
+>s1
+ACGT<A
+>s2
+ACGTTA
+>s3
+ACGT<A
+EOF
+${GOALIGN} draw html -i input -o result
+grep -q '"names":\["s1","s2","s3"\],"sequences":\["ACGT\\u003cA","ACGTTA","ACGT\\u003cA"\],"consensus":"ACGT\\u003cA","conservation":"\*\*\*\* \*"' result
+if grep -q "http" result; then echo "Error: html file should not use the network"; exit 1; fi
+rm -f input result
+
+echo "->goalign draw svg/png"
+cat > input <<EOF
+>s1
+ACGT-ACGTA
+>s2
+ACCT-ACGTA
+>s3
+ACGTTACG-A
+EOF
+${GOALIGN} draw svg -i input --start 1 --length 4 --ref-seq s1 --tracks consensus,gaps > result
+grep -q 'width="118" height="126"' result
+grep -q '<text x="78" y="50" fill="#808080" text-anchor="middle">.</text>' result
+grep -q '<rect x="104" y="101" width="8" height="20" fill="#555555"/>' result
+${GOALIGN} draw png -i input --scheme zappo --tracks entropy -o result
+head -c 4 result | grep -q "PNG"
+${GOALIGN} draw png -i input --scheme unknown -o result 2> result.log && echo "Error: unknown scheme should fail" && exit 1
+rm -f input result result.log
+
+echo "->goalign draw logo"
+cat > input <<EOF
+>s1
+ACGT-ACGTA
+>s2
+ACCT-ACGTA
+>s3
+ACGTTACG-A
+EOF
+cat > weights <<EOF
+s1	0
+s2	1
+s3	0
+EOF
+${GOALIGN} draw logo -i input --ref-seq s3 --start 1 --length 3 > result
+grep -q 'width="110" height="200"' result
+grep -q '<text transform="translate(46,160.00) scale(1,2.0950)"' result
+grep -q 'fill="#f7b32b">G</text>' result
+${GOALIGN} draw logo -i input --start 1 --length 3 --weights weights > result
+grep -q 'fill="#f7b32b">G</text>' result && echo "Error: null weights should remove G from the logo" && exit 1
+${GOALIGN} draw logo -i input --henikoff -o result
+grep -q '<svg' result
+printf "s1\t1\ns2\t1\n" > weights
+${GOALIGN} draw logo -i input --weights weights -o result 2> result.log && echo "Error: missing weight should fail" && exit 1
+grep -q "Sequence s3 is not in the weight file" result.log
+echo "s1 1" > weights
+${GOALIGN} draw logo -i input --weights weights -o result 2> result.log && echo "Error: bad weight file should fail" && exit 1
+rm -f input result result.log weights
+
+echo "->goalign draw term"
+cat > input <<EOF
+>s1
+ACGT-ACGTA
+>s2
+ACCT-ACGTA
+>s3
+ACGTTACG-A
+EOF
+cat > expected <<EOF
+              1
+              ....:.
+s1            ACGT-A
+s2            ACCT-A
+s3            ACGTTA
+Consensus     ACGT-A
+Conservation  ** * *
+
+              7 10
+              ...|
+s1            CGTA
+s2            CGTA
+s3            CG-A
+Consensus     CGTA
+Conservation  ** *
+EOF
+${GOALIGN} draw term -i input --wrap 6 --no-color > result
+diff -q -b result expected
+cat > expected <<EOF
+              359
+s1            G-T
+s2            C..
+s3            .T-
+Consensus     G-T
+Conservation     
+EOF
+${GOALIGN} draw term -i input --ref-seq s1 --diff-only --no-color > result
+diff -q -b result expected
+${GOALIGN} draw term -i input --ref-seq s1 --force-color | grep -q $'\x1b\[30;48;5;221mC'
+${GOALIGN} draw term -i input --ref-seq s1 | grep -q $'\x1b' && echo "Error: colours should be disabled if the output is not a terminal" && exit 1
+${GOALIGN} draw term -i input --diff-only > result 2> result.log && echo "Error: --diff-only without reference should fail" && exit 1
+rm -f input expected result result.log
+
+echo "->goalign pairwise"
+cat > input <<EOF
+>ref
+TTTTTACGTACGTACGTAAAA
+>q1
+ACGTACGTACCTA
+>q2
+GGGGACGTACGTACGTAAAACCCC
+EOF
+cat > expected <<EOF
+q1	0	ref	6	255	13M	*	0	0	ACGTACGTACCTA	*	AS:i:10	NM:i:1	ZI:f:92.31
+q2	0	ref	6	255	4S16M4S	*	0	0	GGGGACGTACGTACGTAAAACCCC	*	AS:i:9	NM:i:0	ZI:f:100.00
+EOF
+${GOALIGN} pairwise -i input --mode glocal --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format sam | grep -v "^@" > result
+diff -q -b result expected
+sed 's/^>\(.*\)$/>\1 some description/' input | ${GOALIGN} pairwise --mode glocal --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format sam > result
+grep -q "^@SQ	SN:ref	LN:21$" result
+grep -v "^@" result | diff -q -b - expected
+cat > expected <<EOF
+>ref
+TTTTTACGTACGTACGTAAAA
+>q1
+-----ACGTACGTACCTA---
+EOF
+${GOALIGN} pairwise -i input --mode glocal --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 > result
+diff -q -b <(head -n 4 result) expected
+${GOALIGN} pairwise -i input --mode local --match 1 --mismatch -2 --format tsv | cut -f 2,14 | tail -n 1 > result
+echo -e "q2\t4S16M4S" > expected
+diff -q -b result expected
+${GOALIGN} pairwise -i input --mode unknown > result 2> result.log && echo "Error: unknown mode should fail" && exit 1
+rm -f input expected result result.log
+
+echo "->goalign pairwise --linear-space --band"
+cat > input <<EOF
+>ref
+TTTTTACGTACGTACGTAAAA
+>q1
+ACGTACGTACCTA
+>q2
+GGGGACGTACGTACGTAAAACCCC
+EOF
+${GOALIGN} pairwise -i input --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format tsv | cut -f 1-4 > expected
+${GOALIGN} pairwise -i input --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format tsv --linear-space | cut -f 1-4 > result
+diff -q -b result expected
+for mode in glocal local
+do
+    ${GOALIGN} pairwise -i input --mode ${mode} --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format tsv > expected
+    ${GOALIGN} pairwise -i input --mode ${mode} --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format tsv --linear-space > result
+    diff -q -b result expected
+done
+${GOALIGN} pairwise -i input --mode glocal --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format tsv > expected
+${GOALIGN} pairwise -i input --mode glocal --match 1 --mismatch -2 --gap-open -4 --gap-extend -1 --format tsv --band 10 > result
+diff -q -b result expected
+${GOALIGN} pairwise -i input --mode local --band 10 > result 2> result.log && echo "Error: banded local alignment should fail" && exit 1
+rm -f input expected result result.log
+
 
 echo "->goalign consensus"
 cat > input <<EOF